@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+)
+
+// Transparency: the alpha modes are in holy-shared/alphamode. An object's alpha is its
+// texture alpha times Color.W.
+
+// alphaMode is the alpha mode the object is drawn with. A tint with an alpha below 1 makes
+// any object blended.
+func (obj *GameObject) alphaMode() alphamode.Mode {
+	if obj.Color.W() < 1 {
+		return alphamode.Blend
+	}
+	return obj.AlphaMode
+}
+
+// sortBackToFront orders blended objects from the farthest to the nearest to the camera.
+// Objects are compared by the center of their box.
+func (a *AppCore) sortBackToFront(objects []*GameObject) {
+	centers := make([]mgl32.Vec3, len(objects))
+	for i, obj := range objects {
+		box := obj.worldAABB()
+		centers[i] = box.Min.Add(box.Max).Mul(0.5)
+	}
+	sorted := make([]*GameObject, 0, len(objects))
+	for _, i := range alphamode.BackToFront(centers, a.cameraPos) {
+		sorted = append(sorted, objects[i])
+	}
+	copy(objects, sorted)
+}
+
+// setAlphaState sets the uniforms and the coverage state that carry out a batch's alpha mode.
+func (a *AppCore) setAlphaState(batch *drawBatch) {
+	a.alphaUniforms.Set(batch.alphaMode, batch.alphaCutoff, batch.alphaToCoverage)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Frustum culling: objects whose world-space bounds are entirely outside the camera's view
+// are left out of the main pass. They are still drawn into the shadow map, since a caster
+// out of view can throw its shadow into it.
+
+// Frustum is the camera's view volume as six planes (left, right, bottom, top, near, far).
+// Each plane is (normal, distance) with the normal pointing into the volume, so a point p is
+// inside a plane when dot(normal, p) + distance >= 0.
+type Frustum [6]mgl32.Vec4
+
+// cameraMatrices returns the camera's view and projection matrices, as the scene shader gets them.
+func (a *AppCore) cameraMatrices() (view, projection mgl32.Mat4) {
+	view = mgl32.LookAtV(a.cameraPos, a.cameraPos.Add(a.cameraFront), a.cameraUp)
+	projection = mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
+	return view, projection
+}
+
+// frustumFromMatrix extracts the planes of a view-projection matrix (Gribb and Hartmann):
+// in clip space a point is inside when -w <= x, y, z <= w, and each of those six
+// inequalities is a plane once the rows of the matrix are added or subtracted.
+func frustumFromMatrix(viewProjection mgl32.Mat4) Frustum {
+	row := func(i int) mgl32.Vec4 { return viewProjection.Row(i) }
+	frustum := Frustum{
+		row(3).Add(row(0)), // Left
+		row(3).Sub(row(0)), // Right
+		row(3).Add(row(1)), // Bottom
+		row(3).Sub(row(1)), // Top
+		row(3).Add(row(2)), // Near
+		row(3).Sub(row(2)), // Far
+	}
+	for i, plane := range frustum {
+		length := plane.Vec3().Len()
+		if length > 0 {
+			frustum[i] = plane.Mul(1 / length)
+		}
+	}
+	return frustum
+}
+
+// intersectsBox reports whether any part of a world-space box may be inside the frustum. It
+// tests the box's corner farthest along each plane's normal; if even that one is outside a
+// plane, the whole box is. Boxes near the frustum's corners can pass without being visible,
+// which only costs a draw.
+func (frustum Frustum) intersectsBox(box BoundingBox) bool {
+	for _, plane := range frustum {
+		corner := box.Min
+		for axis := 0; axis < 3; axis++ {
+			if plane[axis] > 0 {
+				corner[axis] = box.Max[axis]
+			}
+		}
+		if plane.Vec3().Dot(corner)+plane.W() < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// renderBounds returns the world-space box around the object's BoundingBox as the model
+// matrix places it. Unlike worldAABB it ignores the collider shape, it is about the mesh.
+func (obj *GameObject) renderBounds() BoundingBox {
+	box := obj.orientedBox()
+	var half mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		var axis mgl32.Vec3
+		axis[i] = 1
+		half[i] = box.projectedRadius(axis)
+	}
+	return BoundingBox{Min: box.Center.Sub(half), Max: box.Center.Add(half)}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Debug drawing: an immediate-mode line renderer for seeing what physics and picking are
+// doing. Anything can add lines, boxes, spheres, arrows or axes at any time; they are all
+// drawn on top of the scene with one draw call in renderScene. A shape lives for the
+// duration it was added with, or for the next frame only when that is 0, so per-frame
+// visualisations just add their shapes again every frame.
+
+// debugView is one of the built-in visualisations that can be switched on.
+type debugView int
+
+const (
+	debugBounds     debugView = iota // Every object's BoundingBox, as it is oriented in the world
+	debugPickRay                     // The last ray tryPickObject cast from the mouse
+	debugVelocities                  // Each moving object's velocity
+	debugHoldTarget                  // Where the held object is being pulled to
+	debugContacts                    // Contact points and normals found by updatePhysics
+	debugViewCount
+)
+
+// debugViewNames are the names shown on the toggle buttons.
+var debugViewNames = [debugViewCount]string{"Bounds", "Pick Ray", "Velocities", "Hold Target", "Contacts"}
+
+// Debug drawing constants
+const (
+	debugVertexFloats    = 7    // Floats per line vertex: position (3) + color (4)
+	debugCircleSegments  = 24   // Lines per circle of a debug sphere
+	debugArrowHeadSize   = 0.15 // Share of an arrow's length taken by its head
+	debugVelocityScale   = 0.25 // Velocity arrows show where an object will be in this many seconds
+	debugContactDuration = 0.2  // Seconds a contact stays visible, contacts of a few physics steps overlap
+	debugContactSize     = 0.05 // Radius of the sphere marking a contact point
+	debugNormalLength    = 0.3  // Length of the arrow showing a contact's normal
+)
+
+// Colors of the built-in visualisations
+var (
+	debugBoundsColor     = mgl32.Vec4{1, 1, 0, 1}
+	debugRayHitColor     = mgl32.Vec4{0, 1, 0, 1}
+	debugRayMissColor    = mgl32.Vec4{1, 0, 0, 1}
+	debugVelocityColor   = mgl32.Vec4{0, 1, 1, 1}
+	debugContactColor    = mgl32.Vec4{1, 0, 1, 1}
+	debugHoldTargetColor = mgl32.Vec4{1, 1, 1, 1}
+)
+
+// debugLine is a single line segment waiting to be drawn.
+type debugLine struct {
+	from, to mgl32.Vec3
+	color    mgl32.Vec4
+	expires  time.Time // Drawn until then, and at least once
+}
+
+// DebugDraw collects debug shapes and draws them.
+type DebugDraw struct {
+	Show [debugViewCount]bool // Which built-in visualisations are on
+
+	lines    []debugLine
+	vertices []float32 // Reused between frames
+
+	program               uint32
+	viewProjectionUniform int32
+	vao, vbo              uint32
+	bufferSize            int // Bytes allocated for vbo
+}
+
+const debugVertexShaderSource = `
+	#version 410 core
+	layout (location = 0) in vec3 aPos;
+	layout (location = 1) in vec4 aColor;
+
+	out vec4 lineColor;
+
+	uniform mat4 viewProjection;
+
+	void main() {
+		gl_Position = viewProjection * vec4(aPos, 1.0);
+		lineColor = aColor;
+	}
+` + "\x00"
+
+const debugFragmentShaderSource = `
+	#version 410 core
+	in vec4 lineColor;
+	out vec4 FragColor;
+
+	void main() {
+		FragColor = lineColor;
+	}
+` + "\x00"
+
+// newDebugDraw compiles the line shader and creates the buffer the lines are streamed into.
+func newDebugDraw() (*DebugDraw, error) {
+	d := &DebugDraw{bufferSize: 64 * 1024}
+	program, err := compileShader(debugVertexShaderSource, debugFragmentShaderSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile debug line shaders: %w", err)
+	}
+	d.program = program
+	d.viewProjectionUniform = gl.GetUniformLocation(program, gl.Str("viewProjection\x00"))
+
+	gl.GenVertexArrays(1, &d.vao)
+	gl.BindVertexArray(d.vao)
+	gl.GenBuffers(1, &d.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, d.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, d.bufferSize, nil, gl.STREAM_DRAW)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, debugVertexFloats*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 4, gl.FLOAT, false, debugVertexFloats*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+	gl.BindVertexArray(0)
+	return d, nil
+}
+
+// Line adds a line from one point to another, kept for duration seconds.
+func (d *DebugDraw) Line(from, to mgl32.Vec3, color mgl32.Vec4, duration float32) {
+	expires := time.Now().Add(time.Duration(duration * float32(time.Second)))
+	d.lines = append(d.lines, debugLine{from: from, to: to, color: color, expires: expires})
+}
+
+// Box adds the 12 edges of an oriented box.
+func (d *DebugDraw) Box(box OrientedBox, color mgl32.Vec4, duration float32) {
+	corners := box.corners()
+	// Corners whose index differs in one bit differ along one axis, so they share an edge
+	for i := range corners {
+		for axis := 0; axis < 3; axis++ {
+			if i&(1<<axis) == 0 {
+				d.Line(corners[i], corners[i|1<<axis], color, duration)
+			}
+		}
+	}
+}
+
+// AABB adds the edges of an axis-aligned world-space box.
+func (d *DebugDraw) AABB(box BoundingBox, color mgl32.Vec4, duration float32) {
+	d.Box(OrientedBox{
+		Center:      box.Min.Add(box.Max).Mul(0.5),
+		Axes:        [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		HalfExtents: box.Max.Sub(box.Min).Mul(0.5),
+	}, color, duration)
+}
+
+// Sphere adds a sphere as three circles, one around each axis.
+func (d *DebugDraw) Sphere(center mgl32.Vec3, radius float32, color mgl32.Vec4, duration float32) {
+	axes := [3]mgl32.Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+	for i := range axes {
+		u, v := axes[(i+1)%3].Mul(radius), axes[(i+2)%3].Mul(radius)
+		previous := center.Add(u)
+		for segment := 1; segment <= debugCircleSegments; segment++ {
+			angle := float64(segment) / debugCircleSegments * 2 * math.Pi
+			point := center.Add(u.Mul(float32(math.Cos(angle)))).Add(v.Mul(float32(math.Sin(angle))))
+			d.Line(previous, point, color, duration)
+			previous = point
+		}
+	}
+}
+
+// Arrow adds a line from one point to another with a head at the end.
+func (d *DebugDraw) Arrow(from, to mgl32.Vec3, color mgl32.Vec4, duration float32) {
+	d.Line(from, to, color, duration)
+	shaft := to.Sub(from)
+	length := shaft.Len()
+	if length < 1e-6 {
+		return
+	}
+	dir := shaft.Mul(1 / length)
+	u, v := perpendicularAxes(dir)
+	headSize := length * debugArrowHeadSize
+	base := to.Sub(dir.Mul(headSize))
+	for _, side := range []mgl32.Vec3{u, u.Mul(-1), v, v.Mul(-1)} {
+		d.Line(to, base.Add(side.Mul(headSize*0.5)), color, duration)
+	}
+}
+
+// Axes adds the X, Y and Z axes at a point, in red, green and blue.
+func (d *DebugDraw) Axes(position mgl32.Vec3, size, duration float32) {
+	d.Line(position, position.Add(mgl32.Vec3{size, 0, 0}), mgl32.Vec4{1, 0, 0, 1}, duration)
+	d.Line(position, position.Add(mgl32.Vec3{0, size, 0}), mgl32.Vec4{0, 1, 0, 1}, duration)
+	d.Line(position, position.Add(mgl32.Vec3{0, 0, size}), mgl32.Vec4{0, 0, 1, 1}, duration)
+}
+
+// draw draws every line with one draw call, then forgets the ones that have expired.
+// Lines are drawn over the scene without depth testing, so they show through objects.
+func (d *DebugDraw) draw(viewProjection mgl32.Mat4) {
+	if len(d.lines) == 0 {
+		return
+	}
+
+	d.vertices = d.vertices[:0]
+	for _, line := range d.lines {
+		d.vertices = append(d.vertices,
+			line.from.X(), line.from.Y(), line.from.Z(), line.color.X(), line.color.Y(), line.color.Z(), line.color.W(),
+			line.to.X(), line.to.Y(), line.to.Z(), line.color.X(), line.color.Y(), line.color.Z(), line.color.W())
+	}
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.UseProgram(d.program)
+	gl.UniformMatrix4fv(d.viewProjectionUniform, 1, false, &viewProjection[0])
+	gl.BindVertexArray(d.vao)
+	gl.BindBuffer(gl.ARRAY_BUFFER, d.vbo)
+
+	// Same streaming as the UI buffer: fresh storage every frame, doubled when too small
+	size := len(d.vertices) * 4
+	for d.bufferSize < size {
+		d.bufferSize *= 2
+	}
+	gl.BufferData(gl.ARRAY_BUFFER, d.bufferSize, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(d.vertices))
+	gl.DrawArrays(gl.LINES, 0, int32(len(d.vertices)/debugVertexFloats))
+
+	gl.BindVertexArray(0)
+	gl.Disable(gl.BLEND)
+	gl.Enable(gl.DEPTH_TEST)
+
+	now := time.Now()
+	kept := d.lines[:0]
+	for _, line := range d.lines {
+		if line.expires.After(now) {
+			kept = append(kept, line)
+		}
+	}
+	d.lines = kept
+}
+
+// toggle switches a built-in visualisation on or off.
+func (d *DebugDraw) toggle(view debugView) {
+	d.Show[view] = !d.Show[view]
+}
+
+// release deletes the debug renderer's GPU resources.
+func (d *DebugDraw) release() {
+	gl.DeleteProgram(d.program)
+	gl.DeleteVertexArrays(1, &d.vao)
+	gl.DeleteBuffers(1, &d.vbo)
+}
+
+// addDebugViews adds this frame's shapes for the visualisations that are on.
+func (a *AppCore) addDebugViews() {
+	d := a.debug
+	if d.Show[debugBounds] {
+		for _, obj := range a.objects {
+			d.Box(obj.orientedBox(), debugBoundsColor, 0)
+		}
+	}
+	if d.Show[debugPickRay] && a.hasPickRay {
+		end := a.pickRayOrigin.Add(a.pickRayDirection.Mul(a.pickRayLength))
+		if a.pickRayHit {
+			d.Line(a.pickRayOrigin, end, debugRayHitColor, 0)
+			d.Sphere(end, debugContactSize, debugRayHitColor, 0)
+		} else {
+			d.Line(a.pickRayOrigin, end, debugRayMissColor, 0)
+		}
+	}
+	if d.Show[debugVelocities] {
+		for _, obj := range a.objects {
+			if obj.IsKinematic || obj.Velocity.Len() < SettleSpeed {
+				continue
+			}
+			center := obj.orientedBox().Center
+			d.Arrow(center, center.Add(obj.Velocity.Mul(debugVelocityScale)), debugVelocityColor, 0)
+		}
+	}
+	if d.Show[debugHoldTarget] && a.heldObject != nil {
+		target := a.holdTarget()
+		d.Axes(target, 0.5, 0)
+		d.Sphere(target, debugContactSize, debugHoldTargetColor, 0)
+	}
+}
+
+// addDebugContacts marks the contacts of a physics step, when contacts are shown.
+func (a *AppCore) addDebugContacts(manifolds []ContactManifold) {
+	if !a.debug.Show[debugContacts] {
+		return
+	}
+	for _, m := range manifolds {
+		for _, point := range m.Points {
+			a.debug.Sphere(point.Position, debugContactSize, debugContactColor, debugContactDuration)
+			a.debug.Arrow(point.Position, point.Position.Add(m.Normal.Mul(debugNormalLength)), debugContactColor, debugContactDuration)
+		}
+	}
+}
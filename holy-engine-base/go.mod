@@ -3,8 +3,11 @@ go 1.19
 require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 	github.com/go-gl/mathgl v1.2.0
+	github.com/toxichemicals/GO/holy-shared v0.0.0-00010101000000-000000000000
 )
 
 require github.com/go-gl/glfw/v3.3/glfw v0.0.0-20250301202403-da16c1255728
 
 module github.com/toxichemicals/GO/toxic-engine
+
+replace github.com/toxichemicals/GO/holy-shared => ../holy-shared
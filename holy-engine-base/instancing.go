@@ -0,0 +1,249 @@
+package main
+
+import (
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// Instanced drawing: objects that use the same mesh and textures are drawn together with one
+// instanced draw call. Every primitive of a type shares one Mesh, so a scene of thousands of
+// cubes is a handful of draw calls. Each object's model matrix, normal matrix and color go
+// into one instance buffer, filled once per frame and read by both the shadow and main pass.
+// Blended objects can't be batched, they are drawn one by one in back-to-front order.
+// Within a batch the instances in view come first, so the main pass can draw just those
+// while the shadow pass draws them all (see culling.go).
+
+// Per-instance vertex attributes, after the per-vertex ones (locations 0-4)
+const (
+	instanceFloats         = 29 // Floats per instance: model matrix (16) + normal matrix (9) + color (4)
+	instanceModelLocation  = 5  // mat4, takes locations 5-8
+	instanceNormalLocation = 9  // mat3, takes locations 9-11
+	instanceColorLocation  = 12
+)
+
+// Mesh is vertex and index data uploaded to the GPU. GameObjects embed the mesh they are
+// drawn from; primitives share theirs, models own theirs.
+type Mesh struct {
+	Vertices      []float32 // Interleaved position (3) + color (3) + texcoord (2) + normal (3) + tangent (4)
+	Indices       []uint32
+	VAO, VBO, EBO uint32
+	IndicesCount  int32
+	shared        bool // Owned by AppCore.primitiveMeshes, not by the objects drawn from it
+}
+
+// drawBatch is a run of instances drawn with one instanced draw call.
+type drawBatch struct {
+	mesh            *Mesh
+	textureID       uint32 // 0 draws vertex colors
+	normalMapID     uint32 // 0 when there is no normal map
+	unlit           bool   // Light markers, drawn flat in their instance color
+	castsShadow     bool
+	alphaMode       alphamode.Mode
+	alphaCutoff     float32
+	alphaToCoverage bool
+	firstInstance   int32 // The batch's first instance in the instance buffer
+	instanceCount   int32
+	visibleCount    int32 // Instances in the camera's view, at the start of the batch
+}
+
+// drawBatchKey is what objects must have in common to share a batch.
+type drawBatchKey struct {
+	mesh                   *Mesh
+	textureID, normalMapID uint32
+	unlit, castsShadow     bool
+	alphaMode              alphamode.Mode
+	alphaCutoff            float32
+	alphaToCoverage        bool
+}
+
+// setupInstanceBuffer creates the instance buffer every mesh's vertex array reads its
+// per-instance attributes from. It must exist before the first mesh is uploaded.
+func (a *AppCore) setupInstanceBuffer() {
+	gl.GenBuffers(1, &a.instanceVBO)
+	a.primitiveMeshes = make(map[string]*Mesh)
+}
+
+// newMesh uploads vertices and indices into a new vertex array, with the per-vertex
+// attributes read from its own buffers and the per-instance ones from a.instanceVBO.
+func (a *AppCore) newMesh(vertices []float32, indices []uint32) *Mesh {
+	mesh := &Mesh{Vertices: vertices, Indices: indices, IndicesCount: int32(len(indices))}
+
+	gl.GenVertexArrays(1, &mesh.VAO)
+	gl.BindVertexArray(mesh.VAO)
+
+	gl.GenBuffers(1, &mesh.VBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, mesh.VBO)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.GenBuffers(1, &mesh.EBO)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, mesh.EBO)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	// Per-vertex attributes, 3 pos + 3 color + 2 texcoord + 3 normal + 4 tangent
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, vertexFloats*4, gl.Ptr(nil)) // Position
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(3*4)) // Color
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(6*4)) // TexCoord
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(3, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(8*4)) // Normal
+	gl.EnableVertexAttribArray(3)
+	gl.VertexAttribPointer(4, 4, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(11*4)) // Tangent
+	gl.EnableVertexAttribArray(4)
+
+	// Per-instance attributes advance once per instance. Matrices take one location per column.
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.instanceVBO)
+	instanceAttribute := func(location uint32, size int32, offset int) {
+		gl.VertexAttribPointer(location, size, gl.FLOAT, false, instanceFloats*4, gl.PtrOffset(offset*4))
+		gl.EnableVertexAttribArray(location)
+		gl.VertexAttribDivisor(location, 1)
+	}
+	for column := 0; column < 4; column++ {
+		instanceAttribute(uint32(instanceModelLocation+column), 4, column*4)
+	}
+	for column := 0; column < 3; column++ {
+		instanceAttribute(uint32(instanceNormalLocation+column), 3, 16+column*3)
+	}
+	instanceAttribute(instanceColorLocation, 4, 25)
+
+	gl.BindVertexArray(0)
+	return mesh
+}
+
+// primitiveMesh returns the mesh every primitive of shapeType shares, generating and uploading
+// it the first time one is made.
+func (a *AppCore) primitiveMesh(shapeType string, generate func() ([]float32, []uint32)) *Mesh {
+	if mesh, ok := a.primitiveMeshes[shapeType]; ok {
+		return mesh
+	}
+	vertices, indices := generate()
+	// Cubes and planes come with their tangents, the curved shapes get them here
+	vertices, indices = meshgen.GenerateTangents(vertices, indices, vertexLayout)
+	mesh := a.newMesh(vertices, indices)
+	mesh.shared = true
+	a.primitiveMeshes[shapeType] = mesh
+	return mesh
+}
+
+// release deletes the mesh's vertex array and buffers.
+func (mesh *Mesh) release() {
+	gl.DeleteVertexArrays(1, &mesh.VAO)
+	gl.DeleteBuffers(1, &mesh.VBO)
+	gl.DeleteBuffers(1, &mesh.EBO)
+}
+
+// instanceColor is the color an object's instance is drawn with: the light's color for light
+// markers, otherwise the object's tint.
+func (obj *GameObject) instanceColor() mgl32.Vec4 {
+	if obj.Light != nil {
+		return obj.Light.Color.Vec4(1)
+	}
+	return obj.Color
+}
+
+// buildDrawBatches groups the objects into a.drawBatches, in the order their first object
+// appears, and uploads every instance to the instance buffer. Blended objects come last, one
+// batch each from the farthest to the nearest, starting at a.firstBlendedBatch. It runs once
+// per frame, before the shadow pass.
+func (a *AppCore) buildDrawBatches() {
+	a.drawBatches = a.drawBatches[:0]
+	batchIndex := make(map[drawBatchKey]int)
+	var members [][]*GameObject
+	var blended []*GameObject
+	for _, obj := range a.objects {
+		mode := obj.alphaMode()
+		if mode == alphamode.Blend {
+			blended = append(blended, obj)
+			continue
+		}
+		key := drawBatchKey{mesh: obj.Mesh, normalMapID: obj.NormalMapID, unlit: obj.Light != nil, castsShadow: castsShadow(obj), alphaMode: mode}
+		if obj.HasTexture {
+			key.textureID = obj.TextureID
+		}
+		if mode == alphamode.Mask {
+			key.alphaCutoff = obj.AlphaCutoff
+			key.alphaToCoverage = obj.AlphaToCoverage
+		}
+		i, ok := batchIndex[key]
+		if !ok {
+			i = len(a.drawBatches)
+			batchIndex[key] = i
+			a.drawBatches = append(a.drawBatches, drawBatch{mesh: key.mesh, textureID: key.textureID, normalMapID: key.normalMapID, unlit: key.unlit, castsShadow: key.castsShadow,
+				alphaMode: key.alphaMode, alphaCutoff: key.alphaCutoff, alphaToCoverage: key.alphaToCoverage})
+			members = append(members, nil)
+		}
+		members[i] = append(members[i], obj)
+	}
+
+	a.firstBlendedBatch = len(a.drawBatches)
+	a.sortBackToFront(blended)
+	for _, obj := range blended {
+		batch := drawBatch{mesh: obj.Mesh, normalMapID: obj.NormalMapID, castsShadow: castsShadow(obj), alphaMode: alphamode.Blend}
+		if obj.HasTexture {
+			batch.textureID = obj.TextureID
+		}
+		a.drawBatches = append(a.drawBatches, batch)
+		members = append(members, []*GameObject{obj})
+	}
+
+	view, projection := a.cameraMatrices()
+	frustum := frustumFromMatrix(projection.Mul4(view))
+	a.visibleObjects, a.culledObjects = 0, 0
+
+	// In the false color view each object gets its own color, by its place in the scene
+	var falseColors map[*GameObject]mgl32.Vec4
+	if a.viewMode == viewmode.FalseColor {
+		falseColors = make(map[*GameObject]mgl32.Vec4, len(a.objects))
+		for i, obj := range a.objects {
+			falseColors[obj] = viewmode.Color(i).Vec4(obj.Color.W())
+		}
+	}
+
+	a.instanceData = a.instanceData[:0]
+	for i := range a.drawBatches {
+		// Moves the members in view to the front
+		visible := 0
+		for j, obj := range members[i] {
+			if frustum.intersectsBox(obj.renderBounds()) {
+				members[i][visible], members[i][j] = members[i][j], members[i][visible]
+				visible++
+			}
+		}
+		a.visibleObjects += visible
+		a.culledObjects += len(members[i]) - visible
+
+		a.drawBatches[i].firstInstance = int32(len(a.instanceData) / instanceFloats)
+		a.drawBatches[i].instanceCount = int32(len(members[i]))
+		a.drawBatches[i].visibleCount = int32(visible)
+		for _, obj := range members[i] {
+			model := obj.modelMatrix()
+			normalMatrix := model.Mat3().Inv().Transpose() // Keeps normals right under non-uniform scale
+			color := obj.instanceColor()
+			if falseColors != nil && obj.Light == nil {
+				color = falseColors[obj]
+			}
+			a.instanceData = append(a.instanceData, model[:]...)
+			a.instanceData = append(a.instanceData, normalMatrix[:]...)
+			a.instanceData = append(a.instanceData, color[:]...)
+		}
+	}
+
+	// Re-specifying the whole buffer lets the driver hand out fresh memory instead of waiting
+	// for last frame's draws to finish reading it
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.instanceVBO)
+	if len(a.instanceData) > 0 {
+		gl.BufferData(gl.ARRAY_BUFFER, len(a.instanceData)*4, gl.Ptr(a.instanceData), gl.STREAM_DRAW)
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
+
+// drawInstances issues the instanced draw call of the first count instances of a batch:
+// instanceCount for all of them, visibleCount for those in view.
+func drawInstances(batch *drawBatch, count int32) {
+	gl.BindVertexArray(batch.mesh.VAO)
+	gl.DrawElementsInstancedBaseInstance(gl.TRIANGLES, batch.mesh.IndicesCount, gl.UNSIGNED_INT, nil, count, uint32(batch.firstInstance))
+}
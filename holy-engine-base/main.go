@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"image"
 	"image/draw"
@@ -9,14 +11,23 @@ import (
 	"log"
 	"math"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
-	"unsafe" // For gl.PtrOffset
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/bitfont"
+	"github.com/toxichemicals/GO/holy-shared/holym"
+	"github.com/toxichemicals/GO/holy-shared/post"
+	"github.com/toxichemicals/GO/holy-shared/scene"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
 )
 
 // Constants for window dimensions and viewer parameters
@@ -38,8 +49,40 @@ const (
 	MinHoldDistance     = 1.0
 	MaxHoldDistance     = 5.0
 	ScrollSensitivity   = 0.1 // For adjusting hold distance
+	DefaultModelMass    = 1.0 // Mass given to imported .holym and .holymb models
+
+	// Object-vs-object collision constants
+	Restitution         = 0.3  // Bounciness of contacts between objects (0 = dead stop, 1 = perfectly elastic)
+	FrictionCoefficient = 0.5  // Coulomb friction for contacts between objects
+	SolverIterations    = 16   // Impulse solver passes per physics step, more passes give steadier stacks
+	PenetrationSlop     = 0.01 // Overlap allowed before positional correction kicks in
+	PenetrationPercent  = 0.8  // Fraction of the remaining overlap removed each physics step
+	RestingSpeed        = 0.5  // Closing speeds below this don't bounce, which keeps resting contacts quiet
+	SettleSpeed         = 0.05 // Grounded objects moving/spinning slower than this are stopped, so stacks don't creep
+)
+
+// Lighting constants
+const (
+	vertexFloats          = holym.GameObjectFloats // Floats per vertex in GameObject.Vertices: position (3) + color (3) + texcoord (2) + normal (3) + tangent (4)
+	maxPointLights        = 8                      // Must match MAX_POINT_LIGHTS in the scene shader, further lights are ignored
+	DefaultSunYaw         = 60.0                   // Degrees around the Y axis the sunlight comes from
+	DefaultSunPitch       = 50.0                   // Degrees above the horizon
+	DefaultSunIntensity   = 0.8
+	DefaultAmbient        = 0.25 // Light that reaches every surface, so shadowed sides aren't black
+	DefaultLightIntensity = 1.5
+	DefaultLightRange     = 8.0 // Point lights fade out completely at this distance
+	LightMarkerScale      = 0.2 // Size of the sphere that shows where a point light is
+
+	// Shadow mapping constants
+	ShadowMapSize      = 2048 // Width and height of the sun's depth map in texels
+	ShadowAreaHalfSize = 50.0 // Half the side of the square around the origin that receives shadows, the ground plane's size
+	ShadowDepthMargin  = 10.0 // Extra depth in front of and behind the casters, so nothing is clipped by the light's frustum
 )
 
+// vertexLayout is where the attributes are in GameObject.Vertices, for holy-shared/meshgen.
+// It is the layout .holym meshes are loaded in.
+var vertexLayout = holym.GameObjectLayout
+
 // UI Constants - Explicitly define as float32
 const (
 	uiPanelWidth     float32 = 250.0
@@ -47,7 +90,11 @@ const (
 	uiButtonHeight   float32 = 30.0
 	uiSliderHeight   float32 = 20.0
 	uiElementSpacing float32 = 5.0
+	uiTextScale      float32 = 2.0 // Font glyphs are drawn at twice their pixel size
 	uiTextHeight     float32 = 16.0 // Approximate height for a line of text
+
+	uiVertexFloats = 9    // Floats per UI vertex: position (2) + atlas coordinates (2) + color (4) + glyph flag (1)
+	uiInitialQuads = 1024 // Quads the UI buffer has room for at first, it grows when a batch needs more
 )
 
 // AppCore struct encapsulates the editor's state and rendering components.
@@ -55,17 +102,75 @@ type AppCore struct {
 	window *glfw.Window
 
 	// OpenGL program and uniform locations for 3D scene
-	program           uint32
-	modelUniform      int32
-	viewUniform       int32
-	projectionUniform int32
-	textureUniform    int32
-	hasTextureUniform int32 // Uniform to tell shader if texture is present
+	program             uint32
+	viewUniform         int32
+	projectionUniform   int32
+	textureUniform      int32
+	hasTextureUniform   int32 // Uniform to tell shader if texture is present
+	hasNormalMapUniform int32
+	viewPosUniform      int32
+	unlitUniform        int32              // Draws objects flat in their instance color, for light markers
+	alphaUniforms       alphamode.Uniforms // See holy-shared/alphamode
+	viewModeUniform     int32              // See holy-shared/viewmode
+	wireframeUniform    int32
+	depthRangeUniform   int32
+
+	// Lighting uniforms, see applyLighting
+	sunDirectionUniform        int32
+	sunColorUniform            int32
+	ambientColorUniform        int32
+	pointLightCountUniform     int32
+	pointLightPositionsUniform int32
+	pointLightColorsUniform    int32
+	pointLightRangesUniform    int32
+	lighting                   Lighting
+
+	// Shadow map of the sun, rendered by renderShadowMap before the main pass
+	shadowProgram              uint32
+	shadowFBO                  uint32
+	shadowMap                  uint32 // Depth texture
+	shadowLightSpaceUniform    int32
+	lightSpaceMatrixUniform    int32 // Scene shader's copy of the light's view-projection
+	shadowMapUniform           int32
+	shadowsEnabledUniform      int32
+	shadowsEnabled             bool
+
+	// View mode and wireframe overlay, see holy-shared/viewmode
+	viewMode  viewmode.Mode
+	wireframe bool
+	viewKeys  viewmode.Keys
+
+	// Offscreen HDR target and post-processing passes, see holy-shared/post
+	post *post.Chain
+
+	// Debug lines drawn over the scene, see debug.go
+	debug            *DebugDraw
+	hasPickRay       bool       // Set once tryPickObject has cast a ray
+	pickRayOrigin    mgl32.Vec3 // Last ray cast by tryPickObject
+	pickRayDirection mgl32.Vec3
+	pickRayLength    float32 // To the hit, or PickupRange on a miss
+	pickRayHit       bool
+	debugPanelOpen   bool // Debug Draw section of the Tools panel is expanded
+
+	// Instanced drawing, see instancing.go
+	instanceVBO       uint32           // Per-instance data of every batch, refilled each frame
+	instanceData      []float32        // CPU copy of the instance buffer, reused between frames
+	drawBatches       []drawBatch      // This frame's batches, from buildDrawBatches
+	firstBlendedBatch int              // Batches from here on are blended, back to front
+	visibleObjects    int              // Objects in the camera's view last frame, see culling.go
+	culledObjects     int              // Objects left out of last frame's main pass
+	primitiveMeshes   map[string]*Mesh // Shared mesh of each primitive type, made on first use
 
 	// OpenGL program and uniforms for 2D UI
 	uiProgram         uint32
 	uiTransformUniform int32
-	uiColorUniform    int32
+
+	// Text rendering
+	fontTexture      uint32       // Atlas built by holy-shared/bitfont
+	uiVAO, uiVBO     uint32       // Streaming buffer every UI batch is drawn from
+	uiBufferSize     int          // Bytes allocated for uiVBO
+	uiVertices       []float32    // The UI batch: quads waiting for flushUI, in drawing order
+	textQueue        []queuedText // Text waiting to be drawn on top of the UI
 
 	// Window dimensions and title
 	width, height int
@@ -114,22 +219,102 @@ type AppCore struct {
 	eKeyWasPressed bool // Debounce for 'E' key
 }
 
+// Lighting is the scene-wide light: a directional sun plus an ambient term.
+type Lighting struct {
+	SunYaw       float32 // Degrees around the Y axis the sunlight comes from
+	SunPitch     float32 // Degrees above the horizon
+	SunColor     mgl32.Vec3
+	SunIntensity float32
+	Ambient      float32 // Strength of the ambient light, it has the sun's color
+}
+
+// sunDirection returns the direction the sunlight travels in.
+func (l Lighting) sunDirection() mgl32.Vec3 {
+	yaw := float64(mgl32.DegToRad(l.SunYaw))
+	pitch := float64(mgl32.DegToRad(l.SunPitch))
+	towardsSun := mgl32.Vec3{
+		float32(math.Cos(pitch) * math.Cos(yaw)),
+		float32(math.Sin(pitch)),
+		float32(math.Cos(pitch) * math.Sin(yaw)),
+	}
+	return towardsSun.Mul(-1)
+}
+
+// PointLight turns a GameObject into a light shining from its Position in every direction.
+type PointLight struct {
+	Color     mgl32.Vec3
+	Intensity float32
+	Range     float32 // Distance at which the light has faded out completely
+}
+
 // BoundingBox defines an Axis-Aligned Bounding Box.
 type BoundingBox struct {
 	Min mgl32.Vec3
 	Max mgl32.Vec3
 }
 
+// OrientedBox is an object's BoundingBox after its model matrix has been applied,
+// so unlike a world AABB it turns together with GameObject.Rotation.
+type OrientedBox struct {
+	Center      mgl32.Vec3
+	Axes        [3]mgl32.Vec3 // The object's local X, Y and Z axes in world space, unit length
+	HalfExtents mgl32.Vec3    // Half the box size along each of Axes
+}
+
+// ColliderShape selects which narrowphase test is used for a GameObject.
+// Both shapes are built from the object's BoundingBox and Scale.
+type ColliderShape int
+
+const (
+	ColliderBox      ColliderShape = iota // Box spanning the BoundingBox
+	ColliderSphere                        // Sphere centered in the BoundingBox, touching its largest side
+	ColliderCylinder                      // Cylinder around the local Y axis
+	ColliderCone                          // Cone around the local Y axis, tip at the top of the BoundingBox
+	ColliderCapsule                       // Cylinder with half-sphere ends around the local Y axis
+	ColliderTorus                         // Ring around the local Y axis, collides as its convex hull (the hole is filled)
+)
+
+// ContactManifold describes where two objects touch, as found by the narrowphase.
+// Box contacts carry up to four points so stacks don't tip over a single pivot.
+type ContactManifold struct {
+	A, B        *GameObject
+	Normal      mgl32.Vec3 // Points from A towards B
+	Penetration float32    // Overlap depth along Normal
+	Points      []ContactPoint
+
+	// Filled in by prepareManifold
+	tangents                 [2]mgl32.Vec3 // Friction directions
+	tangentImpulse           [2]float32    // Accumulated friction impulses
+	twistImpulse             float32       // Accumulated friction against spinning around the normal
+	invMassA, invMassB       float32
+	invInertiaA, invInertiaB mgl32.Mat3
+}
+
+// ContactPoint is a single world-space point of a ContactManifold plus its solver state.
+type ContactPoint struct {
+	Position mgl32.Vec3
+
+	bounceVelocity float32 // Target separating speed from restitution
+	normalImpulse  float32 // Accumulated impulse, clamped across solver iterations
+}
+
 // GameObject represents a loaded or procedurally generated 3D model.
 type GameObject struct {
-	ID           string
-	Vertices     []float32 // Interleaved position (3) + color (3) + texcoord (2)
-	Indices      []uint32
-	VAO, VBO, EBO uint32
-	IndicesCount int32
-	HasTexture   bool
-	TextureID    uint32
-	TexturePath  string // Path to the original texture file
+	ID              string
+	*Mesh                      // Vertices, indices and buffers, shared by all primitives of a type
+	Color           mgl32.Vec4 // Multiplied into the albedo, white by default
+	HasTexture      bool
+	TextureID       uint32
+	TexturePath     string            // Path to the original texture file
+	NormalMapID     uint32            // 0 when the object has no normal map
+	NormalMap       string            // Path to the normal map file
+	AlphaMode       alphamode.Mode    // How the alpha is used, from the texture unless set, see holy-shared/alphamode
+	AlphaCutoff     float32           // Alpha below this is cut out in the mask mode
+	AlphaToCoverage bool              // Antialias masked edges with MSAA instead of cutting them hard
+	Primitive       string            // Primitive type the mesh was generated from ("cube", "sphere", ...), empty for models
+	ModelPath       string            // Model file the mesh was loaded from, empty for primitives
+	holymb          *holym.BinaryMesh // Mapped .holymb file Vertices and Indices point into, nil otherwise
+	Light           *PointLight       // Set for point lights, the mesh then only marks where the light is
 
 	// Transformation fields
 	Position mgl32.Vec3
@@ -137,12 +322,13 @@ type GameObject struct {
 	Scale    mgl32.Vec3
 
 	// Physics fields
-	Velocity      mgl32.Vec3 // Linear velocity
-	AngularVelocity mgl32.Vec3 // Angular velocity (radians/second)
-	IsKinematic   bool       // If true, object is moved directly, not by physics
-	IsGrounded    bool       // True if object is touching the ground
-	BoundingBox   BoundingBox // Local-space bounding box
-	Mass          float32    // For physics calculations (e.g., momentum)
+	Velocity        mgl32.Vec3    // Linear velocity
+	AngularVelocity mgl32.Vec3    // Angular velocity (radians/second)
+	IsKinematic     bool          // If true, object is moved directly, not by physics
+	IsGrounded      bool          // True if object is touching the ground
+	BoundingBox     BoundingBox   // Local-space bounding box
+	Mass            float32       // For physics calculations (e.g., momentum)
+	Shape           ColliderShape // Narrowphase shape used for object-vs-object collisions
 }
 
 // Global instance of AppCore
@@ -161,15 +347,17 @@ func initApp() error {
 		nextObjectID: 0,
 
 		// Initialize camera state
-		cameraPos:   mgl32.Vec3{0, 2.0, 5.0}, // Start slightly above ground, zoomed out
-		cameraFront: mgl32.Vec3{0, 0, -1},    // Looking towards negative Z
-		cameraUp:    mgl32.Vec3{0, 1, 0},     // Up direction
-		yaw:         -90.0,                    // Yaw to look along negative Z initially
-		pitch:       0.0,                      // No pitch initially
-		firstMouse:  true,
-		isMouseGrabbed: true, // Start with mouse grabbed for immediate camera control
-		holdDistance: InitialHoldDistance, // Default hold distance
-		isEGUIVisible: false, // E GUI is hidden by default
+		cameraPos:      mgl32.Vec3{0, 2.0, 5.0}, // Start slightly above ground, zoomed out
+		cameraFront:    mgl32.Vec3{0, 0, -1},    // Looking towards negative Z
+		cameraUp:       mgl32.Vec3{0, 1, 0},     // Up direction
+		yaw:            -90.0,                   // Yaw to look along negative Z initially
+		pitch:          0.0,                     // No pitch initially
+		firstMouse:     true,
+		isMouseGrabbed: true,                // Start with mouse grabbed for immediate camera control
+		holdDistance:   InitialHoldDistance, // Default hold distance
+		isEGUIVisible:  false,               // E GUI is hidden by default
+		lighting:       defaultLighting(),
+		shadowsEnabled: true,
 	}
 
 	// Initialize GLFW window
@@ -195,6 +383,29 @@ func initApp() error {
 		return fmt.Errorf("scene shader setup failed: %w", err)
 	}
 
+	// Every mesh's vertex array reads its per-instance attributes from the instance buffer
+	app.setupInstanceBuffer()
+
+	// Setup the sun's shadow map and the depth-only shader that renders it
+	if err := app.setupShadowMap(); err != nil {
+		return fmt.Errorf("shadow map setup failed: %w", err)
+	}
+
+	// The scene renders into the post-processing chain's HDR target. Lighting is done in
+	// linear space, so the chain tonemaps it and encodes it for the screen.
+	chain, err := post.NewChain(app.width, app.height, post.DefaultEffects...)
+	if err != nil {
+		return fmt.Errorf("post-processing setup failed: %w", err)
+	}
+	app.post = chain
+
+	// Lines for seeing bounding boxes, rays and contacts, all off until toggled
+	debug, err := newDebugDraw()
+	if err != nil {
+		return fmt.Errorf("debug draw setup failed: %w", err)
+	}
+	app.debug = debug
+
 	// Setup 2D UI shaders and get uniform locations
 	if err := app.setupUIShadersAndUniforms(); err != nil {
 		return fmt.Errorf("UI shader setup failed: %w", err)
@@ -239,6 +450,11 @@ func (a *AppCore) initializeWindow() error {
 		a.height = height
 		gl.Viewport(0, 0, int32(width), int32(height))
 		a.updateCameraAndProjection() // Update projection on resize
+		if a.post != nil {
+			if err := a.post.Resize(width, height); err != nil {
+				log.Printf("Warning: Failed to resize the post-processing targets: %v", err)
+			}
+		}
 	})
 
 	a.window.SetCursorPosCallback(func(_ *glfw.Window, xpos, ypos float64) {
@@ -345,43 +561,171 @@ func (a *AppCore) initializeOpenGL() error {
 
 // setupSceneShadersAndUniforms compiles shaders for the 3D scene.
 func (a *AppCore) setupSceneShadersAndUniforms() error {
-	// Vertex shader that supports both color and texture
+	// Vertex shader that supports both color and texture, and passes on what lighting needs
 	vertexShaderSource := `
 		#version 410 core
 		layout (location = 0) in vec3 aPos;
 		layout (location = 1) in vec3 aColor; // For vertex colors
 		layout (location = 2) in vec2 aTexCoord; // For texture coordinates
+		layout (location = 3) in vec3 aNormal;
+		layout (location = 4) in vec4 aTangent; // Bitangent sign in w
+		// Per instance, see instancing.go
+		layout (location = 5) in mat4 aModel;
+		layout (location = 9) in mat3 aNormalMatrix; // Inverse transpose of the model matrix, keeps normals right under non-uniform scale
+		layout (location = 12) in vec4 aInstanceColor;
 
 		out vec3 ourColor;
 		out vec2 TexCoord;
+		out vec3 FragPos; // World space
+		out vec3 Normal;  // World space
+		out vec4 Tangent; // World space, w passed on
+		out vec4 InstanceColor;
 
-		uniform mat4 model;
 		uniform mat4 view;
 		uniform mat4 projection;
+		uniform mat4 lightSpaceMatrix; // The sun's view-projection, from renderShadowMap
+
+		out vec4 FragPosLightSpace;
 
 		void main() {
-			gl_Position = projection * view * model * vec4(aPos, 1.0);
+			vec4 worldPos = aModel * vec4(aPos, 1.0);
+			gl_Position = projection * view * worldPos;
 			ourColor = aColor;
 			TexCoord = aTexCoord;
+			FragPos = worldPos.xyz;
+			Normal = aNormalMatrix * aNormal;
+			Tangent = vec4(mat3(aModel) * aTangent.xyz, aTangent.w); // Tangents follow the surface, so the model matrix itself
+			FragPosLightSpace = lightSpaceMatrix * worldPos;
+			InstanceColor = aInstanceColor;
 		}
 	` + "\x00"
 
-	// Fragment shader that uses texture if available, otherwise vertex color
+	// Fragment shader that uses texture if available, otherwise vertex color, lit with
+	// Blinn-Phong by the sun, the ambient term and the point lights. A normal map bends
+	// the normal within the tangent frame.
 	fragmentShaderSource := `
 		#version 410 core
+		#define MAX_POINT_LIGHTS 8
+
 		in vec3 ourColor;
 		in vec2 TexCoord;
+		in vec3 FragPos;
+		in vec3 Normal;
+		in vec4 Tangent;
+		in vec4 FragPosLightSpace;
+		in vec4 InstanceColor;
 		out vec4 FragColor;
 
 		uniform sampler2D ourTexture;
+		uniform sampler2D normalMap; // Tangent space, OpenGL convention (green is the top of the image)
+		uniform bool hasNormalMap;
+		uniform sampler2DShadow shadowMap; // Compares depths itself, with linear filtering between texels
+		uniform bool shadowsEnabled;
 		uniform bool hasTexture; // To indicate if a texture is bound
+		uniform bool unlit;      // Light markers are drawn flat in their instance color
+	` + alphamode.ShaderUniforms + `
+	` + viewmode.ShaderUniforms + `
+
+		uniform vec3 viewPos;
+		uniform vec3 sunDirection; // Direction the sunlight travels in
+		uniform vec3 sunColor;     // Already multiplied by the intensity
+		uniform vec3 ambientColor;
+		uniform int pointLightCount;
+		uniform vec3 pointLightPositions[MAX_POINT_LIGHTS];
+		uniform vec3 pointLightColors[MAX_POINT_LIGHTS]; // Already multiplied by the intensity
+		uniform float pointLightRanges[MAX_POINT_LIGHTS];
+
+		const float shininess = 32.0;
+		const float specularStrength = 0.3;
+
+		// blinnPhong returns the diffuse and specular light from one light.
+		// lightDir points from the surface towards the light.
+		vec3 blinnPhong(vec3 normal, vec3 lightDir, vec3 viewDir, vec3 lightColor, vec3 albedo) {
+			float diffuse = max(dot(normal, lightDir), 0.0);
+			float specular = 0.0;
+			if (diffuse > 0.0) {
+				vec3 halfway = normalize(lightDir + viewDir);
+				specular = pow(max(dot(normal, halfway), 0.0), shininess);
+			}
+			return lightColor * (diffuse * albedo + specularStrength * specular);
+		}
+
+		// sunVisibility returns how much of the sunlight reaches the fragment, 0 in full shadow.
+		// It averages a 3x3 block of shadow map comparisons (PCF) to soften the edges.
+		float sunVisibility(vec3 normal) {
+			if (!shadowsEnabled) {
+				return 1.0;
+			}
+			vec3 coords = FragPosLightSpace.xyz / FragPosLightSpace.w * 0.5 + 0.5;
+			if (coords.z > 1.0) {
+				return 1.0; // Beyond the light's far plane, nothing is known
+			}
+			// Surfaces at a grazing angle to the sun need a larger bias to avoid shadow acne
+			float bias = max(0.002 * (1.0 - dot(normal, -sunDirection)), 0.0005);
+			vec2 texelSize = 1.0 / vec2(textureSize(shadowMap, 0));
+			float visibility = 0.0;
+			for (int x = -1; x <= 1; x++) {
+				for (int y = -1; y <= 1; y++) {
+					visibility += texture(shadowMap, vec3(coords.xy + vec2(x, y) * texelSize, coords.z - bias));
+				}
+			}
+			return visibility / 9.0;
+		}
+
+		// surfaceNormal returns the interpolated normal, bent by the normal map if there is one.
+		// The bitangent is rebuilt per pixel from the unnormalized vectors, as MikkTSpace expects.
+		vec3 surfaceNormal() {
+			vec3 normal = normalize(Normal);
+			if (!hasNormalMap) {
+				return normal;
+			}
+			vec3 tangent = normalize(Tangent.xyz - normal * dot(normal, Tangent.xyz));
+			vec3 bitangent = Tangent.w * cross(Normal, Tangent.xyz);
+			vec3 mapped = texture(normalMap, TexCoord).xyz * 2.0 - 1.0;
+			return normalize(mapped.x * tangent + mapped.y * bitangent + mapped.z * normal);
+		}
+
+	` + alphamode.ShaderFunctions + `
+	` + viewmode.ShaderFunctions + `
 
 		void main() {
+			if (wireframe) {
+				FragColor = vec4(wireframeColor, 1.0);
+				return;
+			}
+			if (unlit) {
+				FragColor = vec4(InstanceColor.rgb, 1.0);
+				return;
+			}
+
+			vec4 albedo;
 			if (hasTexture) {
-				FragColor = texture(ourTexture, TexCoord);
+				albedo = texture(ourTexture, TexCoord);
 			} else {
-				FragColor = vec4(ourColor, 1.0);
+				albedo = vec4(ourColor, 1.0);
 			}
+			albedo *= InstanceColor;
+			albedo.a = applyAlphaMode(albedo.a);
+
+			if (viewMode != 0) {
+				FragColor = vec4(viewModeColor(albedo.rgb, InstanceColor.rgb), albedo.a);
+				return;
+			}
+			albedo.rgb = pow(albedo.rgb, vec3(2.2)); // Textures and vertex colors are sRGB, the lighting is linear
+
+			vec3 normal = surfaceNormal();
+			vec3 viewDir = normalize(viewPos - FragPos);
+			vec3 color = ambientColor * albedo.rgb;
+			color += sunVisibility(normalize(Normal)) * blinnPhong(normal, -sunDirection, viewDir, sunColor, albedo.rgb);
+			for (int i = 0; i < pointLightCount; i++) {
+				vec3 toLight = pointLightPositions[i] - FragPos;
+				float distance = length(toLight);
+				// Fades smoothly to nothing at the light's range
+				float attenuation = clamp(1.0 - distance / pointLightRanges[i], 0.0, 1.0);
+				attenuation *= attenuation;
+				color += attenuation * blinnPhong(normal, toLight / max(distance, 0.0001), viewDir, pointLightColors[i], albedo.rgb);
+			}
+			FragColor = vec4(color, albedo.a);
 		}
 	` + "\x00"
 
@@ -392,33 +736,257 @@ func (a *AppCore) setupSceneShadersAndUniforms() error {
 	gl.UseProgram(program)
 	a.program = program
 
-	a.modelUniform = gl.GetUniformLocation(a.program, gl.Str("model\x00"))
 	a.viewUniform = gl.GetUniformLocation(a.program, gl.Str("view\x00"))
 	a.projectionUniform = gl.GetUniformLocation(a.program, gl.Str("projection\x00"))
 	a.textureUniform = gl.GetUniformLocation(a.program, gl.Str("ourTexture\x00"))
 	a.hasTextureUniform = gl.GetUniformLocation(a.program, gl.Str("hasTexture\x00")) // Store uniform location
 	gl.Uniform1i(a.hasTextureUniform, 0) // Default to no texture
+	a.hasNormalMapUniform = gl.GetUniformLocation(a.program, gl.Str("hasNormalMap\x00"))
+	gl.Uniform1i(gl.GetUniformLocation(a.program, gl.Str("normalMap\x00")), 2) // Texture unit 2, after ourTexture and the shadow map
+	a.viewPosUniform = gl.GetUniformLocation(a.program, gl.Str("viewPos\x00"))
+	a.unlitUniform = gl.GetUniformLocation(a.program, gl.Str("unlit\x00"))
+	a.alphaUniforms = alphamode.Locate(a.program)
+	a.viewModeUniform = gl.GetUniformLocation(a.program, gl.Str("viewMode\x00"))
+	a.wireframeUniform = gl.GetUniformLocation(a.program, gl.Str("wireframe\x00"))
+	a.depthRangeUniform = gl.GetUniformLocation(a.program, gl.Str("depthRange\x00"))
+	gl.Uniform2f(a.depthRangeUniform, 0, viewmode.DefaultDepthRange)
+	a.sunDirectionUniform = gl.GetUniformLocation(a.program, gl.Str("sunDirection\x00"))
+	a.sunColorUniform = gl.GetUniformLocation(a.program, gl.Str("sunColor\x00"))
+	a.ambientColorUniform = gl.GetUniformLocation(a.program, gl.Str("ambientColor\x00"))
+	a.pointLightCountUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightCount\x00"))
+	// Arrays are set through the location of their first element
+	a.pointLightPositionsUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightPositions[0]\x00"))
+	a.pointLightColorsUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightColors[0]\x00"))
+	a.pointLightRangesUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightRanges[0]\x00"))
+	a.lightSpaceMatrixUniform = gl.GetUniformLocation(a.program, gl.Str("lightSpaceMatrix\x00"))
+	a.shadowMapUniform = gl.GetUniformLocation(a.program, gl.Str("shadowMap\x00"))
+	a.shadowsEnabledUniform = gl.GetUniformLocation(a.program, gl.Str("shadowsEnabled\x00"))
+	gl.Uniform1i(a.shadowMapUniform, 1) // Texture unit 1, unit 0 is ourTexture
 
 	return nil
 }
 
+// defaultLighting returns the lighting a new scene starts with: a white sun from above and
+// to the side, and a little ambient light.
+func defaultLighting() Lighting {
+	return Lighting{
+		SunYaw:       DefaultSunYaw,
+		SunPitch:     DefaultSunPitch,
+		SunColor:     mgl32.Vec3{1, 1, 1},
+		SunIntensity: DefaultSunIntensity,
+		Ambient:      DefaultAmbient,
+	}
+}
+
+// applyLighting uploads the sun, the ambient light and up to maxPointLights point lights
+// to the scene shader. It runs once per frame, before the objects are drawn.
+func (a *AppCore) applyLighting() {
+	sunDirection := a.lighting.sunDirection()
+	sunColor := a.lighting.SunColor.Mul(a.lighting.SunIntensity)
+	ambientColor := a.lighting.SunColor.Mul(a.lighting.Ambient)
+	gl.Uniform3fv(a.sunDirectionUniform, 1, &sunDirection[0])
+	gl.Uniform3fv(a.sunColorUniform, 1, &sunColor[0])
+	gl.Uniform3fv(a.ambientColorUniform, 1, &ambientColor[0])
+	gl.Uniform3fv(a.viewPosUniform, 1, &a.cameraPos[0])
+
+	var positions, colors [maxPointLights]mgl32.Vec3
+	var ranges [maxPointLights]float32
+	count := 0
+	for _, obj := range a.objects {
+		if obj.Light == nil || count == maxPointLights {
+			continue
+		}
+		positions[count] = obj.Position
+		colors[count] = obj.Light.Color.Mul(obj.Light.Intensity)
+		ranges[count] = obj.Light.Range
+		count++
+	}
+	gl.Uniform1i(a.pointLightCountUniform, int32(count))
+	if count > 0 {
+		gl.Uniform3fv(a.pointLightPositionsUniform, int32(count), &positions[0][0])
+		gl.Uniform3fv(a.pointLightColorsUniform, int32(count), &colors[0][0])
+		gl.Uniform1fv(a.pointLightRangesUniform, int32(count), &ranges[0])
+	}
+}
+
+// setupShadowMap creates the depth texture and framebuffer the sun's shadow map is rendered
+// into, and the depth-only shader that renders it.
+func (a *AppCore) setupShadowMap() error {
+	vertexShaderSource := `
+		#version 410 core
+		layout (location = 0) in vec3 aPos;
+		layout (location = 5) in mat4 aModel; // Per instance
+
+		uniform mat4 lightSpaceMatrix;
+
+		void main() {
+			gl_Position = lightSpaceMatrix * aModel * vec4(aPos, 1.0);
+		}
+	` + "\x00"
+
+	// Only depth is written
+	fragmentShaderSource := `
+		#version 410 core
+		void main() {
+		}
+	` + "\x00"
+
+	program, err := compileShader(vertexShaderSource, fragmentShaderSource)
+	if err != nil {
+		return fmt.Errorf("failed to compile shadow shaders: %w", err)
+	}
+	a.shadowProgram = program
+	a.shadowLightSpaceUniform = gl.GetUniformLocation(program, gl.Str("lightSpaceMatrix\x00"))
+
+	gl.GenTextures(1, &a.shadowMap)
+	gl.BindTexture(gl.TEXTURE_2D, a.shadowMap)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.DEPTH_COMPONENT24, ShadowMapSize, ShadowMapSize, 0, gl.DEPTH_COMPONENT, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	// Outside the map counts as lit
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_BORDER)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_BORDER)
+	borderColor := []float32{1, 1, 1, 1}
+	gl.TexParameterfv(gl.TEXTURE_2D, gl.TEXTURE_BORDER_COLOR, &borderColor[0])
+	// Lets the shader's sampler2DShadow do the depth comparison
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_MODE, gl.COMPARE_REF_TO_TEXTURE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_COMPARE_FUNC, gl.LEQUAL)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+
+	gl.GenFramebuffers(1, &a.shadowFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, a.shadowFBO)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.TEXTURE_2D, a.shadowMap, 0)
+	gl.DrawBuffer(gl.NONE) // No color attachment
+	gl.ReadBuffer(gl.NONE)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("shadow framebuffer is incomplete (status 0x%x)", status)
+	}
+	return nil
+}
+
+// lightSpaceMatrix returns the sun's orthographic view-projection for the shadow map. It is
+// fitted to the ground area that receives shadows sideways, and to every caster in depth,
+// so objects thrown high above the ground still cast shadows onto it.
+func (a *AppCore) lightSpaceMatrix() mgl32.Mat4 {
+	direction := a.lighting.sunDirection()
+	up := mgl32.Vec3{0, 1, 0}
+	if math.Abs(float64(direction.Y())) > 0.99 {
+		up = mgl32.Vec3{0, 0, 1} // The sun is straight overhead
+	}
+	center := mgl32.Vec3{0, GroundPlaneY, 0}
+	view := mgl32.LookAtV(center.Sub(direction), center, up)
+
+	// Anything whose shadow can land on the ground area is inside its corners sideways
+	minV := mgl32.Vec3{float32(math.Inf(1)), float32(math.Inf(1)), float32(math.Inf(1))}
+	maxV := mgl32.Vec3{float32(math.Inf(-1)), float32(math.Inf(-1)), float32(math.Inf(-1))}
+	include := func(p mgl32.Vec3, sideways bool) {
+		v := mgl32.TransformCoordinate(p, view)
+		for i := 0; i < 3; i++ {
+			if i == 2 || sideways {
+				minV[i] = float32(math.Min(float64(minV[i]), float64(v[i])))
+				maxV[i] = float32(math.Max(float64(maxV[i]), float64(v[i])))
+			}
+		}
+	}
+	for _, x := range []float32{-ShadowAreaHalfSize, ShadowAreaHalfSize} {
+		for _, z := range []float32{-ShadowAreaHalfSize, ShadowAreaHalfSize} {
+			include(mgl32.Vec3{x, GroundPlaneY, z}, true)
+		}
+	}
+	for _, obj := range a.objects {
+		if !castsShadow(obj) {
+			continue
+		}
+		box := obj.worldAABB()
+		for i := 0; i < 8; i++ {
+			corner := box.Min
+			for axis := 0; axis < 3; axis++ {
+				if i&(1<<axis) != 0 {
+					corner[axis] = box.Max[axis]
+				}
+			}
+			include(corner, false)
+		}
+	}
+
+	// The view looks down -Z, so the nearest points have the largest Z
+	projection := mgl32.Ortho(minV.X(), maxV.X(), minV.Y(), maxV.Y(), -maxV.Z()-ShadowDepthMargin, -minV.Z()+ShadowDepthMargin)
+	return projection.Mul4(view)
+}
+
+// castsShadow reports whether an object is drawn into the shadow map. The ground only
+// receives shadows, and light markers would block their own light.
+func castsShadow(obj *GameObject) bool {
+	return obj.ID != "GroundPlane" && obj.Light == nil
+}
+
+// renderShadowMap renders the casters' depth as seen from the sun into the shadow map,
+// and hands the scene shader the matrix to look it up with. It runs before the main pass.
+func (a *AppCore) renderShadowMap() {
+	lightSpace := a.lightSpaceMatrix()
+	gl.UseProgram(a.program)
+	gl.UniformMatrix4fv(a.lightSpaceMatrixUniform, 1, false, &lightSpace[0])
+	if !a.shadowsEnabled {
+		gl.Uniform1i(a.shadowsEnabledUniform, 0)
+		return
+	}
+	gl.Uniform1i(a.shadowsEnabledUniform, 1)
+
+	gl.UseProgram(a.shadowProgram)
+	gl.UniformMatrix4fv(a.shadowLightSpaceUniform, 1, false, &lightSpace[0])
+	gl.BindFramebuffer(gl.FRAMEBUFFER, a.shadowFBO)
+	gl.Viewport(0, 0, ShadowMapSize, ShadowMapSize)
+	gl.Clear(gl.DEPTH_BUFFER_BIT)
+	// Pushes the stored depths back a little, on top of the shader's bias
+	gl.Enable(gl.POLYGON_OFFSET_FILL)
+	gl.PolygonOffset(2.0, 4.0)
+
+	for i := range a.drawBatches {
+		if a.drawBatches[i].castsShadow {
+			drawInstances(&a.drawBatches[i], a.drawBatches[i].instanceCount) // Casters out of view still cast into it
+		}
+	}
+	gl.BindVertexArray(0)
+
+	gl.Disable(gl.POLYGON_OFFSET_FILL)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(a.width), int32(a.height))
+}
+
 // setupUIShadersAndUniforms compiles shaders for 2D UI elements.
 func (a *AppCore) setupUIShadersAndUniforms() error {
 	uiVertexShaderSource := `
 		#version 410 core
 		layout (location = 0) in vec2 aPos; // Only 2D position for UI
+		layout (location = 1) in vec2 aTexCoord; // Only used by text
+		layout (location = 2) in vec4 aColor;
+		layout (location = 3) in float aGlyph; // 1 for glyphs, which are cut out of the font atlas
 		uniform mat4 uiTransform; // Orthographic projection + translation/scale
+		out vec2 TexCoord;
+		out vec4 Color;
+		out float Glyph;
 		void main() {
 			gl_Position = uiTransform * vec4(aPos, 0.0, 1.0);
+			TexCoord = aTexCoord;
+			Color = aColor;
+			Glyph = aGlyph;
 		}
 	` + "\x00"
 
 	uiFragmentShaderSource := `
 		#version 410 core
+		in vec2 TexCoord;
+		in vec4 Color;
+		in float Glyph;
 		out vec4 FragColor;
-		uniform vec4 uiColor; // Color for the UI element
+		uniform sampler2D uiTexture; // Font atlas
 		void main() {
-			FragColor = uiColor;
+			if (Glyph > 0.5 && texture(uiTexture, TexCoord).r < 0.5) {
+				discard; // Outside the glyph
+			}
+			FragColor = Color;
 		}
 	` + "\x00"
 
@@ -429,7 +997,27 @@ func (a *AppCore) setupUIShadersAndUniforms() error {
 	a.uiProgram = uiProgram
 
 	a.uiTransformUniform = gl.GetUniformLocation(a.uiProgram, gl.Str("uiTransform\x00"))
-	a.uiColorUniform = gl.GetUniformLocation(a.uiProgram, gl.Str("uiColor\x00"))
+	gl.UseProgram(a.uiProgram)
+	gl.Uniform1i(gl.GetUniformLocation(a.uiProgram, gl.Str("uiTexture\x00")), 0) // Font atlas on texture unit 0
+
+	a.fontTexture = bitfont.NewTexture()
+
+	// UI quads are rebuilt every frame and streamed into this one buffer, see flushUI
+	a.uiBufferSize = uiInitialQuads * 6 * uiVertexFloats * 4
+	gl.GenVertexArrays(1, &a.uiVAO)
+	gl.BindVertexArray(a.uiVAO)
+	gl.GenBuffers(1, &a.uiVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.uiVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, a.uiBufferSize, nil, gl.STREAM_DRAW)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(0)) // 2D position
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(2*4)) // Atlas coordinates
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(2, 4, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(4*4)) // Color
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(3, 1, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(8*4)) // Glyph flag
+	gl.EnableVertexAttribArray(3)
+	gl.BindVertexArray(0)
 
 	return nil
 }
@@ -446,13 +1034,10 @@ func (a *AppCore) updateCameraAndProjection() {
 	frontZ := float32(math.Sin(float64(yawRad)) * math.Cos(float64(pitchRad)))
 	a.cameraFront = mgl32.Vec3{frontX, frontY, frontZ}.Normalize()
 
-	// Update view matrix
+	// Update view and projection matrices
 	gl.UseProgram(a.program) // Ensure 3D shader is active for its uniforms
-	view := mgl32.LookAtV(a.cameraPos, a.cameraPos.Add(a.cameraFront), a.cameraUp)
+	view, projection := a.cameraMatrices()
 	gl.UniformMatrix4fv(a.viewUniform, 1, false, &view[0])
-
-	// Update projection matrix
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
 	gl.UniformMatrix4fv(a.projectionUniform, 1, false, &projection[0])
 }
 
@@ -497,6 +1082,12 @@ func (a *AppCore) processInput(deltaTime float32) {
 	}
 	a.eKeyWasPressed = (currentEState == glfw.Press)
 
+	// Keys 1-5 toggle the post-processing effects
+	a.post.HandleKeys(a.window)
+
+	// M cycles the view modes, F toggles the wireframe
+	a.viewKeys.Handle(a.window, &a.viewMode, &a.wireframe)
+
 
 	// Handle 'R' key for rotating held object
 	if a.heldObject != nil {
@@ -561,9 +1152,7 @@ func (a *AppCore) updateEngine(deltaTime float32) {
 
 	// Handle held object
 	if a.heldObject != nil {
-		// Calculate target position in front of camera
-		targetPos := a.cameraPos.Add(a.cameraFront.Mul(a.holdDistance))
-		a.heldObject.Position = targetPos
+		a.heldObject.Position = a.holdTarget()
 		a.heldObject.IsKinematic = true // Held objects are kinematic
 
 		// Apply angular velocity to held object based on mouse input if rotating
@@ -575,6 +1164,11 @@ func (a *AppCore) updateEngine(deltaTime float32) {
 	}
 }
 
+// holdTarget is where the held object is kept: in front of the camera, holdDistance away.
+func (a *AppCore) holdTarget() mgl32.Vec3 {
+	return a.cameraPos.Add(a.cameraFront.Mul(a.holdDistance))
+}
+
 // updatePhysics updates the physics state of all objects.
 func (a *AppCore) updatePhysics(dt float32) {
 	for _, obj := range a.objects {
@@ -588,23 +1182,71 @@ func (a *AppCore) updatePhysics(dt float32) {
 
 		// Apply gravity
 		obj.Velocity[1] += Gravity * dt // Y-component for gravity
+	}
+
+	// Find touching objects and push their velocities apart before integrating,
+	// so resting objects cancel gravity instead of sinking into each other.
+	manifolds := a.detectCollisions()
+	a.addDebugContacts(manifolds)
+	for i := range manifolds {
+		prepareManifold(&manifolds[i])
+	}
+	for iter := 0; iter < SolverIterations; iter++ {
+		for i := range manifolds {
+			solveManifoldVelocity(&manifolds[i])
+		}
+		for i := len(manifolds) - 1; i >= 0; i-- {
+			solveManifoldVelocity(&manifolds[i])
+		}
+	}
+
+	// The solver never converges exactly, so supported objects are left with tiny leftover
+	// velocities every step. Stop them once they are slow enough to be at rest.
+	for _, m := range manifolds {
+		supported := m.B
+		if m.Normal.Y() < -0.7 {
+			supported = m.A
+		} else if m.Normal.Y() <= 0.7 {
+			continue
+		}
+		if supported.inverseMass() == 0 {
+			continue
+		}
+		if supported.Velocity.Len() < SettleSpeed && supported.AngularVelocity.Len() < SettleSpeed {
+			supported.Velocity = mgl32.Vec3{0, 0, 0}
+			supported.AngularVelocity = mgl32.Vec3{0, 0, 0}
+		}
+	}
+
+	for _, obj := range a.objects {
+		if obj.IsKinematic {
+			continue
+		}
 
 		// Update position based on velocity
 		obj.Position = obj.Position.Add(obj.Velocity.Mul(dt))
 
 		// Update rotation based on angular velocity
-		obj.Rotation = obj.Rotation.Add(obj.AngularVelocity.Mul(dt))
+		obj.Rotation = integrateRotation(obj.Rotation, obj.AngularVelocity, dt)
+	}
+
+	// Remove whatever overlap the velocity pass couldn't
+	for i := range manifolds {
+		correctManifoldPosition(&manifolds[i])
+	}
+
+	for _, obj := range a.objects {
+		if obj.IsKinematic {
+			continue
+		}
 
 		// Simple ground collision
-		// For a box, check its lowest point relative to its local BoundingBox Min Y
-		// This is a simplification; a full AABB-plane collision is more complex.
-		// We're assuming the bounding box is centered around the object's origin (0,0,0)
-		// and its Y extent is from Min.Y to Max.Y.
-		// So, the lowest point of the object is its Position.Y + Scale.Y * BoundingBox.Min.Y
-		lowestPointY := obj.Position.Y() + obj.Scale.Y() * obj.BoundingBox.Min.Y()
+		// The lowest point of the object is the bottom of its world AABB,
+		// which encloses the rotated box (or the shape itself for round colliders).
+		lowestPointY := obj.worldAABB().Min.Y()
 
 		if lowestPointY < GroundPlaneY {
-			obj.Position[1] = GroundPlaneY - obj.Scale.Y() * obj.BoundingBox.Min.Y() // Snap to ground
+			obj.Position[1] += GroundPlaneY - lowestPointY // Snap to ground
 			obj.Velocity[1] = 0 // Stop vertical velocity
 			obj.IsGrounded = true
 			// Apply some damping to horizontal velocity to simulate friction
@@ -616,18 +1258,1191 @@ func (a *AppCore) updatePhysics(dt float32) {
 			obj.IsGrounded = false
 		}
 	}
+
+	// Objects resting on top of other objects count as grounded too
+	for _, m := range manifolds {
+		if m.Normal.Y() > 0.7 && !m.B.IsKinematic {
+			m.B.IsGrounded = true
+		} else if m.Normal.Y() < -0.7 && !m.A.IsKinematic {
+			m.A.IsGrounded = true
+		}
+	}
+
+}
+
+// --- Collision Detection and Response ---
+
+// inverseMass returns 1/Mass, or 0 for kinematic and massless objects (treated as immovable).
+func (obj *GameObject) inverseMass() float32 {
+	if obj.IsKinematic || obj.Mass <= 0 {
+		return 0
+	}
+	return 1.0 / obj.Mass
+}
+
+// inverseInertia returns the inverse inertia tensor in world space.
+// The object is approximated by a solid box or sphere of its scaled BoundingBox,
+// and the box tensor is turned along with the object's rotation.
+func (obj *GameObject) inverseInertia() mgl32.Mat3 {
+	invMass := obj.inverseMass()
+	if invMass == 0 {
+		return mgl32.Mat3{}
+	}
+
+	box := obj.orientedBox()
+	extent := box.HalfExtents.Mul(2)
+	w, h, d := extent.X(), extent.Y(), extent.Z()
+
+	var inertia mgl32.Vec3
+	r := box.roundRadius()
+	switch obj.Shape {
+	case ColliderSphere:
+		r := obj.sphereRadius()
+		i := 0.4 * obj.Mass * r * r // 2/5 m r^2
+		inertia = mgl32.Vec3{i, i, i}
+	case ColliderCylinder, ColliderCapsule: // Capsule treated as a cylinder of the same size
+		side := obj.Mass / 12.0 * (3*r*r + h*h)
+		inertia = mgl32.Vec3{side, obj.Mass / 2.0 * r * r, side}
+	case ColliderCone: // Around the middle of the box rather than the real center of mass
+		side := obj.Mass * (3.0/20.0*r*r + 3.0/80.0*h*h)
+		inertia = mgl32.Vec3{side, 0.3 * obj.Mass * r * r, side}
+	case ColliderTorus:
+		tube := box.HalfExtents.Y()
+		ring := r - tube
+		side := obj.Mass * (ring*ring/2 + 5.0/8.0*tube*tube)
+		inertia = mgl32.Vec3{side, obj.Mass * (ring*ring + 3.0/4.0*tube*tube), side}
+	default:
+		inertia = mgl32.Vec3{
+			obj.Mass / 12.0 * (h*h + d*d),
+			obj.Mass / 12.0 * (w*w + d*d),
+			obj.Mass / 12.0 * (w*w + h*h),
+		}
+	}
+
+	var inv mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		if inertia[i] > 1e-6 {
+			inv[i] = 1.0 / inertia[i]
+		}
+	}
+
+	// Local tensor is diagonal, rotate it into world space: R * I^-1 * R^T
+	rotation := mgl32.Mat3FromCols(box.Axes[0], box.Axes[1], box.Axes[2])
+	return rotation.Mul3(mgl32.Diag3(inv)).Mul3(rotation.Transpose())
+}
+
+// modelMatrix builds the object's local-to-world transform.
+// Rendering, picking and collision all use it so they agree on where the object is.
+func (obj *GameObject) modelMatrix() mgl32.Mat4 {
+	model := mgl32.Ident4()
+	model = model.Mul4(mgl32.Translate3D(obj.Position.X(), obj.Position.Y(), obj.Position.Z()))
+	// Apply rotations in ZYX order for more intuitive Euler angles
+	model = model.Mul4(mgl32.HomogRotate3DZ(obj.Rotation.Z()))
+	model = model.Mul4(mgl32.HomogRotate3DY(obj.Rotation.Y()))
+	model = model.Mul4(mgl32.HomogRotate3DX(obj.Rotation.X()))
+	model = model.Mul4(mgl32.Scale3D(obj.Scale.X(), obj.Scale.Y(), obj.Scale.Z()))
+	return model
+}
+
+// orientedBox returns the object's BoundingBox transformed by its model matrix.
+func (obj *GameObject) orientedBox() OrientedBox {
+	model := obj.modelMatrix()
+	localCenter := obj.BoundingBox.Min.Add(obj.BoundingBox.Max).Mul(0.5)
+	localHalfExtents := obj.BoundingBox.Max.Sub(obj.BoundingBox.Min).Mul(0.5)
+
+	box := OrientedBox{Center: mgl32.TransformCoordinate(localCenter, model)}
+	for i := 0; i < 3; i++ {
+		// Each column of the model matrix is a local axis, stretched by the scale on that axis
+		axis := model.Col(i).Vec3()
+		length := axis.Len()
+		if length < 1e-6 { // Zero scale, keep a valid axis for a flat box
+			axis = mgl32.Vec3{}
+			axis[i] = 1
+		} else {
+			axis = axis.Mul(1.0 / length)
+		}
+		box.Axes[i] = axis
+		box.HalfExtents[i] = localHalfExtents[i] * length
+	}
+	return box
+}
+
+// corners returns the 8 corners of the box in world space.
+func (box OrientedBox) corners() [8]mgl32.Vec3 {
+	var corners [8]mgl32.Vec3
+	for i := range corners {
+		corner := box.Center
+		for axis := 0; axis < 3; axis++ {
+			sign := float32(1)
+			if i&(1<<axis) != 0 {
+				sign = -1
+			}
+			corner = corner.Add(box.Axes[axis].Mul(sign * box.HalfExtents[axis]))
+		}
+		corners[i] = corner
+	}
+	return corners
+}
+
+// roundRadius returns the radius used by colliders that are round around the local Y axis.
+func (box OrientedBox) roundRadius() float32 {
+	return float32(math.Max(float64(box.HalfExtents.X()), float64(box.HalfExtents.Z())))
+}
+
+// projectedRadius returns half the length of the box's shadow on a unit axis.
+func (box OrientedBox) projectedRadius(axis mgl32.Vec3) float32 {
+	var r float32
+	for i := 0; i < 3; i++ {
+		r += box.HalfExtents[i] * float32(math.Abs(float64(box.Axes[i].Dot(axis))))
+	}
+	return r
+}
+
+// worldAABB returns the axis-aligned box enclosing the object in world space, used by the broadphase.
+// For boxes it encloses the rotated BoundingBox, for spheres just the sphere, and for the
+// round shapes their extreme points along each axis.
+func (obj *GameObject) worldAABB() BoundingBox {
+	box := obj.orientedBox()
+	var half mgl32.Vec3
+	if obj.Shape == ColliderSphere {
+		r := obj.sphereRadius()
+		half = mgl32.Vec3{r, r, r}
+	} else if obj.Shape != ColliderBox {
+		var bounds BoundingBox
+		for i := 0; i < 3; i++ {
+			var axis mgl32.Vec3
+			axis[i] = 1
+			bounds.Min[i] = obj.supportPoint(axis.Mul(-1))[i]
+			bounds.Max[i] = obj.supportPoint(axis)[i]
+		}
+		return bounds
+	} else {
+		for i := 0; i < 3; i++ {
+			var axis mgl32.Vec3
+			axis[i] = 1
+			half[i] = box.projectedRadius(axis)
+		}
+	}
+	return BoundingBox{Min: box.Center.Sub(half), Max: box.Center.Add(half)}
+}
+
+// sphereRadius returns the radius used when the object collides as a sphere.
+func (obj *GameObject) sphereRadius() float32 {
+	halfExtents := obj.orientedBox().HalfExtents
+	return float32(math.Max(float64(halfExtents.X()), math.Max(float64(halfExtents.Y()), float64(halfExtents.Z()))))
+}
+
+// integrateRotation advances the Euler angles used by modelMatrix by a world-space
+// angular velocity. Adding the angular velocity to the angles directly only works while
+// the object is unrotated, so the step is applied to the rotation matrix instead.
+func integrateRotation(rotation, angularVelocity mgl32.Vec3, dt float32) mgl32.Vec3 {
+	angle := angularVelocity.Len() * dt
+	if angle < 1e-7 {
+		return rotation
+	}
+	current := mgl32.Rotate3DZ(rotation.Z()).Mul3(mgl32.Rotate3DY(rotation.Y())).Mul3(mgl32.Rotate3DX(rotation.X()))
+	step := mgl32.HomogRotate3D(angle, angularVelocity.Normalize()).Mat3()
+	return eulerFromMatrix(step.Mul3(current))
+}
+
+// eulerFromMatrix recovers (X, Y, Z) angles from a rotation matrix built as Rz * Ry * Rx.
+func eulerFromMatrix(m mgl32.Mat3) mgl32.Vec3 {
+	sinY := mgl32.Clamp(-m.At(2, 0), -1, 1)
+	y := float32(math.Asin(float64(sinY)))
+	if math.Abs(float64(sinY)) > 0.9999 {
+		// Gimbal lock: X and Z turn about the same axis, put it all on X
+		x := float32(math.Atan2(float64(-m.At(1, 2)), float64(m.At(1, 1))))
+		return mgl32.Vec3{x, y, 0}
+	}
+	x := float32(math.Atan2(float64(m.At(2, 1)), float64(m.At(2, 2))))
+	z := float32(math.Atan2(float64(m.At(1, 0)), float64(m.At(0, 0))))
+	return mgl32.Vec3{x, y, z}
+}
+
+// detectCollisions runs the broadphase and narrowphase and returns every contact in the scene.
+// Objects touching the ground also get a manifold against the ground plane, so the solver
+// knows the bottom of a stack is supported and doesn't push it into the floor.
+func (a *AppCore) detectCollisions() []ContactManifold {
+	var manifolds []ContactManifold
+	for _, pair := range a.findCollisionPairs() {
+		if m, ok := collideObjects(pair[0], pair[1]); ok {
+			manifolds = append(manifolds, m)
+		}
+	}
+
+	var ground *GameObject
+	for _, obj := range a.objects {
+		if obj.ID == "GroundPlane" {
+			ground = obj
+			break
+		}
+	}
+	if ground == nil {
+		ground = &GameObject{ID: "GroundPlane", IsKinematic: true} // Scene without a visible ground still has a floor
+	}
+	for _, obj := range a.objects {
+		if obj == ground || obj.inverseMass() == 0 {
+			continue
+		}
+		if m, ok := collideGround(ground, obj); ok {
+			manifolds = append(manifolds, m)
+		}
+	}
+	return manifolds
+}
+
+// findCollisionPairs is the broadphase: a sort-and-sweep over world AABBs along the X axis.
+// It returns the pairs whose boxes overlap on all three axes.
+func (a *AppCore) findCollisionPairs() [][2]*GameObject {
+	type entry struct {
+		obj *GameObject
+		box BoundingBox
+	}
+
+	entries := make([]entry, 0, len(a.objects))
+	for _, obj := range a.objects {
+		// The ground is handled separately against GroundPlaneY, and lights don't collide
+		if obj.ID == "GroundPlane" || obj.Light != nil {
+			continue
+		}
+		entries = append(entries, entry{obj: obj, box: obj.worldAABB()})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].box.Min.X() < entries[j].box.Min.X()
+	})
+
+	var pairs [][2]*GameObject
+	for i := 0; i < len(entries); i++ {
+		for j := i + 1; j < len(entries); j++ {
+			// Sorted by Min.X, so once a box starts past our Max.X nothing later can overlap
+			if entries[j].box.Min.X() > entries[i].box.Max.X() {
+				break
+			}
+			// Two immovable objects never need resolving
+			if entries[i].obj.inverseMass() == 0 && entries[j].obj.inverseMass() == 0 {
+				continue
+			}
+			bi, bj := entries[i].box, entries[j].box
+			if bi.Min.Y() > bj.Max.Y() || bj.Min.Y() > bi.Max.Y() ||
+				bi.Min.Z() > bj.Max.Z() || bj.Min.Z() > bi.Max.Z() {
+				continue
+			}
+			pairs = append(pairs, [2]*GameObject{entries[i].obj, entries[j].obj})
+		}
+	}
+	return pairs
+}
+
+// collideObjects is the narrowphase: it picks the test matching both shapes.
+// The returned normal always points from objA towards objB.
+func collideObjects(objA, objB *GameObject) (ContactManifold, bool) {
+	switch {
+	case objA.Shape == ColliderSphere && objB.Shape == ColliderSphere:
+		return collideSphereSphere(objA, objB)
+	case objA.Shape == ColliderSphere && objB.Shape == ColliderBox:
+		return collideSphereBox(objA, objB)
+	case objA.Shape == ColliderBox && objB.Shape == ColliderSphere:
+		m, ok := collideSphereBox(objB, objA)
+		// Flip so the normal still points from objA to objB
+		m.A, m.B = m.B, m.A
+		m.Normal = m.Normal.Mul(-1)
+		return m, ok
+	case objA.Shape == ColliderBox && objB.Shape == ColliderBox:
+		return collideBoxBox(objA, objB)
+	default:
+		// Every other pair goes through the general convex test
+		return collideConvex(objA, objB)
+	}
+}
+
+// collideGround tests an object against the ground plane (GroundPlaneY, facing up).
+// Boxes touch with whichever corners reach the ground, spheres with their lowest point.
+func collideGround(ground, obj *GameObject) (ContactManifold, bool) {
+	bounds := obj.worldAABB()
+	if bounds.Min.Y() > GroundPlaneY+PenetrationSlop {
+		return ContactManifold{}, false
+	}
+
+	m := ContactManifold{A: ground, B: obj, Normal: mgl32.Vec3{0, 1, 0}, Penetration: GroundPlaneY - bounds.Min.Y()}
+	if obj.Shape == ColliderSphere {
+		center := bounds.Min.Add(bounds.Max).Mul(0.5)
+		m.Points = []ContactPoint{{Position: mgl32.Vec3{center.X(), bounds.Min.Y(), center.Z()}}}
+		return m, true
+	}
+	if obj.Shape == ColliderBox {
+		for _, corner := range obj.orientedBox().corners() {
+			if corner.Y() <= bounds.Min.Y()+PenetrationSlop {
+				m.Points = append(m.Points, ContactPoint{Position: corner})
+			}
+		}
+		return m, true
+	}
+
+	// Other shapes: the bounds only enclose them, find the lowest points of the shape itself
+	feature := obj.contactFeature(mgl32.Vec3{0, -1, 0})
+	lowest := feature[0].Y()
+	if lowest > GroundPlaneY+PenetrationSlop {
+		return ContactManifold{}, false
+	}
+	m.Penetration = GroundPlaneY - lowest
+	for _, point := range feature {
+		m.Points = append(m.Points, ContactPoint{Position: point})
+	}
+	return m, true
+}
+
+// collideBoxBox tests two boxes with intersectOBBOBB. Face contacts clip the face of the
+// other box against the touching face, giving up to four points; edge contacts get one.
+func collideBoxBox(boxA, boxB *GameObject) (ContactManifold, bool) {
+	a, b := boxA.orientedBox(), boxB.orientedBox()
+	hit, normal, depth, axis := intersectOBBOBB(a, b)
+	if !hit {
+		return ContactManifold{}, false
+	}
+
+	m := ContactManifold{A: boxA, B: boxB, Normal: normal, Penetration: depth}
+	switch {
+	case axis < 3:
+		m.Points = clipBoxFaces(a, b, normal, axis)
+	case axis < 6:
+		m.Points = clipBoxFaces(b, a, normal.Mul(-1), axis-3)
+	default:
+		m.Points = []ContactPoint{{Position: edgeContactPoint(a, b, normal, (axis-6)/3, (axis-6)%3)}}
+	}
+	if len(m.Points) == 0 {
+		// Clipping can come up empty for barely touching boxes, fall back to the middle of the overlap
+		deepestA := a.Center.Add(normal.Mul(a.projectedRadius(normal)))
+		deepestB := b.Center.Sub(normal.Mul(b.projectedRadius(normal)))
+		m.Points = []ContactPoint{{Position: deepestA.Add(deepestB).Mul(0.5)}}
+	}
+	return m, true
+}
+
+// intersectOBBOBB runs the separating axis test on two oriented boxes: the three face
+// normals of each box and the nine cross products of their edges. If no axis separates
+// them it returns the axis of least overlap (pointing from a towards b), the overlap depth
+// and which axis won: 0-2 a face of a, 3-5 a face of b, 6-14 an edge of a crossed with an edge of b.
+func intersectOBBOBB(a, b OrientedBox) (bool, mgl32.Vec3, float32, int) {
+	delta := b.Center.Sub(a.Center)
+	bestDepth := float32(math.Inf(1))
+	var bestAxis mgl32.Vec3
+	bestIndex := -1
+
+	test := func(axis mgl32.Vec3, index int) bool {
+		length := axis.Len()
+		if length < 1e-4 { // Parallel edges give no usable axis
+			return true
+		}
+		axis = axis.Mul(1.0 / length)
+		distance := delta.Dot(axis)
+		depth := a.projectedRadius(axis) + b.projectedRadius(axis) - float32(math.Abs(float64(distance)))
+		if depth < 0 {
+			return false
+		}
+		// Edge axes only win by a clear margin, face contacts give steadier manifolds
+		if index >= 6 && depth*1.05+0.001 >= bestDepth {
+			return true
+		}
+		if depth < bestDepth {
+			if distance < 0 {
+				axis = axis.Mul(-1)
+			}
+			bestDepth, bestAxis, bestIndex = depth, axis, index
+		}
+		return true
+	}
+
+	for i := 0; i < 3; i++ {
+		if !test(a.Axes[i], i) || !test(b.Axes[i], 3+i) {
+			return false, mgl32.Vec3{}, 0, -1
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if !test(a.Axes[i].Cross(b.Axes[j]), 6+i*3+j) {
+				return false, mgl32.Vec3{}, 0, -1
+			}
+		}
+	}
+	return true, bestAxis, bestDepth, bestIndex
+}
+
+// clipBoxFaces builds the contact points for a face contact. The reference face is the face
+// of ref along refAxis facing normal (normal points from ref towards inc). The face of inc
+// that faces back is clipped against the reference face's sides, and the corners that end up
+// below the reference face become the contact points, placed halfway through the overlap.
+func clipBoxFaces(ref, inc OrientedBox, normal mgl32.Vec3, refAxis int) []ContactPoint {
+	refCenter := ref.Center.Add(normal.Mul(ref.HalfExtents[refAxis]))
+
+	// Incident face: the face of inc whose normal is most opposite to ours
+	incAxis := 0
+	best := float32(-1)
+	for i := 0; i < 3; i++ {
+		if d := float32(math.Abs(float64(inc.Axes[i].Dot(normal)))); d > best {
+			best, incAxis = d, i
+		}
+	}
+	incNormal := inc.Axes[incAxis]
+	if incNormal.Dot(normal) > 0 {
+		incNormal = incNormal.Mul(-1)
+	}
+	incCenter := inc.Center.Add(incNormal.Mul(inc.HalfExtents[incAxis]))
+	u, v := (incAxis+1)%3, (incAxis+2)%3
+	du, dv := inc.Axes[u].Mul(inc.HalfExtents[u]), inc.Axes[v].Mul(inc.HalfExtents[v])
+	polygon := []mgl32.Vec3{
+		incCenter.Add(du).Add(dv),
+		incCenter.Sub(du).Add(dv),
+		incCenter.Sub(du).Sub(dv),
+		incCenter.Add(du).Sub(dv),
+	}
+
+	// Clip against the four side planes of the reference face
+	for _, side := range []int{(refAxis + 1) % 3, (refAxis + 2) % 3} {
+		axis := ref.Axes[side]
+		offset := axis.Dot(ref.Center)
+		polygon = clipPolygon(polygon, axis, offset+ref.HalfExtents[side])
+		polygon = clipPolygon(polygon, axis.Mul(-1), -offset+ref.HalfExtents[side])
+	}
+
+	var points []ContactPoint
+	for _, p := range polygon {
+		separation := normal.Dot(p.Sub(refCenter)) // Negative when below the reference face
+		if separation <= PenetrationSlop {
+			points = append(points, ContactPoint{Position: p.Sub(normal.Mul(separation * 0.5))})
+		}
+	}
+	return reduceContactPoints(points, ref.Axes[(refAxis+1)%3], ref.Axes[(refAxis+2)%3])
+}
+
+// clipPolygon keeps the part of a convex polygon where planeNormal.Dot(p) <= planeOffset
+// (one Sutherland-Hodgman pass).
+func clipPolygon(polygon []mgl32.Vec3, planeNormal mgl32.Vec3, planeOffset float32) []mgl32.Vec3 {
+	var clipped []mgl32.Vec3
+	for i, current := range polygon {
+		next := polygon[(i+1)%len(polygon)]
+		dCurrent := planeNormal.Dot(current) - planeOffset
+		dNext := planeNormal.Dot(next) - planeOffset
+		if dCurrent <= 0 {
+			clipped = append(clipped, current)
+		}
+		if (dCurrent < 0 && dNext > 0) || (dCurrent > 0 && dNext < 0) {
+			t := dCurrent / (dCurrent - dNext)
+			clipped = append(clipped, current.Add(next.Sub(current).Mul(t)))
+		}
+	}
+	return clipped
+}
+
+// reduceContactPoints keeps at most four points: the outermost ones along the two face axes,
+// which still span the whole contact area.
+func reduceContactPoints(points []ContactPoint, u, v mgl32.Vec3) []ContactPoint {
+	if len(points) <= 4 {
+		return points
+	}
+	extremes := [4]int{}
+	for i, p := range points {
+		if p.Position.Dot(u) < points[extremes[0]].Position.Dot(u) {
+			extremes[0] = i
+		}
+		if p.Position.Dot(u) > points[extremes[1]].Position.Dot(u) {
+			extremes[1] = i
+		}
+		if p.Position.Dot(v) < points[extremes[2]].Position.Dot(v) {
+			extremes[2] = i
+		}
+		if p.Position.Dot(v) > points[extremes[3]].Position.Dot(v) {
+			extremes[3] = i
+		}
+	}
+	var reduced []ContactPoint
+	seen := map[int]bool{}
+	for _, i := range extremes {
+		if !seen[i] {
+			seen[i] = true
+			reduced = append(reduced, points[i])
+		}
+	}
+	return reduced
+}
+
+// edgeContactPoint finds where an edge of a (along a.Axes[edgeA]) crosses an edge of b
+// (along b.Axes[edgeB]). It takes the edges of each box that reach furthest into the other
+// and returns the midpoint of the closest points between them.
+func edgeContactPoint(a, b OrientedBox, normal mgl32.Vec3, edgeA, edgeB int) mgl32.Vec3 {
+	pointA, pointB := a.Center, b.Center
+	for i := 0; i < 3; i++ {
+		if i != edgeA {
+			sign := float32(1)
+			if a.Axes[i].Dot(normal) < 0 {
+				sign = -1
+			}
+			pointA = pointA.Add(a.Axes[i].Mul(sign * a.HalfExtents[i]))
+		}
+		if i != edgeB {
+			sign := float32(-1)
+			if b.Axes[i].Dot(normal) < 0 {
+				sign = 1
+			}
+			pointB = pointB.Add(b.Axes[i].Mul(sign * b.HalfExtents[i]))
+		}
+	}
+
+	// Closest points between the lines pointA + s*dirA and pointB + t*dirB
+	dirA, dirB := a.Axes[edgeA], b.Axes[edgeB]
+	r := pointA.Sub(pointB)
+	d := dirA.Dot(dirB)
+	e := dirA.Dot(r)
+	f := dirB.Dot(r)
+	denom := 1 - d*d
+	var s, t float32
+	if denom > 1e-6 {
+		s = (d*f - e) / denom
+	}
+	t = f + d*s
+	s = mgl32.Clamp(s, -a.HalfExtents[edgeA], a.HalfExtents[edgeA])
+	t = mgl32.Clamp(t, -b.HalfExtents[edgeB], b.HalfExtents[edgeB])
+	return pointA.Add(dirA.Mul(s)).Add(pointB.Add(dirB.Mul(t))).Mul(0.5)
+}
+
+// collideSphereSphere tests two spheres.
+func collideSphereSphere(sphereA, sphereB *GameObject) (ContactManifold, bool) {
+	centerA, centerB := sphereA.orientedBox().Center, sphereB.orientedBox().Center
+	radiusA, radiusB := sphereA.sphereRadius(), sphereB.sphereRadius()
+
+	delta := centerB.Sub(centerA)
+	dist := delta.Len()
+	if dist >= radiusA+radiusB {
+		return ContactManifold{}, false
+	}
+
+	normal := mgl32.Vec3{0, 1, 0} // Perfectly overlapping centers, pick any direction
+	if dist > 1e-6 {
+		normal = delta.Mul(1.0 / dist)
+	}
+	penetration := radiusA + radiusB - dist
+	point := centerA.Add(normal.Mul(radiusA - penetration*0.5))
+	return ContactManifold{A: sphereA, B: sphereB, Normal: normal, Penetration: penetration,
+		Points: []ContactPoint{{Position: point}}}, true
+}
+
+// collideSphereBox tests a sphere against a box using the closest point on the box.
+// The normal points from the sphere towards the box.
+func collideSphereBox(sphere, box *GameObject) (ContactManifold, bool) {
+	b := box.orientedBox()
+	center := sphere.orientedBox().Center
+	radius := sphere.sphereRadius()
+
+	// Find the closest point in the box's own frame, where it is axis aligned
+	offset := center.Sub(b.Center)
+	var local mgl32.Vec3
+	closest := b.Center
+	for i := 0; i < 3; i++ {
+		local[i] = offset.Dot(b.Axes[i])
+		closest = closest.Add(b.Axes[i].Mul(mgl32.Clamp(local[i], -b.HalfExtents[i], b.HalfExtents[i])))
+	}
+
+	delta := closest.Sub(center)
+	dist := delta.Len()
+	if dist > 1e-6 {
+		if dist >= radius {
+			return ContactManifold{}, false
+		}
+		return ContactManifold{A: sphere, B: box, Normal: delta.Mul(1.0 / dist), Penetration: radius - dist,
+			Points: []ContactPoint{{Position: closest}}}, true
+	}
+
+	// Sphere center is inside the box: push out through the nearest face
+	bestAxis := 0
+	bestDist := float32(math.Inf(1))
+	sign := float32(1)
+	for i := 0; i < 3; i++ {
+		if d := local[i] + b.HalfExtents[i]; d < bestDist {
+			bestDist, bestAxis, sign = d, i, 1 // Nearest face is the negative side, so the box lies in the +axis direction
+		}
+		if d := b.HalfExtents[i] - local[i]; d < bestDist {
+			bestDist, bestAxis, sign = d, i, -1
+		}
+	}
+	return ContactManifold{A: sphere, B: box, Normal: b.Axes[bestAxis].Mul(sign), Penetration: radius + bestDist,
+		Points: []ContactPoint{{Position: center}}}, true
+}
+
+// supportPoint returns the point of the object's collider that lies furthest along dir,
+// in world space. This is all collideConvex needs to know about a shape.
+func (obj *GameObject) supportPoint(dir mgl32.Vec3) mgl32.Vec3 {
+	box := obj.orientedBox()
+	var local mgl32.Vec3 // dir in the object's own frame
+	for i := 0; i < 3; i++ {
+		local[i] = dir.Dot(box.Axes[i])
+	}
+	h := box.HalfExtents
+	r := box.roundRadius()
+
+	// Direction across the local Y axis, for the shapes that are round around it
+	radial := mgl32.Vec3{local.X(), 0, local.Z()}
+	if radial.Len() > 1e-6 {
+		radial = radial.Normalize()
+	} else {
+		radial = mgl32.Vec3{0, 0, 0}
+	}
+	up := float32(1)
+	if local.Y() < 0 {
+		up = -1
+	}
+	unit := mgl32.Vec3{0, 0, 0}
+	if local.Len() > 1e-6 {
+		unit = local.Normalize()
+	}
+
+	var p mgl32.Vec3
+	switch obj.Shape {
+	case ColliderSphere:
+		p = unit.Mul(obj.sphereRadius())
+	case ColliderCylinder:
+		p = radial.Mul(r).Add(mgl32.Vec3{0, up * h.Y(), 0})
+	case ColliderCone:
+		tip := mgl32.Vec3{0, h.Y(), 0}
+		rim := radial.Mul(r).Add(mgl32.Vec3{0, -h.Y(), 0})
+		p = rim
+		if tip.Dot(local) >= rim.Dot(local) {
+			p = tip
+		}
+	case ColliderCapsule:
+		halfSegment := float32(math.Max(float64(h.Y()-r), 0))
+		p = mgl32.Vec3{0, up * halfSegment, 0}.Add(unit.Mul(r))
+	case ColliderTorus:
+		tube := h.Y()
+		p = radial.Mul(r - tube).Add(unit.Mul(tube))
+	default: // ColliderBox
+		for i := 0; i < 3; i++ {
+			p[i] = h[i]
+			if local[i] < 0 {
+				p[i] = -h[i]
+			}
+		}
+	}
+
+	world := box.Center
+	for i := 0; i < 3; i++ {
+		world = world.Add(box.Axes[i].Mul(p[i]))
+	}
+	return world
+}
+
+// contactFeature returns the points of the object's collider that lie furthest along dir,
+// found by probing supportPoint in four directions tilted slightly away from dir.
+// A flat face gives its corners or points on its rim, an edge gives its two ends and a
+// curved surface gives a single point. The deepest point comes first.
+func (obj *GameObject) contactFeature(dir mgl32.Vec3) []mgl32.Vec3 {
+	dir = dir.Normalize()
+	u, v := perpendicularAxes(dir)
+	deepest := obj.supportPoint(dir)
+	depth := deepest.Dot(dir)
+
+	points := []mgl32.Vec3{deepest}
+	for _, tilt := range [4][2]float32{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}} {
+		probe := dir.Add(u.Mul(tilt[0] * 0.5)).Add(v.Mul(tilt[1] * 0.5))
+		p := obj.supportPoint(probe)
+		if depth-p.Dot(dir) > PenetrationSlop*0.5 {
+			continue // Curved away from dir, not part of the contact
+		}
+		duplicate := false
+		for _, q := range points {
+			if p.Sub(q).Len() < 1e-3 {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			points = append(points, p)
+		}
+	}
+	// The tilted probes of a flat face already span it, the straight one is just one more point on it
+	if len(points) > 2 {
+		points = points[1:]
+	}
+	return points
+}
+
+// perpendicularAxes returns two unit vectors perpendicular to dir and to each other.
+func perpendicularAxes(dir mgl32.Vec3) (mgl32.Vec3, mgl32.Vec3) {
+	helper := mgl32.Vec3{1, 0, 0}
+	if math.Abs(float64(dir.X())) > 0.9 {
+		helper = mgl32.Vec3{0, 1, 0}
+	}
+	u := dir.Cross(helper).Normalize()
+	return u, dir.Cross(u)
+}
+
+// collideConvex tests any two colliders using only their support points: GJK decides whether
+// they overlap and EPA finds the normal and depth. The contact area is then built by clipping
+// the touching features of both shapes against each other.
+func collideConvex(objA, objB *GameObject) (ContactManifold, bool) {
+	simplex, hit := gjkIntersect(objA, objB)
+	if !hit {
+		return ContactManifold{}, false
+	}
+	normal, depth, contact := expandPolytope(objA, objB, simplex)
+	m := ContactManifold{A: objA, B: objB, Normal: normal, Penetration: depth}
+
+	featureA := objA.contactFeature(normal)
+	featureB := objB.contactFeature(normal.Mul(-1))
+	var points []mgl32.Vec3
+	switch {
+	case len(featureA) == 1 || len(featureB) == 1:
+		// A curved surface touches in a single point
+	case len(featureA) == 2 && len(featureB) == 2:
+		// Two edges: only touch along a line if they are parallel
+		dirA := featureA[1].Sub(featureA[0]).Normalize()
+		dirB := featureB[1].Sub(featureB[0]).Normalize()
+		if math.Abs(float64(dirA.Dot(dirB))) > 0.99 {
+			points = clipFeature(featureB, featureA, normal)
+		}
+	default:
+		points = clipFeature(featureB, featureA, normal)
+	}
+
+	for _, p := range points {
+		separation := normal.Dot(p.Sub(featureA[0])) // Negative when B reaches into A
+		if separation <= PenetrationSlop {
+			m.Points = append(m.Points, ContactPoint{Position: p.Sub(normal.Mul(separation * 0.5))})
+		}
+	}
+	if len(m.Points) == 0 {
+		m.Points = []ContactPoint{{Position: contact}}
+	}
+	u, v := perpendicularAxes(normal)
+	m.Points = reduceContactPoints(m.Points, u, v)
+	return m, true
+}
+
+// clipFeature clips the incident points (a polygon, segment or point) against the side planes
+// of the reference feature, both seen along normal.
+func clipFeature(incident, reference []mgl32.Vec3, normal mgl32.Vec3) []mgl32.Vec3 {
+	polygon := append([]mgl32.Vec3(nil), incident...)
+	if len(reference) == 2 {
+		// A segment only limits the points along its own direction
+		edge := reference[1].Sub(reference[0])
+		length := edge.Len()
+		if length < 1e-6 {
+			return polygon
+		}
+		edge = edge.Mul(1.0 / length)
+		polygon = clipPolygon(polygon, edge, edge.Dot(reference[1]))
+		return clipPolygon(polygon, edge.Mul(-1), -edge.Dot(reference[0]))
+	}
+
+	center := mgl32.Vec3{0, 0, 0}
+	for _, p := range reference {
+		center = center.Add(p)
+	}
+	center = center.Mul(1.0 / float32(len(reference)))
+	for i, p := range reference {
+		edge := reference[(i+1)%len(reference)].Sub(p)
+		side := edge.Cross(normal)
+		if side.Len() < 1e-6 {
+			continue
+		}
+		side = side.Normalize()
+		if side.Dot(center.Sub(p)) > 0 {
+			side = side.Mul(-1) // Make it point out of the polygon
+		}
+		polygon = clipPolygon(polygon, side, side.Dot(p))
+		if len(polygon) == 0 {
+			break
+		}
+	}
+	return polygon
+}
+
+// minkowskiPoint is a point of the Minkowski difference A - B together with the support
+// points it came from, so EPA can turn its answer back into a contact position.
+type minkowskiPoint struct {
+	point, onA, onB mgl32.Vec3
+}
+
+// minkowskiSupport returns the point of A - B furthest along dir.
+func minkowskiSupport(objA, objB *GameObject, dir mgl32.Vec3) minkowskiPoint {
+	onA := objA.supportPoint(dir)
+	onB := objB.supportPoint(dir.Mul(-1))
+	return minkowskiPoint{point: onA.Sub(onB), onA: onA, onB: onB}
+}
+
+// gjkIntersect runs GJK: it grows a simplex inside A - B towards the origin and reports
+// whether the origin is enclosed, which means the shapes overlap.
+// On a hit the returned simplex is a tetrahedron, ready for expandPolytope.
+func gjkIntersect(objA, objB *GameObject) ([]minkowskiPoint, bool) {
+	dir := objB.orientedBox().Center.Sub(objA.orientedBox().Center)
+	if dir.Len() < 1e-6 {
+		dir = mgl32.Vec3{1, 0, 0}
+	}
+	first := minkowskiSupport(objA, objB, dir)
+	simplex := []minkowskiPoint{first}
+	dir = first.point.Mul(-1)
+
+	for iter := 0; iter < 64; iter++ {
+		if dir.Len() < 1e-6 {
+			// The origin lies on the simplex (within float precision); search sideways
+			// so it can still grow into a tetrahedron around it
+			edge := mgl32.Vec3{0, 1, 0}
+			if len(simplex) > 1 {
+				edge = simplex[len(simplex)-1].point.Sub(simplex[0].point)
+			}
+			dir, _ = perpendicularAxes(edge.Normalize())
+		}
+		next := minkowskiSupport(objA, objB, dir)
+		if next.point.Dot(dir) < 0 {
+			return nil, false // Couldn't get past the origin, so it is outside A - B
+		}
+		simplex = append(simplex, next)
+
+		var enclosed bool
+		simplex, dir, enclosed = updateSimplex(simplex)
+		if enclosed {
+			return simplex, true
+		}
+	}
+	return nil, false
+}
+
+// updateSimplex keeps the part of the simplex closest to the origin and returns the
+// direction to search next. The newest point is always last.
+func updateSimplex(simplex []minkowskiPoint) ([]minkowskiPoint, mgl32.Vec3, bool) {
+	a := simplex[len(simplex)-1]
+	ao := a.point.Mul(-1)
+
+	switch len(simplex) {
+	case 2:
+		b := simplex[0]
+		ab := b.point.Sub(a.point)
+		if ab.Dot(ao) > 0 {
+			return simplex, ab.Cross(ao).Cross(ab), false
+		}
+		return []minkowskiPoint{a}, ao, false
+
+	case 3:
+		b, c := simplex[1], simplex[0]
+		ab, ac := b.point.Sub(a.point), c.point.Sub(a.point)
+		abc := ab.Cross(ac)
+		if abc.Cross(ac).Dot(ao) > 0 {
+			if ac.Dot(ao) > 0 {
+				return []minkowskiPoint{c, a}, ac.Cross(ao).Cross(ac), false
+			}
+			return updateSimplex([]minkowskiPoint{b, a})
+		}
+		if ab.Cross(abc).Dot(ao) > 0 {
+			return updateSimplex([]minkowskiPoint{b, a})
+		}
+		if abc.Dot(ao) > 0 {
+			return simplex, abc, false
+		}
+		return []minkowskiPoint{b, c, a}, abc.Mul(-1), false
+
+	default: // Tetrahedron
+		b, c, d := simplex[2], simplex[1], simplex[0]
+		ab, ac, ad := b.point.Sub(a.point), c.point.Sub(a.point), d.point.Sub(a.point)
+		if ab.Cross(ac).Dot(ao) > 0 {
+			return updateSimplex([]minkowskiPoint{c, b, a})
+		}
+		if ac.Cross(ad).Dot(ao) > 0 {
+			return updateSimplex([]minkowskiPoint{d, c, a})
+		}
+		if ad.Cross(ab).Dot(ao) > 0 {
+			return updateSimplex([]minkowskiPoint{b, d, a})
+		}
+		return simplex, mgl32.Vec3{}, true
+	}
+}
+
+// polytopeFace is one triangle of the EPA polytope, with its outward normal and distance from the origin.
+type polytopeFace struct {
+	a, b, c  int
+	normal   mgl32.Vec3
+	distance float32
+}
+
+// expandPolytope runs EPA: starting from GJK's tetrahedron it keeps pushing out the face of
+// A - B closest to the origin until the surface is found. That face gives the normal (from A
+// towards B) and depth, and its support points give the contact position.
+func expandPolytope(objA, objB *GameObject, simplex []minkowskiPoint) (mgl32.Vec3, float32, mgl32.Vec3) {
+	points := append([]minkowskiPoint(nil), simplex...)
+	var faces []polytopeFace
+	addFace := func(a, b, c int) {
+		normal := points[b].point.Sub(points[a].point).Cross(points[c].point.Sub(points[a].point))
+		if normal.Len() < 1e-9 {
+			return // Degenerate sliver, leave it out
+		}
+		normal = normal.Normalize()
+		distance := normal.Dot(points[a].point)
+		if distance < 0 { // The origin is inside, so outward normals face away from it
+			b, c = c, b
+			normal, distance = normal.Mul(-1), -distance
+		}
+		faces = append(faces, polytopeFace{a: a, b: b, c: c, normal: normal, distance: distance})
+	}
+	addFace(0, 1, 2)
+	addFace(0, 3, 1)
+	addFace(0, 2, 3)
+	addFace(1, 3, 2)
+
+	var closest polytopeFace
+	for iter := 0; iter < 32 && len(faces) > 0; iter++ {
+		closest = faces[0]
+		for _, f := range faces[1:] {
+			if f.distance < closest.distance {
+				closest = f
+			}
+		}
+
+		next := minkowskiSupport(objA, objB, closest.normal)
+		if next.point.Dot(closest.normal)-closest.distance < 1e-4 {
+			break // Can't push further, this face is on the surface
+		}
+
+		// Remove every face the new point can see and patch the hole with faces to the new point
+		points = append(points, next)
+		newIndex := len(points) - 1
+		type edge struct{ from, to int }
+		var edges []edge
+		addEdge := func(from, to int) {
+			for i, e := range edges {
+				if e.from == to && e.to == from { // Shared by two removed faces, not on the hole's rim
+					edges = append(edges[:i], edges[i+1:]...)
+					return
+				}
+			}
+			edges = append(edges, edge{from, to})
+		}
+		kept := faces[:0]
+		for _, f := range faces {
+			if f.normal.Dot(next.point.Sub(points[f.a].point)) > 0 {
+				addEdge(f.a, f.b)
+				addEdge(f.b, f.c)
+				addEdge(f.c, f.a)
+			} else {
+				kept = append(kept, f)
+			}
+		}
+		faces = kept
+		for _, e := range edges {
+			addFace(e.from, e.to, newIndex)
+		}
+	}
+
+	// Contact position: where the origin projects onto the closest face, mapped back onto A and B
+	a, b, c := points[closest.a], points[closest.b], points[closest.c]
+	u, v, w := barycentric(closest.normal.Mul(closest.distance), a.point, b.point, c.point)
+	onA := a.onA.Mul(u).Add(b.onA.Mul(v)).Add(c.onA.Mul(w))
+	onB := a.onB.Mul(u).Add(b.onB.Mul(v)).Add(c.onB.Mul(w))
+	// The face normal points out of A - B, which is the way B has to move to get out of A
+	return closest.normal, closest.distance, onA.Add(onB).Mul(0.5)
+}
+
+// barycentric returns the weights of p relative to the triangle a, b, c.
+func barycentric(p, a, b, c mgl32.Vec3) (float32, float32, float32) {
+	v0, v1, v2 := b.Sub(a), c.Sub(a), p.Sub(a)
+	d00, d01, d11 := v0.Dot(v0), v0.Dot(v1), v1.Dot(v1)
+	d20, d21 := v2.Dot(v0), v2.Dot(v1)
+	denom := d00*d11 - d01*d01
+	if math.Abs(float64(denom)) < 1e-12 {
+		return 1, 0, 0
+	}
+	v := (d11*d20 - d01*d21) / denom
+	w := (d00*d21 - d01*d20) / denom
+	return 1 - v - w, v, w
+}
+
+// prepareManifold computes the friction directions and restitution targets
+// before the solver iterations start.
+func prepareManifold(m *ContactManifold) {
+	// Build two tangent directions perpendicular to the normal
+	helper := mgl32.Vec3{1, 0, 0}
+	if math.Abs(float64(m.Normal.X())) > 0.9 {
+		helper = mgl32.Vec3{0, 1, 0}
+	}
+	m.tangents[0] = m.Normal.Cross(helper).Normalize()
+	m.tangents[1] = m.Normal.Cross(m.tangents[0])
+
+	// Mass properties don't change during the solver iterations
+	m.invMassA, m.invMassB = m.A.inverseMass(), m.B.inverseMass()
+	m.invInertiaA, m.invInertiaB = m.A.inverseInertia(), m.B.inverseInertia()
+
+	for i := range m.Points {
+		p := &m.Points[i]
+		// Only bounce when objects hit each other fast enough, resting contacts should stay put
+		closingSpeed := relativeVelocity(m, p.Position).Dot(m.Normal)
+		p.bounceVelocity = 0
+		if closingSpeed < -RestingSpeed {
+			p.bounceVelocity = -Restitution * closingSpeed
+		}
+	}
+}
+
+// relativeVelocity returns the velocity of B relative to A at a world-space point.
+func relativeVelocity(m *ContactManifold, point mgl32.Vec3) mgl32.Vec3 {
+	ra := point.Sub(m.A.Position)
+	rb := point.Sub(m.B.Position)
+	velA := m.A.Velocity.Add(m.A.AngularVelocity.Cross(ra))
+	velB := m.B.Velocity.Add(m.B.AngularVelocity.Cross(rb))
+	return velB.Sub(velA)
+}
+
+// effectiveMass returns 1 / (relative velocity produced by one unit of impulse along dir at point).
+func effectiveMass(m *ContactManifold, point, dir mgl32.Vec3) float32 {
+	ra := point.Sub(m.A.Position)
+	rb := point.Sub(m.B.Position)
+	angularA := m.invInertiaA.Mul3x1(ra.Cross(dir)).Cross(ra)
+	angularB := m.invInertiaB.Mul3x1(rb.Cross(dir)).Cross(rb)
+
+	k := m.invMassA + m.invMassB + dir.Dot(angularA.Add(angularB))
+	if k <= 1e-9 {
+		return 0
+	}
+	return 1.0 / k
+}
+
+// applyImpulse pushes B by impulse and A by -impulse at a world-space point.
+func applyImpulse(m *ContactManifold, point, impulse mgl32.Vec3) {
+	ra := point.Sub(m.A.Position)
+	rb := point.Sub(m.B.Position)
+
+	m.A.Velocity = m.A.Velocity.Sub(impulse.Mul(m.invMassA))
+	m.A.AngularVelocity = m.A.AngularVelocity.Sub(m.invInertiaA.Mul3x1(ra.Cross(impulse)))
+	m.B.Velocity = m.B.Velocity.Add(impulse.Mul(m.invMassB))
+	m.B.AngularVelocity = m.B.AngularVelocity.Add(m.invInertiaB.Mul3x1(rb.Cross(impulse)))
+}
+
+// solveManifoldVelocity runs one sequential-impulse pass over a manifold: non-penetration
+// impulses along the normal followed by Coulomb friction.
+// The normal pass first pushes on the middle of the contact area, shared evenly between the
+// points, so a box resting flat on another gets pushed straight up instead of being tipped
+// towards whichever corner happens to be solved first. The corners then fix up what is left.
+// Friction acts on the middle of the contact area too, limited by the total normal impulse,
+// and contacts spread over an area also resist spinning around the normal.
+func solveManifoldVelocity(m *ContactManifold) {
+	center := mgl32.Vec3{0, 0, 0}
+	for _, p := range m.Points {
+		center = center.Add(p.Position)
+	}
+	share := 1.0 / float32(len(m.Points))
+	center = center.Mul(share)
+	var contactRadius float32 // Average lever arm for twist friction
+	for _, p := range m.Points {
+		contactRadius += p.Position.Sub(center).Len() * share
+	}
+
+	lambda := -relativeVelocity(m, center).Dot(m.Normal) * effectiveMass(m, center, m.Normal)
+	if lambda > 0 {
+		for i := range m.Points {
+			m.Points[i].normalImpulse += lambda * share
+		}
+		applyImpulse(m, center, m.Normal.Mul(lambda))
+	}
+
+	var totalNormalImpulse float32
+	for i := range m.Points {
+		p := &m.Points[i]
+		// Accumulated and clamped so contacts can only push, never pull
+		vn := relativeVelocity(m, p.Position).Dot(m.Normal)
+		lambda := (p.bounceVelocity - vn) * effectiveMass(m, p.Position, m.Normal)
+		newImpulse := float32(math.Max(float64(p.normalImpulse+lambda), 0))
+		lambda = newImpulse - p.normalImpulse
+		p.normalImpulse = newImpulse
+		applyImpulse(m, p.Position, m.Normal.Mul(lambda))
+		totalNormalImpulse += newImpulse
+	}
+
+	// Friction impulses (friction cone approximated by a box)
+	maxFriction := FrictionCoefficient * totalNormalImpulse
+	for t, tangent := range m.tangents {
+		vt := relativeVelocity(m, center).Dot(tangent)
+		lambda := -vt * effectiveMass(m, center, tangent)
+		newImpulse := mgl32.Clamp(m.tangentImpulse[t]+lambda, -maxFriction, maxFriction)
+		lambda = newImpulse - m.tangentImpulse[t]
+		m.tangentImpulse[t] = newImpulse
+		applyImpulse(m, center, tangent.Mul(lambda))
+	}
+
+	// Twist friction, an angular impulse around the normal
+	k := m.Normal.Dot(m.invInertiaA.Mul3x1(m.Normal)) + m.Normal.Dot(m.invInertiaB.Mul3x1(m.Normal))
+	if contactRadius > 0 && k > 1e-9 {
+		spin := m.B.AngularVelocity.Sub(m.A.AngularVelocity).Dot(m.Normal)
+		maxTwist := maxFriction * contactRadius
+		newImpulse := mgl32.Clamp(m.twistImpulse-spin/k, -maxTwist, maxTwist)
+		lambda := newImpulse - m.twistImpulse
+		m.twistImpulse = newImpulse
+		m.A.AngularVelocity = m.A.AngularVelocity.Sub(m.invInertiaA.Mul3x1(m.Normal.Mul(lambda)))
+		m.B.AngularVelocity = m.B.AngularVelocity.Add(m.invInertiaB.Mul3x1(m.Normal.Mul(lambda)))
+	}
+}
+
+// correctManifoldPosition moves overlapping objects apart in proportion to their inverse mass.
+func correctManifoldPosition(m *ContactManifold) {
+	invMassA, invMassB := m.A.inverseMass(), m.B.inverseMass()
+	totalInvMass := invMassA + invMassB
+	if totalInvMass == 0 {
+		return
+	}
+	depth := m.Penetration - PenetrationSlop
+	if depth <= 0 {
+		return
+	}
+	correction := m.Normal.Mul(depth / totalInvMass * PenetrationPercent)
+	m.A.Position = m.A.Position.Sub(correction.Mul(invMassA))
+	m.B.Position = m.B.Position.Add(correction.Mul(invMassB))
 }
 
 // renderScene clears buffers and draws all objects.
 func (a *AppCore) renderScene() {
-	gl.ClearColor(0.2, 0.3, 0.3, 1.0) // Dark teal background
+	// Both passes draw from the same instance data
+	a.buildDrawBatches()
+
+	// Shadow pass first, it renders into its own framebuffer
+	a.renderShadowMap()
+
+	// The scene goes into the post-processing chain's target, the UI straight to the window
+	a.post.Begin()
+	gl.Enable(gl.DEPTH_TEST)
+	// Dark teal background, made linear in the lit view since the chain encodes it again
+	background := mgl32.Vec3{0.2, 0.3, 0.3}
+	if a.viewMode == viewmode.Lit {
+		background = post.LinearColor(background)
+	}
+	gl.ClearColor(background.X(), background.Y(), background.Z(), 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 	// Render 3D objects
 	gl.UseProgram(a.program) // Activate 3D shader
-	for _, obj := range a.objects {
-		a.drawGameObject(obj)
+	a.applyLighting()
+	gl.Uniform1i(a.viewModeUniform, int32(a.viewMode))
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, a.shadowMap)
+	gl.ActiveTexture(gl.TEXTURE0)
+	for i := range a.drawBatches[:a.firstBlendedBatch] {
+		a.drawBatch(&a.drawBatches[i])
+	}
+	gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+
+	// Blended objects last, back to front over the opaque ones. They test against the depth
+	// buffer but don't write to it, so the ones behind still show through.
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	for i := a.firstBlendedBatch; i < len(a.drawBatches); i++ {
+		a.drawBatch(&a.drawBatches[i])
 	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	if a.wireframe {
+		a.drawWireframe()
+	}
+	gl.BindVertexArray(0)
+
+	// Debug lines over the scene, still multisampled
+	a.addDebugViews()
+	view, projection := a.cameraMatrices()
+	a.debug.draw(projection.Mul4(view))
+	viewmode.EndPost(a.post, a.viewMode)
 
 	// Render 2D UI elements
 	a.drawCustomUI()
@@ -642,6 +2457,9 @@ func (a *AppCore) renderScene() {
 func (a *AppCore) drawCustomUI() {
 	// Disable depth test for 2D UI to ensure it's always drawn on top
 	gl.Disable(gl.DEPTH_TEST)
+	// Panels and text are blended over the scene by their alpha
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	gl.UseProgram(a.uiProgram) // Activate 2D UI shader
 
 	// Set up orthographic projection for 2D UI
@@ -652,18 +2470,89 @@ func (a *AppCore) drawCustomUI() {
 	currentY := uiPadding
 
 	// --- Engine Tools Panel ---
+	panelMark := a.uiMark() // The background goes under everything up to drawPanelBackground
 	panelX := uiPadding
 	panelWidth := uiPanelWidth
 	panelHeight := float32(0.0) // Will calculate dynamically
 
-	// "Import Model" button (retained for now, but will just log a message)
+	// "Import Model" button
 	buttonX := panelX + uiPadding
 	buttonY := currentY + uiPadding
 	buttonWidth := panelWidth - uiPadding*2
 	buttonHeight := uiButtonHeight
 
-	if a.handleButton(buttonX, buttonY, buttonWidth, buttonHeight, "Import Model (.holym) - Not Supported") {
-		log.Println("Importing .holym models is not supported in this version.")
+	if a.handleButton(buttonX, buttonY, buttonWidth, buttonHeight, "Import Model") {
+		log.Print("Enter path to .holym or .holymb model file (e.g., models/my_model.holym): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			spawnPos := a.cameraPos.Add(a.cameraFront.Mul(InitialHoldDistance))
+			if _, err := a.loadHolymModel(inputPath, spawnPos); err != nil {
+				log.Printf("Error loading model from %s: %v", inputPath, err)
+			} else {
+				log.Printf("Successfully loaded model from %s", inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
+	// "Save Scene" / "Load Scene" buttons
+	buttonY = currentY + uiPadding
+	if a.handleButton(buttonX, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Save Scene") {
+		log.Print("Enter path to save the scene to (e.g., scenes/my_scene.json): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			if err := a.saveScene(inputPath); err != nil {
+				log.Printf("Error saving scene to %s: %v", inputPath, err)
+			} else {
+				log.Printf("Saved scene to %s", inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
+	}
+	if a.handleButton(buttonX + buttonWidth/2 + uiElementSpacing/2, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Load Scene") {
+		log.Print("Enter path of the scene to load (e.g., scenes/my_scene.json): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			if err := a.loadScene(inputPath); err != nil {
+				log.Printf("Error loading scene from %s: %v", inputPath, err)
+			} else {
+				log.Printf("Loaded scene from %s", inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
+	// "Export glTF" button, writes the whole scene for other tools
+	buttonY = currentY + uiPadding
+	if a.handleButton(buttonX, buttonY, buttonWidth, uiButtonHeight, "Export glTF") {
+		log.Print("Enter path to export the scene to, .gltf or .glb (e.g., scenes/my_scene.glb): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			if err := exportGLTF(inputPath, a.objects); err != nil {
+				log.Printf("Error exporting scene to %s: %v", inputPath, err)
+			} else {
+				log.Printf("Exported %d object(s) to %s", len(a.objects), inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
 	}
 	currentY += uiButtonHeight + uiElementSpacing
 
@@ -675,6 +2564,81 @@ func (a *AppCore) drawCustomUI() {
 	// }
 	// currentY += uiButtonHeight + uiElementSpacing
 
+	// Lighting sliders for the sun and the ambient light
+	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Lighting:", mgl32.Vec4{1, 1, 1, 1})
+	currentY += uiTextHeight + uiElementSpacing
+	lightingSliders := []struct {
+		label    string
+		id       string
+		value    *float32
+		min, max float32
+	}{
+		{"Sun Angle:", "sun_yaw", &a.lighting.SunYaw, 0.0, 360.0},
+		{"Sun Height:", "sun_pitch", &a.lighting.SunPitch, -10.0, 90.0},
+		{"Sun Intensity:", "sun_intensity", &a.lighting.SunIntensity, 0.0, 2.0},
+		{"Ambient:", "ambient", &a.lighting.Ambient, 0.0, 1.0},
+	}
+	for _, slider := range lightingSliders {
+		a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, slider.label, mgl32.Vec4{1, 1, 1, 1})
+		currentY += uiElementSpacing
+		a.handleSlider(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiSliderHeight,
+			slider.id, slider.value, slider.min, slider.max)
+		currentY += uiSliderHeight + uiElementSpacing
+	}
+	if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Shadows: %t", a.shadowsEnabled)) {
+		a.shadowsEnabled = !a.shadowsEnabled
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
+	// View modes, also on M and F
+	if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("View: %s", a.viewMode)) {
+		a.viewMode = (a.viewMode + 1) % viewmode.Count // Cycle through the modes
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+	if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Wireframe: %t", a.wireframe)) {
+		a.wireframe = !a.wireframe
+	}
+	currentY += uiButtonHeight + uiElementSpacing * 2
+
+	// Post-processing toggles, also on keys 1-5
+	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Post Effects:", mgl32.Vec4{1,1,1,1})
+	currentY += uiTextHeight + uiElementSpacing
+	for effect := post.Effect(0); effect < post.EffectCount; effect++ {
+		label := fmt.Sprintf("%d %s: %t", effect+1, post.EffectNames[effect], a.post.Enabled[effect])
+		if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, label) {
+			a.post.Toggle(effect)
+		}
+		currentY += uiButtonHeight + uiElementSpacing
+	}
+	if a.post.Enabled[post.Tonemap] {
+		a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Exposure:", mgl32.Vec4{1,1,1,1})
+		currentY += uiElementSpacing
+		a.handleSlider(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiSliderHeight,
+			"exposure", &a.post.Exposure, 0.1, 4.0)
+		currentY += uiSliderHeight + uiElementSpacing
+	}
+	currentY += uiElementSpacing
+
+	// Debug line toggles, folded away unless needed so the panel stays short
+	debugHeader := "Debug Draw: +"
+	if a.debugPanelOpen {
+		debugHeader = "Debug Draw: -"
+	}
+	if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, debugHeader) {
+		a.debugPanelOpen = !a.debugPanelOpen
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+	if a.debugPanelOpen {
+		for view := debugView(0); view < debugViewCount; view++ {
+			label := fmt.Sprintf("%s: %t", debugViewNames[view], a.debug.Show[view])
+			if a.handleButton(panelX+uiPadding, currentY+uiPadding, panelWidth-uiPadding*2, uiButtonHeight, label) {
+				a.debug.toggle(view)
+			}
+			currentY += uiButtonHeight + uiElementSpacing
+		}
+	}
+	currentY += uiElementSpacing
+
 	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Scene Objects:", mgl32.Vec4{1,1,1,1})
 	currentY += uiTextHeight + uiElementSpacing
 
@@ -704,10 +2668,12 @@ func (a *AppCore) drawCustomUI() {
 	}
 
 	panelHeight = currentY + uiPadding - uiPadding // Adjust for final padding
-	a.drawRect(panelX, uiPadding, panelWidth, panelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Engine Tools
+	a.drawPanelBackground(panelMark, panelX, uiPadding, panelWidth, panelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Engine Tools
+	a.flushUI()
 
 	// --- Properties Panel for selected object ---
 	if a.selectedObject != nil {
+		propPanelMark := a.uiMark()
 		propPanelX := float32(a.width) - uiPanelWidth - uiPadding
 		propPanelY := uiPadding
 		propPanelWidth := uiPanelWidth
@@ -756,6 +2722,50 @@ func (a *AppCore) drawCustomUI() {
 			"scale_z", &a.selectedObject.Scale[2], 0.01, 5.0)
 		currentPropY += uiSliderHeight + uiElementSpacing * 2 // Extra spacing
 
+		// Light Sliders, only for point lights
+		if light := a.selectedObject.Light; light != nil {
+			lightSliders := []struct {
+				label    string
+				id       string
+				value    *float32
+				min, max float32
+			}{
+				{"Light Red:", "light_r", &light.Color[0], 0.0, 1.0},
+				{"Light Green:", "light_g", &light.Color[1], 0.0, 1.0},
+				{"Light Blue:", "light_b", &light.Color[2], 0.0, 1.0},
+				{"Intensity:", "light_intensity", &light.Intensity, 0.0, 5.0},
+				{"Range:", "light_range", &light.Range, 0.5, 30.0},
+			}
+			for _, slider := range lightSliders {
+				a.drawTextOverlay(propPanelX+uiPadding, currentPropY, slider.label, mgl32.Vec4{1, 1, 1, 1})
+				currentPropY += uiElementSpacing
+				a.handleSlider(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiSliderHeight,
+					slider.id, slider.value, slider.min, slider.max)
+				currentPropY += uiSliderHeight + uiElementSpacing
+			}
+			currentPropY += uiElementSpacing // Extra spacing
+		}
+
+		// Transparency, see holy-shared/alphamode. Light markers are always drawn solid.
+		if a.selectedObject.Light == nil {
+			if a.handleButton(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Alpha: %s", a.selectedObject.AlphaMode)) {
+				a.selectedObject.AlphaMode = (a.selectedObject.AlphaMode + 1) % alphamode.Count // Cycle through the modes
+			}
+			currentPropY += uiButtonHeight + uiElementSpacing
+			if a.selectedObject.AlphaMode == alphamode.Mask {
+				a.drawTextOverlay(propPanelX+uiPadding, currentPropY, "Alpha Cutoff:", mgl32.Vec4{1, 1, 1, 1})
+				currentPropY += uiElementSpacing
+				a.handleSlider(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiSliderHeight,
+					"alpha_cutoff", &a.selectedObject.AlphaCutoff, 0.0, 1.0)
+				currentPropY += uiSliderHeight + uiElementSpacing
+				if a.handleButton(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Alpha to Coverage: %t", a.selectedObject.AlphaToCoverage)) {
+					a.selectedObject.AlphaToCoverage = !a.selectedObject.AlphaToCoverage
+				}
+				currentPropY += uiButtonHeight + uiElementSpacing
+			}
+			currentPropY += uiElementSpacing // Extra spacing
+		}
+
 		// Velocity (read-only)
 		a.drawTextOverlay(propPanelX+uiPadding, currentPropY, fmt.Sprintf("Velocity X: %.2f", a.selectedObject.Velocity.X()), mgl32.Vec4{1,1,1,1})
 		currentPropY += uiTextHeight + uiElementSpacing
@@ -788,19 +2798,23 @@ func (a *AppCore) drawCustomUI() {
 		currentPropY += uiButtonHeight + uiElementSpacing
 
 		propPanelHeight = currentPropY - propPanelY + uiPadding
-		a.drawRect(propPanelX, propPanelY, propPanelWidth, propPanelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Properties
+		a.drawPanelBackground(propPanelMark, propPanelX, propPanelY, propPanelWidth, propPanelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Properties
+		a.flushUI()
 	}
 
+	gl.Disable(gl.BLEND)
 	gl.Enable(gl.DEPTH_TEST) // Re-enable depth test for 3D scene
 }
 
 // drawEGUI renders the GUI that appears when 'E' is pressed.
 func (a *AppCore) drawEGUI() {
 	gl.Disable(gl.DEPTH_TEST) // Ensure UI is drawn on top
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	gl.UseProgram(a.uiProgram)
 
 	// Calculate center position for the E GUI panel
-	panelWidth := float32(400) // Example width
+	panelWidth := float32(490) // Fits four items per row
 	panelHeight := float32(300) // Example height
 	panelX := (float32(a.width) - panelWidth) / 2
 	panelY := (float32(a.height) - panelHeight) / 2
@@ -811,48 +2825,44 @@ func (a *AppCore) drawEGUI() {
 	// Grid layout for items
 	gridStartX := panelX + uiPadding
 	gridStartY := panelY + uiPadding + uiTextHeight + uiElementSpacing
-	itemSize := float32(80)
+	itemSize := float32(110) // Wide enough for the longest label
 	itemSpacing := float32(10) // Used for spacing between grid items
 
-	// First square: Cube preview
-	cubeItemX := gridStartX
-	cubeItemY := gridStartY
-
-	// Draw the square for the cube preview
-	a.drawRect(cubeItemX, cubeItemY, itemSize, itemSize, mgl32.Vec4{0.25, 0.25, 0.25, 1.0})
-	a.drawTextOverlay(cubeItemX + itemSize/2 - float32(len("Cube")*3), cubeItemY + itemSize/2 - 8, "Cube", mgl32.Vec4{1,1,1,1})
-
-	// Handle click for the cube preview
-	if a.handleButton(cubeItemX, cubeItemY, itemSize, itemSize, "Spawn Cube Button") {
-		spawnPos := a.cameraPos.Add(a.cameraFront.Mul(InitialHoldDistance))
-		newCube := a.createPrimitive("cube", spawnPos)
-		a.heldObject = newCube // Immediately grab the spawned cube
-		a.isEGUIVisible = false // Close the E GUI
-		a.isMouseGrabbed = true // Re-grab mouse
-		a.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
-		log.Println("Spawned and grabbed a cube from E GUI.")
-	}
-
-	// Example of another item: Sphere preview
-	nextItemX := gridStartX + itemSize + itemSpacing // Use itemSpacing for horizontal offset
-	sphereItemX := nextItemX
-	sphereItemY := gridStartY
-
-	// Draw the square for the sphere preview
-	a.drawRect(sphereItemX, sphereItemY, itemSize, itemSize, mgl32.Vec4{0.25, 0.25, 0.25, 1.0})
-	a.drawTextOverlay(sphereItemX + itemSize/2 - float32(len("Sphere")*3), sphereItemY + itemSize/2 - 8, "Sphere", mgl32.Vec4{1,1,1,1})
-
-	// Handle click for the sphere preview
-	if a.handleButton(sphereItemX, sphereItemY, itemSize, itemSize, "Spawn Sphere Button") {
-		spawnPos := a.cameraPos.Add(a.cameraFront.Mul(InitialHoldDistance))
-		newSphere := a.createPrimitive("sphere", spawnPos) // Call createPrimitive for sphere
-		a.heldObject = newSphere // Immediately grab the spawned sphere
-		a.isEGUIVisible = false // Close the E GUI
-		a.isMouseGrabbed = true // Re-grab mouse
-		a.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
-		log.Println("Spawned and grabbed a sphere from E GUI.")
+	itemsPerRow := 4
+
+	// One square per primitive, laid out left to right, top to bottom
+	spawnItems := []struct {
+		label     string
+		shapeType string
+	}{
+		{"Cube", "cube"},
+		{"Sphere", "sphere"},
+		{"Icosphere", "icosphere"},
+		{"Cylinder", "cylinder"},
+		{"Cone", "cone"},
+		{"Capsule", "capsule"},
+		{"Torus", "torus"},
+		{"Point Light", "light"},
+	}
+
+	for i, item := range spawnItems {
+		itemX := gridStartX + float32(i%itemsPerRow)*(itemSize+itemSpacing)
+		itemY := gridStartY + float32(i/itemsPerRow)*(itemSize+itemSpacing)
+
+		// The item square is a button labelled with the primitive's name
+		if a.handleButton(itemX, itemY, itemSize, itemSize, item.label) {
+			spawnPos := a.cameraPos.Add(a.cameraFront.Mul(InitialHoldDistance))
+			newObject := a.createPrimitive(item.shapeType, spawnPos)
+			a.heldObject = newObject // Immediately grab the spawned object
+			a.isEGUIVisible = false // Close the E GUI
+			a.isMouseGrabbed = true // Re-grab mouse
+			a.window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+			log.Printf("Spawned and grabbed a %s from E GUI.", item.shapeType)
+		}
 	}
 
+	a.flushUI() // One draw call for the whole panel, text on top
+	gl.Disable(gl.BLEND)
 	gl.Enable(gl.DEPTH_TEST) // Re-enable depth test for 3D scene
 }
 
@@ -883,7 +2893,8 @@ func (a *AppCore) handleButton(x, y, width, height float32, label string) bool {
 	}
 
 	a.drawRect(x, y, width, height, buttonColor)
-	a.drawTextOverlay(x + width/2 - float32(len(label)*3), y + height/2 - 8, label, mgl32.Vec4{1,1,1,1}) // Crude text centering
+	textWidth, textHeight := measureText(label)
+	a.drawTextOverlay(x + (width-textWidth)/2, y + (height-textHeight)/2, label, mgl32.Vec4{1,1,1,1}) // Centered label
 
 	return clicked
 }
@@ -921,99 +2932,138 @@ func (a *AppCore) handleSlider(x, y, width, height float32, id string, value *fl
 	}
 }
 
-
-// drawRect draws a filled rectangle.
+// drawRect adds a filled rectangle to the UI batch. It shows up on the next flushUI.
 func (a *AppCore) drawRect(x, y, width, height float32, color mgl32.Vec4) {
-	// Define vertices for a quad
-	vertices := []float32{
-		x, y, // Top-left
-		x + width, y, // Top-right
-		x + width, y + height, // Bottom-right
-		x, y + height, // Bottom-left
-	}
-	indices := []uint32{
-		0, 1, 2,
-		2, 3, 0,
-	}
+	a.uiVertices = appendUIQuad(a.uiVertices, x, y, x+width, y+height, 0, 0, 0, 0, color, false)
+}
 
-	var vao, vbo, ebo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
+// appendUIQuad appends a quad from (x0, y0) to (x1, y1) as two triangles. Glyph quads show
+// the font atlas between (u0, v0) and (u1, v1) in color, other quads are filled with it.
+func appendUIQuad(vertices []float32, x0, y0, x1, y1, u0, v0, u1, v1 float32, color mgl32.Vec4, glyph bool) []float32 {
+	g := float32(0)
+	if glyph {
+		g = 1
+	}
+	r, gr, b, al := color.X(), color.Y(), color.Z(), color.W()
+	return append(vertices,
+		x0, y0, u0, v0, r, gr, b, al, g, // Top-left
+		x1, y0, u1, v0, r, gr, b, al, g, // Top-right
+		x1, y1, u1, v1, r, gr, b, al, g, // Bottom-right
+		x1, y1, u1, v1, r, gr, b, al, g, // Bottom-right
+		x0, y1, u0, v1, r, gr, b, al, g, // Bottom-left
+		x0, y0, u0, v0, r, gr, b, al, g, // Top-left
+	)
+}
 
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+// uiMark returns where the next quad goes in the UI batch, for drawPanelBackground.
+func (a *AppCore) uiMark() int {
+	return len(a.uiVertices)
+}
 
-	gl.GenBuffers(1, &ebo)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+// drawPanelBackground adds a panel's background under every quad added since mark. Panels
+// are sized by their contents, so the background is only known after them.
+func (a *AppCore) drawPanelBackground(mark int, x, y, width, height float32, color mgl32.Vec4) {
+	background := appendUIQuad(nil, x, y, x+width, y+height, 0, 0, 0, 0, color, false)
+	a.uiVertices = append(a.uiVertices, background...)
+	copy(a.uiVertices[mark+len(background):], a.uiVertices[mark:len(a.uiVertices)-len(background)])
+	copy(a.uiVertices[mark:], background)
+}
 
-	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 2*4, gl.Ptr(nil)) // 2D position
-	gl.EnableVertexAttribArray(0)
+// queuedText is one drawTextOverlay call waiting for flushUI.
+type queuedText struct {
+	x, y  float32
+	text  string
+	color mgl32.Vec4
+}
 
-	gl.Uniform4fv(a.uiColorUniform, 1, &color[0])
-	gl.DrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
+// drawTextOverlay draws text with its top-left corner at (x, y) in UI pixels.
+// The text is only queued here and drawn by the next flushUI, on top of the batch's quads.
+func (a *AppCore) drawTextOverlay(x, y float32, text string, color mgl32.Vec4) {
+	a.textQueue = append(a.textQueue, queuedText{x: x, y: y, text: text, color: color})
+}
 
-	gl.BindVertexArray(0)
-	gl.DeleteBuffers(1, &vbo)
-	gl.DeleteBuffers(1, &ebo)
-	gl.DeleteVertexArrays(1, &vao)
+// measureText returns the size in UI pixels that drawTextOverlay uses for text.
+func measureText(text string) (float32, float32) {
+	return float32(len(text)) * bitfont.CellWidth * uiTextScale, bitfont.CellHeight * uiTextScale
 }
 
-// drawTextOverlay is a placeholder for text rendering.
-// Full text rendering in OpenGL is complex and would require font loading,
-// texture atlases, and a more sophisticated rendering pipeline.
-// For this example, we'll just log the text or visually represent it.
-// In a real application, you'd use a library like freetype-go or implement your own.
-func (a *AppCore) drawTextOverlay(x, y float32, text string, color mgl32.Vec4) {
-	// For now, we'll just log the text to the console.
-	// In a full implementation, this would draw actual text on screen.
-	// log.Printf("UI Text: %s at (%.1f, %.1f)", text, x, y) // Uncomment for debug logging
-	// To actually render text, you'd need:
-	// 1. A font texture atlas.
-	// 2. A separate VAO/VBO for text quads.
-	// 3. A shader that samples from the font texture.
-	// This is a significant additional task.
+// flushUI draws the UI batch with one upload and one draw call: the quads added since the
+// last flush, then the queued text as glyph quads cut out of the font atlas. It is called
+// once per panel.
+func (a *AppCore) flushUI() {
+	cellWidth := bitfont.CellWidth * uiTextScale
+	cellHeight := bitfont.CellHeight * uiTextScale
+	for _, t := range a.textQueue {
+		x := t.x
+		for i := 0; i < len(t.text); i++ {
+			u0, v0, u1, v1 := bitfont.GlyphUV(t.text[i])
+			a.uiVertices = appendUIQuad(a.uiVertices, x, t.y, x+cellWidth, t.y+cellHeight, u0, v0, u1, v1, t.color, true)
+			x += cellWidth
+		}
+	}
+	a.textQueue = a.textQueue[:0]
+	if len(a.uiVertices) == 0 {
+		return
+	}
+
+	gl.UseProgram(a.uiProgram)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, a.fontTexture)
+	gl.BindVertexArray(a.uiVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.uiVBO)
+
+	// Re-specifying the storage lets the driver hand out fresh memory instead of waiting for
+	// the last flush's draw to finish reading it. It only grows, doubling when it is too small.
+	size := len(a.uiVertices) * 4
+	for a.uiBufferSize < size {
+		a.uiBufferSize *= 2
+	}
+	gl.BufferData(gl.ARRAY_BUFFER, a.uiBufferSize, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(a.uiVertices))
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(a.uiVertices)/uiVertexFloats))
+
+	gl.BindVertexArray(0)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	a.uiVertices = a.uiVertices[:0]
 }
 
 
-// drawGameObject draws a given GameObject.
-func (a *AppCore) drawGameObject(obj *GameObject) {
-	// Set hasTexture uniform based on the object's property
-	if obj.HasTexture && obj.TextureID != 0 {
+// drawBatch draws the instances of a batch that are in view with one draw call.
+func (a *AppCore) drawBatch(batch *drawBatch) {
+	if batch.visibleCount == 0 {
+		return // Everything in it was culled
+	}
+	// Set hasTexture uniform based on the batch's texture
+	if batch.textureID != 0 {
 		gl.Uniform1i(a.hasTextureUniform, 1) // 1 for true
 		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, obj.TextureID)
+		gl.BindTexture(gl.TEXTURE_2D, batch.textureID)
 		gl.Uniform1i(a.textureUniform, 0) // Texture unit 0
 	} else {
 		gl.Uniform1i(a.hasTextureUniform, 0) // 0 for false
 	}
+	if batch.normalMapID != 0 {
+		gl.Uniform1i(a.hasNormalMapUniform, 1)
+		gl.ActiveTexture(gl.TEXTURE2)
+		gl.BindTexture(gl.TEXTURE_2D, batch.normalMapID)
+		gl.ActiveTexture(gl.TEXTURE0)
+	} else {
+		gl.Uniform1i(a.hasNormalMapUniform, 0)
+	}
 
-	model := mgl32.Ident4()
-	model = model.Mul4(mgl32.Translate3D(obj.Position.X(), obj.Position.Y(), obj.Position.Z()))
-	// Apply rotations in ZYX order for more intuitive Euler angles
-	model = model.Mul4(mgl32.HomogRotate3DZ(obj.Rotation.Z()))
-	model = model.Mul4(mgl32.HomogRotate3DY(obj.Rotation.Y()))
-	model = model.Mul4(mgl32.HomogRotate3DX(obj.Rotation.X()))
-	model = model.Mul4(mgl32.Scale3D(obj.Scale.X(), obj.Scale.Y(), obj.Scale.Z()))
-
-	gl.UniformMatrix4fv(a.modelUniform, 1, false, &model[0])
-
-	gl.BindVertexArray(obj.VAO)
-	// Vertex stride is 8*4 bytes (3 pos + 3 color + 2 texcoord)
-	// Ensure attributes are correctly re-enabled/set for each object if they vary
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 8*4, gl.Ptr(nil)) // Position
-	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(3*4)) // Color
-	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 8*4, gl.PtrOffset(6*4)) // TexCoord
-	gl.EnableVertexAttribArray(2)
+	// Point lights are drawn as a flat marker in their own color
+	if batch.unlit {
+		gl.Uniform1i(a.unlitUniform, 1)
+	} else {
+		gl.Uniform1i(a.unlitUniform, 0)
+	}
+	a.setAlphaState(batch)
 
-	gl.DrawElements(gl.TRIANGLES, obj.IndicesCount, gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
-	gl.BindVertexArray(0)
+	// The vertex array already has every attribute set up, see newMesh
+	drawInstances(batch, batch.visibleCount)
 
 	// Unbind texture if one was used to prevent bleeding
-	if obj.HasTexture && obj.TextureID != 0 {
+	if batch.textureID != 0 {
 		gl.BindTexture(gl.TEXTURE_2D, 0)
 	}
 }
@@ -1023,7 +3073,7 @@ func (a *AppCore) updateAndDisplayFPS() {
 	a.fpsFrames++
 	if time.Since(a.fpsLastUpdateTime) >= time.Second {
 		fps := float64(a.fpsFrames) / time.Since(a.fpsLastUpdateTime).Seconds()
-		a.window.SetTitle(fmt.Sprintf("%s | FPS: %.2f", a.title, fps))
+		a.window.SetTitle(fmt.Sprintf("%s | FPS: %.2f | Visible: %d | Culled: %d", a.title, fps, a.visibleObjects, a.culledObjects))
 		a.fpsFrames = 0
 		a.fpsLastUpdateTime = time.Now()
 	}
@@ -1035,18 +3085,29 @@ func shutdownApp() {
 		return
 	}
 
-	// Delete all objects' buffers
+	// Delete all objects' buffers, then the meshes primitives share
 	for _, obj := range app.objects {
-		gl.DeleteVertexArrays(1, &obj.VAO)
-		gl.DeleteBuffers(1, &obj.VBO)
-		gl.DeleteBuffers(1, &obj.EBO)
-		if obj.TextureID != 0 {
-			gl.DeleteTextures(1, &obj.TextureID)
-		}
+		deleteGameObject(obj)
+	}
+	for _, mesh := range app.primitiveMeshes {
+		mesh.release()
 	}
+	gl.DeleteBuffers(1, &app.instanceVBO)
 
 	gl.DeleteProgram(app.program) // 3D scene program
 	gl.DeleteProgram(app.uiProgram) // 2D UI program
+	gl.DeleteProgram(app.shadowProgram)
+	gl.DeleteFramebuffers(1, &app.shadowFBO)
+	gl.DeleteTextures(1, &app.shadowMap)
+	if app.post != nil {
+		app.post.Release()
+	}
+	if app.debug != nil {
+		app.debug.release()
+	}
+	gl.DeleteTextures(1, &app.fontTexture)
+	gl.DeleteVertexArrays(1, &app.uiVAO)
+	gl.DeleteBuffers(1, &app.uiVBO)
 
 	if app.window != nil {
 		app.window.Destroy()
@@ -1119,6 +3180,19 @@ func intersectRayAABB(rayOrigin, rayDirection mgl32.Vec3, boxMin, boxMax mgl32.V
 	return true, tMin
 }
 
+// intersectRayOBB checks if a ray intersects an oriented box.
+// The ray is moved into the box's own frame, where the box is axis aligned, and tested with
+// intersectRayAABB. The axes are unit length so the returned distance is still in world units.
+func intersectRayOBB(rayOrigin, rayDirection mgl32.Vec3, box OrientedBox) (bool, float32) {
+	offset := rayOrigin.Sub(box.Center)
+	var localOrigin, localDirection mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		localOrigin[i] = offset.Dot(box.Axes[i])
+		localDirection[i] = rayDirection.Dot(box.Axes[i])
+	}
+	return intersectRayAABB(localOrigin, localDirection, box.HalfExtents.Mul(-1), box.HalfExtents)
+}
+
 // tryPickObject attempts to pick up an object using a raycast.
 func (a *AppCore) tryPickObject() {
 	rayOrigin, rayDirection := a.getRayFromMouse()
@@ -1132,20 +3206,8 @@ func (a *AppCore) tryPickObject() {
 			continue
 		}
 
-		// Transform object's local bounding box to world space
-		// Corrected: Manual component-wise multiplication instead of MulV
-		scaledMin := obj.Position.Add(mgl32.Vec3{
-			obj.BoundingBox.Min.X() * obj.Scale.X(),
-			obj.BoundingBox.Min.Y() * obj.Scale.Y(),
-			obj.BoundingBox.Min.Z() * obj.Scale.Z(),
-		})
-		scaledMax := obj.Position.Add(mgl32.Vec3{
-			obj.BoundingBox.Max.X() * obj.Scale.X(),
-			obj.BoundingBox.Max.Y() * obj.Scale.Y(),
-			obj.BoundingBox.Max.Z() * obj.Scale.Z(),
-		})
-
-		if hit, dist := intersectRayAABB(rayOrigin, rayDirection, scaledMin, scaledMax); hit {
+		// Test against the bounding box as drawn, including the object's rotation
+		if hit, dist := intersectRayOBB(rayOrigin, rayDirection, obj.orientedBox()); hit {
 			if dist < PickupRange && dist < closestHit {
 				closestHit = dist
 				hitObject = obj
@@ -1153,6 +3215,15 @@ func (a *AppCore) tryPickObject() {
 		}
 	}
 
+	// Kept for the pick ray debug view
+	a.hasPickRay = true
+	a.pickRayOrigin, a.pickRayDirection = rayOrigin, rayDirection
+	a.pickRayHit = hitObject != nil
+	a.pickRayLength = PickupRange
+	if a.pickRayHit {
+		a.pickRayLength = closestHit
+	}
+
 	if hitObject != nil {
 		a.heldObject = hitObject
 		a.heldObject.IsKinematic = true // Disable physics while held
@@ -1173,7 +3244,14 @@ func (a *AppCore) releaseHeldObject() {
 
 		// If it was rotating, maintain angular velocity, otherwise clear it
 		if !a.isRotatingHeldObject {
-			a.heldObject.AngularVelocity = mgl32.Vec3{0,0,0}
+			a.heldObject.AngularVelocity = mgl32.Vec3{0, 0, 0}
+		}
+
+		// Lights stay where they are let go instead of being thrown
+		if a.heldObject.Light != nil {
+			a.heldObject.IsKinematic = true
+			a.heldObject.Velocity = mgl32.Vec3{0, 0, 0}
+			a.heldObject.AngularVelocity = mgl32.Vec3{0, 0, 0}
 		}
 
 		log.Printf("Released object: %s", a.heldObject.ID)
@@ -1185,8 +3263,9 @@ func (a *AppCore) releaseHeldObject() {
 
 // --- Model/Primitive Creation Functions ---
 
-// newTexture creates an OpenGL texture from an image.
-func newTexture(img image.Image) (uint32, error) {
+// newTexture creates an OpenGL texture from an image, and returns the alpha mode its alpha
+// channel calls for.
+func newTexture(img image.Image) (uint32, alphamode.Mode, error) {
 	var texture uint32
 	gl.GenTextures(1, &texture)
 	gl.BindTexture(gl.TEXTURE_2D, texture)
@@ -1207,16 +3286,17 @@ func newTexture(img image.Image) (uint32, error) {
 
 	gl.BindTexture(gl.TEXTURE_2D, 0) // Unbind texture
 
-	return texture, nil
+	return texture, alphamode.ImageMode(rgba.Pix), nil
 }
 
-// createGameObject initializes OpenGL buffers for a new GameObject and adds it to the scene.
-func (a *AppCore) createGameObject(id string, vertices []float32, indices []uint32, hasTexture bool, texturePath string, initialPos mgl32.Vec3, mass float32, boundingBox BoundingBox) *GameObject {
+// createGameObject adds a new GameObject drawn from mesh to the scene. Models pass a mesh of
+// their own from newMesh, primitives the shared one from primitiveMesh.
+func (a *AppCore) createGameObject(id string, mesh *Mesh, hasTexture bool, texturePath string, initialPos mgl32.Vec3, mass float32, boundingBox BoundingBox) *GameObject {
 	newObj := &GameObject{
 		ID:           id,
-		Vertices:     vertices,
-		Indices:      indices,
-		IndicesCount: int32(len(indices)),
+		Mesh:         mesh,
+		Color:        mgl32.Vec4{1, 1, 1, 1},
+		AlphaCutoff:  alphamode.DefaultCutoff,
 		Position:     initialPos,
 		Rotation:     mgl32.Vec3{0, 0, 0}, // Initial rotation
 		Scale:        mgl32.Vec3{1, 1, 1}, // Initial scale
@@ -1232,105 +3312,197 @@ func (a *AppCore) createGameObject(id string, vertices []float32, indices []uint
 
 	// Load texture if path is provided
 	if newObj.HasTexture && newObj.TexturePath != "" {
-		texID, err := newTextureFromFile(newObj.TexturePath) // Use helper to load from file
+		texID, alphaMode, err := newTextureFromFile(newObj.TexturePath) // Use helper to load from file
 		if err != nil {
 			log.Printf("Warning: Failed to load texture %s for model %s: %v", newObj.TexturePath, newObj.ID, err)
 			newObj.HasTexture = false // Fallback to vertex colors
 		} else {
 			newObj.TextureID = texID
+			newObj.AlphaMode = alphaMode // Cut-out and see-through textures are drawn as such
 		}
 	}
 
-	// Setup OpenGL buffers for the new object
-	gl.GenVertexArrays(1, &newObj.VAO)
-	gl.BindVertexArray(newObj.VAO)
-
-	gl.GenBuffers(1, &newObj.VBO)
-	gl.BindBuffer(gl.ARRAY_BUFFER, newObj.VBO)
-	gl.BufferData(gl.ARRAY_BUFFER, len(newObj.Vertices)*4, gl.Ptr(newObj.Vertices), gl.STATIC_DRAW)
-
-	gl.GenBuffers(1, &newObj.EBO)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, newObj.EBO)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(newObj.Indices)*4, gl.Ptr(newObj.Indices), gl.STATIC_DRAW)
-
-	// Position attribute (layout location 0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 8*4, gl.Ptr(nil)) // 3 pos + 3 color + 2 texcoord
-	gl.EnableVertexAttribArray(0)
-	// Color attribute (layout location 1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
-	// Texture coordinate attribute (layout location 2)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 8*4, gl.PtrOffset(6*4))
-	gl.EnableVertexAttribArray(2)
-
-	gl.BindVertexArray(0) // Unbind VAO
-
 	a.objects = append(a.objects, newObj)
 	a.nextObjectID++
 	return newObj
 }
 
-// newTextureFromFile loads an image from a file and creates an OpenGL texture.
-func newTextureFromFile(imgPath string) (uint32, error) {
+// newTextureFromFile loads an image from a file and creates an OpenGL texture, see newTexture.
+func newTextureFromFile(imgPath string) (uint32, alphamode.Mode, error) {
 	file, err := os.Open(imgPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open texture file %s: %w", imgPath, err)
+		return 0, alphamode.Opaque, fmt.Errorf("failed to open texture file %s: %w", imgPath, err)
 	}
 	defer file.Close()
 
 	img, _, err := image.Decode(file)
 	if err != nil {
-		return 0, fmt.Errorf("failed to decode texture image %s: %w", imgPath, err)
+		return 0, alphamode.Opaque, fmt.Errorf("failed to decode texture image %s: %w", imgPath, err)
 	}
 	return newTexture(img)
 }
 
-// loadHolymModel is now a placeholder as per user request.
-func (a *AppCore) loadHolymModel(filePath string) error {
-	log.Printf("Loading .holym models is currently disabled. Attempted to load: %s", filePath)
-	// For demonstration, we could spawn a default cube instead of loading the model
-	// a.createPrimitive("cube", a.cameraPos.Add(a.cameraFront.Mul(InitialHoldDistance)))
-	return nil // Return nil error to indicate it "handled" the request gracefully
+// boundingBoxFromVertices returns the local-space box around the positions in interleaved vertex data.
+func boundingBoxFromVertices(vertices []float32) BoundingBox {
+	if len(vertices) < vertexFloats {
+		return BoundingBox{}
+	}
+	bbox := BoundingBox{
+		Min: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+		Max: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+	}
+	for i := 0; i+2 < len(vertices); i += vertexFloats { // Position comes first in each vertex
+		for j := 0; j < 3; j++ {
+			bbox.Min[j] = float32(math.Min(float64(bbox.Min[j]), float64(vertices[i+j])))
+			bbox.Max[j] = float32(math.Max(float64(bbox.Max[j]), float64(vertices[i+j])))
+		}
+	}
+	return bbox
+}
+
+// setNormalMap loads a normal map for an object, replacing the one it has. An empty path or
+// a file that can't be loaded leaves the object without one.
+func setNormalMap(obj *GameObject, path string) {
+	if obj.NormalMapID != 0 {
+		gl.DeleteTextures(1, &obj.NormalMapID)
+		obj.NormalMapID = 0
+	}
+	obj.NormalMap = ""
+	if path == "" {
+		return
+	}
+	texID, _, err := newTextureFromFile(path)
+	if err != nil {
+		log.Printf("Warning: Failed to load normal map %s for %s: %v", path, obj.ID, err)
+		return
+	}
+	obj.NormalMapID = texID
+	obj.NormalMap = path
+}
+
+// loadHolymModel loads a .holym model from file as a dynamic object at initialPos, or a
+// .holymb one if it has that extension. It collides as a box around its vertices.
+func (a *AppCore) loadHolymModel(filePath string, initialPos mgl32.Vec3) (*GameObject, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".holymb") {
+		return a.loadHolymbModel(filePath, initialPos)
+	}
+	mesh, err := holym.ParseFile(filePath, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .holym model %s: %w", filePath, err)
+	}
+	vertices, indices := mesh.GameObjectMesh()
+	hasTexture, texturePath := mesh.TexturePath != "", mesh.TexturePath
+	if len(indices) == 0 {
+		return nil, fmt.Errorf(".holym model %s has no faces", filePath)
+	}
+
+	id := fmt.Sprintf("%s_%d", filepath.Base(filePath), a.nextObjectID)
+	bbox := boundingBoxFromVertices(vertices)
+	newObj := a.createGameObject(id, a.newMesh(vertices, indices), hasTexture, texturePath, initialPos, DefaultModelMass, bbox)
+	newObj.ModelPath = filePath
+	setNormalMap(newObj, mesh.NormalMap)
+	a.selectedObject = newObj
+	log.Printf("Loaded model: %s", id)
+	return newObj, nil
+}
+
+// loadHolymbModel loads a binary .holymb model as a dynamic object at initialPos. Its vertices
+// and indices are uploaded straight from the memory-mapped file, which stays mapped as long
+// as the object exists.
+func (a *AppCore) loadHolymbModel(filePath string, initialPos mgl32.Vec3) (*GameObject, error) {
+	mesh, err := holym.OpenBinary(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(mesh.Indices) == 0 {
+		mesh.Close()
+		return nil, fmt.Errorf(".holymb model %s has no faces", filePath)
+	}
+
+	id := fmt.Sprintf("%s_%d", filepath.Base(filePath), a.nextObjectID)
+	vertices, indices := mesh.GameObjectMesh()
+	texturePath := mesh.TexturePath()
+	newObj := a.createGameObject(id, a.newMesh(vertices, indices), texturePath != "", texturePath, initialPos, DefaultModelMass, boundingBoxFromVertices(vertices))
+	newObj.ModelPath = filePath
+	newObj.holymb = mesh
+	setNormalMap(newObj, mesh.NormalMap())
+	a.selectedObject = newObj
+	log.Printf("Loaded model: %s", id)
+	return newObj, nil
 }
 
-// createPrimitive generates a new primitive shape and adds it to the scene.
-// It now returns the created GameObject.
+// createPrimitive adds a new primitive shape to the scene. Primitives of one type share a
+// mesh, generated the first time one is made. It returns the created GameObject.
 func (a *AppCore) createPrimitive(shapeType string, initialPos mgl32.Vec3) *GameObject {
-	var vertices []float32
-	var indices []uint32
+	var generate func() ([]float32, []uint32)
 	var id string
 	var bbox BoundingBox
 	var mass float32 = 1.0 // Default mass for primitives
+	shape := ColliderBox
 
 	switch shapeType {
 	case "cube":
 		id = fmt.Sprintf("Cube_%d", a.nextObjectID)
-		vertices, indices = generateCubeData()
+		generate = generateCubeData
 		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Unit cube
 	case "plane":
 		id = fmt.Sprintf("Plane_%d", a.nextObjectID)
-		vertices, indices = generatePlaneData()
+		generate = generatePlaneData
 		// For a plane, the bounding box typically has zero height on the plane axis
 		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, 0.0, -0.5}, Max: mgl32.Vec3{0.5, 0.0, 0.5}} // Unit plane on Y=0
 		mass = 0.0 // Planes are static/immovable
-	case "sphere": // New case for sphere
+	case "sphere":
 		id = fmt.Sprintf("Sphere_%d", a.nextObjectID)
-		// Placeholder for sphere data generation.
-		// In a real engine, you'd have a generateSphereData() function.
-		// For now, we'll use cube data as a visual stand-in to avoid a crash,
-		// but log a warning.
-		log.Println("Warning: Sphere generation not implemented. Using cube data as placeholder.")
-		vertices, indices = generateCubeData() // Placeholder: use cube data
-		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Placeholder: cube bbox
+		generate = func() ([]float32, []uint32) { return generateUVSphereData(32, 16) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Radius 0.5
+		shape = ColliderSphere
+	case "icosphere":
+		id = fmt.Sprintf("Icosphere_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateIcosphereData(2) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Radius 0.5
+		shape = ColliderSphere
+	case "cylinder":
+		id = fmt.Sprintf("Cylinder_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateCylinderData(32) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Radius 0.5, height 1
+		shape = ColliderCylinder
+	case "cone":
+		id = fmt.Sprintf("Cone_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateConeData(32) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}} // Radius 0.5, height 1
+		shape = ColliderCone
+	case "capsule":
+		id = fmt.Sprintf("Capsule_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateCapsuleData(32, 16) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.25, -0.5, -0.25}, Max: mgl32.Vec3{0.25, 0.5, 0.25}} // Radius 0.25, height 1
+		shape = ColliderCapsule
+	case "torus":
+		id = fmt.Sprintf("Torus_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateTorusData(0.35, 0.15, 32, 16) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.15, -0.5}, Max: mgl32.Vec3{0.5, 0.15, 0.5}}
+		shape = ColliderTorus
+	case "light":
+		id = fmt.Sprintf("PointLight_%d", a.nextObjectID)
+		generate = func() ([]float32, []uint32) { return generateUVSphereData(16, 8) }
+		bbox = BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}}
+		mass = 0.0 // Lights stay where they are put
+		shape = ColliderSphere
 	default:
 		log.Printf("Unsupported primitive type: %s", shapeType)
 		return nil // Return nil if unsupported
 	}
 
-	newObj := a.createGameObject(id, vertices, indices, false, "", initialPos, mass, bbox)
+	newObj := a.createGameObject(id, a.primitiveMesh(shapeType, generate), false, "", initialPos, mass, bbox)
+	newObj.Shape = shape
+	newObj.Primitive = shapeType
 	if shapeType == "plane" {
 		newObj.IsKinematic = true // Ground plane should be kinematic
 	}
+	if shapeType == "light" {
+		newObj.Light = &PointLight{Color: mgl32.Vec3{1, 1, 1}, Intensity: DefaultLightIntensity, Range: DefaultLightRange}
+		newObj.Scale = mgl32.Vec3{LightMarkerScale, LightMarkerScale, LightMarkerScale}
+		newObj.IsKinematic = true
+	}
 	a.selectedObject = newObj
 	log.Printf("Created primitive: %s", id)
 	return newObj // Return the created object
@@ -1338,11 +3510,9 @@ func (a *AppCore) createPrimitive(shapeType string, initialPos mgl32.Vec3) *Game
 
 // createGroundPlane creates a large, static ground plane.
 func (a *AppCore) createGroundPlane() {
-	vertices, indices := generatePlaneData()
 	ground := a.createGameObject(
 		"GroundPlane",
-		vertices,
-		indices,
+		a.primitiveMesh("plane", generatePlaneData), // Same mesh as spawned planes
 		false, // No texture for now
 		"",
 		mgl32.Vec3{0, GroundPlaneY, 0}, // Position at the ground plane Y
@@ -1355,101 +3525,525 @@ func (a *AppCore) createGroundPlane() {
 }
 
 
+// --- Scene files ---
+
+// saveScene writes every object except the ground plane to a scene file.
+func (a *AppCore) saveScene(filePath string) error {
+	l := a.lighting
+	file := scene.File{
+		Lighting: &scene.Lighting{SunYaw: l.SunYaw, SunPitch: l.SunPitch, SunColor: l.SunColor, SunIntensity: l.SunIntensity, Ambient: l.Ambient},
+	}
+	for _, obj := range a.objects {
+		// The ground plane is part of every scene already
+		if obj.ID == "GroundPlane" {
+			continue
+		}
+		texturePath := ""
+		if obj.HasTexture {
+			texturePath = obj.TexturePath
+		}
+		file.Objects = append(file.Objects, scene.Object{
+			ID:              obj.ID,
+			Primitive:       obj.Primitive,
+			ModelPath:       obj.ModelPath,
+			TexturePath:     texturePath,
+			NormalMap:       obj.NormalMap,
+			AlphaMode:       obj.AlphaMode.String(),
+			AlphaCutoff:     obj.AlphaCutoff,
+			AlphaToCoverage: obj.AlphaToCoverage,
+			Position:        obj.Position,
+			Rotation:        obj.Rotation,
+			Scale:           obj.Scale,
+			Mass:            obj.Mass,
+			IsKinematic:     obj.IsKinematic && obj != a.heldObject, // Held objects are only kinematic while held
+			BoundingBox:     scene.Bounds{Min: obj.BoundingBox.Min, Max: obj.BoundingBox.Max},
+		})
+		if obj.Color != (mgl32.Vec4{1, 1, 1, 1}) {
+			color := obj.Color
+			file.Objects[len(file.Objects)-1].Color = &color
+		}
+		if obj.Light != nil {
+			file.Objects[len(file.Objects)-1].Light = &scene.Light{Color: obj.Light.Color, Intensity: obj.Light.Intensity, Range: obj.Light.Range}
+		}
+	}
+	return scene.Save(filePath, &file)
+}
+
+// loadScene replaces every object except the ground plane with the objects in a scene file.
+// Objects whose mesh can't be rebuilt are skipped with a warning.
+func (a *AppCore) loadScene(filePath string) error {
+	file, err := scene.Load(filePath)
+	if err != nil {
+		return err
+	}
+
+	// Clear the current scene, keeping the ground
+	kept := a.objects[:0]
+	for _, obj := range a.objects {
+		if obj.ID == "GroundPlane" {
+			kept = append(kept, obj)
+			continue
+		}
+		deleteGameObject(obj)
+	}
+	a.objects = kept
+	a.selectedObject = nil
+	a.heldObject = nil
+
+	a.lighting = defaultLighting()
+	if l := file.Lighting; l != nil {
+		a.lighting = Lighting{SunYaw: l.SunYaw, SunPitch: l.SunPitch, SunColor: l.SunColor, SunIntensity: l.SunIntensity, Ambient: l.Ambient}
+	}
+
+	for _, so := range file.Objects {
+		var obj *GameObject
+		switch {
+		case so.Primitive != "":
+			obj = a.createPrimitive(so.Primitive, so.Position)
+			if obj == nil {
+				log.Printf("Warning: Skipping scene object %s: unknown primitive %q", so.ID, so.Primitive)
+				continue
+			}
+		case so.ModelPath != "":
+			obj, err = a.loadHolymModel(so.ModelPath, so.Position)
+			if err != nil {
+				log.Printf("Warning: Skipping scene object %s: %v", so.ID, err)
+				continue
+			}
+		default:
+			log.Printf("Warning: Skipping scene object %s: it has neither a primitive nor a model path", so.ID)
+			continue
+		}
+
+		obj.ID = so.ID
+		obj.Position = so.Position
+		obj.Rotation = so.Rotation
+		obj.Scale = so.Scale
+		obj.Mass = so.Mass
+		obj.IsKinematic = so.IsKinematic
+		obj.BoundingBox = BoundingBox{Min: so.BoundingBox.Min, Max: so.BoundingBox.Max}
+		if so.Light != nil && obj.Light != nil {
+			obj.Light = &PointLight{Color: so.Light.Color, Intensity: so.Light.Intensity, Range: so.Light.Range}
+		}
+		if so.TexturePath != "" && so.TexturePath != obj.TexturePath {
+			texID, alphaMode, err := newTextureFromFile(so.TexturePath)
+			if err != nil {
+				log.Printf("Warning: Failed to load texture %s for %s: %v", so.TexturePath, so.ID, err)
+			} else {
+				if obj.TextureID != 0 {
+					gl.DeleteTextures(1, &obj.TextureID)
+				}
+				obj.TextureID = texID
+				obj.HasTexture = true
+				obj.TexturePath = so.TexturePath
+				obj.AlphaMode = alphaMode
+			}
+		}
+		if mode, ok := alphamode.Parse(so.AlphaMode); ok {
+			obj.AlphaMode = mode
+		} else if so.AlphaMode != "" {
+			log.Printf("Warning: Unknown alpha mode %q for %s, keeping %s", so.AlphaMode, so.ID, obj.AlphaMode)
+		}
+		if so.AlphaCutoff > 0 {
+			obj.AlphaCutoff = so.AlphaCutoff
+		}
+		obj.AlphaToCoverage = so.AlphaToCoverage
+		if so.Color != nil {
+			obj.Color = *so.Color
+		}
+		if so.NormalMap != "" && so.NormalMap != obj.NormalMap {
+			setNormalMap(obj, so.NormalMap)
+		}
+
+		// Keep generated IDs from clashing with the loaded ones (e.g. "Cube_7")
+		if n, err := strconv.Atoi(so.ID[strings.LastIndex(so.ID, "_")+1:]); err == nil && n >= a.nextObjectID {
+			a.nextObjectID = n + 1
+		}
+	}
+	a.selectedObject = nil // createPrimitive and loadHolymModel select every object they make
+	return nil
+}
+
+// deleteGameObject frees the OpenGL buffers and textures of an object. Shared primitive
+// meshes stay, other primitives may still use them.
+func deleteGameObject(obj *GameObject) {
+	if !obj.Mesh.shared {
+		obj.Mesh.release()
+	}
+	if obj.TextureID != 0 {
+		gl.DeleteTextures(1, &obj.TextureID)
+	}
+	if obj.NormalMapID != 0 {
+		gl.DeleteTextures(1, &obj.NormalMapID)
+	}
+	if obj.holymb != nil {
+		obj.holymb.Close()
+	}
+}
+
 // generateCubeData returns interleaved vertex data for a unit cube (1x1x1).
 // Each face has its own vertices to allow for distinct UVs and colors.
 func generateCubeData() ([]float32, []uint32) {
-    // Vertices: Position (3) + Color (3) + TexCoord (2) = 8 floats per vertex
-    // Face colors (just for visual distinction)
-    red := []float32{1.0, 0.0, 0.0}
-    green := []float32{0.0, 1.0, 0.0}
-    blue := []float32{0.0, 0.0, 1.0}
-    yellow := []float32{1.0, 1.0, 0.0}
-    cyan := []float32{0.0, 1.0, 1.0}
-    magenta := []float32{1.0, 0.0, 1.0}
-
-    // Standard UVs for a quad
-    uv00 := []float32{0.0, 0.0} // bottom-left
-    uv10 := []float32{1.0, 0.0} // bottom-right
-    uv11 := []float32{1.0, 1.0} // top-right
-    uv01 := []float32{0.0, 1.0} // top-left
-
-    vertices := []float32{
-        // Front face (Red)
-        -0.5, -0.5, 0.5,  red[0], red[1], red[2],  uv00[0], uv00[1], // 0
-         0.5, -0.5, 0.5,  red[0], red[1], red[2],  uv10[0], uv10[1], // 1
-         0.5,  0.5, 0.5,  red[0], red[1], red[2],  uv11[0], uv11[1], // 2
-        -0.5,  0.5, 0.5,  red[0], red[1], red[2],  uv01[0], uv01[1], // 3
-
-        // Back face (Green)
-        -0.5, -0.5, -0.5, green[0], green[1], green[2], uv10[0], uv10[1], // 4
-         0.5, -0.5, -0.5, green[0], green[1], green[2], uv00[0], uv00[1], // 5
-         0.5,  0.5, -0.5, green[0], green[1], green[2], uv01[0], uv01[1], // 6
-        -0.5,  0.5, -0.5, green[0], green[1], green[2], uv11[0], uv11[1], // 7
-
-        // Top face (Blue)
-        -0.5,  0.5,  0.5, blue[0], blue[1], blue[2], uv00[0], uv00[1], // 8 (use existing 3)
-         0.5,  0.5,  0.5, blue[0], blue[1], blue[2], uv10[0], uv10[1], // 9 (use existing 2)
-         0.5,  0.5, -0.5, blue[0], blue[1], blue[2], uv11[0], uv11[1], // 10 (use existing 6)
-        -0.5,  0.5, -0.5, blue[0], blue[1], blue[2], uv01[0], uv01[1], // 11 (use existing 7)
-
-
-        // Bottom face (Yellow)
-        -0.5, -0.5,  0.5, yellow[0], yellow[1], yellow[2], uv01[0], uv01[1], // 12 (use existing 0)
-         0.5, -0.5,  0.5, yellow[0], yellow[1], yellow[2], uv11[0], uv11[1], // 13 (use existing 1)
-         0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv10[0], uv10[1], // 14 (use existing 5)
-        -0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv00[0], uv00[1], // 15 (use existing 4)
-
-
-        // Right face (Cyan)
-         0.5, -0.5,  0.5, cyan[0], cyan[1], cyan[2], uv00[0], uv00[1], // 16 (use existing 1)
-         0.5, -0.5, -0.5, cyan[0], cyan[1], cyan[2], uv10[0], uv10[1], // 17 (use existing 5)
-         0.5,  0.5, -0.5, cyan[0], cyan[1], cyan[2], uv11[0], uv11[1], // 18 (use existing 6)
-         0.5,  0.5,  0.5, cyan[0], cyan[1], cyan[2], uv01[0], uv01[1], // 19 (use existing 2)
-
-
-        // Left face (Magenta)
-        -0.5, -0.5,  0.5, magenta[0], magenta[1], magenta[2], uv10[0], uv10[1], // 20 (use existing 0)
-        -0.5, -0.5, -0.5, magenta[0], magenta[1], magenta[2], uv00[0], uv00[1], // 21 (use existing 4)
-        -0.5,  0.5, -0.5, magenta[0], magenta[1], magenta[2], uv01[0], uv01[1], // 22 (use existing 7)
-        -0.5,  0.5,  0.5, magenta[0], magenta[1], magenta[2], uv11[0], uv11[1], // 23 (use existing 3)
-    }
-
-    indices := []uint32{
-        0, 1, 2,      0, 2, 3,    // Front
-        4, 5, 6,      4, 6, 7,    // Back
-        8, 9, 10,     8, 10, 11,   // Top
-        12, 13, 14,   12, 14, 15,  // Bottom
-        16, 17, 18,   16, 18, 19,  // Right
-        20, 21, 22,   20, 22, 23,  // Left
-    }
-
-    return vertices, indices
+	// Vertices: Position (3) + Color (3) + TexCoord (2) + Normal (3) + Tangent (4) = 15 floats per vertex
+	// Face colors (just for visual distinction)
+	red := []float32{1.0, 0.0, 0.0}
+	green := []float32{0.0, 1.0, 0.0}
+	blue := []float32{0.0, 0.0, 1.0}
+	yellow := []float32{1.0, 1.0, 0.0}
+	cyan := []float32{0.0, 1.0, 1.0}
+	magenta := []float32{1.0, 0.0, 1.0}
+
+	// Standard UVs for a quad
+	uv00 := []float32{0.0, 0.0} // bottom-left
+	uv10 := []float32{1.0, 0.0} // bottom-right
+	uv11 := []float32{1.0, 1.0} // top-right
+	uv01 := []float32{0.0, 1.0} // top-left
+
+	// Face normals, pointing out of the cube
+	front := []float32{0.0, 0.0, 1.0}
+	back := []float32{0.0, 0.0, -1.0}
+	up := []float32{0.0, 1.0, 0.0}
+	down := []float32{0.0, -1.0, 0.0}
+	right := []float32{1.0, 0.0, 0.0}
+	left := []float32{-1.0, 0.0, 0.0}
+
+	// Face tangents, along the direction U grows in. V grows up the faces while images are
+	// uploaded top row first, so the image's top is -V and w is -1 on every face
+	frontT := []float32{1.0, 0.0, 0.0, -1.0}
+	backT := []float32{-1.0, 0.0, 0.0, -1.0}
+	upT := []float32{1.0, 0.0, 0.0, -1.0}
+	downT := []float32{1.0, 0.0, 0.0, -1.0}
+	rightT := []float32{0.0, 0.0, -1.0, -1.0}
+	leftT := []float32{0.0, 0.0, 1.0, -1.0}
+
+	vertices := []float32{
+		// Front face (Red)
+		-0.5, -0.5, 0.5, red[0], red[1], red[2], uv00[0], uv00[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 0
+		0.5, -0.5, 0.5, red[0], red[1], red[2], uv10[0], uv10[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 1
+		0.5, 0.5, 0.5, red[0], red[1], red[2], uv11[0], uv11[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 2
+		-0.5, 0.5, 0.5, red[0], red[1], red[2], uv01[0], uv01[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 3
+
+		// Back face (Green)
+		-0.5, -0.5, -0.5, green[0], green[1], green[2], uv10[0], uv10[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 4
+		0.5, -0.5, -0.5, green[0], green[1], green[2], uv00[0], uv00[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 5
+		0.5, 0.5, -0.5, green[0], green[1], green[2], uv01[0], uv01[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 6
+		-0.5, 0.5, -0.5, green[0], green[1], green[2], uv11[0], uv11[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 7
+
+		// Top face (Blue)
+		-0.5, 0.5, 0.5, blue[0], blue[1], blue[2], uv00[0], uv00[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 8 (use existing 3)
+		0.5, 0.5, 0.5, blue[0], blue[1], blue[2], uv10[0], uv10[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 9 (use existing 2)
+		0.5, 0.5, -0.5, blue[0], blue[1], blue[2], uv11[0], uv11[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 10 (use existing 6)
+		-0.5, 0.5, -0.5, blue[0], blue[1], blue[2], uv01[0], uv01[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 11 (use existing 7)
+
+		// Bottom face (Yellow)
+		-0.5, -0.5, 0.5, yellow[0], yellow[1], yellow[2], uv01[0], uv01[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 12 (use existing 0)
+		0.5, -0.5, 0.5, yellow[0], yellow[1], yellow[2], uv11[0], uv11[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 13 (use existing 1)
+		0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv10[0], uv10[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 14 (use existing 5)
+		-0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv00[0], uv00[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 15 (use existing 4)
+
+		// Right face (Cyan)
+		0.5, -0.5, 0.5, cyan[0], cyan[1], cyan[2], uv00[0], uv00[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 16 (use existing 1)
+		0.5, -0.5, -0.5, cyan[0], cyan[1], cyan[2], uv10[0], uv10[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 17 (use existing 5)
+		0.5, 0.5, -0.5, cyan[0], cyan[1], cyan[2], uv11[0], uv11[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 18 (use existing 6)
+		0.5, 0.5, 0.5, cyan[0], cyan[1], cyan[2], uv01[0], uv01[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 19 (use existing 2)
+
+		// Left face (Magenta)
+		-0.5, -0.5, 0.5, magenta[0], magenta[1], magenta[2], uv10[0], uv10[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 20 (use existing 0)
+		-0.5, -0.5, -0.5, magenta[0], magenta[1], magenta[2], uv00[0], uv00[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 21 (use existing 4)
+		-0.5, 0.5, -0.5, magenta[0], magenta[1], magenta[2], uv01[0], uv01[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 22 (use existing 7)
+		-0.5, 0.5, 0.5, magenta[0], magenta[1], magenta[2], uv11[0], uv11[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 23 (use existing 3)
+	}
+
+	indices := []uint32{
+		0, 1, 2, 0, 2, 3, // Front
+		4, 5, 6, 4, 6, 7, // Back
+		8, 9, 10, 8, 10, 11, // Top
+		12, 13, 14, 12, 14, 15, // Bottom
+		16, 17, 18, 16, 18, 19, // Right
+		20, 21, 22, 20, 22, 23, // Left
+	}
+
+	return vertices, indices
 }
 
 // generatePlaneData returns interleaved vertex data for a unit plane (1x1).
 func generatePlaneData() ([]float32, []uint32) {
-    // Vertices: Position (3) + Color (3) + TexCoord (2) = 8 floats per vertex
-    white := []float32{1.0, 1.0, 1.0}
-    uv00 := []float32{0.0, 0.0}
-    uv10 := []float32{1.0, 0.0}
-    uv11 := []float32{1.0, 1.0}
-    uv01 := []float32{0.0, 1.0}
+	// Vertices: Position (3) + Color (3) + TexCoord (2) + Normal (3) + Tangent (4) = 15 floats per vertex
+	white := []float32{1.0, 1.0, 1.0}
+	up := []float32{0.0, 1.0, 0.0}        // The plane faces +Y
+	upT := []float32{1.0, 0.0, 0.0, -1.0} // U grows along +X, w as on the cube
+	uv00 := []float32{0.0, 0.0}
+	uv10 := []float32{1.0, 0.0}
+	uv11 := []float32{1.0, 1.0}
+	uv01 := []float32{0.0, 1.0}
+
+	vertices := []float32{
+		// Front face of plane (facing +Z)
+		-0.5, 0.0, 0.5, white[0], white[1], white[2], uv00[0], uv00[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // bottom-left
+		0.5, 0.0, 0.5, white[0], white[1], white[2], uv10[0], uv10[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // bottom-right
+		0.5, 0.0, -0.5, white[0], white[1], white[2], uv11[0], uv11[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // top-right
+		-0.5, 0.0, -0.5, white[0], white[1], white[2], uv01[0], uv01[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // top-left
+	}
+
+	indices := []uint32{
+		0, 1, 2, // Triangle 1
+		0, 2, 3, // Triangle 2
+	}
+	return vertices, indices
+}
+
+
+// appendPrimitiveVertex appends one vertex in the position (3) + color (3) + texcoord (2) +
+// normal (3) + tangent (4) layout. The color is a gradient over the UVs, like the torus in
+// holy-torus, so the shape reads clearly without a texture. The normal must be unit length,
+// the tangent is left for meshgen.GenerateTangents.
+func appendPrimitiveVertex(vertices []float32, x, y, z, u, v float32, normal mgl32.Vec3) []float32 {
+	return append(vertices, x, y, z, u, v, 1.0-(u+v)/2.0, u, v, normal.X(), normal.Y(), normal.Z(), 0, 0, 0, 0)
+}
+
+// appendGridIndices adds two triangles for every cell of a (rows+1) x (cols+1) vertex grid
+// that starts at vertex first. With rows running top to bottom and the angle around Y
+// increasing along each row, the triangles face outward.
+func appendGridIndices(indices []uint32, first uint32, rows, cols int) []uint32 {
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			p1 := first + uint32(i*(cols+1)+j)
+			p2 := first + uint32(i*(cols+1)+j+1)
+			p3 := first + uint32((i+1)*(cols+1)+j+1)
+			p4 := first + uint32((i+1)*(cols+1)+j)
+			indices = append(indices, p1, p2, p3) // First triangle
+			indices = append(indices, p1, p3, p4) // Second triangle
+		}
+	}
+	return indices
+}
+
+// appendDisc adds a flat, fan-triangulated circle at height y facing up or down.
+func appendDisc(vertices []float32, indices []uint32, y, radius float32, segments int, facingUp bool) ([]float32, []uint32) {
+	center := uint32(len(vertices) / vertexFloats)
+	normal := mgl32.Vec3{0, -1, 0}
+	if facingUp {
+		normal = mgl32.Vec3{0, 1, 0}
+	}
+	vertices = appendPrimitiveVertex(vertices, 0, y, 0, 0.5, 0.5, normal)
+	for j := 0; j <= segments; j++ {
+		theta := float64(j) / float64(segments) * 2 * math.Pi
+		x := radius * float32(math.Cos(theta))
+		z := radius * float32(math.Sin(theta))
+		vertices = appendPrimitiveVertex(vertices, x, y, z, 0.5+x/(2*radius), 0.5-z/(2*radius), normal)
+	}
+	for j := 0; j < segments; j++ {
+		current, next := center+1+uint32(j), center+2+uint32(j)
+		if facingUp {
+			indices = append(indices, center, next, current)
+		} else {
+			indices = append(indices, center, current, next)
+		}
+	}
+	return vertices, indices
+}
+
+// generateUVSphereData returns interleaved vertex data for a sphere of radius 0.5
+// built from rings of latitude and segments of longitude.
+func generateUVSphereData(segments, rings int) ([]float32, []uint32) {
+	vertices := []float32{}
+	indices := []uint32{}
+
+	for i := 0; i <= rings; i++ {
+		phi := float64(i) / float64(rings) * math.Pi // Angle down from the top
+		y := 0.5 * float32(math.Cos(phi))
+		ringRadius := 0.5 * float32(math.Sin(phi))
+
+		for j := 0; j <= segments; j++ {
+			theta := float64(j) / float64(segments) * 2 * math.Pi // Angle around the Y axis
+			x := ringRadius * float32(math.Cos(theta))
+			z := ringRadius * float32(math.Sin(theta))
+			normal := mgl32.Vec3{x, y, z}.Mul(2) // Radius 0.5, so this is unit length
+			vertices = appendPrimitiveVertex(vertices, x, y, z, float32(j)/float32(segments), 1.0-float32(i)/float32(rings), normal)
+		}
+	}
+	indices = appendGridIndices(indices, 0, rings, segments)
+	return vertices, indices
+}
+
+// generateIcosphereData returns interleaved vertex data for a sphere of radius 0.5 made by
+// splitting each triangle of an icosahedron into four, subdivisions times.
+// Its triangles are much more even than a UV sphere's, at the cost of a UV seam.
+func generateIcosphereData(subdivisions int) ([]float32, []uint32) {
+	t := float32((1.0 + math.Sqrt(5.0)) / 2.0)
+	positions := []mgl32.Vec3{
+		{-1, t, 0}, {1, t, 0}, {-1, -t, 0}, {1, -t, 0},
+		{0, -1, t}, {0, 1, t}, {0, -1, -t}, {0, 1, -t},
+		{t, 0, -1}, {t, 0, 1}, {-t, 0, -1}, {-t, 0, 1},
+	}
+	faces := [][3]uint32{
+		{0, 11, 5}, {0, 5, 1}, {0, 1, 7}, {0, 7, 10}, {0, 10, 11},
+		{1, 5, 9}, {5, 11, 4}, {11, 10, 2}, {10, 7, 6}, {7, 1, 8},
+		{3, 9, 4}, {3, 4, 2}, {3, 2, 6}, {3, 6, 8}, {3, 8, 9},
+		{4, 9, 5}, {2, 4, 11}, {6, 2, 10}, {8, 6, 7}, {9, 8, 1},
+	}
+
+	for s := 0; s < subdivisions; s++ {
+		midpoints := make(map[[2]uint32]uint32) // Shared edges get a single midpoint
+		midpoint := func(a, b uint32) uint32 {
+			key := [2]uint32{a, b}
+			if a > b {
+				key = [2]uint32{b, a}
+			}
+			if index, ok := midpoints[key]; ok {
+				return index
+			}
+			positions = append(positions, positions[a].Add(positions[b]).Mul(0.5))
+			index := uint32(len(positions) - 1)
+			midpoints[key] = index
+			return index
+		}
+
+		var next [][3]uint32
+		for _, f := range faces {
+			ab, bc, ca := midpoint(f[0], f[1]), midpoint(f[1], f[2]), midpoint(f[2], f[0])
+			next = append(next, [3]uint32{f[0], ab, ca}, [3]uint32{f[1], bc, ab}, [3]uint32{f[2], ca, bc}, [3]uint32{ab, bc, ca})
+		}
+		faces = next
+	}
+
+	vertices := []float32{}
+	for _, p := range positions {
+		p = p.Normalize()
+		// Spherical mapping, same orientation as the UV sphere
+		u := float32(math.Atan2(float64(p.Z()), float64(p.X()))/(2*math.Pi)) + 0.5
+		v := float32(math.Asin(float64(p.Y()))/math.Pi) + 0.5
+		normal := p
+		p = p.Mul(0.5)
+		vertices = appendPrimitiveVertex(vertices, p.X(), p.Y(), p.Z(), u, v, normal)
+	}
+	indices := []uint32{}
+	for _, f := range faces {
+		indices = append(indices, f[0], f[1], f[2])
+	}
+	return vertices, indices
+}
+
+// generateCylinderData returns interleaved vertex data for a cylinder of radius 0.5 and
+// height 1 around the Y axis, with capped ends.
+func generateCylinderData(segments int) ([]float32, []uint32) {
+	vertices := []float32{}
+	indices := []uint32{}
+
+	for i := 0; i <= 1; i++ {
+		y := 0.5 - float32(i) // Top ring, then bottom ring
+		for j := 0; j <= segments; j++ {
+			theta := float64(j) / float64(segments) * 2 * math.Pi
+			x := 0.5 * float32(math.Cos(theta))
+			z := 0.5 * float32(math.Sin(theta))
+			normal := mgl32.Vec3{x, 0, z}.Mul(2) // Straight out from the axis
+			vertices = appendPrimitiveVertex(vertices, x, y, z, float32(j)/float32(segments), 1.0-float32(i), normal)
+		}
+	}
+	indices = appendGridIndices(indices, 0, 1, segments)
+
+	vertices, indices = appendDisc(vertices, indices, 0.5, 0.5, segments, true)
+	vertices, indices = appendDisc(vertices, indices, -0.5, 0.5, segments, false)
+	return vertices, indices
+}
+
+// generateConeData returns interleaved vertex data for a cone of radius 0.5 and height 1
+// around the Y axis, tip at the top, with a capped base.
+func generateConeData(segments int) ([]float32, []uint32) {
+	vertices := []float32{}
+	indices := []uint32{}
+
+	// Same grid as the cylinder, but the top ring is squeezed into the tip.
+	// Each segment gets its own tip vertex so the UVs don't collapse.
+	for i := 0; i <= 1; i++ {
+		radius := 0.5 * float32(i)
+		y := 0.5 - float32(i)
+		for j := 0; j <= segments; j++ {
+			theta := float64(j) / float64(segments) * 2 * math.Pi
+			x := radius * float32(math.Cos(theta))
+			z := radius * float32(math.Sin(theta))
+			// The side rises 1 over a radius of 0.5, so its normal tilts up by half its outward part
+			normal := mgl32.Vec3{float32(math.Cos(theta)), 0.5, float32(math.Sin(theta))}.Normalize()
+			vertices = appendPrimitiveVertex(vertices, x, y, z, float32(j)/float32(segments), 1.0-float32(i), normal)
+		}
+	}
+	for j := 0; j < segments; j++ {
+		tip := uint32(j)
+		current, next := uint32(segments+1+j), uint32(segments+2+j)
+		indices = append(indices, tip, next, current)
+	}
 
-    vertices := []float32{
-        // Front face of plane (facing +Z)
-        -0.5, 0.0,  0.5, white[0], white[1], white[2], uv00[0], uv00[1], // bottom-left
-         0.5, 0.0,  0.5, white[0], white[1], white[2], uv10[0], uv10[1], // bottom-right
-         0.5, 0.0, -0.5, white[0], white[1], white[2], uv11[0], uv11[1], // top-right
-        -0.5, 0.0, -0.5, white[0], white[1], white[2], uv01[0], uv01[1], // top-left
-    }
+	vertices, indices = appendDisc(vertices, indices, -0.5, 0.5, segments, false)
+	return vertices, indices
+}
 
-    indices := []uint32{
-        0, 1, 2, // Triangle 1
-        0, 2, 3, // Triangle 2
-    }
-    return vertices, indices
+// generateCapsuleData returns interleaved vertex data for a capsule of radius 0.25 and total
+// height 1 around the Y axis: a UV sphere split at the equator with the halves moved apart.
+// rings should be even so the equator falls on a ring.
+func generateCapsuleData(segments, rings int) ([]float32, []uint32) {
+	const radius, halfSegment = 0.25, 0.25
+	vertices := []float32{}
+	indices := []uint32{}
+
+	half := rings / 2
+	for i := 0; i <= rings+1; i++ {
+		// Rows 0..half are the top half sphere, the rest the bottom one; the equator is repeated
+		ring, offset := i, float32(halfSegment)
+		if i > half {
+			ring, offset = i-1, -halfSegment
+		}
+		phi := float64(ring) / float64(rings) * math.Pi
+		y := radius*float32(math.Cos(phi)) + offset
+		ringRadius := radius * float32(math.Sin(phi))
+
+		for j := 0; j <= segments; j++ {
+			theta := float64(j) / float64(segments) * 2 * math.Pi
+			x := ringRadius * float32(math.Cos(theta))
+			z := ringRadius * float32(math.Sin(theta))
+			normal := mgl32.Vec3{x, y - offset, z}.Mul(1 / radius) // From the center of its half sphere
+			vertices = appendPrimitiveVertex(vertices, x, y, z, float32(j)/float32(segments), y+0.5, normal)
+		}
+	}
+	indices = appendGridIndices(indices, 0, rings+1, segments)
+	return vertices, indices
 }
 
+// generateTorusData returns interleaved vertex data for a torus lying flat around the Y axis.
+// It is the parametric torus from holy-torus, turned so the ring lies in the XZ plane.
+func generateTorusData(majorR, minorR float32, majorSegs, minorSegs int) ([]float32, []uint32) {
+	vertices := []float32{}
+	indices := []uint32{}
+
+	for i := 0; i <= majorSegs; i++ {
+		theta := float64(i) / float64(majorSegs) * 2 * math.Pi // Angle around the central axis
+		cosTheta := float32(math.Cos(theta))
+		sinTheta := float32(math.Sin(theta))
+
+		for j := 0; j <= minorSegs; j++ {
+			phi := float64(j) / float64(minorSegs) * 2 * math.Pi // Angle around the tube's cross-section
+			cosPhi := float32(math.Cos(phi))
+			sinPhi := float32(math.Sin(phi))
+
+			x := (majorR + minorR*cosPhi) * cosTheta
+			y := minorR * sinPhi
+			z := (majorR + minorR*cosPhi) * sinTheta
+			normal := mgl32.Vec3{cosPhi * cosTheta, sinPhi, cosPhi * sinTheta} // Out from the tube's center line
+			vertices = appendPrimitiveVertex(vertices, x, y, z, float32(i)/float32(majorSegs), float32(j)/float32(minorSegs), normal)
+		}
+	}
+	// Rows run around the ring, columns around the tube
+	for i := 0; i < majorSegs; i++ {
+		for j := 0; j < minorSegs; j++ {
+			p1 := uint32(i*(minorSegs+1) + j)
+			p2 := uint32(i*(minorSegs+1) + j + 1)
+			p3 := uint32((i+1)*(minorSegs+1) + j + 1)
+			p4 := uint32((i+1)*(minorSegs+1) + j)
+			indices = append(indices, p1, p2, p3)
+			indices = append(indices, p1, p3, p4)
+		}
+	}
+	return vertices, indices
+}
 
 // --- Helper functions for shader compilation ---
 
@@ -1525,6 +4119,9 @@ func (a *AppCore) shouldClose() bool {
 
 // main orchestrates the application flow.
 func main() {
+	scenePath := flag.String("scene", "", "scene file to open at startup")
+	flag.Parse()
+
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 	defer shutdownApp()
@@ -1533,6 +4130,13 @@ func main() {
 		log.Fatalf("Application initialization failed: %v", err)
 	}
 
+	if *scenePath != "" {
+		if err := app.loadScene(*scenePath); err != nil {
+			log.Fatalf("Failed to open scene: %v", err)
+		}
+		log.Printf("Loaded scene from %s", *scenePath)
+	}
+
 	log.Println("Holy Engine Base initialized. Starting main loop...")
 	log.Println("Controls:")
 	log.Println("  WASD: Move camera")
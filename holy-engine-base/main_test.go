@@ -0,0 +1,330 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// newTestObject returns a unit-sized object of a shape, like createPrimitive makes them.
+func newTestObject(id string, shape ColliderShape, position mgl32.Vec3, mass float32) *GameObject {
+	return &GameObject{
+		ID:          id,
+		Position:    position,
+		Scale:       mgl32.Vec3{1, 1, 1},
+		BoundingBox: BoundingBox{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}},
+		Mass:        mass,
+		Shape:       shape,
+	}
+}
+
+func TestCollideContacts(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       *GameObject
+		wantHit    bool
+		wantNormal mgl32.Vec3
+		wantDepth  float32
+		wantPoints int
+	}{
+		{"spheres overlapping",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderSphere, mgl32.Vec3{0.8, 0, 0}, 1),
+			true, mgl32.Vec3{1, 0, 0}, 0.2, 1},
+		{"spheres diagonal",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderSphere, mgl32.Vec3{0, 0.6, 0.6}, 1),
+			true, mgl32.Vec3{0, float32(math.Sqrt(0.5)), float32(math.Sqrt(0.5))}, 1 - float32(0.6*math.Sqrt2), 1},
+		{"spheres apart",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderSphere, mgl32.Vec3{1.1, 0, 0}, 1),
+			false, mgl32.Vec3{}, 0, 0},
+		{"sphere against box face",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0.9, 0, 0}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{0, 0, 0}, 1),
+			true, mgl32.Vec3{-1, 0, 0}, 0.1, 1},
+		{"box against sphere",
+			newTestObject("A", ColliderBox, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderSphere, mgl32.Vec3{0, 0.95, 0}, 1),
+			true, mgl32.Vec3{0, 1, 0}, 0.05, 1},
+		{"sphere center inside box",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0, 0, 0.3}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{0, 0, 0}, 1),
+			true, mgl32.Vec3{0, 0, -1}, 0.7, 1},
+		{"sphere off box corner",
+			newTestObject("A", ColliderSphere, mgl32.Vec3{0.9, 0.9, 0}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{0, 0, 0}, 1),
+			false, mgl32.Vec3{}, 0, 0},
+		{"boxes stacked",
+			newTestObject("A", ColliderBox, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{0, 0.9, 0}, 1),
+			true, mgl32.Vec3{0, 1, 0}, 0.1, 4},
+		{"boxes side by side",
+			newTestObject("A", ColliderBox, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{-0.95, 0.5, 0}, 1),
+			true, mgl32.Vec3{-1, 0, 0}, 0.05, 4},
+	}
+	for _, tt := range tests {
+		m, hit := collideObjects(tt.a, tt.b)
+		if hit != tt.wantHit {
+			t.Errorf("%s: hit = %v, want %v", tt.name, hit, tt.wantHit)
+			continue
+		}
+		if !hit {
+			continue
+		}
+		if m.A != tt.a || m.B != tt.b {
+			t.Errorf("%s: manifold is between %s and %s, want %s and %s", tt.name, m.A.ID, m.B.ID, tt.a.ID, tt.b.ID)
+		}
+		if !m.Normal.ApproxEqualThreshold(tt.wantNormal, 1e-4) || !mgl32.FloatEqualThreshold(m.Penetration, tt.wantDepth, 1e-4) {
+			t.Errorf("%s: normal %v, depth %v, want %v, %v", tt.name, m.Normal, m.Penetration, tt.wantNormal, tt.wantDepth)
+		}
+		if len(m.Points) != tt.wantPoints {
+			t.Errorf("%s: %d contact points, want %d", tt.name, len(m.Points), tt.wantPoints)
+		}
+	}
+}
+
+func TestCollideGround(t *testing.T) {
+	ground := &GameObject{ID: "GroundPlane", IsKinematic: true}
+	tilted := newTestObject("Tilted", ColliderBox, mgl32.Vec3{0, 0.6, 0}, 1)
+	tilted.Rotation = mgl32.Vec3{0, 0, math.Pi / 4} // Resting on an edge
+	tests := []struct {
+		name       string
+		obj        *GameObject
+		wantHit    bool
+		wantDepth  float32
+		wantPoints int
+	}{
+		{"box sunk in", newTestObject("Box", ColliderBox, mgl32.Vec3{0, 0.45, 0}, 1), true, 0.05, 4},
+		{"box above", newTestObject("Box", ColliderBox, mgl32.Vec3{0, 0.6, 0}, 1), false, 0, 0},
+		{"box on an edge", tilted, true, float32(math.Sqrt(0.5)) - 0.6, 2},
+		{"sphere sunk in", newTestObject("Sphere", ColliderSphere, mgl32.Vec3{1, 0.4, 2}, 1), true, 0.1, 1},
+		{"sphere above", newTestObject("Sphere", ColliderSphere, mgl32.Vec3{1, 0.7, 2}, 1), false, 0, 0},
+	}
+	for _, tt := range tests {
+		m, hit := collideGround(ground, tt.obj)
+		if hit != tt.wantHit {
+			t.Errorf("%s: hit = %v, want %v", tt.name, hit, tt.wantHit)
+			continue
+		}
+		if !hit {
+			continue
+		}
+		if m.Normal != (mgl32.Vec3{0, 1, 0}) || !mgl32.FloatEqualThreshold(m.Penetration, tt.wantDepth, 1e-4) {
+			t.Errorf("%s: normal %v, depth %v, want straight up, %v", tt.name, m.Normal, m.Penetration, tt.wantDepth)
+		}
+		if len(m.Points) != tt.wantPoints {
+			t.Errorf("%s: %d contact points, want %d", tt.name, len(m.Points), tt.wantPoints)
+		}
+		for _, p := range m.Points {
+			if p.Position.Y() > GroundPlaneY {
+				t.Errorf("%s: contact point %v is above the ground", tt.name, p.Position)
+			}
+		}
+	}
+}
+
+// solveContacts runs the velocity solver on a manifold as updatePhysics does.
+func solveContacts(m *ContactManifold) {
+	prepareManifold(m)
+	for iter := 0; iter < SolverIterations; iter++ {
+		solveManifoldVelocity(m)
+	}
+}
+
+func TestSolverHeadOnCollision(t *testing.T) {
+	tests := []struct {
+		name              string
+		shape             ColliderShape
+		massA, massB      float32
+		speedA, speedB    float32 // Along +X, towards each other
+		wantSeparateSpeed float32
+	}{
+		{"equal spheres", ColliderSphere, 1, 1, 2, -2, Restitution * 4},
+		{"light sphere into heavy one", ColliderSphere, 1, 5, 3, 0, Restitution * 3},
+		{"heavy sphere into light one", ColliderSphere, 10, 1, 1, -1, Restitution * 2},
+		{"boxes face to face", ColliderBox, 2, 3, 1.5, -0.5, Restitution * 2},
+		{"slower than RestingSpeed", ColliderBox, 1, 1, 0.1, -0.1, 0},
+	}
+	for _, tt := range tests {
+		a := newTestObject("A", tt.shape, mgl32.Vec3{0, 0, 0}, tt.massA)
+		b := newTestObject("B", tt.shape, mgl32.Vec3{0.99, 0, 0}, tt.massB)
+		a.Velocity = mgl32.Vec3{tt.speedA, 0, 0}
+		b.Velocity = mgl32.Vec3{tt.speedB, 0, 0}
+		momentum := a.Velocity.Mul(a.Mass).Add(b.Velocity.Mul(b.Mass))
+
+		m, hit := collideObjects(a, b)
+		if !hit {
+			t.Errorf("%s: objects don't touch", tt.name)
+			continue
+		}
+		solveContacts(&m)
+
+		after := a.Velocity.Mul(a.Mass).Add(b.Velocity.Mul(b.Mass))
+		if !after.ApproxEqualThreshold(momentum, 1e-4) {
+			t.Errorf("%s: momentum %v after the collision, %v before", tt.name, after, momentum)
+		}
+		if separating := b.Velocity.X() - a.Velocity.X(); !mgl32.FloatEqualThreshold(separating, tt.wantSeparateSpeed, 1e-3) {
+			t.Errorf("%s: separating at %v, want %v", tt.name, separating, tt.wantSeparateSpeed)
+		}
+		// Straight through the centers, nothing may start spinning or move sideways
+		if a.AngularVelocity.Len() > 1e-4 || b.AngularVelocity.Len() > 1e-4 {
+			t.Errorf("%s: spinning at %v and %v", tt.name, a.AngularVelocity, b.AngularVelocity)
+		}
+		if math.Abs(float64(a.Velocity.Y()))+math.Abs(float64(a.Velocity.Z())) > 1e-4 {
+			t.Errorf("%s: A moves sideways at %v", tt.name, a.Velocity)
+		}
+	}
+}
+
+// turned returns a unit box at a position, turned by Euler angles as GameObject.Rotation.
+func turned(position, rotation mgl32.Vec3) OrientedBox {
+	obj := newTestObject("Box", ColliderBox, position, 1)
+	obj.Rotation = rotation
+	return obj.orientedBox()
+}
+
+func TestIntersectOBBOBB(t *testing.T) {
+	quarter := float32(math.Pi / 4)
+	unit := turned(mgl32.Vec3{}, mgl32.Vec3{})
+	// Edge up along X, so another box with an edge down along Z touches it edge on edge
+	ridge := turned(mgl32.Vec3{}, mgl32.Vec3{quarter, 0, 0})
+	tests := []struct {
+		name       string
+		a, b       OrientedBox
+		wantHit    bool
+		wantNormal mgl32.Vec3
+		wantDepth  float32
+		wantAxis   int // 0-2 a face of a, 3-5 a face of b, 6-14 edge crossed with edge
+	}{
+		{"apart", unit, turned(mgl32.Vec3{1.2, 0, 0}, mgl32.Vec3{}), false, mgl32.Vec3{}, 0, -1},
+		{"apart diagonally", unit, turned(mgl32.Vec3{1.1, 1.1, 0}, mgl32.Vec3{0, 0, quarter}), false, mgl32.Vec3{}, 0, -1},
+		{"touching", unit, turned(mgl32.Vec3{0, 0, -1}, mgl32.Vec3{}), true, mgl32.Vec3{0, 0, -1}, 0, 2},
+		{"face of a", unit, turned(mgl32.Vec3{0.2, 0.9, 0}, mgl32.Vec3{0, quarter, 0}), true, mgl32.Vec3{0, 1, 0}, 0.1, 1},
+		{"face of b", turned(mgl32.Vec3{0, 1.2, 0}, mgl32.Vec3{0, 0, quarter}), unit, true, mgl32.Vec3{0, -1, 0}, float32(0.5+math.Sqrt(0.5)) - 1.2, 4},
+		{"edge on edge", ridge, turned(mgl32.Vec3{0, 1.35, 0}, mgl32.Vec3{0, 0, quarter}), true, mgl32.Vec3{0, 1, 0}, float32(math.Sqrt2) - 1.35, 8},
+		// Every face axis overlaps, only the cross product of the two edges separates them
+		{"edges apart", ridge, turned(mgl32.Vec3{0, 1.45, 0}, mgl32.Vec3{0, 0, quarter}), false, mgl32.Vec3{}, 0, -1},
+	}
+	for _, tt := range tests {
+		hit, normal, depth, axis := intersectOBBOBB(tt.a, tt.b)
+		if hit != tt.wantHit {
+			t.Errorf("%s: hit = %v, want %v", tt.name, hit, tt.wantHit)
+			continue
+		}
+		if !hit {
+			continue
+		}
+		if !normal.ApproxEqualThreshold(tt.wantNormal, 1e-4) || !mgl32.FloatEqualThreshold(depth, tt.wantDepth, 1e-4) || axis != tt.wantAxis {
+			t.Errorf("%s: normal %v, depth %v, axis %d, want %v, %v, %d", tt.name, normal, depth, axis, tt.wantNormal, tt.wantDepth, tt.wantAxis)
+		}
+	}
+}
+
+func TestCollideBoxBoxManifold(t *testing.T) {
+	quarter := float32(math.Pi / 4)
+	box := func(position, rotation, scale mgl32.Vec3) *GameObject {
+		obj := newTestObject("Box", ColliderBox, position, 1)
+		obj.Rotation, obj.Scale = rotation, scale
+		return obj
+	}
+	one := mgl32.Vec3{1, 1, 1}
+	tests := []struct {
+		name       string
+		a, b       *GameObject
+		wantPoints int
+	}{
+		{"stacked", box(mgl32.Vec3{}, mgl32.Vec3{}, one), box(mgl32.Vec3{0, 0.95, 0}, mgl32.Vec3{}, one), 4},
+		{"stacked off center", box(mgl32.Vec3{}, mgl32.Vec3{}, one), box(mgl32.Vec3{0.5, 0.95, 0.5}, mgl32.Vec3{}, one), 4},
+		{"small on large", box(mgl32.Vec3{}, mgl32.Vec3{}, mgl32.Vec3{3, 1, 3}), box(mgl32.Vec3{1, 0.74, -1}, mgl32.Vec3{}, mgl32.Vec3{0.5, 0.5, 0.5}), 4},
+		// The turned face is clipped to an octagon, which is cut down to its four outermost points
+		{"turned on top", box(mgl32.Vec3{}, mgl32.Vec3{}, one), box(mgl32.Vec3{0, 0.95, 0}, mgl32.Vec3{0, quarter, 0}, one), 4},
+		{"standing on an edge", box(mgl32.Vec3{}, mgl32.Vec3{}, one), box(mgl32.Vec3{0, 1.2, 0}, mgl32.Vec3{0, 0, quarter}, one), 2},
+		{"edge on edge", box(mgl32.Vec3{}, mgl32.Vec3{quarter, 0, 0}, one), box(mgl32.Vec3{0, 1.35, 0}, mgl32.Vec3{0, 0, quarter}, one), 1},
+	}
+	for _, tt := range tests {
+		m, hit := collideBoxBox(tt.a, tt.b)
+		if !hit {
+			t.Errorf("%s: boxes don't touch", tt.name)
+			continue
+		}
+		if len(m.Points) != tt.wantPoints {
+			t.Errorf("%s: %d contact points, want %d", tt.name, len(m.Points), tt.wantPoints)
+		}
+		// Contacts sit in the overlap, so within reach of both boxes
+		a, b := tt.a.orientedBox(), tt.b.orientedBox()
+		for _, p := range m.Points {
+			for _, box := range []OrientedBox{a, b} {
+				offset := p.Position.Sub(box.Center)
+				for i := 0; i < 3; i++ {
+					if math.Abs(float64(offset.Dot(box.Axes[i]))) > float64(box.HalfExtents[i]+m.Penetration+1e-4) {
+						t.Errorf("%s: contact point %v is outside a box", tt.name, p.Position)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCollideConvexDepth(t *testing.T) {
+	tests := []struct {
+		name       string
+		a, b       *GameObject
+		wantNormal mgl32.Vec3
+		wantDepth  float32
+	}{
+		{"cylinders stacked",
+			newTestObject("A", ColliderCylinder, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderCylinder, mgl32.Vec3{0.2, 0.9, 0}, 1),
+			mgl32.Vec3{0, 1, 0}, 0.1},
+		{"cylinder and sphere side by side",
+			newTestObject("A", ColliderCylinder, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderSphere, mgl32.Vec3{0, 0, 0.9}, 1),
+			mgl32.Vec3{0, 0, 1}, 0.1},
+		{"capsules side by side",
+			newTestObject("A", ColliderCapsule, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderCapsule, mgl32.Vec3{-0.8, 0, 0}, 1),
+			mgl32.Vec3{-1, 0, 0}, 0.2},
+		{"cone tip into a box",
+			newTestObject("A", ColliderCone, mgl32.Vec3{0, 0, 0}, 1), newTestObject("B", ColliderBox, mgl32.Vec3{0.1, 0.95, 0}, 1),
+			mgl32.Vec3{0, 1, 0}, 0.05},
+	}
+	for _, tt := range tests {
+		m, hit := collideObjects(tt.a, tt.b)
+		if !hit {
+			t.Errorf("%s: shapes don't touch", tt.name)
+			continue
+		}
+		// EPA stops once it is within 1e-4 of the surface, which on a round shape leaves the
+		// normal a few degrees out while the depth is close
+		if m.Normal.Dot(tt.wantNormal) < 0.99 || !mgl32.FloatEqualThreshold(m.Penetration, tt.wantDepth, 2e-3) {
+			t.Errorf("%s: normal %v, depth %v, want %v, %v", tt.name, m.Normal, m.Penetration, tt.wantNormal, tt.wantDepth)
+		}
+		if len(m.Points) == 0 {
+			t.Errorf("%s: no contact points", tt.name)
+		}
+	}
+
+	// Apart, GJK doesn't get as far as EPA
+	if _, hit := collideObjects(newTestObject("A", ColliderCylinder, mgl32.Vec3{}, 1), newTestObject("B", ColliderCapsule, mgl32.Vec3{1.05, 0, 0}, 1)); hit {
+		t.Error("cylinder and capsule 1.05 apart touch")
+	}
+}
+
+func TestIntersectRayOBB(t *testing.T) {
+	quarter := float32(math.Pi / 4)
+	stretched := newTestObject("Box", ColliderBox, mgl32.Vec3{1, 0, 0}, 1)
+	stretched.Scale = mgl32.Vec3{4, 1, 1}
+	tests := []struct {
+		name         string
+		origin, dir  mgl32.Vec3
+		box          OrientedBox
+		wantHit      bool
+		wantDistance float32
+	}{
+		{"straight at a face", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}, turned(mgl32.Vec3{}, mgl32.Vec3{}), true, 4.5},
+		{"at a turned edge", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, -1}, turned(mgl32.Vec3{}, mgl32.Vec3{0, quarter, 0}), true, 5 - float32(math.Sqrt(0.5))},
+		// Inside the turned box's world AABB, but past its corner
+		{"past a turned corner", mgl32.Vec3{0.6, 0.6, 5}, mgl32.Vec3{0, 0, -1}, turned(mgl32.Vec3{}, mgl32.Vec3{0, 0, quarter}), false, 0},
+		{"through a turned side", mgl32.Vec3{0.3, 0.3, 5}, mgl32.Vec3{0, 0, -1}, turned(mgl32.Vec3{}, mgl32.Vec3{0, 0, quarter}), true, 4.5},
+		{"along a scaled box", mgl32.Vec3{10, 0, 0}, mgl32.Vec3{-1, 0, 0}, stretched.orientedBox(), true, 7},
+		{"pointing away", mgl32.Vec3{0, 0, 5}, mgl32.Vec3{0, 0, 1}, turned(mgl32.Vec3{}, mgl32.Vec3{}), false, 0},
+		{"from inside", mgl32.Vec3{0.1, 0, 0}, mgl32.Vec3{0, 1, 0}, turned(mgl32.Vec3{}, mgl32.Vec3{0.3, 0.2, 0.1}), true, 0},
+	}
+	for _, tt := range tests {
+		hit, distance := intersectRayOBB(tt.origin, tt.dir, tt.box)
+		if hit != tt.wantHit || (hit && !mgl32.FloatEqualThreshold(distance, tt.wantDistance, 1e-4)) {
+			t.Errorf("%s: hit %v at %v, want %v at %v", tt.name, hit, distance, tt.wantHit, tt.wantDistance)
+		}
+	}
+}
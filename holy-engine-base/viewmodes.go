@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// drawWireframe draws the edges of every batch in view over what renderScene has drawn.
+// The view modes themselves are in holy-shared/viewmode.
+func (a *AppCore) drawWireframe() {
+	viewmode.BeginWireframe(a.wireframeUniform)
+	for i := range a.drawBatches {
+		a.drawBatch(&a.drawBatches[i])
+	}
+	viewmode.EndWireframe(a.wireframeUniform)
+}
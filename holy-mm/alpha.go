@@ -0,0 +1,33 @@
+package main
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+)
+
+// Transparency: the alpha modes are in holy-shared/alphamode. An object's alpha is its
+// texture's alpha, and scene files share the settings with holy-engine-base.
+
+// sortBackToFront orders blended objects from the farthest to the nearest to the camera.
+// Objects are compared by the center of their mesh.
+func (a *AppCore) sortBackToFront(objects []*GameObject) {
+	centers := make([]mgl32.Vec3, len(objects))
+	for i, obj := range objects {
+		centers[i] = mgl32.TransformCoordinate(obj.center, obj.modelMatrix())
+	}
+	sorted := make([]*GameObject, 0, len(objects))
+	for _, i := range alphamode.BackToFront(centers, a.cameraPos) {
+		sorted = append(sorted, objects[i])
+	}
+	copy(objects, sorted)
+}
+
+// setAlphaState sets the uniforms and the coverage state that carry out an object's alpha mode.
+func (a *AppCore) setAlphaState(obj *GameObject) {
+	mode := obj.AlphaMode
+	if obj.Light != nil {
+		mode = alphamode.Opaque // Light markers are always drawn solid
+	}
+	a.alphaUniforms.Set(mode, obj.AlphaCutoff, obj.AlphaToCoverage)
+}
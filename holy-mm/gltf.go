@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/toxichemicals/GO/holy-shared/gltfexport"
+)
+
+// gltfGenerator is written to exported glTF files as the program that made them.
+const gltfGenerator = "Holy Model Maker"
+
+// exportGLTF writes the objects as a glTF 2.0 scene, see gltfexport.Export.
+func exportGLTF(filePath string, objects []*GameObject) error {
+	exported := make([]gltfexport.Object, 0, len(objects))
+	for _, obj := range objects {
+		texturePath := ""
+		if obj.HasTexture {
+			texturePath = obj.TexturePath
+		}
+		object := gltfexport.Object{
+			ID:          obj.ID,
+			Vertices:    obj.Vertices,
+			Indices:     obj.Indices,
+			TexturePath: texturePath,
+			NormalMap:   obj.NormalMap,
+			Position:    obj.Position,
+			Rotation:    obj.Rotation,
+			Scale:       obj.Scale,
+		}
+		if obj.Light != nil {
+			object.Light = &gltfexport.Light{Color: obj.Light.Color, Intensity: obj.Light.Intensity, Range: obj.Light.Range}
+		}
+		exported = append(exported, object)
+	}
+	return gltfexport.Export(filePath, gltfGenerator, exported)
+}
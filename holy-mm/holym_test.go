@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+)
+
+func TestExportHolymRoundTrip(t *testing.T) {
+	vertices, indices := generateCubeData()
+	obj := &GameObject{ID: "Cube_0", Vertices: vertices, Indices: indices, Scale: [3]float32{1, 1, 1},
+		HasTexture: true, TexturePath: "textures/my crate.png", NormalMap: "textures/my crate_normal.png"}
+
+	path := filepath.Join(t.TempDir(), "cube.holym")
+	if err := exportHolym(path, []*GameObject{obj}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	mesh, err := holym.ParseFile(path, true)
+	if err != nil {
+		t.Fatalf("exported file doesn't parse strictly: %v", err)
+	}
+	// Tangents aren't stored, so this also checks that generated ones match the cube's
+	gotVertices, gotIndices := mesh.GameObjectMesh()
+	if !reflect.DeepEqual(gotVertices, vertices) {
+		t.Errorf("vertices changed in the round trip")
+	}
+	if !reflect.DeepEqual(gotIndices, indices) {
+		t.Errorf("generating tangents changed the indices")
+	}
+	if !reflect.DeepEqual(mesh.Indices, indices) {
+		t.Errorf("indices changed in the round trip")
+	}
+	if mesh.TexturePath != obj.TexturePath {
+		t.Errorf("texture = %q, want %q", mesh.TexturePath, obj.TexturePath)
+	}
+	if mesh.NormalMap != obj.NormalMap {
+		t.Errorf("normal map = %q, want %q", mesh.NormalMap, obj.NormalMap)
+	}
+	if mesh.Version != holym.Version {
+		t.Errorf("version = %d, want %d", mesh.Version, holym.Version)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+	"github.com/toxichemicals/GO/holy-shared/objfile"
+)
+
+// convertToHolymb converts a .holym or .obj model to a .holymb file.
+func convertToHolymb(inPath, outPath string, strict bool) error {
+	var mesh *holym.BinaryMesh
+	switch strings.ToLower(filepath.Ext(inPath)) {
+	case ".holym":
+		parsed, err := holym.ParseFile(inPath, strict)
+		if err != nil {
+			return fmt.Errorf("failed to parse .holym model %s: %w", inPath, err)
+		}
+		mesh = holymbFromHolym(parsed)
+	case ".obj":
+		var err error
+		if mesh, err = readObjMesh(inPath, strict); err != nil {
+			return fmt.Errorf("failed to read OBJ model %s: %w", inPath, err)
+		}
+	default:
+		return fmt.Errorf("don't know how to convert %s (expected .holym or .obj)", inPath)
+	}
+
+	if err := holym.WriteBinaryFile(outPath, mesh); err != nil {
+		return err
+	}
+	log.Printf("Converted %s to %s (%d vertices, %d triangles, %d materials)",
+		inPath, outPath, len(mesh.Vertices)*4/mesh.Stride, len(mesh.Indices)/3, len(mesh.Materials))
+	return nil
+}
+
+// holymbFromHolym packs a parsed .holym mesh in the layout GameObjects use, so it loads
+// without conversion. Normals the file doesn't give and tangents are computed from the faces.
+func holymbFromHolym(parsed *holym.Mesh) *holym.BinaryMesh {
+	vertices, indices := parsed.GameObjectMesh()
+	mesh := &holym.BinaryMesh{
+		Layout:   holym.GameObjectAttributes,
+		Stride:   vertexFloats * 4,
+		Vertices: vertices,
+		Indices:  indices,
+		Materials: []holym.Material{{
+			IndexCount:  uint32(len(indices)),
+			Color:       [4]float32{1, 1, 1, 1},
+			TexturePath: parsed.TexturePath,
+			NormalMap:   parsed.NormalMap,
+		}},
+	}
+	return mesh
+}
+
+// readObjMesh reads a Wavefront OBJ file and its MTL libraries into a .holymb mesh. Faces are
+// grouped by material, each group becoming one entry of the material table. Vertices without
+// an OBJ vertex color take their material's Kd color, and missing normals and all tangents
+// are computed. In strict mode the first malformed line is an error, otherwise bad lines
+// are logged and skipped.
+func readObjMesh(filePath string, strict bool) (*holym.BinaryMesh, error) {
+	model, err := objfile.ParseFile(filePath, strict)
+	if err != nil {
+		return nil, err
+	}
+
+	// Always the layout GameObjects use, normals the file doesn't give and tangents are
+	// computed at the end
+	mesh := &holym.BinaryMesh{
+		Layout: holym.GameObjectAttributes,
+		Stride: vertexFloats * 4,
+	}
+
+	// Each distinct corner becomes one vertex. The material is part of the key because
+	// uncolored vertices take their color from it.
+	type vertexKey struct {
+		position, texCoord, normal int
+		material                   string
+	}
+	vertexMap := make(map[vertexKey]uint32)
+	vertexIndex := func(c holym.Corner, material string, color [4]float32) uint32 {
+		key := vertexKey{c.Position, c.TexCoord, c.Normal, material}
+		if index, ok := vertexMap[key]; ok {
+			return index
+		}
+		index := uint32(len(mesh.Vertices) * 4 / mesh.Stride)
+		pos := model.Positions[c.Position]
+		vertexColor := model.Colors[c.Position]
+		if !model.HasColor[c.Position] {
+			vertexColor = mgl32.Vec3{color[0], color[1], color[2]}
+		}
+		texCoord := mgl32.Vec2{0, 0} // Corners without UVs
+		if c.TexCoord >= 0 {
+			texCoord = model.TexCoords[c.TexCoord]
+		}
+		normal := mgl32.Vec3{0, 0, 0} // Corners without normals, filled in by meshgen.FillMissingNormals
+		if c.Normal >= 0 {
+			normal = model.Normals[c.Normal]
+		}
+		mesh.Vertices = append(mesh.Vertices, pos.X(), pos.Y(), pos.Z(), vertexColor.X(), vertexColor.Y(), vertexColor.Z(), texCoord.X(), texCoord.Y(),
+			normal.X(), normal.Y(), normal.Z(), 0, 0, 0, 0)
+		vertexMap[key] = index
+		return index
+	}
+
+	materialOrder, facesByMaterial := model.FacesByMaterial()
+	for _, name := range materialOrder {
+		material := holym.Material{Name: name, Color: [4]float32{1, 1, 1, 1}}
+		if mtl, ok := model.Materials[name]; ok {
+			material.Color = [4]float32{mtl.Kd.X(), mtl.Kd.Y(), mtl.Kd.Z(), mtl.D}
+			if texture := mtl.Maps[objfile.MapDiffuse]; texture != "" {
+				material.TexturePath = resolveObjTexture(mtl.Dir, texture)
+			}
+			if normalMap := mtl.Maps[objfile.MapNormal]; normalMap != "" {
+				material.NormalMap = resolveObjTexture(mtl.Dir, normalMap)
+			}
+		} else if name != "" {
+			log.Printf("Warning: material %q is used but not defined in any MTL file", name)
+		}
+
+		material.FirstIndex = uint32(len(mesh.Indices))
+		for _, face := range facesByMaterial[name] {
+			// Polygons are triangulated as a fan around their first corner
+			hub := vertexIndex(face.Corners[0], name, material.Color)
+			for i := 1; i+1 < len(face.Corners); i++ {
+				mesh.Indices = append(mesh.Indices, hub, vertexIndex(face.Corners[i], name, material.Color), vertexIndex(face.Corners[i+1], name, material.Color))
+			}
+		}
+		material.IndexCount = uint32(len(mesh.Indices)) - material.FirstIndex
+		mesh.Materials = append(mesh.Materials, material)
+	}
+	if len(mesh.Indices) == 0 {
+		return nil, fmt.Errorf("no faces found")
+	}
+	meshgen.FillMissingNormals(mesh.Vertices, mesh.Indices, vertexLayout)
+	mesh.Vertices, mesh.Indices = meshgen.GenerateTangents(mesh.Vertices, mesh.Indices, vertexLayout)
+	return mesh, nil
+}
+
+// resolveObjTexture finds a texture named in an MTL file. It is normally relative to the MTL
+// file, but holy-spinning-models keeps models in source/ and their textures in ../textures/.
+func resolveObjTexture(mtlDir, name string) string {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")) // Exporters on Windows write backslashes
+	candidates := []string{
+		filepath.Join(mtlDir, name),
+		filepath.Join(mtlDir, "..", "textures", filepath.Base(name)),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	log.Printf("Warning: texture %s not found next to its MTL file or in ../textures", name)
+	return candidates[0]
+}
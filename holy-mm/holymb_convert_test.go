@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+)
+
+// testObj is a triangle without a material and a quad using Red, whose vertex 2 has its own
+// color. No normals are given, so they are computed.
+const testObj = `mtllib quad.mtl
+v 0 0 0
+v 1 0 0 0 1 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+f 1/1 2/2 3/3
+usemtl Red
+f 1/1 2/2 3/3 4/4
+`
+
+const testMtl = `newmtl Red
+Kd 1 0 0
+d 0.5
+map_Kd red.png
+`
+
+func TestConvertObjToHolymb(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"quad.obj": testObj, "quad.mtl": testMtl, "red.png": ""} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(dir, "quad.holymb")
+	if err := convertToHolymb(filepath.Join(dir, "quad.obj"), out, true); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	mesh, err := holym.OpenBinary(out)
+	if err != nil {
+		t.Fatalf("converted file doesn't open: %v", err)
+	}
+	defer mesh.Close()
+
+	// Corners are shared inside a material but not across them, and the quad is two triangles
+	vertices, indices := mesh.GameObjectMesh()
+	if len(vertices) != 7*vertexFloats || len(indices) != 9 {
+		t.Fatalf("%d vertices and %d indices, want 7 and 9", len(vertices)/vertexFloats, len(indices))
+	}
+	want := []holym.Material{
+		{Name: "", FirstIndex: 0, IndexCount: 3, Color: [4]float32{1, 1, 1, 1}},
+		{Name: "Red", FirstIndex: 3, IndexCount: 6, Color: [4]float32{1, 0, 0, 0.5}, TexturePath: filepath.Join(dir, "red.png")},
+	}
+	if !reflect.DeepEqual(mesh.Materials, want) {
+		t.Errorf("materials = %+v, want %+v", mesh.Materials, want)
+	}
+
+	tests := []struct {
+		name   string
+		vertex int
+		color  [3]float32
+	}{
+		{"uncolored without a material", 0, [3]float32{1, 1, 1}},
+		{"vertex color", 4, [3]float32{0, 1, 0}},
+		{"uncolored in Red", 5, [3]float32{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		v := vertices[tt.vertex*vertexFloats : (tt.vertex+1)*vertexFloats]
+		if color := [3]float32{v[3], v[4], v[5]}; color != tt.color {
+			t.Errorf("%s: color = %v, want %v", tt.name, color, tt.color)
+		}
+		// The computed normal faces the way the faces wind
+		if normal := [3]float32{v[8], v[9], v[10]}; normal != [3]float32{0, 0, 1} {
+			t.Errorf("%s: normal = %v, want [0 0 1]", tt.name, normal)
+		}
+	}
+}
+
+func TestConvertHolymToHolymb(t *testing.T) {
+	vertices, indices := generateCubeData()
+	obj := &GameObject{ID: "Cube_0", Vertices: vertices, Indices: indices, Scale: [3]float32{1, 1, 1},
+		HasTexture: true, TexturePath: "textures/crate.png", NormalMap: "textures/crate_normal.png"}
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "cube.holym")
+	if err := exportHolym(in, []*GameObject{obj}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	out := filepath.Join(dir, "cube.holymb")
+	if err := convertToHolymb(in, out, true); err != nil {
+		t.Fatalf("conversion failed: %v", err)
+	}
+	mesh, err := holym.OpenBinary(out)
+	if err != nil {
+		t.Fatalf("converted file doesn't open: %v", err)
+	}
+	defer mesh.Close()
+
+	gotVertices, gotIndices := mesh.GameObjectMesh()
+	if !reflect.DeepEqual(gotVertices, vertices) {
+		t.Errorf("vertices changed in the conversion")
+	}
+	if !reflect.DeepEqual(gotIndices, indices) {
+		t.Errorf("indices changed in the conversion")
+	}
+	if mesh.TexturePath() != obj.TexturePath || mesh.NormalMap() != obj.NormalMap {
+		t.Errorf("texture %q and normal map %q, want %q and %q", mesh.TexturePath(), mesh.NormalMap(), obj.TexturePath, obj.NormalMap)
+	}
+}
+
+func TestConvertToHolymbErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.obj")
+	if err := os.WriteFile(bad, []byte("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\nf 1 2 9\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		in      string
+		strict  bool
+		wantErr string // Empty when the conversion succeeds
+	}{
+		{"strict", bad, true, "vertex index 9 out of range"},
+		{"lenient", bad, false, ""},
+		{"unknown extension", filepath.Join(dir, "model.fbx"), false, "don't know how to convert"},
+		{"missing file", filepath.Join(dir, "missing.obj"), false, "failed to read OBJ model"},
+	}
+	for _, tt := range tests {
+		err := convertToHolymb(tt.in, filepath.Join(dir, "out.holymb"), tt.strict)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %v, want one containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"image"
 	"image/draw"
@@ -20,6 +21,13 @@ import (
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/bitfont"
+	"github.com/toxichemicals/GO/holy-shared/holym"
+	"github.com/toxichemicals/GO/holy-shared/post"
+	"github.com/toxichemicals/GO/holy-shared/scene"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
 )
 
 // Constants for window dimensions and viewer parameters
@@ -32,6 +40,23 @@ const (
 	farClippingPlane = 1000.0 // Increased for distant objects
 )
 
+// Lighting constants, the same defaults as holy-engine-base
+const (
+	vertexFloats          = holym.GameObjectFloats // Floats per vertex in GameObject.Vertices: position (3) + color (3) + texcoord (2) + normal (3) + tangent (4)
+	maxPointLights        = 8                      // Must match MAX_POINT_LIGHTS in the scene shader, further lights are ignored
+	DefaultSunYaw         = 60.0                   // Degrees around the Y axis the sunlight comes from
+	DefaultSunPitch       = 50.0                   // Degrees above the horizon
+	DefaultSunIntensity   = 0.8
+	DefaultAmbient        = 0.25 // Light that reaches every surface, so shadowed sides aren't black
+	DefaultLightIntensity = 1.5
+	DefaultLightRange     = 8.0 // Point lights fade out completely at this distance
+	LightMarkerScale      = 0.2 // Size of the cube that shows where a point light is
+)
+
+// vertexLayout is where the attributes are in GameObject.Vertices, for holy-shared/meshgen.
+// It is the layout .holym meshes are loaded in.
+var vertexLayout = holym.GameObjectLayout
+
 // UI Constants - Explicitly define as float32
 const (
 	uiPanelWidth  float32 = 250.0
@@ -39,6 +64,10 @@ const (
 	uiButtonHeight float32 = 30.0
 	uiSliderHeight float32 = 20.0
 	uiElementSpacing float32 = 5.0
+	uiTextScale      float32 = 2.0 // Font glyphs are drawn at twice their pixel size
+
+	uiVertexFloats = 9    // Floats per UI vertex: position (2) + atlas coordinates (2) + color (4) + glyph flag (1)
+	uiInitialQuads = 1024 // Quads the UI buffer has room for at first, it grows when a batch needs more
 )
 
 // AppCore struct encapsulates the editor's state and rendering components.
@@ -46,17 +75,51 @@ type AppCore struct {
 	window *glfw.Window
 
 	// OpenGL program and uniform locations for 3D scene
-	program           uint32
-	modelUniform      int32
-	viewUniform       int32
-	projectionUniform int32
-	textureUniform    int32
-	hasTextureUniform int32 // Uniform to tell shader if texture is present
+	program             uint32
+	modelUniform        int32
+	viewUniform         int32
+	projectionUniform   int32
+	textureUniform      int32
+	hasTextureUniform   int32 // Uniform to tell shader if texture is present
+	hasNormalMapUniform int32
+	normalMatrixUniform int32
+	viewPosUniform      int32
+	unlitUniform        int32 // Draws an object in unlitColor, for light markers
+	unlitColorUniform   int32
+	alphaUniforms       alphamode.Uniforms // See holy-shared/alphamode
+	viewModeUniform     int32              // See holy-shared/viewmode
+	wireframeUniform    int32
+	depthRangeUniform   int32
+	falseColorUniform   int32
+
+	// Lighting uniforms, see applyLighting
+	sunDirectionUniform        int32
+	sunColorUniform            int32
+	ambientColorUniform        int32
+	pointLightCountUniform     int32
+	pointLightPositionsUniform int32
+	pointLightColorsUniform    int32
+	pointLightRangesUniform    int32
+	lighting                   Lighting
+
+	// View mode and wireframe overlay, see holy-shared/viewmode
+	viewMode  viewmode.Mode
+	wireframe bool
+	viewKeys  viewmode.Keys
+
+	// Offscreen HDR target and post-processing passes, see holy-shared/post
+	post *post.Chain
 
 	// OpenGL program and uniforms for 2D UI
 	uiProgram         uint32
 	uiTransformUniform int32
-	uiColorUniform    int32
+
+	// Text rendering
+	fontTexture      uint32       // Atlas built by holy-shared/bitfont
+	uiVAO, uiVBO     uint32       // Streaming buffer every UI batch is drawn from
+	uiBufferSize     int          // Bytes allocated for uiVBO
+	uiVertices       []float32    // The UI batch: quads waiting for flushUI, in drawing order
+	textQueue        []queuedText // Text waiting to be drawn on top of the UI
 
 	// Window dimensions and title
 	width, height int
@@ -90,23 +153,69 @@ type AppCore struct {
 	mousePosX float32
 	mousePosY float32
 	activeUIElement string // Tracks which UI element is being interacted with (e.g., "slider_pos_x")
+
+	strictHolym bool // Reject malformed .holym files instead of skipping the bad lines
+}
+
+// Lighting is the scene-wide light: a directional sun plus an ambient term.
+type Lighting struct {
+	SunYaw       float32 // Degrees around the Y axis the sunlight comes from
+	SunPitch     float32 // Degrees above the horizon
+	SunColor     mgl32.Vec3
+	SunIntensity float32
+	Ambient      float32 // Strength of the ambient light, it has the sun's color
+}
+
+// sunDirection returns the direction the sunlight travels in.
+func (l Lighting) sunDirection() mgl32.Vec3 {
+	yaw := float64(mgl32.DegToRad(l.SunYaw))
+	pitch := float64(mgl32.DegToRad(l.SunPitch))
+	towardsSun := mgl32.Vec3{
+		float32(math.Cos(pitch) * math.Cos(yaw)),
+		float32(math.Sin(pitch)),
+		float32(math.Cos(pitch) * math.Sin(yaw)),
+	}
+	return towardsSun.Mul(-1)
+}
+
+// PointLight turns a GameObject into a light shining from its Position in every direction.
+type PointLight struct {
+	Color     mgl32.Vec3
+	Intensity float32
+	Range     float32 // Distance at which the light has faded out completely
 }
 
 // GameObject represents a loaded or procedurally generated 3D model.
 type GameObject struct {
-	ID           string
-	Vertices     []float32 // Interleaved position (3) + color (3) + texcoord (2)
-	Indices      []uint32
-	VAO, VBO, EBO uint32
-	IndicesCount int32
-	HasTexture   bool
-	TextureID    uint32
-	TexturePath  string // Path to the original texture file
+	ID              string
+	Vertices        []float32 // Interleaved position (3) + color (3) + texcoord (2) + normal (3) + tangent (4)
+	Indices         []uint32
+	VAO, VBO, EBO   uint32
+	IndicesCount    int32
+	HasTexture      bool
+	TextureID       uint32
+	TexturePath     string            // Path to the original texture file
+	NormalMapID     uint32            // 0 when the object has no normal map
+	NormalMap       string            // Path to the normal map file
+	Color           *mgl32.Vec4       // Tint from holy-engine-base, not drawn by the editor but kept in scene files
+	AlphaMode       alphamode.Mode    // How the texture's alpha is used, from the texture unless set, see holy-shared/alphamode
+	AlphaCutoff     float32           // Alpha below this is cut out in the mask mode
+	AlphaToCoverage bool              // Antialias masked edges with MSAA instead of cutting them hard
+	center          mgl32.Vec3        // Middle of the mesh's bounds, blended objects are sorted by it
+	viewColor       mgl32.Vec3        // Flat color in the false color view, see holy-shared/viewmode
+	Primitive       string            // Primitive type the mesh was generated from ("cube", "plane"), empty for models
+	ModelPath       string            // Model file the mesh was loaded from, empty for primitives
+	holymb          *holym.BinaryMesh // Mapped .holymb file Vertices and Indices point into, nil otherwise
+	Light           *PointLight       // Set for point lights, the mesh then only marks where the light is
 
 	// Transformation fields
 	Position mgl32.Vec3
 	Rotation mgl32.Vec3 // Euler angles (pitch, yaw, roll) - still here but not directly manipulated by UI
 	Scale    mgl32.Vec3
+
+	// Physics settings, unused by the editor but kept in scene files for holy-engine-base
+	Mass        float32
+	IsKinematic bool
 }
 
 // Global instance of AppCore
@@ -131,6 +240,7 @@ func initApp() error {
 		yaw:         -90.0,                    // Yaw to look along negative Z initially
 		pitch:       0.0,                      // No pitch initially
 		firstMouse:  true,
+		lighting:    defaultLighting(),
 	}
 
 	// Initialize GLFW window
@@ -148,6 +258,14 @@ func initApp() error {
 		return fmt.Errorf("scene shader setup failed: %w", err)
 	}
 
+	// The scene renders into the post-processing chain's HDR target. Lighting is done in
+	// linear space, so the chain tonemaps it and encodes it for the screen.
+	chain, err := post.NewChain(app.width, app.height, post.DefaultEffects...)
+	if err != nil {
+		return fmt.Errorf("post-processing setup failed: %w", err)
+	}
+	app.post = chain
+
 	// Setup 2D UI shaders and get uniform locations
 	if err := app.setupUIShadersAndUniforms(); err != nil {
 		return fmt.Errorf("UI shader setup failed: %w", err)
@@ -189,6 +307,11 @@ func (a *AppCore) initializeWindow() error {
 		a.height = height
 		gl.Viewport(0, 0, int32(width), int32(height))
 		a.updateCameraAndProjection() // Update projection on resize
+		if a.post != nil {
+			if err := a.post.Resize(width, height); err != nil {
+				log.Printf("Warning: Failed to resize the post-processing targets: %v", err)
+			}
+		}
 	})
 
 	a.window.SetCursorPosCallback(func(_ *glfw.Window, xpos, ypos float64) {
@@ -264,43 +387,138 @@ func (a *AppCore) initializeOpenGL() error {
 
 // setupSceneShadersAndUniforms compiles shaders for the 3D scene.
 func (a *AppCore) setupSceneShadersAndUniforms() error {
-	// Vertex shader that supports both color and texture
+	// Vertex shader that supports both color and texture, and passes on what lighting needs
 	vertexShaderSource := `
 		#version 410 core
 		layout (location = 0) in vec3 aPos;
 		layout (location = 1) in vec3 aColor; // For vertex colors
 		layout (location = 2) in vec2 aTexCoord; // For texture coordinates
+		layout (location = 3) in vec3 aNormal;
+		layout (location = 4) in vec4 aTangent; // Bitangent sign in w
 
 		out vec3 ourColor;
 		out vec2 TexCoord;
+		out vec3 FragPos; // World space
+		out vec3 Normal;  // World space
+		out vec4 Tangent; // World space, w passed on
 
 		uniform mat4 model;
 		uniform mat4 view;
 		uniform mat4 projection;
+		uniform mat3 normalMatrix; // Inverse transpose of the model matrix, keeps normals right under non-uniform scale
 
 		void main() {
-			gl_Position = projection * view * model * vec4(aPos, 1.0);
+			vec4 worldPos = model * vec4(aPos, 1.0);
+			gl_Position = projection * view * worldPos;
 			ourColor = aColor;
 			TexCoord = aTexCoord;
+			FragPos = worldPos.xyz;
+			Normal = normalMatrix * aNormal;
+			Tangent = vec4(mat3(model) * aTangent.xyz, aTangent.w); // Tangents follow the surface, so the model matrix itself
 		}
 	` + "\x00"
 
-	// Fragment shader that uses texture if available, otherwise vertex color
+	// Fragment shader that uses texture if available, otherwise vertex color, lit with
+	// Blinn-Phong by the sun, the ambient term and the point lights. A normal map bends
+	// the normal within the tangent frame.
 	fragmentShaderSource := `
 		#version 410 core
+		#define MAX_POINT_LIGHTS 8
+
 		in vec3 ourColor;
 		in vec2 TexCoord;
+		in vec3 FragPos;
+		in vec3 Normal;
+		in vec4 Tangent;
 		out vec4 FragColor;
 
 		uniform sampler2D ourTexture;
 		uniform bool hasTexture; // To indicate if a texture is bound
+		uniform sampler2D normalMap; // Tangent space, OpenGL convention (green is the top of the image)
+		uniform bool hasNormalMap;
+		uniform bool unlit;      // Light markers are drawn in a flat color
+		uniform vec3 unlitColor;
+	` + alphamode.ShaderUniforms + `
+	` + viewmode.ShaderUniforms + `
+		uniform vec3 falseColor;
+
+		uniform vec3 viewPos;
+		uniform vec3 sunDirection; // Direction the sunlight travels in
+		uniform vec3 sunColor;     // Already multiplied by the intensity
+		uniform vec3 ambientColor;
+		uniform int pointLightCount;
+		uniform vec3 pointLightPositions[MAX_POINT_LIGHTS];
+		uniform vec3 pointLightColors[MAX_POINT_LIGHTS]; // Already multiplied by the intensity
+		uniform float pointLightRanges[MAX_POINT_LIGHTS];
+
+		const float shininess = 32.0;
+		const float specularStrength = 0.3;
+
+		// blinnPhong returns the diffuse and specular light from one light.
+		// lightDir points from the surface towards the light.
+		vec3 blinnPhong(vec3 normal, vec3 lightDir, vec3 viewDir, vec3 lightColor, vec3 albedo) {
+			float diffuse = max(dot(normal, lightDir), 0.0);
+			float specular = 0.0;
+			if (diffuse > 0.0) {
+				vec3 halfway = normalize(lightDir + viewDir);
+				specular = pow(max(dot(normal, halfway), 0.0), shininess);
+			}
+			return lightColor * (diffuse * albedo + specularStrength * specular);
+		}
+
+		// surfaceNormal returns the interpolated normal, bent by the normal map if there is one.
+		// The bitangent is rebuilt per pixel from the unnormalized vectors, as MikkTSpace expects.
+		vec3 surfaceNormal() {
+			vec3 normal = normalize(Normal);
+			if (!hasNormalMap) {
+				return normal;
+			}
+			vec3 tangent = normalize(Tangent.xyz - normal * dot(normal, Tangent.xyz));
+			vec3 bitangent = Tangent.w * cross(Normal, Tangent.xyz);
+			vec3 mapped = texture(normalMap, TexCoord).xyz * 2.0 - 1.0;
+			return normalize(mapped.x * tangent + mapped.y * bitangent + mapped.z * normal);
+		}
+
+	` + alphamode.ShaderFunctions + `
+	` + viewmode.ShaderFunctions + `
 
 		void main() {
+			if (wireframe) {
+				FragColor = vec4(wireframeColor, 1.0);
+				return;
+			}
+			if (unlit) {
+				FragColor = vec4(unlitColor, 1.0);
+				return;
+			}
+
+			vec4 albedo;
 			if (hasTexture) {
-				FragColor = texture(ourTexture, TexCoord);
+				albedo = texture(ourTexture, TexCoord);
 			} else {
-				FragColor = vec4(ourColor, 1.0);
+				albedo = vec4(ourColor, 1.0);
+			}
+			albedo.a = applyAlphaMode(albedo.a);
+
+			if (viewMode != 0) {
+				FragColor = vec4(viewModeColor(albedo.rgb, falseColor), albedo.a);
+				return;
 			}
+			albedo.rgb = pow(albedo.rgb, vec3(2.2)); // Textures and vertex colors are sRGB, the lighting is linear
+
+			vec3 normal = surfaceNormal();
+			vec3 viewDir = normalize(viewPos - FragPos);
+			vec3 color = ambientColor * albedo.rgb;
+			color += blinnPhong(normal, -sunDirection, viewDir, sunColor, albedo.rgb);
+			for (int i = 0; i < pointLightCount; i++) {
+				vec3 toLight = pointLightPositions[i] - FragPos;
+				float distance = length(toLight);
+				// Fades smoothly to nothing at the light's range
+				float attenuation = clamp(1.0 - distance / pointLightRanges[i], 0.0, 1.0);
+				attenuation *= attenuation;
+				color += attenuation * blinnPhong(normal, toLight / max(distance, 0.0001), viewDir, pointLightColors[i], albedo.rgb);
+			}
+			FragColor = vec4(color, albedo.a);
 		}
 	` + "\x00"
 
@@ -317,27 +535,105 @@ func (a *AppCore) setupSceneShadersAndUniforms() error {
 	a.textureUniform = gl.GetUniformLocation(a.program, gl.Str("ourTexture\x00"))
 	a.hasTextureUniform = gl.GetUniformLocation(a.program, gl.Str("hasTexture\x00")) // Store uniform location
 	gl.Uniform1i(a.hasTextureUniform, 0) // Default to no texture
+	a.hasNormalMapUniform = gl.GetUniformLocation(a.program, gl.Str("hasNormalMap\x00"))
+	gl.Uniform1i(gl.GetUniformLocation(a.program, gl.Str("normalMap\x00")), 1) // Texture unit 1, unit 0 is ourTexture
+	a.normalMatrixUniform = gl.GetUniformLocation(a.program, gl.Str("normalMatrix\x00"))
+	a.viewPosUniform = gl.GetUniformLocation(a.program, gl.Str("viewPos\x00"))
+	a.unlitUniform = gl.GetUniformLocation(a.program, gl.Str("unlit\x00"))
+	a.unlitColorUniform = gl.GetUniformLocation(a.program, gl.Str("unlitColor\x00"))
+	a.alphaUniforms = alphamode.Locate(a.program)
+	a.viewModeUniform = gl.GetUniformLocation(a.program, gl.Str("viewMode\x00"))
+	a.wireframeUniform = gl.GetUniformLocation(a.program, gl.Str("wireframe\x00"))
+	a.depthRangeUniform = gl.GetUniformLocation(a.program, gl.Str("depthRange\x00"))
+	a.falseColorUniform = gl.GetUniformLocation(a.program, gl.Str("falseColor\x00"))
+	gl.Uniform2f(a.depthRangeUniform, 0, viewmode.DefaultDepthRange)
+	a.sunDirectionUniform = gl.GetUniformLocation(a.program, gl.Str("sunDirection\x00"))
+	a.sunColorUniform = gl.GetUniformLocation(a.program, gl.Str("sunColor\x00"))
+	a.ambientColorUniform = gl.GetUniformLocation(a.program, gl.Str("ambientColor\x00"))
+	a.pointLightCountUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightCount\x00"))
+	// Arrays are set through the location of their first element
+	a.pointLightPositionsUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightPositions[0]\x00"))
+	a.pointLightColorsUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightColors[0]\x00"))
+	a.pointLightRangesUniform = gl.GetUniformLocation(a.program, gl.Str("pointLightRanges[0]\x00"))
 
 	return nil
 }
 
+// defaultLighting returns the lighting a new scene starts with: a white sun from above and
+// to the side, and a little ambient light.
+func defaultLighting() Lighting {
+	return Lighting{
+		SunYaw:       DefaultSunYaw,
+		SunPitch:     DefaultSunPitch,
+		SunColor:     mgl32.Vec3{1, 1, 1},
+		SunIntensity: DefaultSunIntensity,
+		Ambient:      DefaultAmbient,
+	}
+}
+
+// applyLighting uploads the sun, the ambient light and up to maxPointLights point lights
+// to the scene shader. It runs once per frame, before the objects are drawn.
+func (a *AppCore) applyLighting() {
+	sunDirection := a.lighting.sunDirection()
+	sunColor := a.lighting.SunColor.Mul(a.lighting.SunIntensity)
+	ambientColor := a.lighting.SunColor.Mul(a.lighting.Ambient)
+	gl.Uniform3fv(a.sunDirectionUniform, 1, &sunDirection[0])
+	gl.Uniform3fv(a.sunColorUniform, 1, &sunColor[0])
+	gl.Uniform3fv(a.ambientColorUniform, 1, &ambientColor[0])
+	gl.Uniform3fv(a.viewPosUniform, 1, &a.cameraPos[0])
+
+	var positions, colors [maxPointLights]mgl32.Vec3
+	var ranges [maxPointLights]float32
+	count := 0
+	for _, obj := range a.objects {
+		if obj.Light == nil || count == maxPointLights {
+			continue
+		}
+		positions[count] = obj.Position
+		colors[count] = obj.Light.Color.Mul(obj.Light.Intensity)
+		ranges[count] = obj.Light.Range
+		count++
+	}
+	gl.Uniform1i(a.pointLightCountUniform, int32(count))
+	if count > 0 {
+		gl.Uniform3fv(a.pointLightPositionsUniform, int32(count), &positions[0][0])
+		gl.Uniform3fv(a.pointLightColorsUniform, int32(count), &colors[0][0])
+		gl.Uniform1fv(a.pointLightRangesUniform, int32(count), &ranges[0])
+	}
+}
+
 // setupUIShadersAndUniforms compiles shaders for 2D UI elements.
 func (a *AppCore) setupUIShadersAndUniforms() error {
 	uiVertexShaderSource := `
 		#version 410 core
 		layout (location = 0) in vec2 aPos; // Only 2D position for UI
+		layout (location = 1) in vec2 aTexCoord; // Only used by text
+		layout (location = 2) in vec4 aColor;
+		layout (location = 3) in float aGlyph; // 1 for glyphs, which are cut out of the font atlas
 		uniform mat4 uiTransform; // Orthographic projection + translation/scale
+		out vec2 TexCoord;
+		out vec4 Color;
+		out float Glyph;
 		void main() {
 			gl_Position = uiTransform * vec4(aPos, 0.0, 1.0);
+			TexCoord = aTexCoord;
+			Color = aColor;
+			Glyph = aGlyph;
 		}
 	` + "\x00"
 
 	uiFragmentShaderSource := `
 		#version 410 core
+		in vec2 TexCoord;
+		in vec4 Color;
+		in float Glyph;
 		out vec4 FragColor;
-		uniform vec4 uiColor; // Color for the UI element
+		uniform sampler2D uiTexture; // Font atlas
 		void main() {
-			FragColor = uiColor;
+			if (Glyph > 0.5 && texture(uiTexture, TexCoord).r < 0.5) {
+				discard; // Outside the glyph
+			}
+			FragColor = Color;
 		}
 	` + "\x00"
 
@@ -348,7 +644,27 @@ func (a *AppCore) setupUIShadersAndUniforms() error {
 	a.uiProgram = uiProgram
 
 	a.uiTransformUniform = gl.GetUniformLocation(a.uiProgram, gl.Str("uiTransform\x00"))
-	a.uiColorUniform = gl.GetUniformLocation(a.uiProgram, gl.Str("uiColor\x00"))
+	gl.UseProgram(a.uiProgram)
+	gl.Uniform1i(gl.GetUniformLocation(a.uiProgram, gl.Str("uiTexture\x00")), 0) // Font atlas on texture unit 0
+
+	a.fontTexture = bitfont.NewTexture()
+
+	// UI quads are rebuilt every frame and streamed into this one buffer, see flushUI
+	a.uiBufferSize = uiInitialQuads * 6 * uiVertexFloats * 4
+	gl.GenVertexArrays(1, &a.uiVAO)
+	gl.BindVertexArray(a.uiVAO)
+	gl.GenBuffers(1, &a.uiVBO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.uiVBO)
+	gl.BufferData(gl.ARRAY_BUFFER, a.uiBufferSize, nil, gl.STREAM_DRAW)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(0)) // 2D position
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(2*4)) // Atlas coordinates
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(2, 4, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(4*4)) // Color
+	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(3, 1, gl.FLOAT, false, uiVertexFloats*4, gl.PtrOffset(8*4)) // Glyph flag
+	gl.EnableVertexAttribArray(3)
+	gl.BindVertexArray(0)
 
 	return nil
 }
@@ -403,6 +719,12 @@ func (a *AppCore) processInput(deltaTime float32) {
 		}
 		a.updateCameraAndProjection() // Update camera based on new position
 	}
+
+	// Keys 1-5 toggle the post-processing effects
+	a.post.HandleKeys(a.window)
+
+	// M cycles the view modes, F toggles the wireframe
+	a.viewKeys.Handle(a.window, &a.viewMode, &a.wireframe)
 }
 
 // updateScene updates editor logic.
@@ -412,14 +734,52 @@ func (a *AppCore) updateScene(deltaTime float32) {
 
 // renderScene clears buffers and draws all objects.
 func (a *AppCore) renderScene() {
-	gl.ClearColor(0.2, 0.3, 0.3, 1.0) // Dark teal background
+	// The scene goes into the post-processing chain's target, the UI straight to the window
+	a.post.Begin()
+	gl.Enable(gl.DEPTH_TEST)
+	// Dark teal background, made linear in the lit view since the chain encodes it again
+	background := mgl32.Vec3{0.2, 0.3, 0.3}
+	if a.viewMode == viewmode.Lit {
+		background = post.LinearColor(background)
+	}
+	gl.ClearColor(background.X(), background.Y(), background.Z(), 1.0)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 	// Render 3D objects
 	gl.UseProgram(a.program) // Activate 3D shader
+	a.applyLighting()
+	gl.Uniform1i(a.viewModeUniform, int32(a.viewMode))
+	if a.viewMode == viewmode.FalseColor {
+		// Objects are told apart by their place in the scene
+		for i, obj := range a.objects {
+			obj.viewColor = viewmode.Color(i)
+		}
+	}
+	var blended []*GameObject
 	for _, obj := range a.objects {
+		if obj.AlphaMode == alphamode.Blend && obj.Light == nil {
+			blended = append(blended, obj)
+			continue
+		}
 		a.drawGameObject(obj)
 	}
+	gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+
+	// Blended objects last, back to front over the opaque ones. They test against the depth
+	// buffer but don't write to it, so the ones behind still show through.
+	a.sortBackToFront(blended)
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	gl.DepthMask(false)
+	for _, obj := range blended {
+		a.drawGameObject(obj)
+	}
+	gl.DepthMask(true)
+	gl.Disable(gl.BLEND)
+	if a.wireframe {
+		a.drawWireframe()
+	}
+	viewmode.EndPost(a.post, a.viewMode)
 
 	// Render 2D UI elements
 	a.drawCustomUI()
@@ -431,6 +791,9 @@ func (a *AppCore) renderScene() {
 func (a *AppCore) drawCustomUI() {
 	// Disable depth test for 2D UI to ensure it's always drawn on top
 	gl.Disable(gl.DEPTH_TEST)
+	// Panels and text are blended over the scene by their alpha
+	gl.Enable(gl.BLEND)
+	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
 	gl.UseProgram(a.uiProgram) // Activate 2D UI shader
 
 	// Set up orthographic projection for 2D UI
@@ -441,6 +804,7 @@ func (a *AppCore) drawCustomUI() {
 	currentY := uiPadding
 
 	// --- Editor Tools Panel ---
+	panelMark := a.uiMark() // The background goes under everything up to drawPanelBackground
 	panelX := uiPadding
 	panelWidth := uiPanelWidth
 	panelHeight := float32(0.0) // Will calculate dynamically
@@ -451,14 +815,14 @@ func (a *AppCore) drawCustomUI() {
 	buttonWidth := panelWidth - uiPadding*2
 	buttonHeight := uiButtonHeight
 
-	if a.handleButton(buttonX, buttonY, buttonWidth, buttonHeight, "Import Model (.holym)") {
-		log.Print("Enter path to .holym model file (e.g., models/my_model.holym): ")
+	if a.handleButton(buttonX, buttonY, buttonWidth, buttonHeight, "Import Model") {
+		log.Print("Enter path to .holym or .holymb model file (e.g., models/my_model.holym): ")
 		reader := bufio.NewReader(os.Stdin)
 		inputPath, _ := reader.ReadString('\n')
 		inputPath = strings.TrimSpace(inputPath)
 
 		if inputPath != "" {
-			if err := a.loadHolymModel(inputPath); err != nil {
+			if _, err := a.loadHolymModel(inputPath); err != nil {
 				log.Printf("Error loading model from %s: %v", inputPath, err)
 			} else {
 				log.Printf("Successfully loaded model from %s", inputPath)
@@ -469,6 +833,60 @@ func (a *AppCore) drawCustomUI() {
 	}
 	currentY += uiButtonHeight + uiElementSpacing
 
+	// "Save Scene" / "Load Scene" buttons
+	buttonY = currentY + uiPadding
+	if a.handleButton(buttonX, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Save Scene") {
+		log.Print("Enter path to save the scene to (e.g., scenes/my_scene.json): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			if err := a.saveScene(inputPath); err != nil {
+				log.Printf("Error saving scene to %s: %v", inputPath, err)
+			} else {
+				log.Printf("Saved scene to %s", inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
+	}
+	if a.handleButton(buttonX + buttonWidth/2 + uiElementSpacing/2, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Load Scene") {
+		log.Print("Enter path of the scene to load (e.g., scenes/my_scene.json): ")
+		reader := bufio.NewReader(os.Stdin)
+		inputPath, _ := reader.ReadString('\n')
+		inputPath = strings.TrimSpace(inputPath)
+
+		if inputPath != "" {
+			if err := a.loadScene(inputPath); err != nil {
+				log.Printf("Error loading scene from %s: %v", inputPath, err)
+			} else {
+				log.Printf("Loaded scene from %s", inputPath)
+			}
+		} else {
+			log.Println("No path entered.")
+		}
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
+	// "Export Selected" / "Export All" buttons
+	buttonY = currentY + uiPadding
+	if a.handleButton(buttonX, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Export Selected") {
+		if a.selectedObject == nil {
+			log.Println("No object selected to export.")
+		} else {
+			a.promptExport([]*GameObject{a.selectedObject})
+		}
+	}
+	if a.handleButton(buttonX + buttonWidth/2 + uiElementSpacing/2, buttonY, buttonWidth/2 - uiElementSpacing/2, uiButtonHeight, "Export All") {
+		if len(a.objects) == 0 {
+			log.Println("No objects in scene to export.")
+		} else {
+			a.promptExport(a.objects)
+		}
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
 	// "Create Primitive" buttons
 	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Create Primitive:", mgl32.Vec4{1,1,1,1})
 	currentY += uiButtonHeight + uiElementSpacing
@@ -483,6 +901,63 @@ func (a *AppCore) drawCustomUI() {
 	}
 	currentY += uiButtonHeight + uiElementSpacing
 
+	if a.handleButton(panelX+uiPadding, currentY, buttonWidth, uiButtonHeight, "Point Light") {
+		a.createPrimitive("light")
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+
+	// Lighting sliders for the sun and the ambient light
+	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Lighting:", mgl32.Vec4{1, 1, 1, 1})
+	currentY += uiButtonHeight + uiElementSpacing
+	lightingSliders := []struct {
+		label    string
+		id       string
+		value    *float32
+		min, max float32
+	}{
+		{"Sun Angle:", "sun_yaw", &a.lighting.SunYaw, 0.0, 360.0},
+		{"Sun Height:", "sun_pitch", &a.lighting.SunPitch, -10.0, 90.0},
+		{"Sun Intensity:", "sun_intensity", &a.lighting.SunIntensity, 0.0, 2.0},
+		{"Ambient:", "ambient", &a.lighting.Ambient, 0.0, 1.0},
+	}
+	for _, slider := range lightingSliders {
+		a.drawTextOverlay(panelX+uiPadding, currentY, slider.label, mgl32.Vec4{1, 1, 1, 1})
+		currentY += uiElementSpacing
+		a.handleSlider(panelX+uiPadding, currentY, panelWidth-uiPadding*2, uiSliderHeight,
+			slider.id, slider.value, slider.min, slider.max)
+		currentY += uiSliderHeight + uiElementSpacing
+	}
+	currentY += uiElementSpacing
+
+	// View modes, also on M and F
+	if a.handleButton(panelX+uiPadding, currentY, panelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("View: %s", a.viewMode)) {
+		a.viewMode = (a.viewMode + 1) % viewmode.Count // Cycle through the modes
+	}
+	currentY += uiButtonHeight + uiElementSpacing
+	if a.handleButton(panelX+uiPadding, currentY, panelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Wireframe: %t", a.wireframe)) {
+		a.wireframe = !a.wireframe
+	}
+	currentY += uiButtonHeight + uiElementSpacing*2
+
+	// Post-processing toggles, also on keys 1-5
+	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Post Effects:", mgl32.Vec4{1,1,1,1})
+	currentY += uiButtonHeight + uiElementSpacing
+	for effect := post.Effect(0); effect < post.EffectCount; effect++ {
+		label := fmt.Sprintf("%d %s: %t", effect+1, post.EffectNames[effect], a.post.Enabled[effect])
+		if a.handleButton(panelX+uiPadding, currentY, panelWidth-uiPadding*2, uiButtonHeight, label) {
+			a.post.Toggle(effect)
+		}
+		currentY += uiButtonHeight + uiElementSpacing
+	}
+	if a.post.Enabled[post.Tonemap] {
+		a.drawTextOverlay(panelX+uiPadding, currentY, "Exposure:", mgl32.Vec4{1,1,1,1})
+		currentY += uiElementSpacing
+		a.handleSlider(panelX+uiPadding, currentY, panelWidth-uiPadding*2, uiSliderHeight,
+			"exposure", &a.post.Exposure, 0.1, 4.0)
+		currentY += uiSliderHeight + uiElementSpacing
+	}
+	currentY += uiElementSpacing
+
 	a.drawTextOverlay(panelX+uiPadding, currentY+uiPadding, "Scene Objects:", mgl32.Vec4{1,1,1,1})
 	currentY += uiButtonHeight + uiElementSpacing
 
@@ -505,10 +980,12 @@ func (a *AppCore) drawCustomUI() {
 	}
 
 	panelHeight = currentY + uiPadding - uiPadding // Adjust for final padding
-	a.drawRect(panelX, uiPadding, panelWidth, panelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Editor Tools
+	a.drawPanelBackground(panelMark, panelX, uiPadding, panelWidth, panelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Editor Tools
+	a.flushUI()
 
 	// --- Properties Panel for selected object ---
 	if a.selectedObject != nil {
+		propPanelMark := a.uiMark()
 		propPanelX := float32(a.width) - uiPanelWidth - uiPadding
 		propPanelY := uiPadding
 		propPanelWidth := uiPanelWidth
@@ -557,11 +1034,55 @@ func (a *AppCore) drawCustomUI() {
 			"scale_z", &a.selectedObject.Scale[2], 0.01, 5.0)
 		currentPropY += uiSliderHeight + uiElementSpacing * 2 // Extra spacing
 
+		// Light Sliders, only for point lights
+		if light := a.selectedObject.Light; light != nil {
+			lightSliders := []struct {
+				label    string
+				id       string
+				value    *float32
+				min, max float32
+			}{
+				{"Light Red:", "light_r", &light.Color[0], 0.0, 1.0},
+				{"Light Green:", "light_g", &light.Color[1], 0.0, 1.0},
+				{"Light Blue:", "light_b", &light.Color[2], 0.0, 1.0},
+				{"Intensity:", "light_intensity", &light.Intensity, 0.0, 5.0},
+				{"Range:", "light_range", &light.Range, 0.5, 30.0},
+			}
+			for _, slider := range lightSliders {
+				a.drawTextOverlay(propPanelX+uiPadding, currentPropY, slider.label, mgl32.Vec4{1, 1, 1, 1})
+				currentPropY += uiElementSpacing
+				a.handleSlider(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiSliderHeight,
+					slider.id, slider.value, slider.min, slider.max)
+				currentPropY += uiSliderHeight + uiElementSpacing
+			}
+			currentPropY += uiElementSpacing // Extra spacing
+		}
+
+		// Transparency, see holy-shared/alphamode. Light markers are always drawn solid.
+		if a.selectedObject.Light == nil {
+			if a.handleButton(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Alpha: %s", a.selectedObject.AlphaMode)) {
+				a.selectedObject.AlphaMode = (a.selectedObject.AlphaMode + 1) % alphamode.Count // Cycle through the modes
+			}
+			currentPropY += uiButtonHeight + uiElementSpacing
+			if a.selectedObject.AlphaMode == alphamode.Mask {
+				a.drawTextOverlay(propPanelX+uiPadding, currentPropY, "Alpha Cutoff:", mgl32.Vec4{1, 1, 1, 1})
+				currentPropY += uiElementSpacing
+				a.handleSlider(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiSliderHeight,
+					"alpha_cutoff", &a.selectedObject.AlphaCutoff, 0.0, 1.0)
+				currentPropY += uiSliderHeight + uiElementSpacing
+				if a.handleButton(propPanelX+uiPadding, currentPropY, propPanelWidth-uiPadding*2, uiButtonHeight, fmt.Sprintf("Alpha to Coverage: %t", a.selectedObject.AlphaToCoverage)) {
+					a.selectedObject.AlphaToCoverage = !a.selectedObject.AlphaToCoverage
+				}
+				currentPropY += uiButtonHeight + uiElementSpacing
+			}
+		}
 
 		propPanelHeight = currentPropY - propPanelY + uiPadding
-		a.drawRect(propPanelX, propPanelY, propPanelWidth, propPanelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Properties
+		a.drawPanelBackground(propPanelMark, propPanelX, propPanelY, propPanelWidth, propPanelHeight, mgl32.Vec4{0.15, 0.15, 0.15, 0.8}) // Background for Properties
+		a.flushUI()
 	}
 
+	gl.Disable(gl.BLEND)
 	gl.Enable(gl.DEPTH_TEST) // Re-enable depth test for 3D scene
 }
 
@@ -583,7 +1104,8 @@ func (a *AppCore) handleButton(x, y, width, height float32, label string) bool {
 	}
 
 	a.drawRect(x, y, width, height, buttonColor)
-	a.drawTextOverlay(x + width/2 - float32(len(label)*3), y + height/2 - 8, label, mgl32.Vec4{1,1,1,1}) // Crude text centering
+	textWidth, textHeight := measureText(label)
+	a.drawTextOverlay(x + (width-textWidth)/2, y + (height-textHeight)/2, label, mgl32.Vec4{1,1,1,1}) // Centered label
 
 	clicked := false
 	if isOver && a.mouseLeftReleased {
@@ -625,62 +1147,113 @@ func (a *AppCore) handleSlider(x, y, width, height float32, id string, value *fl
 	}
 }
 
-
-// drawRect draws a filled rectangle.
+// drawRect adds a filled rectangle to the UI batch. It shows up on the next flushUI.
 func (a *AppCore) drawRect(x, y, width, height float32, color mgl32.Vec4) {
-	// Define vertices for a quad
-	vertices := []float32{
-		x, y, // Top-left
-		x + width, y, // Top-right
-		x + width, y + height, // Bottom-right
-		x, y + height, // Bottom-left
-	}
-	indices := []uint32{
-		0, 1, 2,
-		2, 3, 0,
+	a.uiVertices = appendUIQuad(a.uiVertices, x, y, x+width, y+height, 0, 0, 0, 0, color, false)
+}
+
+// appendUIQuad appends a quad from (x0, y0) to (x1, y1) as two triangles. Glyph quads show
+// the font atlas between (u0, v0) and (u1, v1) in color, other quads are filled with it.
+func appendUIQuad(vertices []float32, x0, y0, x1, y1, u0, v0, u1, v1 float32, color mgl32.Vec4, glyph bool) []float32 {
+	g := float32(0)
+	if glyph {
+		g = 1
 	}
+	r, gr, b, al := color.X(), color.Y(), color.Z(), color.W()
+	return append(vertices,
+		x0, y0, u0, v0, r, gr, b, al, g, // Top-left
+		x1, y0, u1, v0, r, gr, b, al, g, // Top-right
+		x1, y1, u1, v1, r, gr, b, al, g, // Bottom-right
+		x1, y1, u1, v1, r, gr, b, al, g, // Bottom-right
+		x0, y1, u0, v1, r, gr, b, al, g, // Bottom-left
+		x0, y0, u0, v0, r, gr, b, al, g, // Top-left
+	)
+}
 
-	var vao, vbo, ebo uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
+// uiMark returns where the next quad goes in the UI batch, for drawPanelBackground.
+func (a *AppCore) uiMark() int {
+	return len(a.uiVertices)
+}
 
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+// drawPanelBackground adds a panel's background under every quad added since mark. Panels
+// are sized by their contents, so the background is only known after them.
+func (a *AppCore) drawPanelBackground(mark int, x, y, width, height float32, color mgl32.Vec4) {
+	background := appendUIQuad(nil, x, y, x+width, y+height, 0, 0, 0, 0, color, false)
+	a.uiVertices = append(a.uiVertices, background...)
+	copy(a.uiVertices[mark+len(background):], a.uiVertices[mark:len(a.uiVertices)-len(background)])
+	copy(a.uiVertices[mark:], background)
+}
 
-	gl.GenBuffers(1, &ebo)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+// queuedText is one drawTextOverlay call waiting for flushUI.
+type queuedText struct {
+	x, y  float32
+	text  string
+	color mgl32.Vec4
+}
 
-	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 2*4, gl.Ptr(nil)) // 2D position
-	gl.EnableVertexAttribArray(0)
+// drawTextOverlay draws text with its top-left corner at (x, y) in UI pixels.
+// The text is only queued here and drawn by the next flushUI, on top of the batch's quads.
+func (a *AppCore) drawTextOverlay(x, y float32, text string, color mgl32.Vec4) {
+	a.textQueue = append(a.textQueue, queuedText{x: x, y: y, text: text, color: color})
+}
+
+// measureText returns the size in UI pixels that drawTextOverlay uses for text.
+func measureText(text string) (float32, float32) {
+	return float32(len(text)) * bitfont.CellWidth * uiTextScale, bitfont.CellHeight * uiTextScale
+}
+
+// flushUI draws the UI batch with one upload and one draw call: the quads added since the
+// last flush, then the queued text as glyph quads cut out of the font atlas. It is called
+// once per panel.
+func (a *AppCore) flushUI() {
+	cellWidth := bitfont.CellWidth * uiTextScale
+	cellHeight := bitfont.CellHeight * uiTextScale
+	for _, t := range a.textQueue {
+		x := t.x
+		for i := 0; i < len(t.text); i++ {
+			u0, v0, u1, v1 := bitfont.GlyphUV(t.text[i])
+			a.uiVertices = appendUIQuad(a.uiVertices, x, t.y, x+cellWidth, t.y+cellHeight, u0, v0, u1, v1, t.color, true)
+			x += cellWidth
+		}
+	}
+	a.textQueue = a.textQueue[:0]
+	if len(a.uiVertices) == 0 {
+		return
+	}
 
-	gl.Uniform4fv(a.uiColorUniform, 1, &color[0])
-	gl.DrawElements(gl.TRIANGLES, int32(len(indices)), gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
+	gl.UseProgram(a.uiProgram)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, a.fontTexture)
+	gl.BindVertexArray(a.uiVAO)
+	gl.BindBuffer(gl.ARRAY_BUFFER, a.uiVBO)
+
+	// Re-specifying the storage lets the driver hand out fresh memory instead of waiting for
+	// the last flush's draw to finish reading it. It only grows, doubling when it is too small.
+	size := len(a.uiVertices) * 4
+	for a.uiBufferSize < size {
+		a.uiBufferSize *= 2
+	}
+	gl.BufferData(gl.ARRAY_BUFFER, a.uiBufferSize, nil, gl.STREAM_DRAW)
+	gl.BufferSubData(gl.ARRAY_BUFFER, 0, size, gl.Ptr(a.uiVertices))
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(a.uiVertices)/uiVertexFloats))
 
 	gl.BindVertexArray(0)
-	gl.DeleteBuffers(1, &vbo)
-	gl.DeleteBuffers(1, &ebo)
-	gl.DeleteVertexArrays(1, &vao)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	a.uiVertices = a.uiVertices[:0]
 }
 
-// drawTextOverlay is a placeholder for text rendering.
-// Full text rendering in OpenGL is complex and would require font loading,
-// texture atlases, and a more sophisticated rendering pipeline.
-// For this example, we'll just log the text or visually represent it.
-// In a real application, you'd use a library like freetype-go or implement your own.
-func (a *AppCore) drawTextOverlay(x, y float32, text string, color mgl32.Vec4) {
-	// For now, we'll just log the text to the console.
-	// In a full implementation, this would draw actual text on screen.
-	// log.Printf("UI Text: %s at (%.1f, %.1f)", text, x, y) // Uncomment for debug logging
-	// To actually render text, you'd need:
-	// 1. A font texture atlas.
-	// 2. A separate VAO/VBO for text quads.
-	// 3. A shader that samples from the font texture.
-	// This is a significant additional task.
+// modelMatrix returns the object's local-to-world transform: translate, rotate (Z, Y, X), then scale.
+func (obj *GameObject) modelMatrix() mgl32.Mat4 {
+	model := mgl32.Ident4()
+	model = model.Mul4(mgl32.Translate3D(obj.Position.X(), obj.Position.Y(), obj.Position.Z()))
+	// Apply rotations in ZYX order for more intuitive Euler angles
+	model = model.Mul4(mgl32.HomogRotate3DZ(obj.Rotation.Z()))
+	model = model.Mul4(mgl32.HomogRotate3DY(obj.Rotation.Y()))
+	model = model.Mul4(mgl32.HomogRotate3DX(obj.Rotation.X()))
+	model = model.Mul4(mgl32.Scale3D(obj.Scale.X(), obj.Scale.Y(), obj.Scale.Z()))
+	return model
 }
 
-
 // drawGameObject draws a given GameObject.
 func (a *AppCore) drawGameObject(obj *GameObject) {
 	// Set hasTexture uniform based on the object's property
@@ -692,26 +1265,45 @@ func (a *AppCore) drawGameObject(obj *GameObject) {
 	} else {
 		gl.Uniform1i(a.hasTextureUniform, 0) // 0 for false
 	}
+	if obj.NormalMapID != 0 {
+		gl.Uniform1i(a.hasNormalMapUniform, 1)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, obj.NormalMapID)
+		gl.ActiveTexture(gl.TEXTURE0)
+	} else {
+		gl.Uniform1i(a.hasNormalMapUniform, 0)
+	}
 
-	model := mgl32.Ident4()
-	model = model.Mul4(mgl32.Translate3D(obj.Position.X(), obj.Position.Y(), obj.Position.Z()))
-	// Apply rotations in ZYX order for more intuitive Euler angles
-	model = model.Mul4(mgl32.HomogRotate3DZ(obj.Rotation.Z()))
-	model = model.Mul4(mgl32.HomogRotate3DY(obj.Rotation.Y()))
-	model = model.Mul4(mgl32.HomogRotate3DX(obj.Rotation.X()))
-	model = model.Mul4(mgl32.Scale3D(obj.Scale.X(), obj.Scale.Y(), obj.Scale.Z()))
-
+	model := obj.modelMatrix()
 	gl.UniformMatrix4fv(a.modelUniform, 1, false, &model[0])
+	normalMatrix := model.Mat3().Inv().Transpose()
+	gl.UniformMatrix3fv(a.normalMatrixUniform, 1, false, &normalMatrix[0])
+
+	// Point lights are drawn as a flat marker in their own color
+	if obj.Light != nil {
+		gl.Uniform1i(a.unlitUniform, 1)
+		gl.Uniform3fv(a.unlitColorUniform, 1, &obj.Light.Color[0])
+	} else {
+		gl.Uniform1i(a.unlitUniform, 0)
+	}
+	gl.Uniform3fv(a.falseColorUniform, 1, &obj.viewColor[0])
+	a.setAlphaState(obj)
 
 	gl.BindVertexArray(obj.VAO)
-	// Vertex stride is 8*4 bytes (3 pos + 3 color + 2 texcoord)
+	// The pointers below read from the bound ARRAY_BUFFER, and the UI batch leaves its own VBO bound
+	gl.BindBuffer(gl.ARRAY_BUFFER, obj.VBO)
+	// Vertex stride is 15*4 bytes (3 pos + 3 color + 2 texcoord + 3 normal + 4 tangent)
 	// Ensure attributes are correctly re-enabled/set for each object if they vary
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 8*4, gl.Ptr(nil)) // Position
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, vertexFloats*4, gl.Ptr(nil)) // Position
 	gl.EnableVertexAttribArray(0)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(3*4)) // Color
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(3*4)) // Color
 	gl.EnableVertexAttribArray(1)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 8*4, gl.PtrOffset(6*4)) // TexCoord
+	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(6*4)) // TexCoord
 	gl.EnableVertexAttribArray(2)
+	gl.VertexAttribPointer(3, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(8*4)) // Normal
+	gl.EnableVertexAttribArray(3)
+	gl.VertexAttribPointer(4, 4, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(11*4)) // Tangent
+	gl.EnableVertexAttribArray(4)
 
 	gl.DrawElements(gl.TRIANGLES, obj.IndicesCount, gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
 	gl.BindVertexArray(0)
@@ -741,16 +1333,17 @@ func shutdownApp() {
 
 	// Delete all objects' buffers
 	for _, obj := range app.objects {
-		gl.DeleteVertexArrays(1, &obj.VAO)
-		gl.DeleteBuffers(1, &obj.VBO)
-		gl.DeleteBuffers(1, &obj.EBO)
-		if obj.TextureID != 0 {
-			gl.DeleteTextures(1, &obj.TextureID)
-		}
+		deleteGameObject(obj)
 	}
 
 	gl.DeleteProgram(app.program) // 3D scene program
 	gl.DeleteProgram(app.uiProgram) // 2D UI program
+	if app.post != nil {
+		app.post.Release()
+	}
+	gl.DeleteTextures(1, &app.fontTexture)
+	gl.DeleteVertexArrays(1, &app.uiVAO)
+	gl.DeleteBuffers(1, &app.uiVBO)
 
 	if app.window != nil {
 		app.window.Destroy()
@@ -760,145 +1353,147 @@ func shutdownApp() {
 
 // --- Helper functions for .holym model loading and texture creation ---
 
-// parseHolym reads a .holym file and returns parsed data.
-// Format: v X Y Z [c R G B], vt U V, f V1/VT1 V2/VT2 V3/VT3, tex_path <path>
-func parseHolym(filePath string) ([]float32, []uint32, bool, string, error) {
-	file, err := os.Open(filePath)
+// promptExport asks for a file path on stdin and exports the objects to it.
+// .gltf and .glb paths get a glTF scene, anything else a .holym model.
+func (a *AppCore) promptExport(objects []*GameObject) {
+	log.Print("Enter path to export to, .holym, .gltf or .glb (e.g., models/my_model.holym): ")
+	reader := bufio.NewReader(os.Stdin)
+	inputPath, _ := reader.ReadString('\n')
+	inputPath = strings.TrimSpace(inputPath)
+
+	if inputPath == "" {
+		log.Println("No path entered.")
+		return
+	}
+	export := exportHolym
+	if ext := strings.ToLower(filepath.Ext(inputPath)); ext == ".gltf" || ext == ".glb" {
+		export = exportGLTF
+	}
+	if err := export(inputPath, objects); err != nil {
+		log.Printf("Error exporting model to %s: %v", inputPath, err)
+	} else {
+		log.Printf("Exported %d object(s) to %s", len(objects), inputPath)
+	}
+}
+
+// exportHolym writes the objects to a single .holym file with their Position/Rotation/Scale
+// baked into the vertex positions, in the format holym.ParseFile reads.
+// Every vertex gets its own v (with color), vt and vn line, written in the order the faces first
+// use them, so holym.ParseFile gives back exactly the same vertex and index data. Tangents aren't
+// part of the format, they are generated again on import.
+// Point lights have nothing to export and are left out.
+func exportHolym(filePath string, objects []*GameObject) error {
+	file, err := os.Create(filePath)
 	if err != nil {
-		return nil, nil, false, "", fmt.Errorf("failed to open .holym file: %w", err)
+		return fmt.Errorf("failed to create .holym file: %w", err)
 	}
 	defer file.Close()
 
-	var positions []mgl32.Vec3
-	var colors []mgl32.Vec3
-	var texCoords []mgl32.Vec2
-	var faces [][3]struct{ Vertex, TexCoord int } // Store 0-based indices for vertex/texcoord
-	var texturePath string
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-		if len(fields) == 0 {
+	// A .holym file has a single texture and normal map
+	texturePath, normalMap := "", ""
+	for _, obj := range objects {
+		if obj.NormalMap != "" {
+			if normalMap == "" {
+				normalMap = obj.NormalMap
+			} else if obj.NormalMap != normalMap {
+				log.Printf("Warning: %s uses normal map %s, but the exported model can only reference %s", obj.ID, obj.NormalMap, normalMap)
+			}
+		}
+		if !obj.HasTexture || obj.TexturePath == "" {
 			continue
 		}
-
-		switch fields[0] {
-		case "v": // Vertex position and optional color
-			if len(fields) < 4 { continue }
-			x, _ := strconv.ParseFloat(fields[1], 32)
-			y, _ := strconv.ParseFloat(fields[2], 32)
-			z, _ := strconv.ParseFloat(fields[3], 32)
-			positions = append(positions, mgl32.Vec3{float32(x), float32(y), float32(z)})
-
-			// Check for optional color
-			if len(fields) == 7 && fields[4] == "c" {
-				r, _ := strconv.ParseFloat(fields[5], 32)
-				g, _ := strconv.ParseFloat(fields[6], 32)
-				b, _ := strconv.ParseFloat(fields[7], 32)
-				colors = append(colors, mgl32.Vec3{float32(r), float32(g), float32(b)})
-			} else {
-				colors = append(colors, mgl32.Vec3{1.0, 1.0, 1.0}) // Default white
-			}
-
-		case "vt": // Texture coordinate
-			if len(fields) < 3 { continue }
-			u, _ := strconv.ParseFloat(fields[1], 32)
-			v, _ := strconv.ParseFloat(fields[2], 32)
-			texCoords = append(texCoords, mgl32.Vec2{float32(u), float32(v)})
-
-		case "f": // Face (triangle)
-			if len(fields) < 4 { continue } // Expecting 3 vertex/texcoord pairs for a triangle
-			var face [3]struct{ Vertex, TexCoord int }
-			for i := 0; i < 3; i++ {
-				parts := strings.Split(fields[i+1], "/")
-				if len(parts) != 2 {
-					return nil, nil, false, "", fmt.Errorf("invalid face format: %s (expected V/VT)", fields[i+1])
-				}
-				vIdx, _ := strconv.Atoi(parts[0])
-				vtIdx, _ := strconv.Atoi(parts[1])
-				face[i].Vertex = vIdx - 1   // Convert to 0-based index
-				face[i].TexCoord = vtIdx - 1 // Convert to 0-based index
-			}
-			faces = append(faces, face)
-		case "tex_path": // Texture path
-			if len(fields) < 2 { continue }
-			texturePath = fields[1]
+		if texturePath == "" {
+			texturePath = obj.TexturePath
+		} else if obj.TexturePath != texturePath {
+			log.Printf("Warning: %s uses texture %s, but the exported model can only reference %s", obj.ID, obj.TexturePath, texturePath)
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, nil, false, "", fmt.Errorf("error scanning .holym file: %w", err)
-	}
 
-	// Removed `interleavedVertices` as it was declared but not used.
-	uniqueVertices := make([]float32, 0) // Stores interleaved unique vertex data
-	var indices []uint32
-
-	// For faces, we need to map V/VT to actual interleaved vertex data.
-	// We'll create a map to store unique vertex combinations (pos+color+texcoord)
-	// to allow for efficient reuse and proper EBO.
-	type VertexKey struct {
-		PosIndex int
-		ColorIndex int // Assuming color is also indexed by position index for simplicity
-		TexCoordIndex int
+	w := bufio.NewWriter(file)
+	fmt.Fprintf(w, "holym %d\n", holym.Version)
+	fmt.Fprintln(w, "# Exported from Holy Model Maker")
+	if texturePath != "" {
+		fmt.Fprintf(w, "tex_path %s\n", texturePath)
+	}
+	if normalMap != "" {
+		fmt.Fprintf(w, "norm_path %s\n", normalMap)
 	}
-	vertexMap := make(map[VertexKey]uint32)
 
+	formatFloat := func(f float32) string {
+		return strconv.FormatFloat(float64(f), 'g', -1, 32) // Shortest text that parses back to the same float32
+	}
 
-	for _, face := range faces {
-		for i := 0; i < 3; i++ {
-			vIdx := face[i].Vertex
-			vtIdx := face[i].TexCoord
+	written := 0 // Vertices written so far, .holym indices are 1-based across the whole file
+	for _, obj := range objects {
+		if obj.Light != nil {
+			continue
+		}
+		if len(obj.Indices)%3 != 0 {
+			return fmt.Errorf("object %s has %d indices, not a multiple of 3", obj.ID, len(obj.Indices))
+		}
+		model := obj.modelMatrix()
+		normalMatrix := model.Mat3().Inv().Transpose() // Keeps normals right under non-uniform scale
+		// A mirroring transform turns the triangles inside out, so their winding is flipped back
+		corners := [3]int{0, 1, 2}
+		if model.Mat3().Det() < 0 {
+			corners = [3]int{0, 2, 1}
+		}
 
-			if vIdx < 0 || vIdx >= len(positions) {
-				return nil, nil, false, "", fmt.Errorf("vertex index out of bounds: %d", vIdx+1)
-			}
-			if vtIdx < 0 || vtIdx >= len(texCoords) {
-				return nil, nil, false, "", fmt.Errorf("texture coordinate index out of bounds: %d", vtIdx+1)
-			}
-			if vIdx >= len(colors) { // Ensure color exists for vertex
-				log.Printf("Warning: No color specified for vertex %d, defaulting to white.", vIdx+1)
-				colors[vIdx] = mgl32.Vec3{1,1,1} // Ensure there's a color
+		fmt.Fprintf(w, "# %s\n", obj.ID)
+		fileIndex := make(map[uint32]int) // Object vertex index -> index in the file
+		var faces [][3]int
+		for t := 0; t+2 < len(obj.Indices); t += 3 {
+			var face [3]int
+			for k, corner := range corners {
+				vi := obj.Indices[t+corner]
+				if int(vi)*vertexFloats+vertexFloats > len(obj.Vertices) {
+					return fmt.Errorf("object %s: index %d is out of range", obj.ID, vi)
+				}
+				index, ok := fileIndex[vi]
+				if !ok {
+					v := obj.Vertices[vi*vertexFloats : vi*vertexFloats+vertexFloats]
+					pos := mgl32.TransformCoordinate(mgl32.Vec3{v[0], v[1], v[2]}, model)
+					normal := normalMatrix.Mul3x1(mgl32.Vec3{v[8], v[9], v[10]})
+					if normal.Len() > 0 {
+						normal = normal.Normalize()
+					}
+					fmt.Fprintf(w, "v %s %s %s c %s %s %s\n",
+						formatFloat(pos.X()), formatFloat(pos.Y()), formatFloat(pos.Z()),
+						formatFloat(v[3]), formatFloat(v[4]), formatFloat(v[5]))
+					fmt.Fprintf(w, "vt %s %s\n", formatFloat(v[6]), formatFloat(v[7]))
+					fmt.Fprintf(w, "vn %s %s %s\n", formatFloat(normal.X()), formatFloat(normal.Y()), formatFloat(normal.Z()))
+					written++
+					index = written
+					fileIndex[vi] = index
+				}
+				face[k] = index
 			}
+			faces = append(faces, face)
+		}
 
-
-			key := VertexKey{PosIndex: vIdx, ColorIndex: vIdx, TexCoordIndex: vtIdx}
-
-			if index, ok := vertexMap[key]; ok {
-				indices = append(indices, index)
-			} else {
-				// Add new unique vertex
-				newIndex := uint32(len(uniqueVertices) / 8) // 8 floats per vertex
-
-				pos := positions[vIdx]
-				color := colors[vIdx]
-				texCoord := texCoords[vtIdx]
-
-				uniqueVertices = append(uniqueVertices, pos.X(), pos.Y(), pos.Z())
-				uniqueVertices = append(uniqueVertices, color.X(), color.Y(), color.Z())
-				uniqueVertices = append(uniqueVertices, texCoord.X(), texCoord.Y())
-
-				vertexMap[key] = newIndex
-				indices = append(indices, newIndex)
-			}
+		for _, f := range faces {
+			fmt.Fprintf(w, "f %d/%d/%d %d/%d/%d %d/%d/%d\n", f[0], f[0], f[0], f[1], f[1], f[1], f[2], f[2], f[2])
 		}
 	}
 
-	hasTexture := (texturePath != "")
-	return uniqueVertices, indices, hasTexture, texturePath, nil
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write .holym file: %w", err)
+	}
+	return nil
 }
 
-// newTexture creates an OpenGL texture from an image path.
-func newTexture(imgPath string) (uint32, error) {
+// newTexture creates an OpenGL texture from an image path, and returns the alpha mode its
+// alpha channel calls for.
+func newTexture(imgPath string) (uint32, alphamode.Mode, error) {
 	file, err := os.Open(imgPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open texture file %s: %w", imgPath, err)
+		return 0, alphamode.Opaque, fmt.Errorf("failed to open texture file %s: %w", imgPath, err)
 	}
 	defer file.Close()
 
 	img, _, err := image.Decode(file)
 	if err != nil {
-		return 0, fmt.Errorf("failed to decode texture image %s: %w", imgPath, err)
+		return 0, alphamode.Opaque, fmt.Errorf("failed to decode texture image %s: %w", imgPath, err)
 	}
 
 	rgba := image.NewRGBA(img.Bounds())
@@ -918,7 +1513,7 @@ func newTexture(imgPath string) (uint32, error) {
 	gl.GenerateMipmap(gl.TEXTURE_2D)
 
 	gl.BindTexture(gl.TEXTURE_2D, 0)
-	return texture, nil
+	return texture, alphamode.ImageMode(rgba.Pix), nil
 }
 
 // createGameObject initializes OpenGL buffers for a new GameObject and adds it to the scene.
@@ -933,16 +1528,21 @@ func (a *AppCore) createGameObject(id string, vertices []float32, indices []uint
 		Scale:        mgl32.Vec3{1, 1, 1}, // Initial scale
 		HasTexture:   hasTexture,
 		TexturePath:  texturePath,
+		AlphaCutoff:  alphamode.DefaultCutoff,
+		Mass:         1.0, // Same default as holy-engine-base primitives
 	}
+	bounds := meshBounds(vertices)
+	newObj.center = bounds.Min.Add(bounds.Max).Mul(0.5)
 
 	// Load texture if path is provided
 	if newObj.HasTexture && newObj.TexturePath != "" {
-		texID, err := newTexture(newObj.TexturePath)
+		texID, alphaMode, err := newTexture(newObj.TexturePath)
 		if err != nil {
 			log.Printf("Warning: Failed to load texture %s for model %s: %v", newObj.TexturePath, newObj.ID, err)
 			newObj.HasTexture = false // Fallback to vertex colors
 		} else {
 			newObj.TextureID = texID
+			newObj.AlphaMode = alphaMode // Cut-out and see-through textures are drawn as such
 		}
 	}
 
@@ -959,14 +1559,20 @@ func (a *AppCore) createGameObject(id string, vertices []float32, indices []uint
 	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(newObj.Indices)*4, gl.Ptr(newObj.Indices), gl.STATIC_DRAW)
 
 	// Position attribute (layout location 0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 8*4, gl.Ptr(nil)) // 3 pos + 3 color + 2 texcoord
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, vertexFloats*4, gl.Ptr(nil)) // 3 pos + 3 color + 2 texcoord + 3 normal + 4 tangent
 	gl.EnableVertexAttribArray(0)
 	// Color attribute (layout location 1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 8*4, gl.PtrOffset(3*4))
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(3*4))
 	gl.EnableVertexAttribArray(1)
 	// Texture coordinate attribute (layout location 2)
-	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, 8*4, gl.PtrOffset(6*4))
+	gl.VertexAttribPointer(2, 2, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(6*4))
 	gl.EnableVertexAttribArray(2)
+	// Normal attribute (layout location 3)
+	gl.VertexAttribPointer(3, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(8*4))
+	gl.EnableVertexAttribArray(3)
+	// Tangent attribute (layout location 4)
+	gl.VertexAttribPointer(4, 4, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(11*4))
+	gl.EnableVertexAttribArray(4)
 
 	gl.BindVertexArray(0) // Unbind VAO
 
@@ -975,20 +1581,68 @@ func (a *AppCore) createGameObject(id string, vertices []float32, indices []uint
 	return newObj
 }
 
-// loadHolymModel loads a .holym model from file.
-func (a *AppCore) loadHolymModel(filePath string) error {
-	vertices, indices, hasTexture, texturePath, err := parseHolym(filePath)
+// setNormalMap loads a normal map for an object, replacing the one it has. An empty path or
+// a file that can't be loaded leaves the object without one.
+func setNormalMap(obj *GameObject, path string) {
+	if obj.NormalMapID != 0 {
+		gl.DeleteTextures(1, &obj.NormalMapID)
+		obj.NormalMapID = 0
+	}
+	obj.NormalMap = ""
+	if path == "" {
+		return
+	}
+	texID, _, err := newTexture(path)
+	if err != nil {
+		log.Printf("Warning: Failed to load normal map %s for %s: %v", path, obj.ID, err)
+		return
+	}
+	obj.NormalMapID = texID
+	obj.NormalMap = path
+}
+
+// loadHolymModel loads a .holym model from file, or a .holymb one if it has that extension.
+func (a *AppCore) loadHolymModel(filePath string) (*GameObject, error) {
+	if strings.EqualFold(filepath.Ext(filePath), ".holymb") {
+		return a.loadHolymbModel(filePath)
+	}
+	mesh, err := holym.ParseFile(filePath, a.strictHolym)
 	if err != nil {
-		return fmt.Errorf("failed to parse .holym model %s: %w", filePath, err)
+		return nil, fmt.Errorf("failed to parse .holym model %s: %w", filePath, err)
 	}
 
 	id := fmt.Sprintf("%s_%d", filepath.Base(filePath), a.nextObjectID)
-	a.selectedObject = a.createGameObject(id, vertices, indices, hasTexture, texturePath)
-	return nil
+	vertices, indices := mesh.GameObjectMesh()
+	a.selectedObject = a.createGameObject(id, vertices, indices, mesh.TexturePath != "", mesh.TexturePath)
+	a.selectedObject.ModelPath = filePath
+	setNormalMap(a.selectedObject, mesh.NormalMap)
+	return a.selectedObject, nil
+}
+
+// loadHolymbModel loads a binary .holymb model. Its vertices and indices are uploaded straight
+// from the memory-mapped file, which stays mapped as long as the object exists.
+func (a *AppCore) loadHolymbModel(filePath string) (*GameObject, error) {
+	mesh, err := holym.OpenBinary(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(mesh.Indices) == 0 {
+		mesh.Close()
+		return nil, fmt.Errorf(".holymb model %s has no faces", filePath)
+	}
+
+	id := fmt.Sprintf("%s_%d", filepath.Base(filePath), a.nextObjectID)
+	texturePath := mesh.TexturePath()
+	vertices, indices := mesh.GameObjectMesh()
+	a.selectedObject = a.createGameObject(id, vertices, indices, texturePath != "", texturePath)
+	a.selectedObject.ModelPath = filePath
+	a.selectedObject.holymb = mesh
+	setNormalMap(a.selectedObject, mesh.NormalMap())
+	return a.selectedObject, nil
 }
 
 // createPrimitive generates a new primitive shape and adds it to the scene.
-func (a *AppCore) createPrimitive(shapeType string) {
+func (a *AppCore) createPrimitive(shapeType string) *GameObject {
 	var vertices []float32
 	var indices []uint32
 	var id string
@@ -1000,110 +1654,298 @@ func (a *AppCore) createPrimitive(shapeType string) {
 	case "plane":
 		id = fmt.Sprintf("Plane_%d", a.nextObjectID)
 		vertices, indices = generatePlaneData()
+	case "light":
+		id = fmt.Sprintf("PointLight_%d", a.nextObjectID)
+		vertices, indices = generateCubeData() // holy-engine-base shows lights as spheres
 	default:
 		log.Printf("Unsupported primitive type: %s", shapeType)
-		return
+		return nil
 	}
 
 	a.selectedObject = a.createGameObject(id, vertices, indices, false, "") // Primitives start untextured
+	a.selectedObject.Primitive = shapeType
+	if shapeType == "plane" {
+		// Planes are static in holy-engine-base
+		a.selectedObject.Mass = 0.0
+		a.selectedObject.IsKinematic = true
+	}
+	if shapeType == "light" {
+		// Lights stay where they are put in holy-engine-base too
+		a.selectedObject.Light = &PointLight{Color: mgl32.Vec3{1, 1, 1}, Intensity: DefaultLightIntensity, Range: DefaultLightRange}
+		a.selectedObject.Scale = mgl32.Vec3{LightMarkerScale, LightMarkerScale, LightMarkerScale}
+		a.selectedObject.Mass = 0.0
+		a.selectedObject.IsKinematic = true
+	}
 	log.Printf("Created primitive: %s", id)
+	return a.selectedObject
+}
+
+// --- Scene files ---
+
+// meshBounds returns the bounding box of interleaved vertex data.
+func meshBounds(vertices []float32) scene.Bounds {
+	if len(vertices) < vertexFloats {
+		return scene.Bounds{}
+	}
+	bounds := scene.Bounds{
+		Min: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+		Max: mgl32.Vec3{vertices[0], vertices[1], vertices[2]},
+	}
+	for i := 0; i+2 < len(vertices); i += vertexFloats {
+		for j := 0; j < 3; j++ {
+			bounds.Min[j] = float32(math.Min(float64(bounds.Min[j]), float64(vertices[i+j])))
+			bounds.Max[j] = float32(math.Max(float64(bounds.Max[j]), float64(vertices[i+j])))
+		}
+	}
+	return bounds
+}
+
+// saveScene writes every object to a scene file.
+func (a *AppCore) saveScene(filePath string) error {
+	l := a.lighting
+	file := scene.File{
+		Lighting: &scene.Lighting{SunYaw: l.SunYaw, SunPitch: l.SunPitch, SunColor: l.SunColor, SunIntensity: l.SunIntensity, Ambient: l.Ambient},
+	}
+	for _, obj := range a.objects {
+		texturePath := ""
+		if obj.HasTexture {
+			texturePath = obj.TexturePath
+		}
+		file.Objects = append(file.Objects, scene.Object{
+			ID:              obj.ID,
+			Primitive:       obj.Primitive,
+			ModelPath:       obj.ModelPath,
+			TexturePath:     texturePath,
+			NormalMap:       obj.NormalMap,
+			Color:           obj.Color,
+			AlphaMode:       obj.AlphaMode.String(),
+			AlphaCutoff:     obj.AlphaCutoff,
+			AlphaToCoverage: obj.AlphaToCoverage,
+			Position:        obj.Position,
+			Rotation:        obj.Rotation,
+			Scale:           obj.Scale,
+			Mass:            obj.Mass,
+			IsKinematic:     obj.IsKinematic,
+			BoundingBox:     meshBounds(obj.Vertices),
+		})
+		if obj.Light != nil {
+			file.Objects[len(file.Objects)-1].Light = &scene.Light{Color: obj.Light.Color, Intensity: obj.Light.Intensity, Range: obj.Light.Range}
+		}
+	}
+	return scene.Save(filePath, &file)
+}
+
+// loadScene replaces every object with the objects in a scene file.
+// Objects whose mesh can't be rebuilt are skipped with a warning.
+func (a *AppCore) loadScene(filePath string) error {
+	file, err := scene.Load(filePath)
+	if err != nil {
+		return err
+	}
+
+	for _, obj := range a.objects {
+		deleteGameObject(obj)
+	}
+	a.objects = a.objects[:0]
+	a.selectedObject = nil
+
+	a.lighting = defaultLighting()
+	if l := file.Lighting; l != nil {
+		a.lighting = Lighting{SunYaw: l.SunYaw, SunPitch: l.SunPitch, SunColor: l.SunColor, SunIntensity: l.SunIntensity, Ambient: l.Ambient}
+	}
+
+	for _, so := range file.Objects {
+		var obj *GameObject
+		switch {
+		case so.Primitive != "":
+			obj = a.createPrimitive(so.Primitive)
+			if obj == nil {
+				log.Printf("Warning: Skipping scene object %s: unknown primitive %q", so.ID, so.Primitive)
+				continue
+			}
+		case so.ModelPath != "":
+			obj, err = a.loadHolymModel(so.ModelPath)
+			if err != nil {
+				log.Printf("Warning: Skipping scene object %s: %v", so.ID, err)
+				continue
+			}
+		default:
+			log.Printf("Warning: Skipping scene object %s: it has neither a primitive nor a model path", so.ID)
+			continue
+		}
+
+		obj.ID = so.ID
+		obj.Position = so.Position
+		obj.Rotation = so.Rotation
+		obj.Scale = so.Scale
+		obj.Mass = so.Mass
+		obj.IsKinematic = so.IsKinematic
+		obj.Color = so.Color
+		if so.Light != nil && obj.Light != nil {
+			obj.Light = &PointLight{Color: so.Light.Color, Intensity: so.Light.Intensity, Range: so.Light.Range}
+		}
+		if so.TexturePath != "" && so.TexturePath != obj.TexturePath {
+			texID, alphaMode, err := newTexture(so.TexturePath)
+			if err != nil {
+				log.Printf("Warning: Failed to load texture %s for %s: %v", so.TexturePath, so.ID, err)
+			} else {
+				if obj.TextureID != 0 {
+					gl.DeleteTextures(1, &obj.TextureID)
+				}
+				obj.TextureID = texID
+				obj.HasTexture = true
+				obj.TexturePath = so.TexturePath
+				obj.AlphaMode = alphaMode
+			}
+		}
+		if mode, ok := alphamode.Parse(so.AlphaMode); ok {
+			obj.AlphaMode = mode
+		} else if so.AlphaMode != "" {
+			log.Printf("Warning: Unknown alpha mode %q for %s, keeping %s", so.AlphaMode, so.ID, obj.AlphaMode)
+		}
+		if so.AlphaCutoff > 0 {
+			obj.AlphaCutoff = so.AlphaCutoff
+		}
+		obj.AlphaToCoverage = so.AlphaToCoverage
+		if so.NormalMap != "" && so.NormalMap != obj.NormalMap {
+			setNormalMap(obj, so.NormalMap)
+		}
+
+		// Keep generated IDs from clashing with the loaded ones (e.g. "Cube_7")
+		if n, err := strconv.Atoi(so.ID[strings.LastIndex(so.ID, "_")+1:]); err == nil && n >= a.nextObjectID {
+			a.nextObjectID = n + 1
+		}
+	}
+	a.selectedObject = nil // createPrimitive and loadHolymModel select every object they make
+	return nil
+}
+
+// deleteGameObject frees the OpenGL buffers and textures of an object.
+func deleteGameObject(obj *GameObject) {
+	gl.DeleteVertexArrays(1, &obj.VAO)
+	gl.DeleteBuffers(1, &obj.VBO)
+	gl.DeleteBuffers(1, &obj.EBO)
+	if obj.TextureID != 0 {
+		gl.DeleteTextures(1, &obj.TextureID)
+	}
+	if obj.NormalMapID != 0 {
+		gl.DeleteTextures(1, &obj.NormalMapID)
+	}
+	if obj.holymb != nil {
+		obj.holymb.Close()
+	}
 }
 
 // generateCubeData returns interleaved vertex data for a unit cube (1x1x1).
 // Each face has its own vertices to allow for distinct UVs and colors.
 func generateCubeData() ([]float32, []uint32) {
-    // Vertices: Position (3) + Color (3) + TexCoord (2) = 8 floats per vertex
-    // Face colors (just for visual distinction)
-    red := []float32{1.0, 0.0, 0.0}
-    green := []float32{0.0, 1.0, 0.0}
-    blue := []float32{0.0, 0.0, 1.0}
-    yellow := []float32{1.0, 1.0, 0.0}
-    cyan := []float32{0.0, 1.0, 1.0}
-    magenta := []float32{1.0, 0.0, 1.0}
-
-    // Standard UVs for a quad
-    uv00 := []float32{0.0, 0.0} // bottom-left
-    uv10 := []float32{1.0, 0.0} // bottom-right
-    uv11 := []float32{1.0, 1.0} // top-right
-    uv01 := []float32{0.0, 1.0} // top-left
-
-    vertices := []float32{
-        // Front face (Red)
-        -0.5, -0.5, 0.5,  red[0], red[1], red[2],  uv00[0], uv00[1], // 0
-         0.5, -0.5, 0.5,  red[0], red[1], red[2],  uv10[0], uv10[1], // 1
-         0.5,  0.5, 0.5,  red[0], red[1], red[2],  uv11[0], uv11[1], // 2
-        -0.5,  0.5, 0.5,  red[0], red[1], red[2],  uv01[0], uv01[1], // 3
-
-        // Back face (Green)
-        -0.5, -0.5, -0.5, green[0], green[1], green[2], uv10[0], uv10[1], // 4
-         0.5, -0.5, -0.5, green[0], green[1], green[2], uv00[0], uv00[1], // 5
-         0.5,  0.5, -0.5, green[0], green[1], green[2], uv01[0], uv01[1], // 6
-        -0.5,  0.5, -0.5, green[0], green[1], green[2], uv11[0], uv11[1], // 7
-
-        // Top face (Blue)
-        -0.5,  0.5,  0.5, blue[0], blue[1], blue[2], uv00[0], uv00[1], // 8 (use existing 3)
-         0.5,  0.5,  0.5, blue[0], blue[1], blue[2], uv10[0], uv10[1], // 9 (use existing 2)
-         0.5,  0.5, -0.5, blue[0], blue[1], blue[2], uv11[0], uv11[1], // 10 (use existing 6)
-        -0.5,  0.5, -0.5, blue[0], blue[1], blue[2], uv01[0], uv01[1], // 11 (use existing 7)
-
-
-        // Bottom face (Yellow)
-        -0.5, -0.5,  0.5, yellow[0], yellow[1], yellow[2], uv01[0], uv01[1], // 12 (use existing 0)
-         0.5, -0.5,  0.5, yellow[0], yellow[1], yellow[2], uv11[0], uv11[1], // 13 (use existing 1)
-         0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv10[0], uv10[1], // 14 (use existing 5)
-        -0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv00[0], uv00[1], // 15 (use existing 4)
-
-
-        // Right face (Cyan)
-         0.5, -0.5,  0.5, cyan[0], cyan[1], cyan[2], uv00[0], uv00[1], // 16 (use existing 1)
-         0.5, -0.5, -0.5, cyan[0], cyan[1], cyan[2], uv10[0], uv10[1], // 17 (use existing 5)
-         0.5,  0.5, -0.5, cyan[0], cyan[1], cyan[2], uv11[0], uv11[1], // 18 (use existing 6)
-         0.5,  0.5,  0.5, cyan[0], cyan[1], cyan[2], uv01[0], uv01[1], // 19 (use existing 2)
-
-
-        // Left face (Magenta)
-        -0.5, -0.5,  0.5, magenta[0], magenta[1], magenta[2], uv10[0], uv10[1], // 20 (use existing 0)
-        -0.5, -0.5, -0.5, magenta[0], magenta[1], magenta[2], uv00[0], uv00[1], // 21 (use existing 4)
-        -0.5,  0.5, -0.5, magenta[0], magenta[1], magenta[2], uv01[0], uv01[1], // 22 (use existing 7)
-        -0.5,  0.5,  0.5, magenta[0], magenta[1], magenta[2], uv11[0], uv11[1], // 23 (use existing 3)
-    }
-
-    indices := []uint32{
-        0, 1, 2,      0, 2, 3,    // Front
-        4, 5, 6,      4, 6, 7,    // Back
-        8, 9, 10,     8, 10, 11,   // Top
-        12, 13, 14,   12, 14, 15,  // Bottom
-        16, 17, 18,   16, 18, 19,  // Right
-        20, 21, 22,   20, 22, 23,  // Left
-    }
-
-    return vertices, indices
+	// Vertices: Position (3) + Color (3) + TexCoord (2) + Normal (3) + Tangent (4) = 15 floats per vertex
+	// Face colors (just for visual distinction)
+	red := []float32{1.0, 0.0, 0.0}
+	green := []float32{0.0, 1.0, 0.0}
+	blue := []float32{0.0, 0.0, 1.0}
+	yellow := []float32{1.0, 1.0, 0.0}
+	cyan := []float32{0.0, 1.0, 1.0}
+	magenta := []float32{1.0, 0.0, 1.0}
+
+	// Standard UVs for a quad
+	uv00 := []float32{0.0, 0.0} // bottom-left
+	uv10 := []float32{1.0, 0.0} // bottom-right
+	uv11 := []float32{1.0, 1.0} // top-right
+	uv01 := []float32{0.0, 1.0} // top-left
+
+	// Face normals, pointing out of the cube
+	front := []float32{0.0, 0.0, 1.0}
+	back := []float32{0.0, 0.0, -1.0}
+	up := []float32{0.0, 1.0, 0.0}
+	down := []float32{0.0, -1.0, 0.0}
+	right := []float32{1.0, 0.0, 0.0}
+	left := []float32{-1.0, 0.0, 0.0}
+
+	// Face tangents, along the direction U grows in. V grows up the faces while images are
+	// uploaded top row first, so the image's top is -V and w is -1 on every face
+	frontT := []float32{1.0, 0.0, 0.0, -1.0}
+	backT := []float32{-1.0, 0.0, 0.0, -1.0}
+	upT := []float32{1.0, 0.0, 0.0, -1.0}
+	downT := []float32{1.0, 0.0, 0.0, -1.0}
+	rightT := []float32{0.0, 0.0, -1.0, -1.0}
+	leftT := []float32{0.0, 0.0, 1.0, -1.0}
+
+	vertices := []float32{
+		// Front face (Red)
+		-0.5, -0.5, 0.5, red[0], red[1], red[2], uv00[0], uv00[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 0
+		0.5, -0.5, 0.5, red[0], red[1], red[2], uv10[0], uv10[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 1
+		0.5, 0.5, 0.5, red[0], red[1], red[2], uv11[0], uv11[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 2
+		-0.5, 0.5, 0.5, red[0], red[1], red[2], uv01[0], uv01[1], front[0], front[1], front[2], frontT[0], frontT[1], frontT[2], frontT[3], // 3
+
+		// Back face (Green)
+		-0.5, -0.5, -0.5, green[0], green[1], green[2], uv10[0], uv10[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 4
+		0.5, -0.5, -0.5, green[0], green[1], green[2], uv00[0], uv00[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 5
+		0.5, 0.5, -0.5, green[0], green[1], green[2], uv01[0], uv01[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 6
+		-0.5, 0.5, -0.5, green[0], green[1], green[2], uv11[0], uv11[1], back[0], back[1], back[2], backT[0], backT[1], backT[2], backT[3], // 7
+
+		// Top face (Blue)
+		-0.5, 0.5, 0.5, blue[0], blue[1], blue[2], uv00[0], uv00[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 8 (use existing 3)
+		0.5, 0.5, 0.5, blue[0], blue[1], blue[2], uv10[0], uv10[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 9 (use existing 2)
+		0.5, 0.5, -0.5, blue[0], blue[1], blue[2], uv11[0], uv11[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 10 (use existing 6)
+		-0.5, 0.5, -0.5, blue[0], blue[1], blue[2], uv01[0], uv01[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // 11 (use existing 7)
+
+		// Bottom face (Yellow)
+		-0.5, -0.5, 0.5, yellow[0], yellow[1], yellow[2], uv01[0], uv01[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 12 (use existing 0)
+		0.5, -0.5, 0.5, yellow[0], yellow[1], yellow[2], uv11[0], uv11[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 13 (use existing 1)
+		0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv10[0], uv10[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 14 (use existing 5)
+		-0.5, -0.5, -0.5, yellow[0], yellow[1], yellow[2], uv00[0], uv00[1], down[0], down[1], down[2], downT[0], downT[1], downT[2], downT[3], // 15 (use existing 4)
+
+		// Right face (Cyan)
+		0.5, -0.5, 0.5, cyan[0], cyan[1], cyan[2], uv00[0], uv00[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 16 (use existing 1)
+		0.5, -0.5, -0.5, cyan[0], cyan[1], cyan[2], uv10[0], uv10[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 17 (use existing 5)
+		0.5, 0.5, -0.5, cyan[0], cyan[1], cyan[2], uv11[0], uv11[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 18 (use existing 6)
+		0.5, 0.5, 0.5, cyan[0], cyan[1], cyan[2], uv01[0], uv01[1], right[0], right[1], right[2], rightT[0], rightT[1], rightT[2], rightT[3], // 19 (use existing 2)
+
+		// Left face (Magenta)
+		-0.5, -0.5, 0.5, magenta[0], magenta[1], magenta[2], uv10[0], uv10[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 20 (use existing 0)
+		-0.5, -0.5, -0.5, magenta[0], magenta[1], magenta[2], uv00[0], uv00[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 21 (use existing 4)
+		-0.5, 0.5, -0.5, magenta[0], magenta[1], magenta[2], uv01[0], uv01[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 22 (use existing 7)
+		-0.5, 0.5, 0.5, magenta[0], magenta[1], magenta[2], uv11[0], uv11[1], left[0], left[1], left[2], leftT[0], leftT[1], leftT[2], leftT[3], // 23 (use existing 3)
+	}
+
+	indices := []uint32{
+		0, 1, 2, 0, 2, 3, // Front
+		4, 5, 6, 4, 6, 7, // Back
+		8, 9, 10, 8, 10, 11, // Top
+		12, 13, 14, 12, 14, 15, // Bottom
+		16, 17, 18, 16, 18, 19, // Right
+		20, 21, 22, 20, 22, 23, // Left
+	}
+
+	return vertices, indices
 }
 
 // generatePlaneData returns interleaved vertex data for a unit plane (1x1).
 func generatePlaneData() ([]float32, []uint32) {
-    // Vertices: Position (3) + Color (3) + TexCoord (2) = 8 floats per vertex
-    white := []float32{1.0, 1.0, 1.0}
-    uv00 := []float32{0.0, 0.0}
-    uv10 := []float32{1.0, 0.0}
-    uv11 := []float32{1.0, 1.0}
-    uv01 := []float32{0.0, 1.0}
-
-    vertices := []float32{
-        // Front face of plane (facing +Z)
-        -0.5, 0.0,  0.5, white[0], white[1], white[2], uv00[0], uv00[1], // bottom-left
-         0.5, 0.0,  0.5, white[0], white[1], white[2], uv10[0], uv10[1], // bottom-right
-         0.5, 0.0, -0.5, white[0], white[1], white[2], uv11[0], uv11[1], // top-right
-        -0.5, 0.0, -0.5, white[0], white[1], white[2], uv01[0], uv01[1], // top-left
-    }
-
-    indices := []uint32{
-        0, 1, 2, // Triangle 1
-        0, 2, 3, // Triangle 2
-    }
-    return vertices, indices
-}
+	// Vertices: Position (3) + Color (3) + TexCoord (2) + Normal (3) + Tangent (4) = 15 floats per vertex
+	white := []float32{1.0, 1.0, 1.0}
+	up := []float32{0.0, 1.0, 0.0}        // The plane faces +Y
+	upT := []float32{1.0, 0.0, 0.0, -1.0} // U grows along +X, w as on the cube
+	uv00 := []float32{0.0, 0.0}
+	uv10 := []float32{1.0, 0.0}
+	uv11 := []float32{1.0, 1.0}
+	uv01 := []float32{0.0, 1.0}
 
+	vertices := []float32{
+		// Front face of plane (facing +Z)
+		-0.5, 0.0, 0.5, white[0], white[1], white[2], uv00[0], uv00[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // bottom-left
+		0.5, 0.0, 0.5, white[0], white[1], white[2], uv10[0], uv10[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // bottom-right
+		0.5, 0.0, -0.5, white[0], white[1], white[2], uv11[0], uv11[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // top-right
+		-0.5, 0.0, -0.5, white[0], white[1], white[2], uv01[0], uv01[1], up[0], up[1], up[2], upT[0], upT[1], upT[2], upT[3], // top-left
+	}
+
+	indices := []uint32{
+		0, 1, 2, // Triangle 1
+		0, 2, 3, // Triangle 2
+	}
+	return vertices, indices
+}
 
 // --- Helper functions for shader compilation ---
 
@@ -1179,6 +2021,24 @@ func (a *AppCore) shouldClose() bool {
 
 // main orchestrates the application flow.
 func main() {
+	scenePath := flag.String("scene", "", "scene file to open at startup")
+	strictHolym := flag.Bool("strict", false, "reject malformed .holym and .obj files instead of skipping bad lines")
+	convertPath := flag.String("convert", "", "convert a .holym or .obj model to .holymb and exit")
+	outputPath := flag.String("o", "", "output file for -convert (default: the input with a .holymb extension)")
+	flag.Parse()
+
+	// Conversion doesn't need a window
+	if *convertPath != "" {
+		out := *outputPath
+		if out == "" {
+			out = strings.TrimSuffix(*convertPath, filepath.Ext(*convertPath)) + ".holymb"
+		}
+		if err := convertToHolymb(*convertPath, out, *strictHolym); err != nil {
+			log.Fatalf("Conversion failed: %v", err)
+		}
+		return
+	}
+
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 	defer shutdownApp()
@@ -1186,13 +2046,21 @@ func main() {
 	if err := initApp(); err != nil {
 		log.Fatalf("Application initialization failed: %v", err)
 	}
+	app.strictHolym = *strictHolym
+
+	if *scenePath != "" {
+		if err := app.loadScene(*scenePath); err != nil {
+			log.Fatalf("Failed to open scene: %v", err)
+		}
+		log.Printf("Loaded scene from %s", *scenePath)
+	}
 
 	log.Println("Holy Model Maker (Editor) initialized. Starting main loop...")
 	log.Println("Controls:")
 	log.Println("  WASD: Move camera")
 	log.Println("  Right-click + Drag: Look around")
 	log.Println("  Left-click: Cycle through objects (outside UI)")
-	log.Println("  Use UI panels to Load Models, Create Primitives, and Transform Selected Objects.")
+	log.Println("  Use UI panels to Load and Export Models, Create Primitives, and Transform Selected Objects.")
 	log.Println("  ESC: Exit")
 
 	// Main Editor Loop
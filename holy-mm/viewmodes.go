@@ -0,0 +1,15 @@
+package main
+
+import (
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// drawWireframe draws the edges of every object over what renderScene has drawn.
+// The view modes themselves are in holy-shared/viewmode.
+func (a *AppCore) drawWireframe() {
+	viewmode.BeginWireframe(a.wireframeUniform)
+	for _, obj := range a.objects {
+		a.drawGameObject(obj)
+	}
+	viewmode.EndWireframe(a.wireframeUniform)
+}
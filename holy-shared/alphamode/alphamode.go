@@ -0,0 +1,142 @@
+// Package alphamode holds the transparency settings of holy-engine-base, holy-mm and
+// holy-spinning-models. Every object or material has an alpha mode, as glTF materials do.
+// Opaque ones ignore their alpha, masked ones are cut out where it falls below a cutoff,
+// and blended ones are drawn after everything else, back to front, over what is already on
+// screen. Scene files store the mode by its name.
+//
+// The shader side is ShaderUniforms and ShaderFunctions, pasted into each program's
+// fragment shader. What the alpha is made of (texture, tint, material color) is left to the
+// programs.
+package alphamode
+
+import (
+	"sort"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Mode is how an object's alpha is used. Its value is the shader's alphaMode uniform.
+type Mode int
+
+const (
+	Opaque Mode = iota // Alpha is ignored
+	Mask               // Cut out below the cutoff, for hair cards, leaves and fences
+	Blend              // Blended over what is behind, sorted back to front, for glass and soft edges
+	Count
+)
+
+// DefaultCutoff is the cutoff of masked objects unless they set their own, as in glTF.
+const DefaultCutoff float32 = 0.5
+
+// Names are the names used in scene files and on the properties panels.
+var Names = [Count]string{"opaque", "mask", "blend"}
+
+func (mode Mode) String() string {
+	if mode < 0 || mode >= Count {
+		return "opaque"
+	}
+	return Names[mode]
+}
+
+// Parse returns the alpha mode with the given name, and false for unknown names.
+func Parse(name string) (Mode, bool) {
+	for i, modeName := range Names {
+		if name == modeName {
+			return Mode(i), true
+		}
+	}
+	return Opaque, false
+}
+
+// ImageMode picks the alpha mode an RGBA image needs: opaque when every pixel is, mask
+// when the alpha is only ever fully on or off, blend otherwise.
+func ImageMode(pix []uint8) Mode {
+	mode := Opaque
+	for i := 3; i < len(pix); i += 4 {
+		switch pix[i] {
+		case 255:
+		case 0:
+			mode = Mask
+		default:
+			return Blend
+		}
+	}
+	return mode
+}
+
+// BackToFront returns the order to draw blended objects in: indices into centers from the
+// farthest to the nearest to cameraPos, so each is blended over the ones behind it. Objects
+// at the same distance keep their order.
+func BackToFront(centers []mgl32.Vec3, cameraPos mgl32.Vec3) []int {
+	order := make([]int, len(centers))
+	distances := make([]float32, len(centers))
+	for i, center := range centers {
+		order[i] = i
+		distances[i] = center.Sub(cameraPos).LenSqr()
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return distances[order[i]] > distances[order[j]]
+	})
+	return order
+}
+
+// ShaderUniforms declares what the alpha modes need in a fragment shader.
+const ShaderUniforms = `
+		uniform int alphaMode;   // 0 opaque, 1 mask, 2 blend, see holy-shared/alphamode
+		uniform float alphaCutoff;
+		uniform bool alphaToCoverage;
+`
+
+// ShaderFunctions defines applyAlphaMode, which returns the alpha a fragment is drawn with,
+// or discards it when it is masked out. It goes after ShaderUniforms.
+const ShaderFunctions = `
+		// applyAlphaMode returns the alpha the fragment is drawn with, or discards it.
+		float applyAlphaMode(float alpha) {
+			if (alphaMode == 0) {
+				return 1.0;
+			} else if (alphaMode == 1) {
+				if (alphaToCoverage) {
+					// Sharpens the alpha to a ramp about a pixel wide around the cutoff, which
+					// the coverage mask then turns into an antialiased edge
+					return clamp((alpha - alphaCutoff) / max(fwidth(alpha), 0.0001) + 0.5, 0.0, 1.0);
+				} else if (alpha < alphaCutoff) {
+					discard;
+				}
+				return 1.0;
+			}
+			return alpha;
+		}
+`
+
+// Uniforms are the locations of ShaderUniforms in a program.
+type Uniforms struct {
+	Mode            int32
+	Cutoff          int32
+	AlphaToCoverage int32
+}
+
+// Locate looks up the uniforms in a linked program.
+func Locate(program uint32) Uniforms {
+	return Uniforms{
+		Mode:            gl.GetUniformLocation(program, gl.Str("alphaMode\x00")),
+		Cutoff:          gl.GetUniformLocation(program, gl.Str("alphaCutoff\x00")),
+		AlphaToCoverage: gl.GetUniformLocation(program, gl.Str("alphaToCoverage\x00")),
+	}
+}
+
+// Set sets the uniforms and the coverage state that carry out an alpha mode, on the
+// program in use. Alpha to coverage turns alpha into the share of the pixel's samples that
+// are covered, so masked edges are antialiased by MSAA instead of stair-stepped; it only
+// applies to Mask.
+func (u Uniforms) Set(mode Mode, cutoff float32, alphaToCoverage bool) {
+	gl.Uniform1i(u.Mode, int32(mode))
+	gl.Uniform1f(u.Cutoff, cutoff)
+	if mode == Mask && alphaToCoverage {
+		gl.Uniform1i(u.AlphaToCoverage, 1)
+		gl.Enable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+	} else {
+		gl.Uniform1i(u.AlphaToCoverage, 0)
+		gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+	}
+}
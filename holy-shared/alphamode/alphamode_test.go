@@ -0,0 +1,53 @@
+package alphamode
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestParse(t *testing.T) {
+	for mode := Opaque; mode < Count; mode++ {
+		if got, ok := Parse(mode.String()); !ok || got != mode {
+			t.Errorf("Parse(%q) = %v, %t, want %v, true", mode.String(), got, ok, mode)
+		}
+	}
+	if got, ok := Parse("glass"); ok || got != Opaque {
+		t.Errorf("Parse(\"glass\") = %v, %t, want opaque, false", got, ok)
+	}
+	if got := Mode(7).String(); got != "opaque" {
+		t.Errorf("Mode(7).String() = %q, want \"opaque\"", got)
+	}
+}
+
+func TestImageMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		alphas []uint8
+		want   Mode
+	}{
+		{"no pixels", nil, Opaque},
+		{"all opaque", []uint8{255, 255, 255}, Opaque},
+		{"cut out", []uint8{255, 0, 255}, Mask},
+		{"see-through", []uint8{255, 0, 128}, Blend},
+	}
+	for _, tt := range tests {
+		pix := make([]uint8, 0, len(tt.alphas)*4)
+		for _, a := range tt.alphas {
+			pix = append(pix, 10, 20, 30, a)
+		}
+		if got := ImageMode(pix); got != tt.want {
+			t.Errorf("%s: ImageMode = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBackToFront(t *testing.T) {
+	centers := []mgl32.Vec3{{0, 0, -1}, {0, 0, -5}, {0, 0, 1}, {0, 0, -3}, {0, 0, 5}}
+	// The camera is at 1 on Z, so the fourth and fifth are both 4 away and keep their order
+	got := BackToFront(centers, mgl32.Vec3{0, 0, 1})
+	if want := []int{1, 3, 4, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("BackToFront = %v, want %v", got, want)
+	}
+}
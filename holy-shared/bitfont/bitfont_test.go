@@ -0,0 +1,76 @@
+package bitfont
+
+import "testing"
+
+func TestGlyphUV(t *testing.T) {
+	// 95 glyphs, 16 per row: a 96x48 atlas of 6 rows
+	if width, height := AtlasSize(); width != 96 || height != 48 {
+		t.Fatalf("atlas is %dx%d, want 96x48", width, height)
+	}
+	tests := []struct {
+		name  string
+		c     byte
+		cellX int
+		cellY int
+	}{
+		{"first character", ' ', 0, 0},
+		{"end of the first row", '/', 15, 0},
+		{"start of the second row", '0', 0, 1},
+		{"last character", '~', 14, 5},
+		{"below the range", '\n', 15, 1}, // Drawn as '?'
+		{"above the range", 127, 15, 1},
+		{"outside ASCII", 0xe9, 15, 1},
+	}
+	for _, tt := range tests {
+		u0, v0, u1, v1 := GlyphUV(tt.c)
+		want := [4]float32{
+			float32(tt.cellX*CellWidth) / 96, float32(tt.cellY*CellHeight) / 48,
+			float32((tt.cellX+1)*CellWidth) / 96, float32((tt.cellY+1)*CellHeight) / 48,
+		}
+		if got := [4]float32{u0, v0, u1, v1}; got != want {
+			t.Errorf("%s: GlyphUV(%q) = %v, want cell (%d, %d) at %v", tt.name, tt.c, got, tt.cellX, tt.cellY, want)
+		}
+	}
+}
+
+func TestAtlas(t *testing.T) {
+	width, height := AtlasSize()
+	pixels := Atlas()
+	if len(pixels) != width*height {
+		t.Fatalf("%d pixels, want %d", len(pixels), width*height)
+	}
+
+	// Every glyph row lands in its cell, the leftmost pixel from bit 4
+	for i, glyph := range Glyphs {
+		cellX := (i % AtlasColumns) * CellWidth
+		cellY := (i / AtlasColumns) * CellHeight
+		for y := 0; y < CellHeight; y++ {
+			for x := 0; x < CellWidth; x++ {
+				want := uint8(0)
+				if x < GlyphWidth && y < GlyphHeight && glyph[y]&(0x10>>uint(x)) != 0 {
+					want = 255
+				}
+				if got := pixels[(cellY+y)*width+cellX+x]; got != want {
+					t.Fatalf("glyph %q pixel (%d, %d) = %d, want %d", rune(FirstChar+i), x, y, got, want)
+				}
+			}
+		}
+	}
+
+	// '|' is a line down the middle column, and the spare cells after '~' stay empty
+	u0, v0, _, _ := GlyphUV('|')
+	x, y := int(u0*float32(width))+2, int(v0*float32(height))
+	for row := 0; row < GlyphHeight; row++ {
+		if pixels[(y+row)*width+x] != 255 {
+			t.Errorf("'|' row %d is not drawn", row)
+		}
+	}
+	spareX, spareY := (len(Glyphs)%AtlasColumns)*CellWidth, (len(Glyphs)/AtlasColumns)*CellHeight
+	for y := spareY; y < height; y++ {
+		for x := spareX; x < width; x++ {
+			if pixels[y*width+x] != 0 {
+				t.Errorf("pixel (%d, %d) after the last glyph is drawn", x, y)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package glshader compiles GLSL shaders into OpenGL programs.
+package glshader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+)
+
+// Compile compiles a vertex and a fragment shader and links them into a program. The
+// sources must end with a NUL byte. The OpenGL context must be current and initialized.
+func Compile(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
+	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
+	shaderSource(vertexShader, vertexShaderSource)
+	gl.CompileShader(vertexShader)
+	if err := checkShaderCompileStatus(vertexShader, "vertex"); err != nil {
+		return 0, err
+	}
+
+	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
+	shaderSource(fragmentShader, fragmentShaderSource)
+	gl.CompileShader(fragmentShader)
+	if err := checkShaderCompileStatus(fragmentShader, "fragment"); err != nil {
+		return 0, err
+	}
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+	if err := checkProgramLinkStatus(program); err != nil {
+		return 0, err
+	}
+
+	gl.DeleteShader(vertexShader)
+	gl.DeleteShader(fragmentShader)
+
+	return program, nil
+}
+
+// shaderSource passes GLSL source to OpenGL.
+func shaderSource(shader uint32, source string) {
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+}
+
+// checkShaderCompileStatus checks if a shader compiled successfully.
+func checkShaderCompileStatus(shader uint32, shaderType string) error {
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to compile %s shader:\n%v", shaderType, log)
+	}
+	return nil
+}
+
+// checkProgramLinkStatus checks if a shader program linked successfully.
+func checkProgramLinkStatus(program uint32) error {
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to link program:\n%v", log)
+	}
+	return nil
+}
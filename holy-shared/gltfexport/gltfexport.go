@@ -0,0 +1,501 @@
+// Package gltfexport writes scenes as glTF 2.0, so scenes built in holy-engine-base and
+// holy-mm can be opened in Blender and other engines.
+package gltfexport
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+)
+
+// Object is one object of the exported scene.
+type Object struct {
+	ID          string
+	Vertices    []float32 // Interleaved in holym.GameObjectLayout
+	Indices     []uint32
+	TexturePath string // Empty for vertex colors
+	NormalMap   string // Empty for none
+	Position    mgl32.Vec3
+	Rotation    mgl32.Vec3 // Euler angles in radians, applied Z, then Y, then X
+	Scale       mgl32.Vec3
+	Light       *Light // Set for point lights, which are exported without a mesh
+}
+
+// Light is a point light.
+type Light struct {
+	Color     mgl32.Vec3
+	Intensity float32
+	Range     float32
+}
+
+// vertexFloats is the number of floats per vertex in Object.Vertices.
+const vertexFloats = holym.GameObjectFloats
+
+// Only the parts of the glTF JSON schema the exporter writes are declared here.
+
+// glTF constants (they are OpenGL enum values)
+const (
+	gltfFloat              = 5126
+	gltfUnsignedInt        = 5125
+	gltfArrayBuffer        = 34962
+	gltfElementArrayBuffer = 34963
+	gltfLinear             = 9729
+	gltfLinearMipmapLinear = 9987
+	gltfRepeat             = 10497
+	glbMagic               = 0x46546C67 // "glTF"
+	glbChunkJSON           = 0x4E4F534A // "JSON"
+	glbChunkBIN            = 0x004E4942 // "BIN\0"
+	glbVersion             = 2
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+
+	// Point lights are written with KHR_lights_punctual
+	ExtensionsUsed []string                `json:"extensionsUsed,omitempty"`
+	Extensions     *gltfDocumentExtensions `json:"extensions,omitempty"`
+}
+
+type gltfDocumentExtensions struct {
+	LightsPunctual gltfLightsPunctual `json:"KHR_lights_punctual"`
+}
+
+type gltfLightsPunctual struct {
+	Lights []gltfLight `json:"lights"`
+}
+
+type gltfLight struct {
+	Name      string     `json:"name,omitempty"`
+	Type      string     `json:"type"`
+	Color     [3]float32 `json:"color"`
+	Intensity float32    `json:"intensity"`
+	Range     float32    `json:"range,omitempty"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string              `json:"name,omitempty"`
+	Mesh        *int                `json:"mesh,omitempty"` // Nil for light nodes
+	Translation [3]float32          `json:"translation"`
+	Rotation    [4]float32          `json:"rotation"` // Quaternion x, y, z, w
+	Scale       [3]float32          `json:"scale"`
+	Extensions  *gltfNodeExtensions `json:"extensions,omitempty"`
+}
+
+type gltfNodeExtensions struct {
+	LightsPunctual gltfNodeLight `json:"KHR_lights_punctual"`
+}
+
+type gltfNodeLight struct {
+	Light int `json:"light"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    int            `json:"indices"`
+	Material   int            `json:"material"`
+}
+
+type gltfMaterial struct {
+	Name                 string                   `json:"name,omitempty"`
+	PBRMetallicRoughness gltfPBRMetallicRoughness `json:"pbrMetallicRoughness"`
+	NormalTexture        *gltfTextureInfo         `json:"normalTexture,omitempty"`
+	DoubleSided          bool                     `json:"doubleSided"`
+}
+
+type gltfPBRMetallicRoughness struct {
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32          `json:"metallicFactor"`
+	RoughnessFactor  float32          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler int `json:"sampler"`
+	Source  int `json:"source"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+	WrapS     int `json:"wrapS"`
+	WrapT     int `json:"wrapT"`
+}
+
+type gltfImage struct {
+	Name       string `json:"name,omitempty"`
+	BufferView int    `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ByteOffset    int       `json:"byteOffset,omitempty"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride,omitempty"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri,omitempty"`
+}
+
+// gltfExporter collects the document and its single binary buffer while objects are added.
+type gltfExporter struct {
+	doc       gltfDocument
+	buffer    []byte
+	materials map[[2]string]int // Texture and normal map path -> material index, empty paths are the vertex color material
+	images    map[string]int    // Image file -> texture index
+}
+
+// Export writes the objects as a glTF 2.0 scene, one node per object with its
+// Position/Rotation/Scale, one mesh per object with its interleaved vertex data, and one
+// material per texture and normal map. Point lights become KHR_lights_punctual lights. A
+// .glb path gets a binary glTF, anything else a .gltf with the buffer embedded, so the result
+// is always a single file. Textures are embedded too. The generator is the program's name,
+// written to the asset.
+func Export(filePath, generator string, objects []Object) error {
+	if len(objects) == 0 {
+		return fmt.Errorf("no objects to export")
+	}
+	e := &gltfExporter{
+		doc: gltfDocument{
+			Asset:  gltfAsset{Version: "2.0", Generator: generator},
+			Scenes: []gltfScene{{}},
+		},
+		materials: make(map[[2]string]int),
+		images:    make(map[string]int),
+	}
+	for _, obj := range objects {
+		if err := e.addObject(obj); err != nil {
+			return err
+		}
+	}
+	if len(e.doc.Meshes) == 0 {
+		return fmt.Errorf("no meshes to export, only lights")
+	}
+
+	binaryGLTF := strings.EqualFold(filepath.Ext(filePath), ".glb")
+	buffer := gltfBuffer{ByteLength: len(e.buffer)}
+	if !binaryGLTF {
+		buffer.URI = "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(e.buffer)
+	}
+	e.doc.Buffers = []gltfBuffer{buffer}
+
+	jsonData, err := json.Marshal(e.doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode glTF JSON: %w", err)
+	}
+
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create glTF file: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	if binaryGLTF {
+		writeGLB(w, jsonData, e.buffer)
+	} else {
+		w.Write(jsonData)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write glTF file: %w", err)
+	}
+	return nil
+}
+
+// writeGLB writes the GLB container: a 12-byte header, the JSON chunk padded with spaces
+// and the binary chunk padded with zeros, both to 4 bytes as the spec requires.
+func writeGLB(w *bufio.Writer, jsonData, bin []byte) {
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+	binPadded := bin
+	for len(binPadded)%4 != 0 {
+		binPadded = append(binPadded, 0)
+	}
+
+	total := 12 + 8 + len(jsonData) + 8 + len(binPadded)
+	header := make([]byte, 0, 20)
+	header = binary.LittleEndian.AppendUint32(header, glbMagic)
+	header = binary.LittleEndian.AppendUint32(header, glbVersion)
+	header = binary.LittleEndian.AppendUint32(header, uint32(total))
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(jsonData)))
+	header = binary.LittleEndian.AppendUint32(header, glbChunkJSON)
+	w.Write(header)
+	w.Write(jsonData)
+
+	chunk := make([]byte, 0, 8)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(binPadded)))
+	chunk = binary.LittleEndian.AppendUint32(chunk, glbChunkBIN)
+	w.Write(chunk)
+	w.Write(binPadded)
+}
+
+// addObject adds a node, mesh and (if needed) material for one object.
+func (e *gltfExporter) addObject(obj Object) error {
+	if obj.Light != nil {
+		e.addLight(obj)
+		return nil
+	}
+	if len(obj.Vertices)%vertexFloats != 0 || len(obj.Vertices) == 0 {
+		return fmt.Errorf("object %s has %d vertex floats, not a multiple of %d", obj.ID, len(obj.Vertices), vertexFloats)
+	}
+	if len(obj.Indices)%3 != 0 || len(obj.Indices) == 0 {
+		return fmt.Errorf("object %s has %d indices, not a multiple of 3", obj.ID, len(obj.Indices))
+	}
+	vertexCount := len(obj.Vertices) / vertexFloats
+	for _, index := range obj.Indices {
+		if int(index) >= vertexCount {
+			return fmt.Errorf("object %s: index %d is out of range", obj.ID, index)
+		}
+	}
+
+	material := e.material(obj.ID, obj.TexturePath, obj.NormalMap)
+
+	// The vertices go in as they are, interleaved, with one accessor per attribute
+	vertexData := make([]byte, 0, len(obj.Vertices)*4)
+	for _, f := range obj.Vertices {
+		vertexData = binary.LittleEndian.AppendUint32(vertexData, math.Float32bits(f))
+	}
+	vertexView := e.addBufferView(vertexData, vertexFloats*4, gltfArrayBuffer)
+
+	// POSITION needs its bounds
+	min := []float32{obj.Vertices[0], obj.Vertices[1], obj.Vertices[2]}
+	max := []float32{obj.Vertices[0], obj.Vertices[1], obj.Vertices[2]}
+	for i := 0; i < len(obj.Vertices); i += vertexFloats {
+		for k := 0; k < 3; k++ {
+			min[k] = float32(math.Min(float64(min[k]), float64(obj.Vertices[i+k])))
+			max[k] = float32(math.Max(float64(max[k]), float64(obj.Vertices[i+k])))
+		}
+	}
+	layout := holym.GameObjectLayout
+	attributes := map[string]int{
+		"POSITION":   e.addAccessor(gltfAccessor{BufferView: vertexView, ComponentType: gltfFloat, Count: vertexCount, Type: "VEC3", Min: min, Max: max}),
+		"TEXCOORD_0": e.addAccessor(gltfAccessor{BufferView: vertexView, ByteOffset: layout.TexCoord * 4, ComponentType: gltfFloat, Count: vertexCount, Type: "VEC2"}),
+		"NORMAL":     e.addAccessor(gltfAccessor{BufferView: vertexView, ByteOffset: layout.Normal * 4, ComponentType: gltfFloat, Count: vertexCount, Type: "VEC3"}),
+		"TANGENT":    e.addAccessor(gltfAccessor{BufferView: vertexView, ByteOffset: layout.Tangent * 4, ComponentType: gltfFloat, Count: vertexCount, Type: "VEC4"}),
+	}
+	// Textured objects are drawn with the texture alone, so their colors are left out
+	// (glTF would multiply the two)
+	if e.doc.Materials[material].PBRMetallicRoughness.BaseColorTexture == nil {
+		attributes["COLOR_0"] = e.addAccessor(gltfAccessor{BufferView: vertexView, ByteOffset: 3 * 4, ComponentType: gltfFloat, Count: vertexCount, Type: "VEC3"})
+	}
+
+	indexData := make([]byte, 0, len(obj.Indices)*4)
+	for _, index := range obj.Indices {
+		indexData = binary.LittleEndian.AppendUint32(indexData, index)
+	}
+	indexView := e.addBufferView(indexData, 0, gltfElementArrayBuffer)
+	indices := e.addAccessor(gltfAccessor{BufferView: indexView, ComponentType: gltfUnsignedInt, Count: len(obj.Indices), Type: "SCALAR"})
+
+	e.doc.Meshes = append(e.doc.Meshes, gltfMesh{
+		Name:       obj.ID,
+		Primitives: []gltfPrimitive{{Attributes: attributes, Indices: indices, Material: material}},
+	})
+
+	mesh := len(e.doc.Meshes) - 1
+	node := e.objectNode(obj)
+	node.Mesh = &mesh
+	e.addNode(node)
+	return nil
+}
+
+// addLight adds a point light object as a KHR_lights_punctual light. Its marker mesh isn't exported.
+func (e *gltfExporter) addLight(obj Object) {
+	if e.doc.Extensions == nil {
+		e.doc.Extensions = &gltfDocumentExtensions{}
+		e.doc.ExtensionsUsed = append(e.doc.ExtensionsUsed, "KHR_lights_punctual")
+	}
+	lights := &e.doc.Extensions.LightsPunctual.Lights
+	*lights = append(*lights, gltfLight{
+		Name:      obj.ID,
+		Type:      "point",
+		Color:     [3]float32{obj.Light.Color.X(), obj.Light.Color.Y(), obj.Light.Color.Z()},
+		Intensity: obj.Light.Intensity,
+		Range:     obj.Light.Range,
+	})
+
+	node := e.objectNode(obj)
+	node.Scale = [3]float32{1, 1, 1} // The marker's scale means nothing to the light
+	node.Extensions = &gltfNodeExtensions{LightsPunctual: gltfNodeLight{Light: len(*lights) - 1}}
+	e.addNode(node)
+}
+
+// objectNode returns a node with the object's name and transform.
+func (e *gltfExporter) objectNode(obj Object) gltfNode {
+	// The programs' model matrices rotate Z, then Y, then X; the quaternion is taken from the
+	// same rotation
+	rotation := mgl32.HomogRotate3DZ(obj.Rotation.Z()).
+		Mul4(mgl32.HomogRotate3DY(obj.Rotation.Y())).
+		Mul4(mgl32.HomogRotate3DX(obj.Rotation.X()))
+	q := mgl32.Mat4ToQuat(rotation).Normalize()
+	return gltfNode{
+		Name:        obj.ID,
+		Translation: [3]float32{obj.Position.X(), obj.Position.Y(), obj.Position.Z()},
+		Rotation:    [4]float32{q.V.X(), q.V.Y(), q.V.Z(), q.W},
+		Scale:       [3]float32{obj.Scale.X(), obj.Scale.Y(), obj.Scale.Z()},
+	}
+}
+
+// addNode adds a node to the exported scene.
+func (e *gltfExporter) addNode(node gltfNode) {
+	e.doc.Nodes = append(e.doc.Nodes, node)
+	e.doc.Scenes[0].Nodes = append(e.doc.Scenes[0].Nodes, len(e.doc.Nodes)-1)
+}
+
+// material returns the material for a texture and normal map, adding it and its images the
+// first time. A texture that can't be read falls back to the vertex color material, a normal
+// map that can't be read is left out.
+func (e *gltfExporter) material(objectID, texturePath, normalMap string) int {
+	key := [2]string{texturePath, normalMap}
+	if index, ok := e.materials[key]; ok {
+		return index
+	}
+
+	// Non-metallic and fully rough is the closest match to how the scene is drawn here
+	material := gltfMaterial{
+		Name:                 "VertexColor",
+		PBRMetallicRoughness: gltfPBRMetallicRoughness{MetallicFactor: 0, RoughnessFactor: 1},
+		DoubleSided:          true, // Faces aren't culled here
+	}
+	if texturePath != "" {
+		texture, err := e.texture(texturePath)
+		if err != nil {
+			log.Printf("Warning: %s is exported without its texture: %v", objectID, err)
+			return e.material(objectID, "", normalMap)
+		}
+		material.Name = strings.TrimSuffix(filepath.Base(texturePath), filepath.Ext(texturePath))
+		material.PBRMetallicRoughness.BaseColorTexture = &gltfTextureInfo{Index: texture}
+	}
+	if normalMap != "" {
+		texture, err := e.texture(normalMap)
+		if err != nil {
+			log.Printf("Warning: %s is exported without its normal map: %v", objectID, err)
+			return e.material(objectID, texturePath, "")
+		}
+		material.NormalTexture = &gltfTextureInfo{Index: texture}
+	}
+
+	e.doc.Materials = append(e.doc.Materials, material)
+	e.materials[key] = len(e.doc.Materials) - 1
+	return len(e.doc.Materials) - 1
+}
+
+// texture returns the texture for an image file, embedding the image the first time.
+func (e *gltfExporter) texture(imagePath string) (int, error) {
+	if index, ok := e.images[imagePath]; ok {
+		return index, nil
+	}
+	data, mimeType, err := readGLTFImageData(imagePath)
+	if err != nil {
+		return 0, err
+	}
+	if len(e.doc.Samplers) == 0 {
+		e.doc.Samplers = append(e.doc.Samplers, gltfSampler{MagFilter: gltfLinear, MinFilter: gltfLinearMipmapLinear, WrapS: gltfRepeat, WrapT: gltfRepeat})
+	}
+	name := strings.TrimSuffix(filepath.Base(imagePath), filepath.Ext(imagePath))
+	e.doc.Images = append(e.doc.Images, gltfImage{Name: name, BufferView: e.addBufferView(data, 0, 0), MimeType: mimeType})
+	e.doc.Textures = append(e.doc.Textures, gltfTexture{Sampler: 0, Source: len(e.doc.Images) - 1})
+	e.images[imagePath] = len(e.doc.Textures) - 1
+	return len(e.doc.Textures) - 1, nil
+}
+
+// readGLTFImageData returns a texture file's contents as a glTF image, which must be PNG or
+// JPEG. Files in other formats are converted to PNG.
+func readGLTFImageData(texturePath string) ([]byte, string, error) {
+	data, err := os.ReadFile(texturePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read texture file %s: %w", texturePath, err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode texture image %s: %w", texturePath, err)
+	}
+	switch format {
+	case "png":
+		return data, "image/png", nil
+	case "jpeg":
+		return data, "image/jpeg", nil
+	}
+	var converted bytes.Buffer
+	if err := png.Encode(&converted, img); err != nil {
+		return nil, "", fmt.Errorf("failed to convert texture image %s to PNG: %w", texturePath, err)
+	}
+	return converted.Bytes(), "image/png", nil
+}
+
+// addBufferView appends data to the buffer, aligned to 4 bytes, and returns its buffer view.
+func (e *gltfExporter) addBufferView(data []byte, stride, target int) int {
+	for len(e.buffer)%4 != 0 {
+		e.buffer = append(e.buffer, 0)
+	}
+	e.doc.BufferViews = append(e.doc.BufferViews, gltfBufferView{
+		Buffer:     0,
+		ByteOffset: len(e.buffer),
+		ByteLength: len(data),
+		ByteStride: stride,
+		Target:     target,
+	})
+	e.buffer = append(e.buffer, data...)
+	return len(e.doc.BufferViews) - 1
+}
+
+// addAccessor adds an accessor and returns its index.
+func (e *gltfExporter) addAccessor(accessor gltfAccessor) int {
+	e.doc.Accessors = append(e.doc.Accessors, accessor)
+	return len(e.doc.Accessors) - 1
+}
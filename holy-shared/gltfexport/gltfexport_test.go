@@ -0,0 +1,219 @@
+package gltfexport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/qmuntal/gltf"
+)
+
+// testTriangle returns a triangle in the GameObject layout with the corners at the given
+// x offsets, red, facing +Z.
+func testTriangle(x float32) []float32 {
+	return []float32{
+		x + 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, 1, 0, 0, -1,
+		x + 1, 0, 0, 1, 0, 0, 1, 0, 0, 0, 1, 1, 0, 0, -1,
+		x + 0, 2, 0, 1, 0, 0, 0, 1, 0, 0, 1, 1, 0, 0, -1,
+	}
+}
+
+// writeTestPNG writes a small PNG texture and returns its path and contents.
+func writeTestPNG(t *testing.T, dir string) (string, []byte) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	img.Set(1, 1, color.RGBA{255, 128, 0, 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "crate.png")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, buf.Bytes()
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-5
+}
+
+func TestExportReadBack(t *testing.T) {
+	dir := t.TempDir()
+	texturePath, textureData := writeTestPNG(t, dir)
+	objects := []Object{
+		{
+			ID: "Textured_1", Vertices: testTriangle(0), Indices: []uint32{0, 1, 2}, TexturePath: texturePath,
+			Position: mgl32.Vec3{1, 2, 3}, Scale: mgl32.Vec3{1, 1, 1},
+		},
+		{
+			ID: "Turned_2", Vertices: testTriangle(-4), Indices: []uint32{0, 1, 2, 2, 1, 0},
+			Rotation: mgl32.Vec3{0, math.Pi / 2, 0}, Scale: mgl32.Vec3{2, 3, 4},
+		},
+		{
+			ID: "Light_3", Position: mgl32.Vec3{0, 5, 0}, Scale: mgl32.Vec3{0.2, 0.2, 0.2},
+			Light: &Light{Color: mgl32.Vec3{1, 1, 0.5}, Intensity: 3, Range: 8},
+		},
+	}
+	sin45 := math.Sqrt(0.5)
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"embedded buffer", "scene.gltf"},
+		{"binary", "scene.glb"},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file)
+		if err := Export(path, "Holy Test", objects); err != nil {
+			t.Errorf("%s: export failed: %v", tt.name, err)
+			continue
+		}
+		doc, err := gltf.Open(path)
+		if err != nil {
+			t.Errorf("%s: the exported file doesn't open: %v", tt.name, err)
+			continue
+		}
+		if doc.Asset.Generator != "Holy Test" {
+			t.Errorf("%s: generator = %q", tt.name, doc.Asset.Generator)
+		}
+
+		// Node transforms
+		if len(doc.Nodes) != 3 || len(doc.Meshes) != 2 {
+			t.Errorf("%s: %d nodes and %d meshes, want 3 and 2", tt.name, len(doc.Nodes), len(doc.Meshes))
+			continue
+		}
+		wantNodes := []struct {
+			translation [3]float64
+			rotation    [4]float64
+			scale       [3]float64
+			hasMesh     bool
+		}{
+			{[3]float64{1, 2, 3}, [4]float64{0, 0, 0, 1}, [3]float64{1, 1, 1}, true},
+			{[3]float64{0, 0, 0}, [4]float64{0, sin45, 0, sin45}, [3]float64{2, 3, 4}, true},
+			{[3]float64{0, 5, 0}, [4]float64{0, 0, 0, 1}, [3]float64{1, 1, 1}, false}, // A light's scale means nothing
+		}
+		for i, want := range wantNodes {
+			node := doc.Nodes[i]
+			for k := 0; k < 4; k++ {
+				if (k < 3 && (!near(node.Translation[k], want.translation[k]) || !near(node.Scale[k], want.scale[k]))) || !near(node.Rotation[k], want.rotation[k]) {
+					t.Errorf("%s: node %d is at %v turned %v scaled %v, want %v %v %v", tt.name, i, node.Translation, node.Rotation, node.Scale, want.translation, want.rotation, want.scale)
+					break
+				}
+			}
+			if (node.Mesh != nil) != want.hasMesh {
+				t.Errorf("%s: node %d has mesh %v, want one: %v", tt.name, i, node.Mesh, want.hasMesh)
+			}
+		}
+
+		// Accessors
+		for m, mesh := range doc.Meshes {
+			primitive := mesh.Primitives[0]
+			position := doc.Accessors[primitive.Attributes[gltf.POSITION]]
+			x := float64(-4 * m)
+			if position.Count != 3 || len(position.Min) != 3 || len(position.Max) != 3 {
+				t.Errorf("%s: mesh %d POSITION count %d, min %v, max %v", tt.name, m, position.Count, position.Min, position.Max)
+			} else if !near(position.Min[0], x) || !near(position.Min[1], 0) || !near(position.Max[0], x+1) || !near(position.Max[1], 2) {
+				t.Errorf("%s: mesh %d POSITION bounds %v to %v, want x %v to %v and y 0 to 2", tt.name, m, position.Min, position.Max, x, x+1)
+			}
+			for _, attribute := range []string{gltf.TEXCOORD_0, gltf.NORMAL, gltf.TANGENT} {
+				index, ok := primitive.Attributes[attribute]
+				if !ok || doc.Accessors[index].Count != 3 {
+					t.Errorf("%s: mesh %d has no %s with 3 elements", tt.name, m, attribute)
+				}
+			}
+			// Textured objects are drawn without their vertex colors
+			if _, ok := primitive.Attributes[gltf.COLOR_0]; ok != (m == 1) {
+				t.Errorf("%s: mesh %d has COLOR_0: %v", tt.name, m, ok)
+			}
+			if indices := doc.Accessors[*primitive.Indices]; indices.Count != 3*(m+1) {
+				t.Errorf("%s: mesh %d has %d indices, want %d", tt.name, m, indices.Count, 3*(m+1))
+			}
+		}
+
+		// The texture is embedded as it was
+		if len(doc.Images) != 1 || doc.Images[0].BufferView == nil {
+			t.Errorf("%s: %d images, want the texture embedded", tt.name, len(doc.Images))
+			continue
+		}
+		view := doc.BufferViews[*doc.Images[0].BufferView]
+		embedded := doc.Buffers[view.Buffer].Data[view.ByteOffset : view.ByteOffset+view.ByteLength]
+		if doc.Images[0].MimeType != "image/png" || !bytes.Equal(embedded, textureData) {
+			t.Errorf("%s: embedded image is %s, %d bytes, want the %d byte PNG", tt.name, doc.Images[0].MimeType, len(embedded), len(textureData))
+		}
+	}
+}
+
+func TestExportGLBChunks(t *testing.T) {
+	dir := t.TempDir()
+	texturePath, _ := writeTestPNG(t, dir)
+	le := binary.LittleEndian
+
+	// The texture and a single triangle make both chunks a length that needs padding
+	for _, texture := range []string{"", texturePath} {
+		path := filepath.Join(dir, "padding.glb")
+		objects := []Object{{ID: "Triangle", Vertices: testTriangle(0), Indices: []uint32{0, 1, 2}, TexturePath: texture, Scale: mgl32.Vec3{1, 1, 1}}}
+		if err := Export(path, "Holy Test", objects); err != nil {
+			t.Fatalf("export failed: %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if le.Uint32(data[0:]) != glbMagic || le.Uint32(data[4:]) != glbVersion || int(le.Uint32(data[8:])) != len(data) {
+			t.Errorf("texture %q: header is %x, file is %d bytes", texture, data[:12], len(data))
+			continue
+		}
+		jsonLength := int(le.Uint32(data[12:]))
+		if le.Uint32(data[16:]) != glbChunkJSON || jsonLength%4 != 0 {
+			t.Errorf("texture %q: JSON chunk is %d bytes of type %x", texture, jsonLength, le.Uint32(data[16:]))
+			continue
+		}
+		jsonChunk := string(data[20 : 20+jsonLength])
+		if trimmed := strings.TrimRight(jsonChunk, " "); !strings.HasSuffix(trimmed, "}") || len(jsonChunk)-len(trimmed) > 3 {
+			t.Errorf("texture %q: JSON chunk isn't padded with spaces: %q", texture, jsonChunk[len(jsonChunk)-8:])
+		}
+
+		bin := data[20+jsonLength:]
+		binLength := int(le.Uint32(bin[0:]))
+		if le.Uint32(bin[4:]) != glbChunkBIN || binLength%4 != 0 || 8+binLength != len(bin) {
+			t.Errorf("texture %q: BIN chunk is %d bytes of type %x, %d bytes left in the file", texture, binLength, le.Uint32(bin[4:]), len(bin)-8)
+		}
+	}
+}
+
+func TestExportErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []Object
+		wantErr string // Part of the expected error
+	}{
+		{"nothing", nil, "no objects to export"},
+		{"only lights", []Object{{ID: "Light_1", Light: &Light{Intensity: 1}}}, "no meshes to export"},
+		{"partial vertex", []Object{{ID: "Broken", Vertices: testTriangle(0)[:20], Indices: []uint32{0, 1, 2}}}, "not a multiple of 15"},
+		{"partial triangle", []Object{{ID: "Broken", Vertices: testTriangle(0), Indices: []uint32{0, 1}}}, "not a multiple of 3"},
+		{"index out of range", []Object{{ID: "Broken", Vertices: testTriangle(0), Indices: []uint32{0, 1, 3}}}, "index 3 is out of range"},
+	}
+
+	path := filepath.Join(t.TempDir(), "broken.gltf")
+	for _, tt := range tests {
+		err := Export(path, "Holy Test", tt.objects)
+		if err == nil {
+			t.Errorf("%s: expected an error containing %q, got none", tt.name, tt.wantErr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %q, want it to contain %q", tt.name, err.Error(), tt.wantErr)
+		}
+	}
+}
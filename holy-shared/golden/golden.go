@@ -0,0 +1,156 @@
+// Package golden compares rendered frames against reference PNGs in testdata/golden/ of the
+// package under test. Run the tests with -update to rewrite the references after a change
+// that is meant to change the picture, and look at them before committing.
+//
+// Colors are compared in CIELAB, so the tolerance is about what can be seen rather than
+// raw byte values, and a few pixels may be over it, for rounding differences at triangle
+// edges between compilers and CPUs. When a frame fails, what was rendered and a diff image
+// go to testdata/failed/.
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden images in testdata/golden/ from the current renders")
+
+// Tolerances
+const (
+	pixelTolerance = 3.0   // CIE76 color difference a pixel may be off by, 2.3 is about the smallest anyone notices
+	maxBadPixels   = 0.002 // Share of the pixels allowed over pixelTolerance
+)
+
+// Check compares a frame against testdata/golden/<name>.png, or writes it there with -update.
+func Check(t testing.TB, name string, got *image.RGBA) {
+	t.Helper()
+	goldenPath := filepath.Join("testdata", "golden", name+".png")
+	if *updateGolden {
+		if err := writePNG(goldenPath, got); err != nil {
+			t.Fatalf("failed to update golden image: %v", err)
+		}
+		t.Logf("Updated %s", goldenPath)
+		return
+	}
+
+	want, err := readPNG(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden image (run with -update to create it): %v", err)
+	}
+	if want.Bounds().Size() != got.Bounds().Size() {
+		t.Fatalf("frame is %v, golden image %s is %v", got.Bounds().Size(), goldenPath, want.Bounds().Size())
+	}
+
+	diff, badPixels, worst := diffImages(want, got)
+	total := got.Bounds().Dx() * got.Bounds().Dy()
+	if float64(badPixels) <= maxBadPixels*float64(total) {
+		return
+	}
+
+	failedDir := filepath.Join("testdata", "failed")
+	gotPath := filepath.Join(failedDir, name+".png")
+	diffPath := filepath.Join(failedDir, name+"_diff.png")
+	if err := writePNG(gotPath, got); err != nil {
+		t.Logf("Warning: Could not write the rendered frame: %v", err)
+	}
+	if err := writePNG(diffPath, diff); err != nil {
+		t.Logf("Warning: Could not write the diff image: %v", err)
+	}
+	t.Errorf("%s: %d of %d pixels differ by more than %.1f (worst %.1f); got %s, diff %s",
+		name, badPixels, total, pixelTolerance, worst, gotPath, diffPath)
+}
+
+// diffImages compares two images of the same size pixel by pixel. It returns an image of
+// the differences, the reference dimmed to gray with the pixels over the tolerance in red
+// (brighter the further off they are), how many pixels are over it and the worst difference.
+func diffImages(want, got image.Image) (*image.RGBA, int, float64) {
+	bounds := want.Bounds()
+	gotOrigin := got.Bounds().Min
+	diff := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	badPixels := 0
+	worst := 0.0
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			wantColor := color.RGBAModel.Convert(want.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.RGBA)
+			gotColor := color.RGBAModel.Convert(got.At(gotOrigin.X+x, gotOrigin.Y+y)).(color.RGBA)
+			delta := deltaE(wantColor, gotColor)
+			if delta > worst {
+				worst = delta
+			}
+			if delta > pixelTolerance {
+				badPixels++
+				diff.SetRGBA(x, y, color.RGBA{uint8(math.Min(255, 128+delta*4)), 0, 0, 255})
+				continue
+			}
+			l, _, _ := toLab(wantColor)
+			gray := uint8(l * 255 / 100 / 3)
+			diff.SetRGBA(x, y, color.RGBA{gray, gray, gray, 255})
+		}
+	}
+	return diff, badPixels, worst
+}
+
+// deltaE is the CIE76 difference between two sRGB colors, the distance between them in CIELAB.
+func deltaE(a, b color.RGBA) float64 {
+	l1, a1, b1 := toLab(a)
+	l2, a2, b2 := toLab(b)
+	return math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+}
+
+// toLab converts an sRGB color to CIELAB, with the D65 white point.
+func toLab(c color.RGBA) (l, a, b float64) {
+	linear := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.04045 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	r, g, bl := linear(c.R), linear(c.G), linear(c.B)
+	x := (0.4124*r + 0.3576*g + 0.1805*bl) / 0.95047
+	y := 0.2126*r + 0.7152*g + 0.0722*bl
+	z := (0.0193*r + 0.1192*g + 0.9505*bl) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389.0 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27.0*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return img, nil
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,77 @@
+package golden
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDeltaE(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b color.RGBA
+		want float64
+	}{
+		{"same color", color.RGBA{40, 90, 200, 255}, color.RGBA{40, 90, 200, 255}, 0},
+		{"black and white", color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}, 100},
+		{"one step of gray", color.RGBA{128, 128, 128, 255}, color.RGBA{129, 129, 129, 255}, 0.39},
+	}
+	for _, tt := range tests {
+		got := deltaE(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("%s: deltaE = %.3f, want %.3f", tt.name, got, tt.want)
+		}
+		if reverse := deltaE(tt.b, tt.a); reverse != got {
+			t.Errorf("%s: deltaE is %.3f one way and %.3f the other", tt.name, got, reverse)
+		}
+	}
+}
+
+func TestDiffImages(t *testing.T) {
+	fill := func(c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+		}
+		return img
+	}
+	gray := color.RGBA{128, 128, 128, 255}
+
+	nearlyGray := fill(gray)
+	nearlyGray.SetRGBA(1, 1, color.RGBA{129, 128, 128, 255})
+	offCorner := fill(gray)
+	offCorner.SetRGBA(3, 0, color.RGBA{255, 0, 0, 255})
+
+	tests := []struct {
+		name          string
+		got           *image.RGBA
+		wantBadPixels int
+	}{
+		{"identical", fill(gray), 0},
+		{"within tolerance", nearlyGray, 0},
+		{"one pixel off", offCorner, 1},
+		{"all off", fill(color.RGBA{0, 0, 255, 255}), 16},
+	}
+	for _, tt := range tests {
+		diff, badPixels, worst := diffImages(fill(gray), tt.got)
+		if badPixels != tt.wantBadPixels {
+			t.Errorf("%s: %d bad pixels, want %d", tt.name, badPixels, tt.wantBadPixels)
+		}
+		if (worst > pixelTolerance) != (tt.wantBadPixels > 0) {
+			t.Errorf("%s: worst difference %.1f with %d bad pixels", tt.name, worst, badPixels)
+		}
+		if diff.Bounds() != tt.got.Bounds() {
+			t.Errorf("%s: diff image is %v, want %v", tt.name, diff.Bounds(), tt.got.Bounds())
+		}
+	}
+
+	// Bad pixels are red in the diff, the rest gray
+	diff, _, _ := diffImages(fill(gray), offCorner)
+	if c := diff.RGBAAt(3, 0); c.R <= c.G || c.G != 0 {
+		t.Errorf("bad pixel is %v in the diff, want red", c)
+	}
+	if c := diff.RGBAAt(0, 0); c.R != c.G || c.G != c.B {
+		t.Errorf("good pixel is %v in the diff, want gray", c)
+	}
+}
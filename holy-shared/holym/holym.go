@@ -0,0 +1,420 @@
+// Package holym reads the .holym model format described in holy-mm/HOLYM.md.
+package holym
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+)
+
+// Version is the newest .holym version this parser understands.
+const Version = 1
+
+// GameObjectFloats is the number of floats per vertex in the layout GameObjects use in
+// holy-engine-base and holy-mm: position (3) + color (3) + texcoord (2) + normal (3) +
+// tangent (4). GameObjectMesh returns meshes in it.
+const GameObjectFloats = 15
+
+// GameObjectLayout is where the attributes are in a GameObject vertex.
+var GameObjectLayout = meshgen.Layout{Stride: GameObjectFloats, TexCoord: 6, Normal: 8, Tangent: 11}
+
+// Mesh is the result of parsing a .holym file.
+type Mesh struct {
+	Vertices    []float32 // Interleaved position (3) + color (3) + texcoord (2)
+	Indices     []uint32  // Triangles
+	Normals     []float32 // 3 floats per vertex in Vertices, nil when no face uses normals
+	TexturePath string    // Empty when the model is untextured
+	NormalMap   string    // Tangent-space normal map, empty when there is none
+	Version     int       // From the "holym" header, 0 for files without one
+}
+
+// Error is a problem at a specific place in a .holym file.
+type Error struct {
+	File   string
+	Line   int
+	Column int // 1-based byte offset in the line
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// Token is one whitespace-separated word of a line and where it starts. The OBJ
+// reader in holy-shared/objfile shares the tokenizer, OBJ being close enough to .holym.
+type Token struct {
+	Text   string
+	Column int
+}
+
+// Corner is one corner of a face as written in the file. TexCoord and Normal are -1
+// when the corner doesn't have them. Indices are 0-based once the whole file is read.
+type Corner struct {
+	Position, TexCoord, Normal int
+	Line, Column               int // For errors found after the whole file is read
+}
+
+// ParseFile opens and parses a .holym file. In strict mode the first malformed line is
+// returned as a *Error; otherwise malformed lines are logged and skipped.
+func ParseFile(filePath string, strict bool) (*Mesh, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .holym file: %w", err)
+	}
+	defer file.Close()
+	return Parse(file, filePath, strict)
+}
+
+// Parse parses .holym data from r. name is only used in error messages.
+func Parse(r io.Reader, name string, strict bool) (*Mesh, error) {
+	mesh := &Mesh{}
+	var positions []mgl32.Vec3
+	var colors []mgl32.Vec3
+	var texCoords []mgl32.Vec2
+	var normals []mgl32.Vec3
+	var faces [][]Corner
+	sawData := false // Anything but comments and blank lines, the header has to come first
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Allow very long lines
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		tokens := SplitLine(line)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		fail := func(column int, format string, args ...interface{}) error {
+			return &Error{File: name, Line: lineNumber, Column: column, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		var lineErr error
+		keyword := tokens[0]
+		switch keyword.Text {
+		case "holym": // Version header
+			if len(tokens) != 2 {
+				lineErr = fail(keyword.Column, "expected \"holym <version>\"")
+				break
+			}
+			version, err := strconv.Atoi(tokens[1].Text)
+			if err != nil || version < 1 {
+				lineErr = fail(tokens[1].Column, "invalid version %q", tokens[1].Text)
+				break
+			}
+			if version > Version {
+				// Never skipped: the rest of the file may mean something else entirely
+				return nil, fail(tokens[1].Column, "unsupported .holym version %d (this build reads up to %d)", version, Version)
+			}
+			if sawData || mesh.Version != 0 {
+				lineErr = fail(keyword.Column, "the holym header must be the first line")
+				break
+			}
+			mesh.Version = version
+
+		case "v": // Vertex position and optional color
+			var values []float32
+			var err error
+			switch len(tokens) {
+			case 4:
+				values, err = ParseFloats(tokens[1:4], fail)
+				if err == nil {
+					values = append(values, 1.0, 1.0, 1.0) // Default white
+				}
+			case 8:
+				if tokens[4].Text != "c" {
+					err = fail(tokens[4].Column, "expected \"c\" before the vertex color, got %q", tokens[4].Text)
+					break
+				}
+				values, err = ParseFloats(append(tokens[1:4:4], tokens[5:8]...), fail)
+			default:
+				err = fail(keyword.Column, "expected \"v X Y Z\" or \"v X Y Z c R G B\"")
+			}
+			if err != nil {
+				lineErr = err
+				break
+			}
+			positions = append(positions, mgl32.Vec3{values[0], values[1], values[2]})
+			colors = append(colors, mgl32.Vec3{values[3], values[4], values[5]})
+
+		case "vt": // Texture coordinate
+			if len(tokens) != 3 {
+				lineErr = fail(keyword.Column, "expected \"vt U V\"")
+				break
+			}
+			values, err := ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			texCoords = append(texCoords, mgl32.Vec2{values[0], values[1]})
+
+		case "vn": // Normal
+			if len(tokens) != 4 {
+				lineErr = fail(keyword.Column, "expected \"vn X Y Z\"")
+				break
+			}
+			values, err := ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			normals = append(normals, mgl32.Vec3{values[0], values[1], values[2]})
+
+		case "f": // Face, any polygon with at least 3 corners
+			if len(tokens) < 4 {
+				lineErr = fail(keyword.Column, "a face needs at least 3 corners")
+				break
+			}
+			face := make([]Corner, 0, len(tokens)-1)
+			for _, token := range tokens[1:] {
+				corner, err := ParseCorner(token, len(positions), len(texCoords), len(normals), fail)
+				if err != nil {
+					lineErr = err
+					break
+				}
+				corner.Line, corner.Column = lineNumber, token.Column
+				face = append(face, corner)
+			}
+			if lineErr == nil {
+				faces = append(faces, face)
+			}
+
+		case "tex_path": // Texture path, the rest of the line so it may contain spaces
+			path := strings.TrimSpace(line[keyword.Column-1+len(keyword.Text):])
+			if path == "" {
+				lineErr = fail(keyword.Column, "expected \"tex_path <path>\"")
+				break
+			}
+			mesh.TexturePath = path
+
+		case "norm_path": // Normal map path, read like tex_path
+			path := strings.TrimSpace(line[keyword.Column-1+len(keyword.Text):])
+			if path == "" {
+				lineErr = fail(keyword.Column, "expected \"norm_path <path>\"")
+				break
+			}
+			mesh.NormalMap = path
+
+		default:
+			if strict {
+				lineErr = fail(keyword.Column, "unknown keyword %q", keyword.Text)
+			}
+			// Lenient mode ignores unknown lines so newer files still load
+		}
+		sawData = true
+
+		if lineErr != nil {
+			if strict {
+				return nil, lineErr
+			}
+			log.Printf("Warning: %v (line skipped)", lineErr)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning .holym file: %w", err)
+	}
+
+	// Positive indices may point forward, so ranges are only checked now
+	checkRange := func(c Corner, index, count int, what string) error {
+		if index < 0 || index >= count {
+			return &Error{File: name, Line: c.Line, Column: c.Column,
+				Msg: fmt.Sprintf("%s index %d out of range (file has %d)", what, index+1, count)}
+		}
+		return nil
+	}
+	validFaces := faces[:0]
+	for _, face := range faces {
+		var faceErr error
+		for _, c := range face {
+			if faceErr = checkRange(c, c.Position, len(positions), "vertex"); faceErr != nil {
+				break
+			}
+			if c.TexCoord >= 0 {
+				if faceErr = checkRange(c, c.TexCoord, len(texCoords), "texture coordinate"); faceErr != nil {
+					break
+				}
+			}
+			if c.Normal >= 0 {
+				if faceErr = checkRange(c, c.Normal, len(normals), "normal"); faceErr != nil {
+					break
+				}
+			}
+		}
+		if faceErr != nil {
+			if strict {
+				return nil, faceErr
+			}
+			log.Printf("Warning: %v (face skipped)", faceErr)
+			continue
+		}
+		validFaces = append(validFaces, face)
+	}
+
+	// Each distinct position/texcoord/normal combination becomes one interleaved vertex
+	type vertexKey struct{ position, texCoord, normal int }
+	vertexMap := make(map[vertexKey]uint32)
+	hasNormals := false
+	for _, face := range validFaces {
+		for _, c := range face {
+			if c.Normal >= 0 {
+				hasNormals = true
+			}
+		}
+	}
+
+	vertexIndex := func(c Corner) uint32 {
+		key := vertexKey{c.Position, c.TexCoord, c.Normal}
+		if index, ok := vertexMap[key]; ok {
+			return index
+		}
+		index := uint32(len(mesh.Vertices) / 8) // 8 floats per vertex
+
+		pos := positions[c.Position]
+		color := colors[c.Position]
+		texCoord := mgl32.Vec2{0, 0} // Faces without UVs
+		if c.TexCoord >= 0 {
+			texCoord = texCoords[c.TexCoord]
+		}
+		mesh.Vertices = append(mesh.Vertices, pos.X(), pos.Y(), pos.Z())
+		mesh.Vertices = append(mesh.Vertices, color.X(), color.Y(), color.Z())
+		mesh.Vertices = append(mesh.Vertices, texCoord.X(), texCoord.Y())
+		if hasNormals {
+			normal := mgl32.Vec3{0, 0, 0} // Corners without normals
+			if c.Normal >= 0 {
+				normal = normals[c.Normal]
+			}
+			mesh.Normals = append(mesh.Normals, normal.X(), normal.Y(), normal.Z())
+		}
+
+		vertexMap[key] = index
+		return index
+	}
+
+	for _, face := range validFaces {
+		// Polygons are triangulated as a fan around their first corner
+		first := vertexIndex(face[0])
+		for i := 1; i+1 < len(face); i++ {
+			mesh.Indices = append(mesh.Indices, first, vertexIndex(face[i]), vertexIndex(face[i+1]))
+		}
+	}
+	return mesh, nil
+}
+
+// GameObjectMesh returns the vertices in the position (3) + color (3) + texcoord (2) +
+// normal (3) + tangent (4) layout GameObjects use, and the indices that go with them.
+// Normals the file doesn't give are computed from the faces, tangents always are, which can
+// add vertices (see meshgen.GenerateTangents).
+func (m *Mesh) GameObjectMesh() ([]float32, []uint32) {
+	vertexCount := len(m.Vertices) / 8
+	vertices := make([]float32, 0, vertexCount*GameObjectFloats)
+	for v := 0; v < vertexCount; v++ {
+		vertices = append(vertices, m.Vertices[v*8:v*8+8]...)
+		if m.Normals != nil {
+			vertices = append(vertices, m.Normals[v*3:v*3+3]...)
+		} else {
+			vertices = append(vertices, 0, 0, 0)
+		}
+		vertices = append(vertices, 0, 0, 0, 0) // Tangent, generated below
+	}
+	meshgen.FillMissingNormals(vertices, m.Indices, GameObjectLayout)
+	return meshgen.GenerateTangents(vertices, m.Indices, GameObjectLayout)
+}
+
+// SplitLine splits a line into whitespace-separated tokens, dropping a trailing comment.
+// A comment starts with a token beginning with '#'.
+func SplitLine(line string) []Token {
+	var tokens []Token
+	start := -1
+	for i, r := range line {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				tokens = append(tokens, Token{Text: line[start:i], Column: start + 1})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			if r == '#' {
+				return tokens
+			}
+			start = i
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: line[start:], Column: start + 1})
+	}
+	return tokens
+}
+
+// ParseFloats parses each token as a finite float32.
+func ParseFloats(tokens []Token, fail func(int, string, ...interface{}) error) ([]float32, error) {
+	values := make([]float32, len(tokens))
+	for i, token := range tokens {
+		f, err := strconv.ParseFloat(token.Text, 32)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fail(token.Column, "invalid number %q", token.Text)
+		}
+		values[i] = float32(f)
+	}
+	return values, nil
+}
+
+// ParseCorner parses a face corner: V, V/VT, V//VN or V/VT/VN.
+// Negative indices count back from the last element defined so far (-1 is the latest).
+// The counts are how many positions, texcoords and normals have been read up to this line.
+func ParseCorner(token Token, positionCount, texCoordCount, normalCount int, fail func(int, string, ...interface{}) error) (Corner, error) {
+	corner := Corner{TexCoord: -1, Normal: -1}
+	parts := strings.Split(token.Text, "/")
+	if len(parts) > 3 {
+		return corner, fail(token.Column, "invalid face corner %q (expected V, V/VT, V//VN or V/VT/VN)", token.Text)
+	}
+
+	column := token.Column
+	resolve := func(part string, count int, what string) (int, error) {
+		index, err := strconv.Atoi(part)
+		if err != nil || index == 0 {
+			return 0, fail(column, "invalid %s index %q", what, part)
+		}
+		if index < 0 {
+			if -index > count {
+				return 0, fail(column, "relative %s index %d reaches before the first one", what, index)
+			}
+			return count + index, nil
+		}
+		return index - 1, nil // Convert to 0-based index
+	}
+
+	var err error
+	if corner.Position, err = resolve(parts[0], positionCount, "vertex"); err != nil {
+		return corner, err
+	}
+	column += len(parts[0]) + 1
+	if len(parts) > 1 && parts[1] != "" {
+		if corner.TexCoord, err = resolve(parts[1], texCoordCount, "texture coordinate"); err != nil {
+			return corner, err
+		}
+	} else if len(parts) == 2 {
+		return corner, fail(column, "missing texture coordinate index in %q", token.Text)
+	}
+	if len(parts) > 1 {
+		column += len(parts[1]) + 1
+	}
+	if len(parts) == 3 {
+		if corner.Normal, err = resolve(parts[2], normalCount, "normal"); err != nil {
+			return corner, err
+		}
+	}
+	return corner, nil
+}
@@ -0,0 +1,325 @@
+package holym
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHolym(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantVerts   []float32 // Interleaved position, color, texcoord
+		wantIndices []uint32
+		wantNormals []float32
+		wantTexture string
+		wantVersion int
+	}{
+		{
+			name: "legacy triangle",
+			input: `v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 0 1
+f 1/1 2/2 3/3
+tex_path tex.png
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 1, 0,
+				0, 1, 0, 1, 1, 1, 0, 1,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantTexture: "tex.png",
+		},
+		{
+			name: "vertex colors",
+			input: `holym 1
+v 0 0 0 c 1 0 0
+v 1 0 0 c 0 1 0
+v 0 1 0 c 0 0 1
+vt 0 0
+f 1/1 2/1 3/1
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 0, 0, 0, 0,
+				1, 0, 0, 0, 1, 0, 0, 0,
+				0, 1, 0, 0, 0, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantVersion: 1,
+		},
+		{
+			name: "comments and blank lines",
+			input: `# header comment
+
+holym 1   # trailing comment
+v 0 0 0 # first
+v 1 0 0
+   # indented comment
+v 0 1 0
+f 1 2 3 # no uvs
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantVersion: 1,
+		},
+		{
+			name: "quad is triangulated as a fan",
+			input: `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+f 1 2 3 4
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				1, 1, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2, 0, 2, 3},
+		},
+		{
+			name: "pentagon",
+			input: `v 0 0 0
+v 1 0 0
+v 2 1 0
+v 1 2 0
+v 0 1 0
+f 1 2 3 4 5
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				2, 1, 0, 1, 1, 1, 0, 0,
+				1, 2, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4},
+		},
+		{
+			name: "normals",
+			input: `v 0 0 0
+v 1 0 0
+v 0 1 0
+vn 0 0 1
+f 1//1 2//1 3
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantNormals: []float32{0, 0, 1, 0, 0, 1, 0, 0, 0},
+		},
+		{
+			name: "position, texcoord and normal",
+			input: `v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0.5 0.25
+vn 0 0 1
+f 1/1/1 2/1/1 3/1/1
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0.5, 0.25,
+				1, 0, 0, 1, 1, 1, 0.5, 0.25,
+				0, 1, 0, 1, 1, 1, 0.5, 0.25,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantNormals: []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+		},
+		{
+			name: "negative indices",
+			input: `v 9 9 9
+v 0 0 0
+v 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 1
+f -3/-2 -2/-1 -1/-1
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 1, 1,
+				0, 1, 0, 1, 1, 1, 1, 1,
+			},
+			wantIndices: []uint32{0, 1, 2},
+		},
+		{
+			name: "shared corners are deduplicated",
+			input: `v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+f 1/1 2/1 3/1
+f 1/1 3/1 4/1
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				1, 1, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2, 0, 2, 3},
+		},
+		{
+			name: "faces may come before their vertices",
+			input: `f 1 2 3
+v 0 0 0
+v 1 0 0
+v 0 1 0
+`,
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2},
+		},
+		{
+			name:        "texture path with spaces",
+			input:       "tex_path  my textures/crate #1.png  \r\n",
+			wantTexture: "my textures/crate #1.png",
+		},
+		{
+			name:  "windows line endings",
+			input: "holym 1\r\nv 0 0 0\r\nv 1 0 0\r\nv 0 1 0\r\nf 1 2 3\r\n",
+			wantVerts: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0,
+				1, 0, 0, 1, 1, 1, 0, 0,
+				0, 1, 0, 1, 1, 1, 0, 0,
+			},
+			wantIndices: []uint32{0, 1, 2},
+			wantVersion: 1,
+		},
+		{
+			name:  "empty file",
+			input: "",
+		},
+	}
+
+	for _, tt := range tests {
+		for _, strict := range []bool{true, false} {
+			mesh, err := Parse(strings.NewReader(tt.input), "test.holym", strict)
+			if err != nil {
+				t.Errorf("%s (strict=%v): unexpected error: %v", tt.name, strict, err)
+				continue
+			}
+			if !reflect.DeepEqual(mesh.Vertices, tt.wantVerts) {
+				t.Errorf("%s (strict=%v): vertices = %v, want %v", tt.name, strict, mesh.Vertices, tt.wantVerts)
+			}
+			if !reflect.DeepEqual(mesh.Indices, tt.wantIndices) {
+				t.Errorf("%s (strict=%v): indices = %v, want %v", tt.name, strict, mesh.Indices, tt.wantIndices)
+			}
+			if !reflect.DeepEqual(mesh.Normals, tt.wantNormals) {
+				t.Errorf("%s (strict=%v): normals = %v, want %v", tt.name, strict, mesh.Normals, tt.wantNormals)
+			}
+			if mesh.TexturePath != tt.wantTexture {
+				t.Errorf("%s (strict=%v): texture = %q, want %q", tt.name, strict, mesh.TexturePath, tt.wantTexture)
+			}
+			if mesh.Version != tt.wantVersion {
+				t.Errorf("%s (strict=%v): version = %d, want %d", tt.name, strict, mesh.Version, tt.wantVersion)
+			}
+		}
+	}
+}
+
+func TestParseHolymStrictErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string // Expected error text, including the position
+	}{
+		{"bad number", "v 0 x 0\n", "test.holym:1:5: invalid number \"x\""},
+		{"nan", "v 0 NaN 0\n", "test.holym:1:5: invalid number \"NaN\""},
+		{"too few coordinates", "v 0 0\n", "test.holym:1:1: expected \"v X Y Z\" or \"v X Y Z c R G B\""},
+		{"missing color marker", "v 0 0 0 x 1 1 1\n", "test.holym:1:9: expected \"c\" before the vertex color, got \"x\""},
+		{"bad color", "v 0 0 0 c 1 oops 1\n", "test.holym:1:13: invalid number \"oops\""},
+		{"bad texcoord", "vt 0\n", "test.holym:1:1: expected \"vt U V\""},
+		{"bad normal", "vn 0 0 z\n", "test.holym:1:8: invalid number \"z\""},
+		{"face too small", "v 0 0 0\nf 1 1\n", "test.holym:2:1: a face needs at least 3 corners"},
+		{"zero index", "v 0 0 0\nf 1 0 1\n", "test.holym:2:5: invalid vertex index \"0\""},
+		{"bad corner", "v 0 0 0\nf 1 1/2/3/4 1\n", "test.holym:2:5: invalid face corner \"1/2/3/4\" (expected V, V/VT, V//VN or V/VT/VN)"},
+		{"empty texcoord", "v 0 0 0\nf 1/ 1 1\n", "test.holym:2:5: missing texture coordinate index in \"1/\""},
+		{"bad texcoord index", "v 0 0 0\nvt 0 0\nf 1/a 1 1\n", "test.holym:3:5: invalid texture coordinate index \"a\""},
+		{"bad normal index", "v 0 0 0\nvn 0 0 1\nf 1 1//b 1\n", "test.holym:3:8: invalid normal index \"b\""},
+		{"vertex out of range", "v 0 0 0\nv 1 0 0\nf 1 2 3\n", "test.holym:3:7: vertex index 3 out of range (file has 2)"},
+		{"texcoord out of range", "v 0 0 0\nvt 0 0\nf 1/1 1/2 1/1\n", "test.holym:3:7: texture coordinate index 2 out of range (file has 1)"},
+		{"normal out of range", "v 0 0 0\nf 1//1 1 1\n", "test.holym:2:3: normal index 1 out of range (file has 0)"},
+		{"relative index too far back", "v 0 0 0\nf -1 -2 -1\n", "test.holym:2:6: relative vertex index -2 reaches before the first one"},
+		{"unknown keyword", "v 0 0 0\nusemtl wood\n", "test.holym:2:1: unknown keyword \"usemtl\""},
+		{"header after data", "v 0 0 0\nholym 1\n", "test.holym:2:1: the holym header must be the first line"},
+		{"bad version", "holym one\n", "test.holym:1:7: invalid version \"one\""},
+		{"future version", "holym 2\n", "test.holym:1:7: unsupported .holym version 2 (this build reads up to 1)"},
+		{"empty texture path", "tex_path   \n", "test.holym:1:1: expected \"tex_path <path>\""},
+		{"position after leading spaces", "   v 0 0 q\n", "test.holym:1:10: invalid number \"q\""},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input), "test.holym", true)
+		if err == nil {
+			t.Errorf("%s: expected error %q, got none", tt.name, tt.wantErr)
+			continue
+		}
+		var holymErr *Error
+		if !errors.As(err, &holymErr) {
+			t.Errorf("%s: error %v is not a *Error", tt.name, err)
+		}
+		if err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %q, want %q", tt.name, err.Error(), tt.wantErr)
+		}
+	}
+}
+
+func TestParseHolymLenientSkipsBadLines(t *testing.T) {
+	input := `v 0 0 0
+v 1 0 oops
+v 1 0 0
+usemtl ignored
+v 0 1 0
+f 1 2 3
+f 1 2 9
+`
+	mesh, err := Parse(strings.NewReader(input), "test.holym", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The bad vertex is skipped, so the face refers to the three good ones
+	wantVerts := []float32{
+		0, 0, 0, 1, 1, 1, 0, 0,
+		1, 0, 0, 1, 1, 1, 0, 0,
+		0, 1, 0, 1, 1, 1, 0, 0,
+	}
+	if !reflect.DeepEqual(mesh.Vertices, wantVerts) {
+		t.Errorf("vertices = %v, want %v", mesh.Vertices, wantVerts)
+	}
+	if !reflect.DeepEqual(mesh.Indices, []uint32{0, 1, 2}) {
+		t.Errorf("indices = %v, want [0 1 2]", mesh.Indices)
+	}
+
+	// A newer version is refused even when lenient
+	if _, err := Parse(strings.NewReader("holym 99\nv 0 0 0\n"), "test.holym", false); err == nil {
+		t.Error("expected an error for an unsupported version")
+	}
+}
+
+func TestParseHolymFileMissing(t *testing.T) {
+	_, err := ParseFile(filepath.Join(t.TempDir(), "missing.holym"), true)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("error = %v, want a not-exist error", err)
+	}
+}
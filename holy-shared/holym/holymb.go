@@ -0,0 +1,434 @@
+package holym
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"unsafe"
+
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+)
+
+// .holymb is the binary companion of .holym: the same mesh, already deduplicated and
+// interleaved, so loading it is a memory map and a checksum instead of a parse.
+// The layout is described in holy-mm/HOLYM.md.
+
+const (
+	holymbVersion       = 2  // Newest .holymb version this build reads and writes
+	holymbHeaderSize    = 64 // Bytes
+	holymbAttributeSize = 16 // Bytes per layout descriptor entry
+	holymbFloat32       = 1  // Component type of an attribute, the only one so far
+)
+
+var holymbMagic = [8]byte{'H', 'O', 'L', 'Y', 'M', 'B', 0, 0}
+
+// Attribute semantics in the vertex layout descriptor
+const (
+	holymbPosition = 1
+	holymbColor    = 2
+	holymbTexCoord = 3
+	holymbNormal   = 4
+	holymbTangent  = 5 // xyz, and the bitangent's sign in w
+)
+
+// GameObjectAttributes is GameObjectLayout as a .holymb layout. Files written by holy-mm use
+// it, so their vertices are uploaded as they are.
+var GameObjectAttributes = []Attribute{
+	{holymbPosition, 3, 0}, {holymbColor, 3, 12}, {holymbTexCoord, 2, 24}, {holymbNormal, 3, 32}, {holymbTangent, 4, 44},
+}
+
+// The checksum is CRC-32C (Castagnoli), which has hardware support on most CPUs
+var holymbCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// nativeLittleEndian is true when the file's little-endian data can be used in place.
+var nativeLittleEndian = *(*uint16)(unsafe.Pointer(&[2]byte{1, 0})) == 1
+
+// Attribute is one entry of the vertex layout descriptor.
+type Attribute struct {
+	Semantic   uint32 // holymbPosition, holymbColor, ...
+	Components uint32 // Number of float32s
+	Offset     uint32 // Byte offset inside a vertex
+}
+
+// Material is one entry of the material table. Each material owns a range of indices.
+type Material struct {
+	Name        string
+	FirstIndex  uint32
+	IndexCount  uint32
+	Color       [4]float32 // Base color (RGBA), used where there is no texture
+	TexturePath string     // Empty for untextured materials
+	NormalMap   string     // Empty when there is no normal map, always empty in version 1
+}
+
+// BinaryMesh is the content of a .holymb file.
+type BinaryMesh struct {
+	Layout    []Attribute
+	Stride    int       // Bytes per vertex
+	Vertices  []float32 // Interleaved as described by Layout
+	Indices   []uint32  // Triangles
+	Materials []Material
+
+	mapping []byte // The mapped file while Vertices and Indices point into it, see Close
+}
+
+// OpenBinary memory-maps a .holymb file and checks it. On little-endian machines Vertices and
+// Indices point straight into the mapping, so they are read-only and only valid until Close.
+func OpenBinary(filePath string) (*BinaryMesh, error) {
+	data, err := mapHolymbFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to map .holymb file: %w", err)
+	}
+	mesh, err := decodeHolymb(data, nativeLittleEndian)
+	if err != nil {
+		unmapHolymbFile(data)
+		return nil, fmt.Errorf("invalid .holymb file %s: %w", filePath, err)
+	}
+	if nativeLittleEndian {
+		mesh.mapping = data
+	} else {
+		unmapHolymbFile(data) // Everything was copied out
+	}
+	return mesh, nil
+}
+
+// Close unmaps the file behind the mesh. Vertices and Indices must not be used afterwards.
+func (m *BinaryMesh) Close() error {
+	if m.mapping == nil {
+		return nil
+	}
+	err := unmapHolymbFile(m.mapping)
+	m.mapping, m.Vertices, m.Indices = nil, nil, nil
+	return err
+}
+
+// decodeHolymb checks a whole .holymb file and reads its tables. With inPlace, Vertices and
+// Indices alias data instead of being copied, which needs a little-endian machine.
+func decodeHolymb(data []byte, inPlace bool) (*BinaryMesh, error) {
+	le := binary.LittleEndian
+	if len(data) < holymbHeaderSize || !bytes.Equal(data[:8], holymbMagic[:]) {
+		return nil, fmt.Errorf("not a .holymb file")
+	}
+	field := func(offset int) uint32 { return le.Uint32(data[offset:]) }
+
+	version := field(8)
+	if version == 0 || version > holymbVersion {
+		return nil, fmt.Errorf("unsupported .holymb version %d (this build reads up to %d)", version, holymbVersion)
+	}
+	headerSize := field(12)
+	checksum := field(20)
+	vertexCount, stride := field(24), field(28)
+	attributeCount, layoutOffset := field(32), field(36)
+	vertexOffset := field(40)
+	indexCount, indexOffset := field(44), field(48)
+	materialCount, materialOffset := field(52), field(56)
+	fileSize := field(60)
+
+	if fileSize != uint32(len(data)) {
+		return nil, fmt.Errorf("file is %d bytes, header says %d (truncated?)", len(data), fileSize)
+	}
+	if headerSize < holymbHeaderSize || headerSize > fileSize {
+		return nil, fmt.Errorf("invalid header size %d", headerSize)
+	}
+	if sum := crc32.Checksum(data[headerSize:], holymbCRCTable); sum != checksum {
+		return nil, fmt.Errorf("checksum mismatch (file says %08x, data is %08x)", checksum, sum)
+	}
+
+	// section returns the bytes of a table after checking it lies inside the file.
+	// Sizes are computed in 64 bits so corrupt counts can't overflow.
+	section := func(name string, offset uint32, count, size uint64) ([]byte, error) {
+		end := uint64(offset) + count*size
+		if offset < headerSize || offset%4 != 0 || end > uint64(fileSize) {
+			return nil, fmt.Errorf("%s table (offset %d, %d bytes) is outside the file", name, offset, count*size)
+		}
+		return data[offset:end], nil
+	}
+
+	mesh := &BinaryMesh{Stride: int(stride)}
+	if stride == 0 || stride%4 != 0 {
+		return nil, fmt.Errorf("invalid vertex stride %d", stride)
+	}
+
+	layout, err := section("layout", layoutOffset, uint64(attributeCount), holymbAttributeSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(layout); i += holymbAttributeSize {
+		attr := Attribute{Semantic: le.Uint32(layout[i:]), Components: le.Uint32(layout[i+4:]), Offset: le.Uint32(layout[i+12:])}
+		if componentType := le.Uint32(layout[i+8:]); componentType != holymbFloat32 {
+			return nil, fmt.Errorf("attribute %d has unsupported component type %d", attr.Semantic, componentType)
+		}
+		if attr.Components == 0 || attr.Offset%4 != 0 || uint64(attr.Offset)+uint64(attr.Components)*4 > uint64(stride) {
+			return nil, fmt.Errorf("attribute %d (offset %d, %d components) doesn't fit in a %d byte vertex", attr.Semantic, attr.Offset, attr.Components, stride)
+		}
+		mesh.Layout = append(mesh.Layout, attr)
+	}
+
+	vertexBytes, err := section("vertex", vertexOffset, uint64(vertexCount), uint64(stride))
+	if err != nil {
+		return nil, err
+	}
+	indexBytes, err := section("index", indexOffset, uint64(indexCount), 4)
+	if err != nil {
+		return nil, err
+	}
+	if indexCount%3 != 0 {
+		return nil, fmt.Errorf("%d indices is not a whole number of triangles", indexCount)
+	}
+	mesh.Vertices = holymbFloats(vertexBytes, inPlace)
+	mesh.Indices = holymbUint32s(indexBytes, inPlace)
+	for i, index := range mesh.Indices {
+		if index >= vertexCount {
+			return nil, fmt.Errorf("index %d is %d, but there are only %d vertices", i, index, vertexCount)
+		}
+	}
+
+	// The material table is last and its entries have variable length, so it runs to the end
+	if _, err := section("material", materialOffset, 0, 0); err != nil {
+		return nil, err
+	}
+	materials := data[materialOffset:]
+	readString := func() (string, error) {
+		if len(materials) < 4 {
+			return "", fmt.Errorf("material table is truncated")
+		}
+		n := uint64(le.Uint32(materials))
+		padded := (n + 3) &^ 3
+		if 4+padded > uint64(len(materials)) {
+			return "", fmt.Errorf("material table is truncated")
+		}
+		s := string(materials[4 : 4+n])
+		materials = materials[4+padded:]
+		return s, nil
+	}
+	for i := uint32(0); i < materialCount; i++ {
+		if len(materials) < 24 {
+			return nil, fmt.Errorf("material table is truncated")
+		}
+		mat := Material{FirstIndex: le.Uint32(materials), IndexCount: le.Uint32(materials[4:])}
+		for c := range mat.Color {
+			mat.Color[c] = math.Float32frombits(le.Uint32(materials[8+c*4:]))
+		}
+		materials = materials[24:]
+		if uint64(mat.FirstIndex)+uint64(mat.IndexCount) > uint64(indexCount) {
+			return nil, fmt.Errorf("material %d covers indices %d to %d, but there are only %d", i, mat.FirstIndex, uint64(mat.FirstIndex)+uint64(mat.IndexCount), indexCount)
+		}
+		if mat.Name, err = readString(); err != nil {
+			return nil, err
+		}
+		if mat.TexturePath, err = readString(); err != nil {
+			return nil, err
+		}
+		if version >= 2 {
+			if mat.NormalMap, err = readString(); err != nil {
+				return nil, err
+			}
+		}
+		mesh.Materials = append(mesh.Materials, mat)
+	}
+	return mesh, nil
+}
+
+// holymbFloats turns little-endian bytes into float32s, in place when possible.
+func holymbFloats(b []byte, inPlace bool) []float32 {
+	if len(b) == 0 {
+		return nil
+	}
+	if inPlace {
+		return unsafe.Slice((*float32)(unsafe.Pointer(&b[0])), len(b)/4)
+	}
+	values := make([]float32, len(b)/4)
+	for i := range values {
+		values[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return values
+}
+
+// holymbUint32s turns little-endian bytes into uint32s, in place when possible.
+func holymbUint32s(b []byte, inPlace bool) []uint32 {
+	if len(b) == 0 {
+		return nil
+	}
+	if inPlace {
+		return unsafe.Slice((*uint32)(unsafe.Pointer(&b[0])), len(b)/4)
+	}
+	values := make([]uint32, len(b)/4)
+	for i := range values {
+		values[i] = binary.LittleEndian.Uint32(b[i*4:])
+	}
+	return values
+}
+
+// attribute returns the layout entry for a semantic, or false if the vertices don't have it.
+func (m *BinaryMesh) attribute(semantic uint32) (Attribute, bool) {
+	for _, attr := range m.Layout {
+		if attr.Semantic == semantic {
+			return attr, true
+		}
+	}
+	return Attribute{}, false
+}
+
+// GameObjectMesh returns the vertices in the position (3) + color (3) + texcoord (2) +
+// normal (3) + tangent (4) layout GameObjects use, and the indices that go with them. That is
+// Vertices and Indices themselves when the file already has that layout, otherwise a
+// converted copy with white color and (0, 0) texcoords where they are missing, and normals
+// and tangents computed from the triangles.
+func (m *BinaryMesh) GameObjectMesh() ([]float32, []uint32) {
+	if m.Stride == GameObjectFloats*4 && len(m.Layout) == len(GameObjectAttributes) {
+		same := true
+		for i := range GameObjectAttributes {
+			same = same && m.Layout[i] == GameObjectAttributes[i]
+		}
+		if same {
+			return m.Vertices, m.Indices
+		}
+	}
+
+	floatsPerVertex := m.Stride / 4
+	vertexCount := len(m.Vertices) / floatsPerVertex
+	vertices := make([]float32, 0, vertexCount*GameObjectFloats)
+	defaults := []struct {
+		semantic uint32
+		values   []float32
+	}{
+		{holymbPosition, []float32{0, 0, 0}},
+		{holymbColor, []float32{1, 1, 1}},
+		{holymbTexCoord, []float32{0, 0}},
+		{holymbNormal, []float32{0, 0, 0}},     // Filled in below
+		{holymbTangent, []float32{0, 0, 0, 0}}, // Same
+	}
+	for v := 0; v < vertexCount; v++ {
+		src := m.Vertices[v*floatsPerVertex : (v+1)*floatsPerVertex]
+		for _, d := range defaults {
+			attr, ok := m.attribute(d.semantic)
+			for c := range d.values {
+				if ok && c < int(attr.Components) {
+					vertices = append(vertices, src[int(attr.Offset)/4+c])
+				} else {
+					vertices = append(vertices, d.values[c])
+				}
+			}
+		}
+	}
+	meshgen.FillMissingNormals(vertices, m.Indices, GameObjectLayout)
+	return meshgen.GenerateTangents(vertices, m.Indices, GameObjectLayout)
+}
+
+// TexturePath returns the first texture in the material table, GameObjects only have one.
+func (m *BinaryMesh) TexturePath() string {
+	for _, mat := range m.Materials {
+		if mat.TexturePath != "" {
+			return mat.TexturePath
+		}
+	}
+	return ""
+}
+
+// NormalMap returns the first normal map in the material table, like TexturePath.
+func (m *BinaryMesh) NormalMap() string {
+	for _, mat := range m.Materials {
+		if mat.NormalMap != "" {
+			return mat.NormalMap
+		}
+	}
+	return ""
+}
+
+// writeHolymb writes mesh in the .holymb format.
+func writeHolymb(w io.Writer, mesh *BinaryMesh) error {
+	le := binary.LittleEndian
+	if mesh.Stride <= 0 || mesh.Stride%4 != 0 || len(mesh.Vertices)*4%mesh.Stride != 0 {
+		return fmt.Errorf("vertex data doesn't match the %d byte stride", mesh.Stride)
+	}
+	for _, attr := range mesh.Layout {
+		if attr.Components == 0 || attr.Offset%4 != 0 || int(attr.Offset)+int(attr.Components)*4 > mesh.Stride {
+			return fmt.Errorf("attribute %d doesn't fit in a %d byte vertex", attr.Semantic, mesh.Stride)
+		}
+	}
+	vertexCount := len(mesh.Vertices) * 4 / mesh.Stride
+	if len(mesh.Indices)%3 != 0 {
+		return fmt.Errorf("%d indices is not a whole number of triangles", len(mesh.Indices))
+	}
+	for _, index := range mesh.Indices {
+		if int(index) >= vertexCount {
+			return fmt.Errorf("index %d out of range (%d vertices)", index, vertexCount)
+		}
+	}
+
+	// Everything after the header is built first, the header needs its size and checksum
+	var body bytes.Buffer
+	put := func(values ...uint32) {
+		for _, v := range values {
+			binary.Write(&body, le, v)
+		}
+	}
+	putString := func(s string) {
+		put(uint32(len(s)))
+		body.WriteString(s)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	layoutOffset := holymbHeaderSize + body.Len()
+	for _, attr := range mesh.Layout {
+		put(attr.Semantic, attr.Components, holymbFloat32, attr.Offset)
+	}
+	vertexOffset := holymbHeaderSize + body.Len()
+	binary.Write(&body, le, mesh.Vertices)
+	indexOffset := holymbHeaderSize + body.Len()
+	binary.Write(&body, le, mesh.Indices)
+	materialOffset := holymbHeaderSize + body.Len()
+	for _, mat := range mesh.Materials {
+		if uint64(mat.FirstIndex)+uint64(mat.IndexCount) > uint64(len(mesh.Indices)) {
+			return fmt.Errorf("material %q covers indices past the end", mat.Name)
+		}
+		put(mat.FirstIndex, mat.IndexCount)
+		binary.Write(&body, le, mat.Color)
+		putString(mat.Name)
+		putString(mat.TexturePath)
+		putString(mat.NormalMap)
+	}
+	fileSize := uint64(holymbHeaderSize + body.Len())
+	if fileSize > math.MaxUint32 {
+		return fmt.Errorf("mesh is too large for a .holymb file (%d bytes, limit 4 GiB)", fileSize)
+	}
+
+	header := make([]byte, holymbHeaderSize)
+	copy(header, holymbMagic[:])
+	for i, v := range []uint32{
+		holymbVersion, holymbHeaderSize, 0, // Version, header size, flags (none yet)
+		crc32.Checksum(body.Bytes(), holymbCRCTable),
+		uint32(vertexCount), uint32(mesh.Stride),
+		uint32(len(mesh.Layout)), uint32(layoutOffset),
+		uint32(vertexOffset),
+		uint32(len(mesh.Indices)), uint32(indexOffset),
+		uint32(len(mesh.Materials)), uint32(materialOffset),
+		uint32(fileSize),
+	} {
+		le.PutUint32(header[8+i*4:], v)
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := body.WriteTo(w)
+	return err
+}
+
+// WriteBinaryFile writes mesh to a new .holymb file.
+func WriteBinaryFile(filePath string, mesh *BinaryMesh) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to create .holymb file: %w", err)
+	}
+	if err := writeHolymb(file, mesh); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write .holymb file: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package holym
+
+import "os"
+
+// mapHolymbFile reads the whole file into memory on systems without mmap support here
+// (Windows). The rest of the loader can't tell the difference.
+func mapHolymbFile(filePath string) ([]byte, error) {
+	return os.ReadFile(filePath)
+}
+
+// unmapHolymbFile releases data from mapHolymbFile. The garbage collector frees it.
+func unmapHolymbFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package holym
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// mapHolymbFile maps a whole file read-only. The pages are shared with the OS file cache
+// instead of copied onto the Go heap, though the checksum in OpenBinary still reads every
+// one of them once when the model is opened.
+func mapHolymbFile(filePath string) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() // The mapping stays valid after the file is closed
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size == 0 {
+		return nil, fmt.Errorf("%s is empty", filePath)
+	}
+	if int64(int(size)) != size {
+		return nil, fmt.Errorf("%s is too large to map", filePath)
+	}
+	return syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// unmapHolymbFile releases a mapping made by mapHolymbFile.
+func unmapHolymbFile(data []byte) error {
+	return syscall.Munmap(data)
+}
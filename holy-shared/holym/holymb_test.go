@@ -0,0 +1,280 @@
+package holym
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testQuad is a unit quad in the GameObject layout, split between two materials.
+func testQuad() *BinaryMesh {
+	return &BinaryMesh{
+		Layout: GameObjectAttributes,
+		Stride: GameObjectFloats * 4,
+		Vertices: []float32{
+			0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 1, 1, 0, 0, -1,
+			1, 0, 0, 1, 1, 1, 1, 0, 0, 0, 1, 1, 0, 0, -1,
+			1, 1, 0, 1, 1, 1, 1, 1, 0, 0, 1, 1, 0, 0, -1,
+			0, 1, 0, 1, 1, 1, 0, 1, 0, 0, 1, 1, 0, 0, -1,
+		},
+		Indices: []uint32{0, 1, 2, 0, 2, 3},
+		Materials: []Material{
+			{Name: "front", FirstIndex: 0, IndexCount: 3, Color: [4]float32{1, 0, 0, 1}, TexturePath: "textures/my crate.png"},
+			{Name: "back", FirstIndex: 3, IndexCount: 3, Color: [4]float32{0, 1, 0, 0.5}, NormalMap: "n.png"},
+		},
+	}
+}
+
+// encodeHolymb writes a mesh to memory, failing the test if it can't.
+func encodeHolymb(t *testing.T, mesh *BinaryMesh) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writeHolymb(&buf, mesh); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// resealHolymb fixes the file size and checksum in the header after data was changed, so
+// decodeHolymb gets past them to the tables.
+func resealHolymb(data []byte) []byte {
+	le := binary.LittleEndian
+	le.PutUint32(data[60:], uint32(len(data)))
+	le.PutUint32(data[20:], crc32.Checksum(data[holymbHeaderSize:], holymbCRCTable))
+	return data
+}
+
+func TestHolymbRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		mesh *BinaryMesh
+	}{
+		{"gameobject layout with materials", testQuad()},
+		{
+			name: "custom layout without materials",
+			mesh: &BinaryMesh{
+				Layout:   []Attribute{{holymbPosition, 3, 0}, {holymbTexCoord, 2, 12}},
+				Stride:   20,
+				Vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1},
+				Indices:  []uint32{0, 1, 2},
+			},
+		},
+		{
+			// String lengths 1, 2, 3 and 4 cover every amount of padding
+			name: "padded strings",
+			mesh: &BinaryMesh{
+				Layout:   []Attribute{{holymbPosition, 3, 0}},
+				Stride:   12,
+				Vertices: []float32{0, 0, 0, 1, 0, 0, 0, 1, 0},
+				Indices:  []uint32{0, 1, 2},
+				Materials: []Material{
+					{Name: "a", IndexCount: 3, TexturePath: "bc", NormalMap: "def"},
+					{Name: "ghij", IndexCount: 3},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		data := encodeHolymb(t, tt.mesh)
+		inPlaceModes := []bool{false}
+		if nativeLittleEndian {
+			inPlaceModes = append(inPlaceModes, true)
+		}
+		for _, inPlace := range inPlaceModes {
+			got, err := decodeHolymb(append([]byte(nil), data...), inPlace)
+			if err != nil {
+				t.Errorf("%s (inPlace=%v): unexpected error: %v", tt.name, inPlace, err)
+				continue
+			}
+			if !reflect.DeepEqual(got.Layout, tt.mesh.Layout) {
+				t.Errorf("%s (inPlace=%v): layout = %v, want %v", tt.name, inPlace, got.Layout, tt.mesh.Layout)
+			}
+			if got.Stride != tt.mesh.Stride {
+				t.Errorf("%s (inPlace=%v): stride = %d, want %d", tt.name, inPlace, got.Stride, tt.mesh.Stride)
+			}
+			if !reflect.DeepEqual(got.Vertices, tt.mesh.Vertices) {
+				t.Errorf("%s (inPlace=%v): vertices = %v, want %v", tt.name, inPlace, got.Vertices, tt.mesh.Vertices)
+			}
+			if !reflect.DeepEqual(got.Indices, tt.mesh.Indices) {
+				t.Errorf("%s (inPlace=%v): indices = %v, want %v", tt.name, inPlace, got.Indices, tt.mesh.Indices)
+			}
+			if !reflect.DeepEqual(got.Materials, tt.mesh.Materials) {
+				t.Errorf("%s (inPlace=%v): materials = %+v, want %+v", tt.name, inPlace, got.Materials, tt.mesh.Materials)
+			}
+		}
+	}
+
+	// Through a file, which is memory-mapped where the system allows it
+	path := filepath.Join(t.TempDir(), "quad.holymb")
+	want := testQuad()
+	if err := WriteBinaryFile(path, want); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	mesh, err := OpenBinary(path)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if !reflect.DeepEqual(mesh.Vertices, want.Vertices) || !reflect.DeepEqual(mesh.Indices, want.Indices) {
+		t.Errorf("file: mesh data changed in the round trip")
+	}
+	if mesh.TexturePath() != "textures/my crate.png" || mesh.NormalMap() != "n.png" {
+		t.Errorf("file: texture = %q, normal map = %q", mesh.TexturePath(), mesh.NormalMap())
+	}
+	if err := mesh.Close(); err != nil {
+		t.Errorf("close failed: %v", err)
+	}
+	if mesh.Vertices != nil || mesh.Indices != nil {
+		t.Errorf("file: vertices and indices are still set after Close")
+	}
+}
+
+func TestDecodeHolymbErrors(t *testing.T) {
+	le := binary.LittleEndian
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+		wantErr string // Part of the expected error
+	}{
+		{"short header", func(data []byte) []byte { return data[:40] }, "not a .holymb file"},
+		{"bad magic", func(data []byte) []byte { data[0] = 'X'; return data }, "not a .holymb file"},
+		{"future version", func(data []byte) []byte { le.PutUint32(data[8:], holymbVersion+1); return data }, "unsupported .holymb version 3"},
+		{"truncated file", func(data []byte) []byte { return data[:len(data)-8] }, "truncated?"},
+		{"checksum mismatch", func(data []byte) []byte { data[len(data)-1] ^= 0xff; return data }, "checksum mismatch"},
+		{"checksum field", func(data []byte) []byte { data[20]++; return data }, "checksum mismatch"},
+		{"layout past the end", func(data []byte) []byte {
+			le.PutUint32(data[32:], 1000) // Attribute count
+			return resealHolymb(data)
+		}, "layout table"},
+		{"vertices past the end", func(data []byte) []byte {
+			le.PutUint32(data[24:], 1000) // Vertex count
+			return resealHolymb(data)
+		}, "vertex table"},
+		{"indices past the end", func(data []byte) []byte {
+			le.PutUint32(data[44:], 999) // Index count
+			return resealHolymb(data)
+		}, "index table"},
+		{"vertex count overflow", func(data []byte) []byte {
+			le.PutUint32(data[24:], math.MaxUint32)
+			return resealHolymb(data)
+		}, "vertex table"},
+		{"material table cut short", func(data []byte) []byte {
+			return resealHolymb(data[:len(data)-12])
+		}, "material table is truncated"},
+		{"material entry cut short", func(data []byte) []byte {
+			materialOffset := le.Uint32(data[56:])
+			return resealHolymb(data[:materialOffset+20])
+		}, "material table is truncated"},
+		{"index out of range", func(data []byte) []byte {
+			indexOffset := le.Uint32(data[48:])
+			le.PutUint32(data[indexOffset:], 4)
+			return resealHolymb(data)
+		}, "index 0 is 4, but there are only 4 vertices"},
+		{"material past the indices", func(data []byte) []byte {
+			materialOffset := le.Uint32(data[56:])
+			le.PutUint32(data[materialOffset+4:], 7) // First material's index count
+			return resealHolymb(data)
+		}, "material 0 covers indices 0 to 7"},
+		{"attribute outside the vertex", func(data []byte) []byte {
+			layoutOffset := le.Uint32(data[36:])
+			le.PutUint32(data[layoutOffset+12:], 60) // Position's offset
+			return resealHolymb(data)
+		}, "doesn't fit in a 60 byte vertex"},
+	}
+
+	valid := encodeHolymb(t, testQuad())
+	for _, tt := range tests {
+		data := tt.corrupt(append([]byte(nil), valid...))
+		_, err := decodeHolymb(data, false)
+		if err == nil {
+			t.Errorf("%s: expected an error containing %q, got none", tt.name, tt.wantErr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %q, want it to contain %q", tt.name, err.Error(), tt.wantErr)
+		}
+	}
+}
+
+func TestHolymbGameObjectMesh(t *testing.T) {
+	// A triangle in the z = 0 plane, facing +Z
+	tests := []struct {
+		name         string
+		mesh         *BinaryMesh
+		wantVertices []float32 // In the GameObject layout
+	}{
+		{
+			name: "position and texcoord",
+			mesh: &BinaryMesh{
+				Layout:   []Attribute{{holymbPosition, 3, 0}, {holymbTexCoord, 2, 12}},
+				Stride:   20,
+				Vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1},
+				Indices:  []uint32{0, 1, 2},
+			},
+			wantVertices: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0, 0, 0, 1, 1, 0, 0, -1,
+				1, 0, 0, 1, 1, 1, 1, 0, 0, 0, 1, 1, 0, 0, -1,
+				0, 1, 0, 1, 1, 1, 0, 1, 0, 0, 1, 1, 0, 0, -1,
+			},
+		},
+		{
+			// Attributes in another order, and no UVs to take a tangent from
+			name: "color before position",
+			mesh: &BinaryMesh{
+				Layout:   []Attribute{{holymbColor, 3, 0}, {holymbPosition, 3, 12}},
+				Stride:   24,
+				Vertices: []float32{1, 0, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 1, 0, 1, 0},
+				Indices:  []uint32{0, 1, 2},
+			},
+			wantVertices: []float32{
+				0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 1, -1, 0, 0, 1,
+				1, 0, 0, 0, 1, 0, 0, 0, 0, 0, 1, -1, 0, 0, 1,
+				0, 1, 0, 0, 0, 1, 0, 0, 0, 0, 1, -1, 0, 0, 1,
+			},
+		},
+		{
+			// A normal with only two components keeps them and gets 0 for the third
+			name: "short normal",
+			mesh: &BinaryMesh{
+				Layout:   []Attribute{{holymbPosition, 3, 0}, {holymbNormal, 2, 12}},
+				Stride:   20,
+				Vertices: []float32{0, 0, 0, 0, 1, 1, 0, 0, 0, 1, 0, 1, 0, 0, 1},
+				Indices:  []uint32{0, 1, 2},
+			},
+			wantVertices: []float32{
+				0, 0, 0, 1, 1, 1, 0, 0, 0, 1, 0, 0, 0, -1, 1,
+				1, 0, 0, 1, 1, 1, 0, 0, 0, 1, 0, 0, 0, -1, 1,
+				0, 1, 0, 1, 1, 1, 0, 0, 0, 1, 0, 0, 0, -1, 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		vertices, indices := tt.mesh.GameObjectMesh()
+		if !reflect.DeepEqual(indices, tt.mesh.Indices) {
+			t.Errorf("%s: indices = %v, want %v", tt.name, indices, tt.mesh.Indices)
+		}
+		if len(vertices) != len(tt.wantVertices) {
+			t.Errorf("%s: %d floats, want %d", tt.name, len(vertices), len(tt.wantVertices))
+			continue
+		}
+		for i := range vertices {
+			if math.Abs(float64(vertices[i]-tt.wantVertices[i])) > 1e-5 {
+				t.Errorf("%s: vertices = %v, want %v", tt.name, vertices, tt.wantVertices)
+				break
+			}
+		}
+	}
+
+	// Files already in the GameObject layout are used as they are, without a copy
+	quad := testQuad()
+	vertices, indices := quad.GameObjectMesh()
+	if &vertices[0] != &quad.Vertices[0] || &indices[0] != &quad.Indices[0] {
+		t.Errorf("a mesh in the GameObject layout was copied")
+	}
+}
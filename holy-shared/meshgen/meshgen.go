@@ -0,0 +1,193 @@
+// Package meshgen fills in the vertex attributes a mesh file may leave out: normals and
+// the tangents normal maps need. Vertices are interleaved float32s, described by a Layout.
+package meshgen
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Layout is where the attributes are in an interleaved vertex, counted in floats. The
+// position is always the first three.
+type Layout struct {
+	Stride   int // Floats per vertex
+	TexCoord int // Offset of the texture coordinates (2)
+	Normal   int // Offset of the normal (3)
+	Tangent  int // Offset of the tangent (3) and the handedness (1)
+}
+
+// FillMissingNormals gives every vertex whose normal is (0, 0, 0) the average of the normals
+// of the triangles around it, weighted by their area. Vertices shared between triangles
+// come out smooth, split ones (like a cube's corners) keep hard edges.
+func FillMissingNormals(vertices []float32, indices []uint32, layout Layout) {
+	stride := layout.Stride
+	vertexCount := len(vertices) / stride
+	missing := make([]bool, vertexCount)
+	anyMissing := false
+	for v := 0; v < vertexCount; v++ {
+		n := vertices[v*stride+layout.Normal : v*stride+layout.Normal+3]
+		missing[v] = n[0] == 0 && n[1] == 0 && n[2] == 0
+		anyMissing = anyMissing || missing[v]
+	}
+	if !anyMissing {
+		return
+	}
+
+	position := func(i uint32) mgl32.Vec3 {
+		base := int(i) * stride
+		return mgl32.Vec3{vertices[base], vertices[base+1], vertices[base+2]}
+	}
+	sums := make([]mgl32.Vec3, vertexCount)
+	for t := 0; t+2 < len(indices); t += 3 {
+		i0, i1, i2 := indices[t], indices[t+1], indices[t+2]
+		if int(i0) >= vertexCount || int(i1) >= vertexCount || int(i2) >= vertexCount {
+			continue
+		}
+		p0 := position(i0)
+		// The cross product's length is twice the triangle's area, which does the weighting
+		faceNormal := position(i1).Sub(p0).Cross(position(i2).Sub(p0))
+		for _, i := range [3]uint32{i0, i1, i2} {
+			sums[i] = sums[i].Add(faceNormal)
+		}
+	}
+	for v := 0; v < vertexCount; v++ {
+		if !missing[v] || sums[v].Len() == 0 {
+			continue
+		}
+		n := sums[v].Normalize()
+		copy(vertices[v*stride+layout.Normal:v*stride+layout.Normal+3], n[:])
+	}
+}
+
+// GenerateTangents computes the tangent of every vertex whose tangent is (0, 0, 0, 0), the
+// way MikkTSpace does, so normal maps baked by other tools come out right:
+//   - each triangle's tangent follows its U direction, with the bitangent's sign
+//     (the UV mapping's handedness) in w,
+//   - corners add their triangle's tangent, projected onto the vertex normal's plane and
+//     weighted by the corner's angle,
+//   - a vertex shared by triangles of both handedness (where mirrored UVs meet) is split
+//     in two, so neither side is averaged with the other.
+//
+// The shader rebuilds the bitangent as cross(normal, tangent) * w, pointing to the top of
+// the image like glTF's: images are uploaded top row first, so that is towards -V. Vertices
+// without usable UVs get some tangent perpendicular to their normal. The input isn't
+// modified, it may be a read-only mapped file; the returned vertices may have more entries
+// than the input, the indices are the same count with split vertices renumbered.
+func GenerateTangents(vertices []float32, indices []uint32, layout Layout) ([]float32, []uint32) {
+	stride := layout.Stride
+	vertexCount := len(vertices) / stride
+	missing := make([]bool, vertexCount)
+	anyMissing := false
+	for v := 0; v < vertexCount; v++ {
+		t := vertices[v*stride+layout.Tangent : v*stride+layout.Tangent+4]
+		missing[v] = t[0] == 0 && t[1] == 0 && t[2] == 0 && t[3] == 0
+		anyMissing = anyMissing || missing[v]
+	}
+	if !anyMissing {
+		return vertices, indices
+	}
+	vertices = append([]float32(nil), vertices...)
+	indices = append([]uint32(nil), indices...)
+
+	vec3 := func(i uint32, offset int) mgl32.Vec3 {
+		base := int(i)*stride + offset
+		return mgl32.Vec3{vertices[base], vertices[base+1], vertices[base+2]}
+	}
+	uv := func(i uint32) mgl32.Vec2 {
+		base := int(i)*stride + layout.TexCoord
+		return mgl32.Vec2{vertices[base], vertices[base+1]}
+	}
+
+	// Each missing vertex keeps the handedness of the first triangle that uses it, triangles
+	// of the other handedness get a copy. Both slices grow with the copies.
+	sums := make([]mgl32.Vec3, vertexCount)
+	signs := make([]float32, vertexCount) // 0 until a triangle with UVs uses the vertex
+	mirrored := make(map[uint32]uint32)   // Vertex -> its copy for the other handedness
+
+	for t := 0; t+2 < len(indices); t += 3 {
+		corners := [3]uint32{indices[t], indices[t+1], indices[t+2]}
+		if int(corners[0]) >= vertexCount || int(corners[1]) >= vertexCount || int(corners[2]) >= vertexCount {
+			continue
+		}
+		p0, p1, p2 := vec3(corners[0], 0), vec3(corners[1], 0), vec3(corners[2], 0)
+		uv0, uv1, uv2 := uv(corners[0]), uv(corners[1]), uv(corners[2])
+		e1, e2 := p1.Sub(p0), p2.Sub(p0)
+		du1, dv1 := uv1.X()-uv0.X(), uv1.Y()-uv0.Y()
+		du2, dv2 := uv2.X()-uv0.X(), uv2.Y()-uv0.Y()
+		r := du1*dv2 - du2*dv1
+		if r == 0 {
+			continue // No UV area, the triangle doesn't say which way U goes
+		}
+		faceTangent := e1.Mul(dv2).Sub(e2.Mul(dv1)).Mul(1 / r)
+		faceBitangent := e2.Mul(du1).Sub(e1.Mul(du2)).Mul(1 / r)
+		// Handedness is taken against the vertex normals rather than the winding, some
+		// meshes here have inside-out triangles that are still lit from their normals' side
+		faceNormal := vec3(corners[0], layout.Normal).Add(vec3(corners[1], layout.Normal)).Add(vec3(corners[2], layout.Normal))
+		if faceNormal.Len() == 0 {
+			faceNormal = e1.Cross(e2)
+		}
+		faceSign := float32(1)
+		if faceNormal.Cross(faceTangent).Dot(faceBitangent) > 0 { // The bitangent goes towards -V
+			faceSign = -1
+		}
+
+		for k, i := range corners {
+			if !missing[i] {
+				continue
+			}
+			if signs[i] == 0 {
+				signs[i] = faceSign
+			} else if signs[i] != faceSign {
+				copyIndex, ok := mirrored[i]
+				if !ok {
+					copyIndex = uint32(len(vertices) / stride)
+					vertices = append(vertices, vertices[int(i)*stride:int(i+1)*stride]...)
+					sums = append(sums, mgl32.Vec3{})
+					signs = append(signs, faceSign)
+					mirrored[i] = copyIndex
+				}
+				indices[t+k] = copyIndex
+				i = copyIndex
+			}
+
+			// The corner's angle, and the tangent flattened onto the vertex normal's plane
+			p := [3]mgl32.Vec3{p0, p1, p2}
+			a, b := p[(k+1)%3].Sub(p[k]), p[(k+2)%3].Sub(p[k])
+			if a.Len() == 0 || b.Len() == 0 {
+				continue
+			}
+			angle := float32(math.Acos(float64(mgl32.Clamp(a.Normalize().Dot(b.Normalize()), -1, 1))))
+			normal := vec3(i, layout.Normal)
+			projected := faceTangent.Sub(normal.Mul(normal.Dot(faceTangent)))
+			if projected.Len() > 0 {
+				sums[i] = sums[i].Add(projected.Normalize().Mul(angle))
+			}
+		}
+	}
+
+	for v := range sums {
+		if v < vertexCount && !missing[v] {
+			continue
+		}
+		normal := vec3(uint32(v), layout.Normal)
+		tangent := sums[v]
+		if tangent.Len() == 0 {
+			// No UVs to go by, any direction along the surface will do
+			tangent = normal.Cross(mgl32.Vec3{0, 1, 0})
+			if tangent.Len() < 0.001 {
+				tangent = normal.Cross(mgl32.Vec3{1, 0, 0})
+			}
+			if tangent.Len() == 0 {
+				tangent = mgl32.Vec3{1, 0, 0} // Not even a normal
+			}
+		}
+		tangent = tangent.Normalize()
+		w := signs[v]
+		if w == 0 {
+			w = 1
+		}
+		copy(vertices[v*stride+layout.Tangent:v*stride+layout.Tangent+4], []float32{tangent.X(), tangent.Y(), tangent.Z(), w})
+	}
+	return vertices, indices
+}
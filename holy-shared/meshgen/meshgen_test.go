@@ -0,0 +1,197 @@
+package meshgen
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// testLayouts are holy-engine-base's and holy-mm's vertex layout, and holy-spinning-models'.
+var testLayouts = []Layout{
+	{Stride: 15, TexCoord: 6, Normal: 8, Tangent: 11},
+	{Stride: 12, TexCoord: 3, Normal: 5, Tangent: 8},
+}
+
+// testVertex is a vertex before it is packed into a layout.
+type testVertex struct {
+	pos, normal mgl32.Vec3
+	uv          mgl32.Vec2
+	tangent     mgl32.Vec4
+}
+
+// pack interleaves vertices in a layout, with 0 in any floats the layout has besides.
+func pack(vertices []testVertex, layout Layout) []float32 {
+	packed := make([]float32, len(vertices)*layout.Stride)
+	for i, v := range vertices {
+		base := i * layout.Stride
+		copy(packed[base:], v.pos[:])
+		copy(packed[base+layout.TexCoord:], v.uv[:])
+		copy(packed[base+layout.Normal:], v.normal[:])
+		copy(packed[base+layout.Tangent:], v.tangent[:])
+	}
+	return packed
+}
+
+func TestGenerateTangents(t *testing.T) {
+	up := mgl32.Vec3{0, 0, 1}
+	tests := []struct {
+		name         string
+		vertices     []testVertex
+		indices      []uint32
+		wantIndices  []uint32
+		wantFrom     []int        // The input vertex each output vertex is, or is a copy of
+		wantTangents []mgl32.Vec4 // Per output vertex
+	}{
+		{
+			// V goes up the triangle, the bitangent towards -V points down: right handed
+			name: "V up",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}, uv: mgl32.Vec2{0, 0}, normal: up},
+				{pos: mgl32.Vec3{1, 0, 0}, uv: mgl32.Vec2{1, 0}, normal: up},
+				{pos: mgl32.Vec3{0, 1, 0}, uv: mgl32.Vec2{0, 1}, normal: up},
+			},
+			indices:      []uint32{0, 1, 2},
+			wantIndices:  []uint32{0, 1, 2},
+			wantFrom:     []int{0, 1, 2},
+			wantTangents: []mgl32.Vec4{{1, 0, 0, -1}, {1, 0, 0, -1}, {1, 0, 0, -1}},
+		},
+		{
+			name: "V down",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}, uv: mgl32.Vec2{0, 1}, normal: up},
+				{pos: mgl32.Vec3{1, 0, 0}, uv: mgl32.Vec2{1, 1}, normal: up},
+				{pos: mgl32.Vec3{0, 1, 0}, uv: mgl32.Vec2{0, 0}, normal: up},
+			},
+			indices:      []uint32{0, 1, 2},
+			wantIndices:  []uint32{0, 1, 2},
+			wantFrom:     []int{0, 1, 2},
+			wantTangents: []mgl32.Vec4{{1, 0, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1}},
+		},
+		{
+			// The right triangle's U runs backwards, like the mirrored half of a symmetric
+			// model. The two vertices on the seam are split, the copies go to the right one.
+			name: "mirrored UVs",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}, uv: mgl32.Vec2{0, 0}, normal: up},
+				{pos: mgl32.Vec3{1, 0, 0}, uv: mgl32.Vec2{1, 0}, normal: up},
+				{pos: mgl32.Vec3{1, 1, 0}, uv: mgl32.Vec2{1, 1}, normal: up},
+				{pos: mgl32.Vec3{2, 0, 0}, uv: mgl32.Vec2{0, 0}, normal: up},
+			},
+			indices:     []uint32{0, 1, 2, 1, 3, 2},
+			wantIndices: []uint32{0, 1, 2, 4, 3, 5},
+			wantFrom:    []int{0, 1, 2, 3, 1, 2},
+			wantTangents: []mgl32.Vec4{
+				{1, 0, 0, -1}, {1, 0, 0, -1}, {1, 0, 0, -1},
+				{-1, 0, 0, 1}, {-1, 0, 0, 1}, {-1, 0, 0, 1},
+			},
+		},
+		{
+			// All corners have the same UV, so the tangent is just across the normal
+			name: "no UVs",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}, normal: up},
+				{pos: mgl32.Vec3{1, 0, 0}, normal: up},
+				{pos: mgl32.Vec3{0, 0, 1}, normal: mgl32.Vec3{0, 1, 0}},
+			},
+			indices:      []uint32{0, 1, 2},
+			wantIndices:  []uint32{0, 1, 2},
+			wantFrom:     []int{0, 1, 2},
+			wantTangents: []mgl32.Vec4{{-1, 0, 0, 1}, {-1, 0, 0, 1}, {0, 0, -1, 1}},
+		},
+		{
+			// Tangents from the file are kept, only the missing one is computed
+			name: "partly given",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}, uv: mgl32.Vec2{0, 0}, normal: up, tangent: mgl32.Vec4{0, 1, 0, 1}},
+				{pos: mgl32.Vec3{1, 0, 0}, uv: mgl32.Vec2{1, 0}, normal: up, tangent: mgl32.Vec4{0, 1, 0, 1}},
+				{pos: mgl32.Vec3{0, 1, 0}, uv: mgl32.Vec2{0, 1}, normal: up},
+			},
+			indices:      []uint32{0, 1, 2},
+			wantIndices:  []uint32{0, 1, 2},
+			wantFrom:     []int{0, 1, 2},
+			wantTangents: []mgl32.Vec4{{0, 1, 0, 1}, {0, 1, 0, 1}, {1, 0, 0, -1}},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, layout := range testLayouts {
+			input := pack(tt.vertices, layout)
+			original := append([]float32(nil), input...)
+			vertices, indices := GenerateTangents(input, append([]uint32(nil), tt.indices...), layout)
+
+			if !reflect.DeepEqual(input, original) {
+				t.Errorf("%s (stride %d): input vertices were modified", tt.name, layout.Stride)
+			}
+			if !reflect.DeepEqual(indices, tt.wantIndices) {
+				t.Errorf("%s (stride %d): indices = %v, want %v", tt.name, layout.Stride, indices, tt.wantIndices)
+			}
+			if len(vertices) != len(tt.wantFrom)*layout.Stride {
+				t.Errorf("%s (stride %d): %d vertices, want %d", tt.name, layout.Stride, len(vertices)/layout.Stride, len(tt.wantFrom))
+				continue
+			}
+			for v, from := range tt.wantFrom {
+				got := vertices[v*layout.Stride : (v+1)*layout.Stride]
+				want := original[from*layout.Stride : (from+1)*layout.Stride]
+				// Everything but the tangent comes from the input vertex
+				if !reflect.DeepEqual(got[:layout.Tangent], want[:layout.Tangent]) || !reflect.DeepEqual(got[layout.Tangent+4:], want[layout.Tangent+4:]) {
+					t.Errorf("%s (stride %d): vertex %d = %v, want a copy of vertex %d %v", tt.name, layout.Stride, v, got, from, want)
+				}
+				tangent := mgl32.Vec4{got[layout.Tangent], got[layout.Tangent+1], got[layout.Tangent+2], got[layout.Tangent+3]}
+				if !tangent.ApproxEqualThreshold(tt.wantTangents[v], 1e-5) {
+					t.Errorf("%s (stride %d): vertex %d tangent = %v, want %v", tt.name, layout.Stride, v, tangent, tt.wantTangents[v])
+				}
+			}
+		}
+	}
+}
+
+func TestFillMissingNormals(t *testing.T) {
+	tests := []struct {
+		name        string
+		vertices    []testVertex
+		indices     []uint32
+		wantNormals []mgl32.Vec3
+	}{
+		{
+			name: "flat triangle",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}},
+				{pos: mgl32.Vec3{1, 0, 0}},
+				{pos: mgl32.Vec3{0, 1, 0}},
+			},
+			indices:     []uint32{0, 1, 2},
+			wantNormals: []mgl32.Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		},
+		{
+			// The shared edge is smoothed between the floor and the wall, the normal the
+			// file gave the last vertex is kept
+			name: "shared edge",
+			vertices: []testVertex{
+				{pos: mgl32.Vec3{0, 0, 0}},
+				{pos: mgl32.Vec3{1, 0, 0}},
+				{pos: mgl32.Vec3{0, 0, -1}},
+				{pos: mgl32.Vec3{0, 1, 0}, normal: mgl32.Vec3{0, 0, 1}},
+			},
+			indices: []uint32{0, 1, 2, 0, 1, 3},
+			wantNormals: []mgl32.Vec3{
+				{0, 0.70710677, 0.70710677}, {0, 0.70710677, 0.70710677},
+				{0, 1, 0}, {0, 0, 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		for _, layout := range testLayouts {
+			vertices := pack(tt.vertices, layout)
+			FillMissingNormals(vertices, tt.indices, layout)
+			for v, want := range tt.wantNormals {
+				base := v*layout.Stride + layout.Normal
+				got := mgl32.Vec3{vertices[base], vertices[base+1], vertices[base+2]}
+				if !got.ApproxEqualThreshold(want, 1e-5) {
+					t.Errorf("%s (stride %d): vertex %d normal = %v, want %v", tt.name, layout.Stride, v, got, want)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Package objfile reads Wavefront OBJ models and the MTL material libraries they name, for
+// holy-mm's .holymb converter and holy-spinning-models. Errors are handled like .holym files:
+// in strict mode the first malformed line is returned as a *holym.Error, otherwise malformed
+// lines are logged and skipped.
+package objfile
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+)
+
+// Model is the content of an OBJ file and the MTL files it references.
+type Model struct {
+	Positions []mgl32.Vec3
+	Colors    []mgl32.Vec3 // One per position, white where the file gives none
+	HasColor  []bool       // Which positions were written with an RGB color after them
+	TexCoords []mgl32.Vec2
+	Normals   []mgl32.Vec3
+	Faces     []Face               // Only faces whose indices all exist
+	Materials map[string]*Material // By name, from every mtllib
+}
+
+// Face is one polygon of at least 3 corners. Corner indices are 0-based, TexCoord and Normal
+// are -1 for corners that don't have them.
+type Face struct {
+	Corners  []holym.Corner
+	Material string // From the last usemtl before the face, empty if there was none
+}
+
+// MapKind is one of the texture maps an MTL material can name.
+type MapKind int
+
+const (
+	MapDiffuse   MapKind = iota // map_Kd, the base color
+	MapRoughness                // map_Pr, from the PBR extension to MTL
+	MapMetallic                 // map_Pm, from the PBR extension to MTL
+	MapSpecular                 // map_Ks
+	MapAO                       // map_Ka, which most exporters use for ambient occlusion, or map_ao
+	MapNormal                   // norm, or bump since many exporters write the normal map there
+	MapCount
+)
+
+// mapKeys are the MTL statements naming maps.
+var mapKeys = map[string]MapKind{
+	"map_Kd":   MapDiffuse,
+	"map_Pr":   MapRoughness,
+	"map_Pm":   MapMetallic,
+	"map_Ks":   MapSpecular,
+	"map_Ka":   MapAO,
+	"map_ao":   MapAO,
+	"norm":     MapNormal,
+	"map_Bump": MapNormal,
+	"map_bump": MapNormal,
+	"bump":     MapNormal,
+}
+
+// Material is one newmtl entry of an MTL file.
+type Material struct {
+	Name string
+	Kd   mgl32.Vec3       // Diffuse color
+	D    float32          // Dissolve (opacity), 1 is opaque
+	Ns   float32          // Phong shininess, -1 if not given
+	Pr   float32          // PBR roughness extension, -1 if not given
+	Pm   float32          // PBR metallic extension, -1 if not given
+	Maps [MapCount]string // Textures as written in the MTL file, empty if there are none
+	Dir  string           // Directory of the MTL file, map paths are relative to it
+}
+
+// ParseFile opens and parses an OBJ file. Material libraries are looked for next to it.
+func ParseFile(filePath string, strict bool) (*Model, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OBJ file: %w", err)
+	}
+	defer file.Close()
+	return Parse(file, filePath, filepath.Dir(filePath), strict)
+}
+
+// Parse parses OBJ data from r. name is only used in error messages, mtlDir is where mtllib
+// files are looked for (see FindFile). A material library that can't be found or read is an
+// error in strict mode and a warning otherwise.
+func Parse(r io.Reader, name, mtlDir string, strict bool) (*Model, error) {
+	model := &Model{Materials: make(map[string]*Material)}
+	var faces []Face // Before their indices are checked
+	currentMaterial := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Allow very long lines
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		tokens := holym.SplitLine(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		fail := func(column int, format string, args ...interface{}) error {
+			return &holym.Error{File: name, Line: lineNumber, Column: column, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		var lineErr error
+		keyword := tokens[0]
+		switch keyword.Text {
+		case "v": // Position, with an optional W (ignored) or an RGB color some exporters add
+			if len(tokens) != 4 && len(tokens) != 5 && len(tokens) != 7 {
+				lineErr = fail(keyword.Column, "expected \"v X Y Z\" or \"v X Y Z R G B\"")
+				break
+			}
+			values, err := holym.ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			model.Positions = append(model.Positions, mgl32.Vec3{values[0], values[1], values[2]})
+			if len(values) == 6 {
+				model.Colors = append(model.Colors, mgl32.Vec3{values[3], values[4], values[5]})
+				model.HasColor = append(model.HasColor, true)
+			} else {
+				model.Colors = append(model.Colors, mgl32.Vec3{1, 1, 1})
+				model.HasColor = append(model.HasColor, false)
+			}
+
+		case "vt": // Texture coordinate, an optional W is ignored
+			if len(tokens) < 3 || len(tokens) > 4 {
+				lineErr = fail(keyword.Column, "expected \"vt U V\"")
+				break
+			}
+			values, err := holym.ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			model.TexCoords = append(model.TexCoords, mgl32.Vec2{values[0], values[1]})
+
+		case "vn": // Normal
+			if len(tokens) != 4 {
+				lineErr = fail(keyword.Column, "expected \"vn X Y Z\"")
+				break
+			}
+			values, err := holym.ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			model.Normals = append(model.Normals, mgl32.Vec3{values[0], values[1], values[2]})
+
+		case "f": // Face, OBJ corners use the same syntax as .holym
+			if len(tokens) < 4 {
+				lineErr = fail(keyword.Column, "a face needs at least 3 corners")
+				break
+			}
+			face := Face{Corners: make([]holym.Corner, 0, len(tokens)-1), Material: currentMaterial}
+			for _, token := range tokens[1:] {
+				corner, err := holym.ParseCorner(token, len(model.Positions), len(model.TexCoords), len(model.Normals), fail)
+				if err != nil {
+					lineErr = err
+					break
+				}
+				corner.Line, corner.Column = lineNumber, token.Column
+				face.Corners = append(face.Corners, corner)
+			}
+			if lineErr == nil {
+				faces = append(faces, face)
+			}
+
+		case "usemtl": // The rest of the line, names may contain spaces
+			currentMaterial = strings.TrimSpace(line[keyword.Column-1+len(keyword.Text):])
+
+		case "mtllib":
+			for _, token := range tokens[1:] {
+				mtlPath, err := FindFile(mtlDir, token.Text)
+				if err == nil {
+					err = ParseMtlFile(mtlPath, model.Materials, strict)
+				}
+				if err != nil {
+					if strict {
+						return nil, fmt.Errorf("material library %s: %w", token.Text, err)
+					}
+					log.Printf("Warning: material library %s skipped: %v", token.Text, err)
+				}
+			}
+
+		default:
+			// Groups, objects, smoothing groups and the rest don't change the mesh
+		}
+
+		if lineErr != nil {
+			if strict {
+				return nil, lineErr
+			}
+			log.Printf("Warning: %v (line skipped)", lineErr)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning OBJ data: %w", err)
+	}
+
+	// Positive indices may point forward, so ranges are only checked now
+	for _, face := range faces {
+		if err := model.checkFace(name, face); err != nil {
+			if strict {
+				return nil, err
+			}
+			log.Printf("Warning: %v (face skipped)", err)
+			continue
+		}
+		model.Faces = append(model.Faces, face)
+	}
+	return model, nil
+}
+
+// checkFace checks that every index of a face exists.
+func (m *Model) checkFace(name string, face Face) error {
+	for _, c := range face.Corners {
+		for _, check := range []struct {
+			index, count int
+			what         string
+		}{
+			{c.Position, len(m.Positions), "vertex"},
+			{c.TexCoord, len(m.TexCoords), "texture coordinate"},
+			{c.Normal, len(m.Normals), "normal"},
+		} {
+			if check.index >= check.count { // Missing texcoords and normals are -1
+				return &holym.Error{File: name, Line: c.Line, Column: c.Column,
+					Msg: fmt.Sprintf("%s index %d out of range (file has %d)", check.what, check.index+1, check.count)}
+			}
+		}
+	}
+	return nil
+}
+
+// FacesByMaterial groups the faces by material, returning the material names in the order
+// faces first use them. Faces before any usemtl are under "".
+func (m *Model) FacesByMaterial() ([]string, map[string][]Face) {
+	var order []string
+	groups := make(map[string][]Face)
+	for _, face := range m.Faces {
+		if _, ok := groups[face.Material]; !ok {
+			order = append(order, face.Material)
+		}
+		groups[face.Material] = append(groups[face.Material], face)
+	}
+	return order, groups
+}
+
+// ParseMtlFile adds the materials of an MTL file to materials. Malformed statements are
+// handled as in Parse; statements it doesn't know are ignored.
+func ParseMtlFile(mtlPath string, materials map[string]*Material, strict bool) error {
+	file, err := os.Open(mtlPath)
+	if err != nil {
+		return fmt.Errorf("failed to open MTL file: %w", err)
+	}
+	defer file.Close()
+
+	var current *Material
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		tokens := holym.SplitLine(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		fail := func(column int, format string, args ...interface{}) error {
+			return &holym.Error{File: mtlPath, Line: lineNumber, Column: column, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		var lineErr error
+		keyword := tokens[0]
+		if keyword.Text == "newmtl" {
+			name := strings.TrimSpace(line[keyword.Column-1+len(keyword.Text):])
+			current = &Material{Name: name, Kd: mgl32.Vec3{1, 1, 1}, D: 1, Ns: -1, Pr: -1, Pm: -1, Dir: filepath.Dir(mtlPath)}
+			materials[name] = current
+			continue
+		}
+		if current == nil {
+			continue // Statements before the first newmtl have nothing to apply to
+		}
+		switch keyword.Text {
+		case "Kd":
+			if len(tokens) != 4 {
+				lineErr = fail(keyword.Column, "expected \"Kd R G B\"")
+				break
+			}
+			values, err := holym.ParseFloats(tokens[1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			current.Kd = mgl32.Vec3{values[0], values[1], values[2]}
+		case "d", "Ns", "Pr", "Pm":
+			// d may have a -halo option first, the value is last
+			if len(tokens) < 2 {
+				lineErr = fail(keyword.Column, "expected \"%s <value>\"", keyword.Text)
+				break
+			}
+			values, err := holym.ParseFloats(tokens[len(tokens)-1:], fail)
+			if err != nil {
+				lineErr = err
+				break
+			}
+			switch keyword.Text {
+			case "d":
+				current.D = values[0]
+			case "Ns":
+				current.Ns = values[0]
+			case "Pr":
+				current.Pr = values[0]
+			default:
+				current.Pm = values[0]
+			}
+		default:
+			kind, ok := mapKeys[keyword.Text]
+			if !ok {
+				break
+			}
+			// Options like "-s 1 1 1" or "-bm 1" may come first, the file name is last
+			if len(tokens) < 2 {
+				lineErr = fail(keyword.Column, "expected \"%s <file>\"", keyword.Text)
+				break
+			}
+			current.Maps[kind] = tokens[len(tokens)-1].Text
+		}
+
+		if lineErr != nil {
+			if strict {
+				return lineErr
+			}
+			log.Printf("Warning: %v (line skipped)", lineErr)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error scanning MTL file %s: %w", mtlPath, err)
+	}
+	return nil
+}
+
+// FindFile finds a file referenced by an OBJ or MTL file. It is usually next to the
+// referencing file, but some downloads put it in a subfolder, so dir is searched too.
+func FindFile(dir, name string) (string, error) {
+	name = filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")) // Exporters on Windows write backslashes
+	direct := filepath.Join(dir, name)
+	if _, err := os.Stat(direct); err == nil {
+		return direct, nil
+	}
+
+	found := ""
+	stop := errors.New("found") // Ends the walk early
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.EqualFold(d.Name(), filepath.Base(name)) {
+			found = path
+			return stop
+		}
+		return nil
+	})
+	if found == "" {
+		return "", fmt.Errorf("no %s in %s", filepath.Base(name), dir)
+	}
+	return found, nil
+}
@@ -0,0 +1,263 @@
+package objfile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/holym"
+)
+
+// cornerIndices returns the position, texcoord and normal indices of a face's corners.
+func cornerIndices(face Face) [][3]int {
+	var indices [][3]int
+	for _, c := range face.Corners {
+		indices = append(indices, [3]int{c.Position, c.TexCoord, c.Normal})
+	}
+	return indices
+}
+
+func TestParse(t *testing.T) {
+	input := `# A quad and a triangle
+v 0 0 0
+v 1 0 0 1
+v 1 1 0 1 0 0
+v 0 1 0
+vt 0 0
+vt 1 1 0
+vn 0 0 1
+f 1/1/1 2/2/1 3/2/1 4/1/1
+usemtl Red Paint
+f -3//-1 -2//1 -1//-1
+g ignored
+s off
+f 1 2 5
+v 2 2 2
+`
+	model, err := Parse(strings.NewReader(input), "test.obj", t.TempDir(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(model.Positions) != 5 || len(model.TexCoords) != 2 || len(model.Normals) != 1 {
+		t.Fatalf("read %d positions, %d texcoords and %d normals, want 5, 2 and 1", len(model.Positions), len(model.TexCoords), len(model.Normals))
+	}
+	if !reflect.DeepEqual(model.HasColor, []bool{false, false, true, false, false}) || model.Colors[2] != (mgl32.Vec3{1, 0, 0}) || model.Colors[0] != (mgl32.Vec3{1, 1, 1}) {
+		t.Errorf("colors = %v, has color %v", model.Colors, model.HasColor)
+	}
+	if model.Positions[1] != (mgl32.Vec3{1, 0, 0}) {
+		t.Errorf("position with W = %v, want the W dropped", model.Positions[1])
+	}
+
+	want := []struct {
+		corners  [][3]int
+		material string
+	}{
+		{[][3]int{{0, 0, 0}, {1, 1, 0}, {2, 1, 0}, {3, 0, 0}}, ""},
+		{[][3]int{{1, -1, 0}, {2, -1, 0}, {3, -1, 0}}, "Red Paint"},    // Negative indices count back from the latest
+		{[][3]int{{0, -1, -1}, {1, -1, -1}, {4, -1, -1}}, "Red Paint"}, // Positive indices may point forward
+	}
+	if len(model.Faces) != len(want) {
+		t.Fatalf("%d faces, want %d", len(model.Faces), len(want))
+	}
+	for i, w := range want {
+		if got := cornerIndices(model.Faces[i]); !reflect.DeepEqual(got, w.corners) || model.Faces[i].Material != w.material {
+			t.Errorf("face %d = %v with material %q, want %v with %q", i, got, model.Faces[i].Material, w.corners, w.material)
+		}
+	}
+
+	order, groups := model.FacesByMaterial()
+	if !reflect.DeepEqual(order, []string{"", "Red Paint"}) || len(groups[""]) != 1 || len(groups["Red Paint"]) != 2 {
+		t.Errorf("materials %q with %d and %d faces, want [\"\" \"Red Paint\"] with 1 and 2", order, len(groups[""]), len(groups["Red Paint"]))
+	}
+}
+
+func TestParseStrictErrors(t *testing.T) {
+	const vertices = "v 0 0 0\nv 1 0 0\nv 0 1 0\nvt 0 0\nvn 0 0 1\n"
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"zero index", vertices + "f 1 2 0\n", "test.obj:6:7: invalid vertex index \"0\""},
+		{"not a number", vertices + "f 1 2 x\n", "test.obj:6:7: invalid vertex index \"x\""},
+		{"vertex out of range", vertices + "f 1 2 4\n", "test.obj:6:7: vertex index 4 out of range (file has 3)"},
+		{"texcoord out of range", vertices + "f 1/1 2/2 3/1\n", "test.obj:6:7: texture coordinate index 2 out of range (file has 1)"},
+		{"normal out of range", vertices + "f 1//1 2//1 3//3\n", "test.obj:6:13: normal index 3 out of range (file has 1)"},
+		{"negative before the first", vertices + "f -1 -2 -4\n", "test.obj:6:9: relative vertex index -4 reaches before the first one"},
+		{"negative counts only what came before", "v 0 0 0\nf -1 -1 -2\nv 1 0 0\n", "test.obj:2:9: relative vertex index -2 reaches before the first one"},
+		{"missing texcoord", vertices + "f 1/ 2/ 3/\n", "test.obj:6:5: missing texture coordinate index in \"1/\""},
+		{"two corners", vertices + "f 1 2\n", "test.obj:6:1: a face needs at least 3 corners"},
+		{"bad number", "v 0 0 zero\n", "test.obj:1:7: invalid number \"zero\""},
+		{"short position", "v 0 0\n", "test.obj:1:1: expected \"v X Y Z\" or \"v X Y Z R G B\""},
+		{"short normal", "vn 0 1\n", "test.obj:1:1: expected \"vn X Y Z\""},
+	}
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input), "test.obj", t.TempDir(), true)
+		if err == nil {
+			t.Errorf("%s: expected error %q, got none", tt.name, tt.wantErr)
+			continue
+		}
+		var objErr *holym.Error
+		if !errors.As(err, &objErr) {
+			t.Errorf("%s: error %v is not a *holym.Error", tt.name, err)
+		}
+		if err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %q, want %q", tt.name, err.Error(), tt.wantErr)
+		}
+	}
+}
+
+func TestParseLenientSkipsBadLines(t *testing.T) {
+	input := `v 0 0 0
+v 1 0 oops
+v 1 0 0
+v 0 1 0
+f 1 2 3
+f 1 2 0
+f 1 2 9
+f -1 -2 -3
+`
+	model, err := Parse(strings.NewReader(input), "test.obj", t.TempDir(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The bad vertex is skipped, so the faces refer to the three good ones
+	if len(model.Positions) != 3 {
+		t.Errorf("%d positions, want 3", len(model.Positions))
+	}
+	want := [][][3]int{
+		{{0, -1, -1}, {1, -1, -1}, {2, -1, -1}},
+		{{2, -1, -1}, {1, -1, -1}, {0, -1, -1}},
+	}
+	var got [][][3]int
+	for _, face := range model.Faces {
+		got = append(got, cornerIndices(face))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("faces = %v, want %v", got, want)
+	}
+}
+
+func TestParseMissingMtl(t *testing.T) {
+	dir := t.TempDir()
+	objPath := filepath.Join(dir, "model.obj")
+	input := "mtllib missing.mtl\nv 0 0 0\nv 1 0 0\nv 0 1 0\nusemtl Gone\nf 1 2 3\n"
+	if err := os.WriteFile(objPath, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lenient: the model loads without the materials
+	model, err := ParseFile(objPath, false)
+	if err != nil {
+		t.Fatalf("lenient: unexpected error: %v", err)
+	}
+	if len(model.Materials) != 0 || len(model.Faces) != 1 || model.Faces[0].Material != "Gone" {
+		t.Errorf("lenient: %d materials and %d faces, want none and the one face using \"Gone\"", len(model.Materials), len(model.Faces))
+	}
+
+	// Strict: the missing library is an error
+	if _, err := ParseFile(objPath, true); err == nil || !strings.Contains(err.Error(), "material library missing.mtl") {
+		t.Errorf("strict: error = %v, want one naming the missing library", err)
+	}
+
+	// So is a missing OBJ file
+	if _, err := ParseFile(filepath.Join(dir, "missing.obj"), false); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing OBJ: error = %v, want a not-exist error", err)
+	}
+}
+
+func TestParseMtl(t *testing.T) {
+	dir := t.TempDir()
+	// The library is in a subfolder, as some downloads have it
+	mtlDir := filepath.Join(dir, "materials")
+	if err := os.Mkdir(mtlDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	mtl := `# Exported materials
+Kd 0 0 0
+newmtl Crust
+Kd 0.8 0.5 0.2
+d -halo 0.5
+Ns 96
+Pr 0.25
+Pm 1
+map_Kd -s 1 1 1 textures\crust.png
+map_Pr crust_rough.png
+map_Pm crust_metal.png
+map_Ks crust_spec.png
+map_Ka crust_ao.png
+map_Bump -bm 0.5 crust_normal.png
+illum 2
+
+newmtl Cheese Top
+map_ao cheese_ao.png
+norm cheese_normal.png
+newmtl Sauce
+bump sauce_bump.png
+newmtl Olive
+map_bump olive_bump.png
+`
+	if err := os.WriteFile(filepath.Join(mtlDir, "Pizza.mtl"), []byte(mtl), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model, err := Parse(strings.NewReader("mtllib pizza.mtl\n"), "test.obj", dir, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want Material
+	}{
+		{"Crust", Material{
+			Name: "Crust", Kd: mgl32.Vec3{0.8, 0.5, 0.2}, D: 0.5, Ns: 96, Pr: 0.25, Pm: 1, Dir: mtlDir,
+			Maps: [MapCount]string{`textures\crust.png`, "crust_rough.png", "crust_metal.png", "crust_spec.png", "crust_ao.png", "crust_normal.png"},
+		}},
+		{"Cheese Top", Material{
+			Name: "Cheese Top", Kd: mgl32.Vec3{1, 1, 1}, D: 1, Ns: -1, Pr: -1, Pm: -1, Dir: mtlDir,
+			Maps: [MapCount]string{MapAO: "cheese_ao.png", MapNormal: "cheese_normal.png"},
+		}},
+		{"Sauce", Material{
+			Name: "Sauce", Kd: mgl32.Vec3{1, 1, 1}, D: 1, Ns: -1, Pr: -1, Pm: -1, Dir: mtlDir,
+			Maps: [MapCount]string{MapNormal: "sauce_bump.png"},
+		}},
+		{"Olive", Material{
+			Name: "Olive", Kd: mgl32.Vec3{1, 1, 1}, D: 1, Ns: -1, Pr: -1, Pm: -1, Dir: mtlDir,
+			Maps: [MapCount]string{MapNormal: "olive_bump.png"},
+		}},
+	}
+	if len(model.Materials) != len(tests) {
+		t.Errorf("%d materials, want %d", len(model.Materials), len(tests))
+	}
+	for _, tt := range tests {
+		got, ok := model.Materials[tt.name]
+		if !ok {
+			t.Errorf("%s: material missing", tt.name)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("%s: material = %+v, want %+v", tt.name, *got, tt.want)
+		}
+	}
+
+	// Strict mode reports malformed statements, lenient mode skips them
+	bad := filepath.Join(dir, "bad.mtl")
+	if err := os.WriteFile(bad, []byte("newmtl Bad\nKd 1 0\nmap_Kd\nd 0.5\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ParseMtlFile(bad, map[string]*Material{}, true); err == nil || err.Error() != bad+":2:1: expected \"Kd R G B\"" {
+		t.Errorf("strict: error = %v", err)
+	}
+	materials := map[string]*Material{}
+	if err := ParseMtlFile(bad, materials, false); err != nil {
+		t.Errorf("lenient: unexpected error: %v", err)
+	} else if m := materials["Bad"]; m == nil || m.Kd != (mgl32.Vec3{1, 1, 1}) || m.Maps[MapDiffuse] != "" || m.D != 0.5 {
+		t.Errorf("lenient: material = %+v, want the bad lines skipped and d read", m)
+	}
+}
@@ -0,0 +1,296 @@
+package phong
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/glshader"
+	"github.com/toxichemicals/GO/holy-shared/post"
+)
+
+// GLRenderer draws with OpenGL on the current context, through the post-processing chain.
+type GLRenderer struct {
+	program uint32
+	meshes  map[Mesh]glMesh
+	lastID  Mesh // Last Mesh handed out
+
+	// Uniform locations
+	modelUniform        int32
+	viewUniform         int32
+	projectionUniform   int32
+	normalMatrixUniform int32
+	viewPosUniform      int32
+
+	// Offscreen HDR target and post-processing passes, see holy-shared/post. Programs
+	// hand it their input to toggle the effects.
+	Post *post.Chain
+
+	lighting Lighting
+
+	width, height int
+}
+
+// glMesh is the vertex array and buffers of a Mesh.
+type glMesh struct {
+	vao, vbo, ebo uint32
+	indicesCount  int32
+}
+
+// NewGLRenderer compiles the shaders and sets up the post-processing chain. The OpenGL
+// context must be current and initialized.
+func NewGLRenderer(width, height int, lighting Lighting) (*GLRenderer, error) {
+	if err := lighting.validate(); err != nil {
+		return nil, err
+	}
+	r := &GLRenderer{meshes: make(map[Mesh]glMesh), lighting: lighting, width: width, height: height}
+	if err := r.setupShadersAndUniforms(); err != nil {
+		return nil, fmt.Errorf("shader setup failed: %w", err)
+	}
+
+	// The scene renders into the post-processing chain's HDR target. Lighting is done in
+	// linear space, so the chain tonemaps it and encodes it for the screen.
+	chain, err := post.NewChain(width, height, post.DefaultEffects...)
+	if err != nil {
+		gl.DeleteProgram(r.program)
+		return nil, fmt.Errorf("post-processing setup failed: %w", err)
+	}
+	r.Post = chain
+	return r, nil
+}
+
+// setupShadersAndUniforms compiles shaders, links the program, and gets uniform locations.
+func (r *GLRenderer) setupShadersAndUniforms() error {
+	vertexShaderSource := `
+		#version 410 core
+		layout (location = 0) in vec3 aPos;
+		layout (location = 1) in vec3 aColor;
+		layout (location = 2) in vec3 aNormal;
+
+		out vec3 ourColor;
+		out vec3 FragPos; // World space
+		out vec3 Normal;  // World space
+
+		uniform mat4 model;
+		uniform mat4 view;
+		uniform mat4 projection;
+		uniform mat3 normalMatrix; // Inverse transpose of the model matrix
+
+		void main() {
+			vec4 worldPos = model * vec4(aPos, 1.0);
+			gl_Position = projection * view * worldPos;
+			ourColor = aColor;
+			FragPos = worldPos.xyz;
+			Normal = normalMatrix * aNormal;
+		}
+	` + "\x00"
+
+	// Fragment shader lit with Blinn-Phong by the sun, the ambient term and the point lights.
+	// SoftwareRenderer.shade does the same on the CPU.
+	fragmentShaderSource := `
+		#version 410 core
+		#define MAX_POINT_LIGHTS 8 // MaxPointLights
+
+		in vec3 ourColor;
+		in vec3 FragPos;
+		in vec3 Normal;
+		out vec4 FragColor;
+
+		uniform vec3 viewPos;
+		uniform vec3 sunDirection; // Direction the sunlight travels in
+		uniform vec3 sunColor;     // Already multiplied by the intensity
+		uniform vec3 ambientColor;
+		uniform int pointLightCount;
+		uniform vec3 pointLightPositions[MAX_POINT_LIGHTS];
+		uniform vec3 pointLightColors[MAX_POINT_LIGHTS]; // Already multiplied by the intensity
+		uniform float pointLightRanges[MAX_POINT_LIGHTS];
+
+		const float shininess = 32.0;
+		const float specularStrength = 0.3;
+
+		// blinnPhong returns the diffuse and specular light from one light.
+		// lightDir points from the surface towards the light.
+		vec3 blinnPhong(vec3 normal, vec3 lightDir, vec3 viewDir, vec3 lightColor, vec3 albedo) {
+			float diffuse = max(dot(normal, lightDir), 0.0);
+			float specular = 0.0;
+			if (diffuse > 0.0) {
+				vec3 halfway = normalize(lightDir + viewDir);
+				specular = pow(max(dot(normal, halfway), 0.0), shininess);
+			}
+			return lightColor * (diffuse * albedo + specularStrength * specular);
+		}
+
+		void main() {
+			vec3 albedo = pow(ourColor, vec3(2.2)); // Vertex colors are sRGB, the lighting is linear
+			vec3 normal = normalize(Normal);
+			vec3 viewDir = normalize(viewPos - FragPos);
+			vec3 color = ambientColor * albedo;
+			color += blinnPhong(normal, -sunDirection, viewDir, sunColor, albedo);
+			for (int i = 0; i < pointLightCount; i++) {
+				vec3 toLight = pointLightPositions[i] - FragPos;
+				float distance = length(toLight);
+				// Fades smoothly to nothing at the light's range
+				float attenuation = clamp(1.0 - distance / pointLightRanges[i], 0.0, 1.0);
+				attenuation *= attenuation;
+				color += attenuation * blinnPhong(normal, toLight / max(distance, 0.0001), viewDir, pointLightColors[i], albedo);
+			}
+			FragColor = vec4(color, 1.0);
+		}
+	` + "\x00"
+
+	program, err := glshader.Compile(vertexShaderSource, fragmentShaderSource)
+	if err != nil {
+		return fmt.Errorf("failed to compile shaders: %w", err)
+	}
+	gl.UseProgram(program)
+	r.program = program
+
+	r.modelUniform = gl.GetUniformLocation(r.program, gl.Str("model\x00"))
+	r.viewUniform = gl.GetUniformLocation(r.program, gl.Str("view\x00"))
+	r.projectionUniform = gl.GetUniformLocation(r.program, gl.Str("projection\x00"))
+	r.normalMatrixUniform = gl.GetUniformLocation(r.program, gl.Str("normalMatrix\x00"))
+	r.viewPosUniform = gl.GetUniformLocation(r.program, gl.Str("viewPos\x00"))
+	r.applyLighting()
+
+	return nil
+}
+
+// applyLighting uploads the sun, the ambient light and the point lights. They never
+// change, so this runs once after the program is linked.
+func (r *GLRenderer) applyLighting() {
+	l := r.lighting
+	sunColor := mgl32.Vec3{l.SunIntensity, l.SunIntensity, l.SunIntensity}
+	ambientColor := mgl32.Vec3{l.Ambient, l.Ambient, l.Ambient}
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("sunDirection\x00")), 1, &l.SunDirection[0])
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("sunColor\x00")), 1, &sunColor[0])
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("ambientColor\x00")), 1, &ambientColor[0])
+
+	gl.Uniform1i(gl.GetUniformLocation(r.program, gl.Str("pointLightCount\x00")), int32(len(l.PointLights)))
+	for i, light := range l.PointLights {
+		// Array elements each have their own location
+		gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightPositions[%d]\x00", i))), 1, &light.Position[0])
+		gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightColors[%d]\x00", i))), 1, &light.Color[0])
+		gl.Uniform1f(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightRanges[%d]\x00", i))), light.Range)
+	}
+}
+
+// CreateMesh configures a VAO, VBO, and EBO for the vertex and index data.
+func (r *GLRenderer) CreateMesh(vertices []float32, indices []uint32) (Mesh, error) {
+	if len(vertices) == 0 || len(indices) == 0 {
+		return 0, fmt.Errorf("mesh has no vertices or no indices")
+	}
+	var m glMesh
+	m.indicesCount = int32(len(indices))
+
+	gl.GenVertexArrays(1, &m.vao)
+	gl.BindVertexArray(m.vao)
+
+	gl.GenBuffers(1, &m.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.GenBuffers(1, &m.ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	// Position attribute (layout location 0)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, VertexFloats*4, gl.Ptr(nil))
+	gl.EnableVertexAttribArray(0)
+
+	// Color attribute (layout location 1)
+	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, VertexFloats*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+
+	// Normal attribute (layout location 2)
+	gl.VertexAttribPointer(2, 3, gl.FLOAT, false, VertexFloats*4, gl.PtrOffset(6*4))
+	gl.EnableVertexAttribArray(2)
+
+	gl.BindVertexArray(0) // Unbind VAO
+
+	r.lastID++
+	r.meshes[r.lastID] = m
+	return r.lastID, nil
+}
+
+// DeleteMesh deletes a mesh's vertex array and buffers.
+func (r *GLRenderer) DeleteMesh(mesh Mesh) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	gl.DeleteVertexArrays(1, &m.vao)
+	gl.DeleteBuffers(1, &m.vbo)
+	gl.DeleteBuffers(1, &m.ebo)
+	delete(r.meshes, mesh)
+}
+
+// SetCamera uploads the view and projection matrices and the camera position.
+func (r *GLRenderer) SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3) {
+	gl.UseProgram(r.program) // The post-processing passes use their own programs
+	gl.UniformMatrix4fv(r.viewUniform, 1, false, &view[0])
+	gl.UniformMatrix4fv(r.projectionUniform, 1, false, &projection[0])
+	gl.Uniform3fv(r.viewPosUniform, 1, &position[0]) // For specular highlights
+}
+
+// BeginFrame starts drawing into the post-processing chain's target and clears it.
+func (r *GLRenderer) BeginFrame(clearColor mgl32.Vec3) {
+	r.Post.Begin()
+	gl.Enable(gl.DEPTH_TEST)
+	linear := post.LinearColor(clearColor)
+	gl.ClearColor(linear.X(), linear.Y(), linear.Z(), 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// Draw draws a mesh with the given model matrix.
+func (r *GLRenderer) Draw(mesh Mesh, model mgl32.Mat4) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	gl.UseProgram(r.program)
+	gl.UniformMatrix4fv(r.modelUniform, 1, false, &model[0])
+	normalMatrix := model.Mat3().Inv().Transpose()
+	gl.UniformMatrix3fv(r.normalMatrixUniform, 1, false, &normalMatrix[0])
+
+	gl.BindVertexArray(m.vao)
+	gl.DrawElements(gl.TRIANGLES, m.indicesCount, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+}
+
+// EndFrame runs the post-processing chain, which leaves the frame in the window's back buffer.
+func (r *GLRenderer) EndFrame() {
+	r.Post.End()
+}
+
+// ReadPixels reads the window's back buffer. OpenGL's rows start at the bottom, so they
+// are flipped.
+func (r *GLRenderer) ReadPixels() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	pixels := make([]uint8, len(img.Pix))
+	gl.ReadPixels(0, 0, int32(r.width), int32(r.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	for y := 0; y < r.height; y++ {
+		copy(img.Pix[y*img.Stride:(y+1)*img.Stride], pixels[(r.height-1-y)*img.Stride:(r.height-y)*img.Stride])
+	}
+	return img
+}
+
+// Resize changes the viewport and the size of the post-processing targets.
+func (r *GLRenderer) Resize(width, height int) error {
+	r.width, r.height = width, height
+	gl.Viewport(0, 0, int32(width), int32(height))
+	if err := r.Post.Resize(width, height); err != nil {
+		return fmt.Errorf("failed to resize the post-processing targets: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the meshes, the post-processing chain and the program.
+func (r *GLRenderer) Release() {
+	for mesh := range r.meshes {
+		r.DeleteMesh(mesh)
+	}
+	r.Post.Release()
+	gl.DeleteProgram(r.program)
+}
@@ -0,0 +1,67 @@
+// Package phong draws vertex-colored meshes lit with Blinn-Phong by a sun, an ambient term
+// and a few point lights. GLRenderer draws with OpenGL through the post-processing chain,
+// SoftwareRenderer rasterizes the same thing on the CPU into an image, so frames can be
+// rendered in tests and on machines without a GPU.
+package phong
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// VertexFloats is the number of floats per vertex in a mesh: position (3) + color (3) + normal (3).
+// Colors are sRGB, they are made linear before lighting.
+const VertexFloats = 9
+
+// MaxPointLights is the most point lights a Lighting can have, the shader's MAX_POINT_LIGHTS.
+const MaxPointLights = 8
+
+// Mesh identifies a mesh created by a Renderer. 0 is no mesh.
+type Mesh uint32
+
+// Renderer is what a program draws its meshes with. A frame is BeginFrame, any number of
+// Draws and EndFrame, after which ReadPixels returns it.
+type Renderer interface {
+	// CreateMesh uploads interleaved vertices (VertexFloats each) and triangle indices.
+	CreateMesh(vertices []float32, indices []uint32) (Mesh, error)
+	DeleteMesh(mesh Mesh)
+
+	// SetCamera sets the view and projection matrices and the camera's world position,
+	// which the specular highlights depend on.
+	SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3)
+
+	BeginFrame(clearColor mgl32.Vec3)
+	Draw(mesh Mesh, model mgl32.Mat4)
+	EndFrame()
+
+	// ReadPixels returns the last frame, top row first.
+	ReadPixels() *image.RGBA
+
+	Resize(width, height int) error
+	Release()
+}
+
+// Lighting is the light a renderer shades with. It is fixed when the renderer is created.
+type Lighting struct {
+	SunDirection mgl32.Vec3 // Direction the sunlight travels in, normalized
+	SunIntensity float32
+	Ambient      float32 // Light that reaches every surface, so the side facing away isn't black
+	PointLights  []PointLight
+}
+
+// PointLight is a light that fades out with distance.
+type PointLight struct {
+	Position mgl32.Vec3
+	Color    mgl32.Vec3 // Already multiplied by the intensity
+	Range    float32    // Distance at which the light has faded out completely
+}
+
+// validate checks that the shader can take the lighting.
+func (l Lighting) validate() error {
+	if len(l.PointLights) > MaxPointLights {
+		return fmt.Errorf("%d point lights, at most %d are supported", len(l.PointLights), MaxPointLights)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package phong
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/post"
+	"github.com/toxichemicals/GO/holy-shared/raster"
+)
+
+// Software rendering: SoftwareRenderer draws what GLRenderer draws without a GPU, running
+// the same vertex and lighting math per vertex and per pixel on the CPU. It is meant for
+// tests and headless tools, so it keeps to the basics: no bloom and no antialiasing, only
+// the depth-tested triangles, tonemapped and gamma encoded as the default chain does.
+
+// softMesh is a mesh's data, kept as it was given to CreateMesh.
+type softMesh struct {
+	vertices []float32
+	indices  []uint32
+}
+
+// SoftwareRenderer rasterizes into memory. Its buffers have row 0 at the top, like images.
+type SoftwareRenderer struct {
+	frame raster.Frame // Linear, unclamped colors
+
+	meshes map[Mesh]*softMesh
+	lastID Mesh // Last Mesh handed out
+
+	lighting Lighting
+
+	view, projection mgl32.Mat4
+	viewPos          mgl32.Vec3
+	transformed      []raster.Vertex // Vertex stage output, reused between draws
+}
+
+// NewSoftwareRenderer creates a renderer with a width x height frame.
+func NewSoftwareRenderer(width, height int, lighting Lighting) (*SoftwareRenderer, error) {
+	if err := lighting.validate(); err != nil {
+		return nil, err
+	}
+	r := &SoftwareRenderer{meshes: make(map[Mesh]*softMesh), lighting: lighting, view: mgl32.Ident4(), projection: mgl32.Ident4()}
+	if err := r.Resize(width, height); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// CreateMesh keeps copies of the vertices and indices.
+func (r *SoftwareRenderer) CreateMesh(vertices []float32, indices []uint32) (Mesh, error) {
+	if len(vertices) == 0 || len(indices) == 0 {
+		return 0, fmt.Errorf("mesh has no vertices or no indices")
+	}
+	vertexCount := uint32(len(vertices) / VertexFloats)
+	for _, index := range indices {
+		if index >= vertexCount {
+			return 0, fmt.Errorf("index %d is out of range for %d vertices", index, vertexCount)
+		}
+	}
+	m := &softMesh{
+		vertices: append([]float32(nil), vertices...),
+		indices:  append([]uint32(nil), indices...),
+	}
+	r.lastID++
+	r.meshes[r.lastID] = m
+	return r.lastID, nil
+}
+
+// DeleteMesh forgets a mesh.
+func (r *SoftwareRenderer) DeleteMesh(mesh Mesh) {
+	delete(r.meshes, mesh)
+}
+
+// SetCamera sets the matrices and position the next draws use.
+func (r *SoftwareRenderer) SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3) {
+	r.view, r.projection, r.viewPos = view, projection, position
+}
+
+// BeginFrame clears the color and depth buffers.
+func (r *SoftwareRenderer) BeginFrame(clearColor mgl32.Vec3) {
+	r.frame.Clear(post.LinearColor(clearColor))
+}
+
+// Draw runs the vertex stage over the whole mesh, then rasterizes its triangles. The
+// varyings are world position (3) + color (3) + normal (3), as the shader's FragPos, ourColor
+// and Normal.
+func (r *SoftwareRenderer) Draw(mesh Mesh, model mgl32.Mat4) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	viewProjection := r.projection.Mul4(r.view)
+	normalMatrix := model.Mat3().Inv().Transpose()
+
+	vertexCount := len(m.vertices) / VertexFloats
+	if cap(r.transformed) < vertexCount {
+		r.transformed = make([]raster.Vertex, vertexCount)
+	}
+	r.transformed = r.transformed[:vertexCount]
+	for i := range r.transformed {
+		v := m.vertices[i*VertexFloats : (i+1)*VertexFloats]
+		world := model.Mul4x1(mgl32.Vec4{v[0], v[1], v[2], 1})
+		normal := normalMatrix.Mul3x1(mgl32.Vec3{v[6], v[7], v[8]})
+		r.transformed[i] = raster.Vertex{
+			Clip:     viewProjection.Mul4x1(world),
+			Varyings: [raster.MaxVaryings]float32{world[0], world[1], world[2], v[3], v[4], v[5], normal[0], normal[1], normal[2]},
+		}
+	}
+
+	for i := 0; i+2 < len(m.indices); i += 3 {
+		r.frame.DrawTriangle(r.transformed[m.indices[i]], r.transformed[m.indices[i+1]], r.transformed[m.indices[i+2]], false, r.shade)
+	}
+}
+
+// shade is the fragment shader of GLRenderer on the CPU: Blinn-Phong lighting by the sun,
+// the ambient term and the point lights.
+func (r *SoftwareRenderer) shade(f *raster.Fragment) (mgl32.Vec3, float32, bool) {
+	varyings := &f.Varyings
+	fragPos := mgl32.Vec3{varyings[0], varyings[1], varyings[2]}
+	albedo := post.LinearColor(mgl32.Vec3{varyings[3], varyings[4], varyings[5]})
+	normal := mgl32.Vec3{varyings[6], varyings[7], varyings[8]}.Normalize()
+	viewDir := r.viewPos.Sub(fragPos).Normalize()
+
+	l := r.lighting
+	sunColor := mgl32.Vec3{l.SunIntensity, l.SunIntensity, l.SunIntensity}
+	color := albedo.Mul(l.Ambient)
+	color = color.Add(blinnPhong(normal, l.SunDirection.Mul(-1), viewDir, sunColor, albedo))
+	for _, light := range l.PointLights {
+		toLight := light.Position.Sub(fragPos)
+		distance := toLight.Len()
+		// Fades smoothly to nothing at the light's range
+		attenuation := mgl32.Clamp(1-distance/light.Range, 0, 1)
+		attenuation *= attenuation
+		lightDir := toLight.Mul(1 / float32(math.Max(float64(distance), 0.0001)))
+		color = color.Add(blinnPhong(normal, lightDir, viewDir, light.Color, albedo).Mul(attenuation))
+	}
+	return color, 1, true
+}
+
+// Blinn-Phong constants, as in the fragment shader
+const (
+	shininess        = 32.0
+	specularStrength = 0.3
+)
+
+// blinnPhong returns the diffuse and specular light from one light.
+// lightDir points from the surface towards the light.
+func blinnPhong(normal, lightDir, viewDir, lightColor, albedo mgl32.Vec3) mgl32.Vec3 {
+	diffuse := float32(math.Max(float64(normal.Dot(lightDir)), 0))
+	var specular float32
+	if diffuse > 0 {
+		halfway := lightDir.Add(viewDir).Normalize()
+		specular = float32(math.Pow(math.Max(float64(normal.Dot(halfway)), 0), shininess))
+	}
+	return mgl32.Vec3{
+		lightColor[0] * (diffuse*albedo[0] + specularStrength*specular),
+		lightColor[1] * (diffuse*albedo[1] + specularStrength*specular),
+		lightColor[2] * (diffuse*albedo[2] + specularStrength*specular),
+	}
+}
+
+// EndFrame has nothing to do, every draw went straight into the buffers.
+func (r *SoftwareRenderer) EndFrame() {}
+
+// ReadPixels tonemaps and gamma encodes the linear frame like the default post-processing
+// chain, then clamps it to 8 bits per channel.
+func (r *SoftwareRenderer) ReadPixels() *image.RGBA {
+	return r.frame.ReadPixels(func(c mgl32.Vec3) mgl32.Vec3 {
+		return post.DisplayColor(c, post.DefaultExposure)
+	})
+}
+
+// Resize reallocates the buffers. Their contents are lost until the next frame.
+func (r *SoftwareRenderer) Resize(width, height int) error {
+	return r.frame.Resize(width, height)
+}
+
+// Release drops the meshes and buffers.
+func (r *SoftwareRenderer) Release() {
+	r.meshes = make(map[Mesh]*softMesh)
+	r.frame.Release()
+}
@@ -0,0 +1,511 @@
+// Package post is the HDR post-processing chain the programs draw their scenes through.
+//
+// The scene is drawn into an offscreen HDR framebuffer instead of the window, and a chain of
+// full-screen passes turns it into the image on screen. Colors above 1 survive until the
+// tonemapper, so bright highlights can glow (bloom) instead of clipping. The passes run in
+// this order, each effect can be toggled at runtime with EffectKeys:
+//
+//	bright pass + blur (bloom) -> exposure and ACES tonemapping, bloom, vignette, gamma -> FXAA
+//
+// The UI is drawn afterwards, straight into the window.
+package post
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/glshader"
+)
+
+// Effect is one of the effects of the chain.
+type Effect int
+
+const (
+	Tonemap  Effect = iota // Exposure and the ACES filmic curve, from HDR to display range
+	Bloom                  // Glow around everything brighter than bloomThreshold
+	FXAA                   // Smooths jagged edges in the final image
+	Vignette               // Darkens the corners
+	Gamma                  // Encodes linear colors for the screen (2.2 power curve)
+	EffectCount
+)
+
+// EffectNames are the names shown when an effect is toggled.
+var EffectNames = [EffectCount]string{"Tonemap", "Bloom", "FXAA", "Vignette", "Gamma"}
+
+// EffectKeys toggle the effects, in Effect order.
+var EffectKeys = [EffectCount]glfw.Key{glfw.Key1, glfw.Key2, glfw.Key3, glfw.Key4, glfw.Key5}
+
+// DefaultEffects are the effects every program starts with. The scene shaders light in
+// linear space and leave colors above 1, so tonemapping and gamma are what turn their output
+// into display colors; the vignette is a matter of taste and starts off.
+var DefaultEffects = []Effect{Tonemap, Bloom, FXAA, Gamma}
+
+// Post-processing constants
+const (
+	postSamples      = 4   // MSAA samples of the scene framebuffer, resolved before the passes
+	bloomThreshold   = 1.0 // Brightness above which a pixel blooms
+	bloomStrength    = 0.5 // How much of the blurred bright pass is added back
+	bloomBlurPasses  = 4   // Horizontal + vertical blur pairs, at half resolution
+	vignetteStrength = 0.35
+)
+
+// DefaultExposure is the Exposure a new Chain starts with.
+const DefaultExposure = 1.0
+
+// Chain is the offscreen scene target and the passes applied to it.
+type Chain struct {
+	Enabled  [EffectCount]bool
+	Exposure float32 // Scene colors are multiplied by it before tonemapping
+
+	width, height int32
+
+	// Scene target: multisampled renderbuffers, resolved into sceneTexture
+	msFBO, msColor, msDepth  uint32
+	sceneFBO, sceneTexture   uint32
+	bloomFBOs, bloomTextures [2]uint32 // Half resolution, the blur ping-pongs between them
+	ldrFBO, ldrTexture       uint32    // Composited image for FXAA to read
+
+	vao              uint32 // Empty, the passes make a full-screen triangle from gl_VertexID
+	brightProgram    uint32
+	blurProgram      uint32
+	compositeProgram uint32
+	fxaaProgram      uint32
+
+	blurDirectionUniform     int32
+	compositeTonemapUniform  int32
+	compositeBloomUniform    int32
+	compositeVignetteUniform int32
+	compositeGammaUniform    int32
+	compositeExposureUniform int32
+	fxaaTexelSizeUniform     int32
+
+	keyWasPressed [EffectCount]bool
+}
+
+// postVertexShaderSource draws one triangle that covers the screen, with no vertex buffer.
+const postVertexShaderSource = `
+	#version 410 core
+	out vec2 TexCoord;
+	void main() {
+		vec2 corner = vec2((gl_VertexID << 1) & 2, gl_VertexID & 2); // (0,0), (2,0), (0,2)
+		TexCoord = corner;
+		gl_Position = vec4(corner * 2.0 - 1.0, 0.0, 1.0);
+	}
+` + "\x00"
+
+// brightFragmentShaderSource keeps the part of each pixel above the bloom threshold.
+const brightFragmentShaderSource = `
+	#version 410 core
+	in vec2 TexCoord;
+	out vec4 FragColor;
+	uniform sampler2D image;
+	uniform float threshold;
+	void main() {
+		vec3 color = texture(image, TexCoord).rgb;
+		float brightness = max(color.r, max(color.g, color.b));
+		FragColor = vec4(color * max(brightness - threshold, 0.0) / max(brightness, 0.0001), 1.0);
+	}
+` + "\x00"
+
+// blurFragmentShaderSource is one direction of a separable 9-tap Gaussian blur.
+const blurFragmentShaderSource = `
+	#version 410 core
+	in vec2 TexCoord;
+	out vec4 FragColor;
+	uniform sampler2D image;
+	uniform vec2 direction; // One texel along the blur axis
+	const float weights[5] = float[](0.227027, 0.1945946, 0.1216216, 0.054054, 0.016216);
+	void main() {
+		vec3 color = texture(image, TexCoord).rgb * weights[0];
+		for (int i = 1; i < 5; i++) {
+			color += texture(image, TexCoord + direction * float(i)).rgb * weights[i];
+			color += texture(image, TexCoord - direction * float(i)).rgb * weights[i];
+		}
+		FragColor = vec4(color, 1.0);
+	}
+` + "\x00"
+
+// compositeFragmentShaderSource adds the bloom and brings the HDR scene to display range.
+const compositeFragmentShaderSource = `
+	#version 410 core
+	in vec2 TexCoord;
+	out vec4 FragColor;
+	uniform sampler2D scene;
+	uniform sampler2D bloom;
+	uniform bool tonemap;
+	uniform bool bloomEnabled;
+	uniform bool vignette;
+	uniform bool gamma;
+	uniform float exposure;
+	uniform float bloomStrength;
+	uniform float vignetteStrength;
+
+	// Narkowicz's fit of the ACES filmic curve
+	vec3 aces(vec3 x) {
+		return clamp(x * (2.51 * x + 0.03) / (x * (2.43 * x + 0.59) + 0.14), 0.0, 1.0);
+	}
+
+	void main() {
+		vec3 color = texture(scene, TexCoord).rgb;
+		if (bloomEnabled) {
+			color += bloomStrength * texture(bloom, TexCoord).rgb;
+		}
+		if (tonemap) {
+			color = aces(color * exposure);
+		}
+		if (vignette) {
+			vec2 fromCenter = TexCoord - 0.5;
+			color *= 1.0 - vignetteStrength * smoothstep(0.2, 0.8, length(fromCenter) * 1.414);
+		}
+		color = clamp(color, 0.0, 1.0);
+		if (gamma) {
+			color = pow(color, vec3(1.0 / 2.2));
+		}
+		FragColor = vec4(color, 1.0);
+	}
+` + "\x00"
+
+// fxaaFragmentShaderSource is the compact form of Lottes's FXAA: it finds the direction of
+// the edge through a pixel from the luma around it, and blurs along that edge only.
+const fxaaFragmentShaderSource = `
+	#version 410 core
+	in vec2 TexCoord;
+	out vec4 FragColor;
+	uniform sampler2D image;
+	uniform vec2 texelSize;
+	const float spanMax = 8.0;
+	const float reduceMul = 1.0 / 8.0;
+	const float reduceMin = 1.0 / 128.0;
+	const vec3 lumaWeights = vec3(0.299, 0.587, 0.114);
+	void main() {
+		float lumaNW = dot(texture(image, TexCoord + vec2(-1.0, -1.0) * texelSize).rgb, lumaWeights);
+		float lumaNE = dot(texture(image, TexCoord + vec2(1.0, -1.0) * texelSize).rgb, lumaWeights);
+		float lumaSW = dot(texture(image, TexCoord + vec2(-1.0, 1.0) * texelSize).rgb, lumaWeights);
+		float lumaSE = dot(texture(image, TexCoord + vec2(1.0, 1.0) * texelSize).rgb, lumaWeights);
+		vec3 rgbM = texture(image, TexCoord).rgb;
+		float lumaM = dot(rgbM, lumaWeights);
+		float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+		float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+		vec2 dir = vec2(-((lumaNW + lumaNE) - (lumaSW + lumaSE)), (lumaNW + lumaSW) - (lumaNE + lumaSE));
+		float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * 0.25 * reduceMul, reduceMin);
+		float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+		dir = clamp(dir * rcpDirMin, vec2(-spanMax), vec2(spanMax)) * texelSize;
+
+		vec3 rgbA = 0.5 * (texture(image, TexCoord + dir * (1.0 / 3.0 - 0.5)).rgb +
+		                   texture(image, TexCoord + dir * (2.0 / 3.0 - 0.5)).rgb);
+		vec3 rgbB = rgbA * 0.5 + 0.25 * (texture(image, TexCoord - dir * 0.5).rgb +
+		                                 texture(image, TexCoord + dir * 0.5).rgb);
+		float lumaB = dot(rgbB, lumaWeights);
+		// The wider sample went past the edge if it left the local luma range
+		if (lumaB < lumaMin || lumaB > lumaMax) {
+			FragColor = vec4(rgbA, 1.0);
+		} else {
+			FragColor = vec4(rgbB, 1.0);
+		}
+	}
+` + "\x00"
+
+// NewChain compiles the passes and creates the targets for a width x height window,
+// with the given effects enabled.
+func NewChain(width, height int, enabled ...Effect) (*Chain, error) {
+	p := &Chain{Exposure: DefaultExposure}
+	for _, effect := range enabled {
+		p.Enabled[effect] = true
+	}
+
+	programs := []struct {
+		program        *uint32
+		name, fragment string
+	}{
+		{&p.brightProgram, "bright pass", brightFragmentShaderSource},
+		{&p.blurProgram, "blur", blurFragmentShaderSource},
+		{&p.compositeProgram, "composite", compositeFragmentShaderSource},
+		{&p.fxaaProgram, "FXAA", fxaaFragmentShaderSource},
+	}
+	for _, pass := range programs {
+		program, err := glshader.Compile(postVertexShaderSource, pass.fragment)
+		if err != nil {
+			p.Release()
+			return nil, fmt.Errorf("failed to compile %s shaders: %w", pass.name, err)
+		}
+		*pass.program = program
+	}
+
+	gl.UseProgram(p.brightProgram)
+	gl.Uniform1i(gl.GetUniformLocation(p.brightProgram, gl.Str("image\x00")), 0)
+	gl.Uniform1f(gl.GetUniformLocation(p.brightProgram, gl.Str("threshold\x00")), bloomThreshold)
+
+	gl.UseProgram(p.blurProgram)
+	gl.Uniform1i(gl.GetUniformLocation(p.blurProgram, gl.Str("image\x00")), 0)
+	p.blurDirectionUniform = gl.GetUniformLocation(p.blurProgram, gl.Str("direction\x00"))
+
+	gl.UseProgram(p.compositeProgram)
+	gl.Uniform1i(gl.GetUniformLocation(p.compositeProgram, gl.Str("scene\x00")), 0)
+	gl.Uniform1i(gl.GetUniformLocation(p.compositeProgram, gl.Str("bloom\x00")), 1)
+	gl.Uniform1f(gl.GetUniformLocation(p.compositeProgram, gl.Str("bloomStrength\x00")), bloomStrength)
+	gl.Uniform1f(gl.GetUniformLocation(p.compositeProgram, gl.Str("vignetteStrength\x00")), vignetteStrength)
+	p.compositeTonemapUniform = gl.GetUniformLocation(p.compositeProgram, gl.Str("tonemap\x00"))
+	p.compositeBloomUniform = gl.GetUniformLocation(p.compositeProgram, gl.Str("bloomEnabled\x00"))
+	p.compositeVignetteUniform = gl.GetUniformLocation(p.compositeProgram, gl.Str("vignette\x00"))
+	p.compositeGammaUniform = gl.GetUniformLocation(p.compositeProgram, gl.Str("gamma\x00"))
+	p.compositeExposureUniform = gl.GetUniformLocation(p.compositeProgram, gl.Str("exposure\x00"))
+
+	gl.UseProgram(p.fxaaProgram)
+	gl.Uniform1i(gl.GetUniformLocation(p.fxaaProgram, gl.Str("image\x00")), 0)
+	p.fxaaTexelSizeUniform = gl.GetUniformLocation(p.fxaaProgram, gl.Str("texelSize\x00"))
+	gl.UseProgram(0)
+
+	gl.GenVertexArrays(1, &p.vao)
+	if err := p.Resize(width, height); err != nil {
+		p.Release()
+		return nil, err
+	}
+	return p, nil
+}
+
+// newPostTexture creates a texture for a pass to render into and the next one to sample.
+func newPostTexture(internalFormat int32, width, height int32) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, width, height, 0, gl.RGBA, gl.FLOAT, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	return texture
+}
+
+// newPostFramebuffer creates a framebuffer that renders into texture.
+func newPostFramebuffer(texture uint32) (uint32, error) {
+	var fbo uint32
+	gl.GenFramebuffers(1, &fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, texture, 0)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		gl.DeleteFramebuffers(1, &fbo)
+		return 0, fmt.Errorf("post-processing framebuffer is incomplete (status 0x%x)", status)
+	}
+	return fbo, nil
+}
+
+// Resize recreates the targets for a new window size. Minimized windows (0 x 0) keep the
+// old ones.
+func (p *Chain) Resize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	p.releaseTargets()
+	p.width, p.height = int32(width), int32(height)
+
+	// The scene is drawn multisampled, so MSAA and alpha to coverage still work offscreen
+	gl.GenFramebuffers(1, &p.msFBO)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.msFBO)
+	gl.GenRenderbuffers(1, &p.msColor)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, p.msColor)
+	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, postSamples, gl.RGBA16F, p.width, p.height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, p.msColor)
+	gl.GenRenderbuffers(1, &p.msDepth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, p.msDepth)
+	gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, postSamples, gl.DEPTH24_STENCIL8, p.width, p.height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, p.msDepth)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("scene framebuffer is incomplete (status 0x%x)", status)
+	}
+
+	var err error
+	p.sceneTexture = newPostTexture(gl.RGBA16F, p.width, p.height)
+	if p.sceneFBO, err = newPostFramebuffer(p.sceneTexture); err != nil {
+		return err
+	}
+	for i := range p.bloomTextures {
+		p.bloomTextures[i] = newPostTexture(gl.RGBA16F, p.bloomWidth(), p.bloomHeight())
+		if p.bloomFBOs[i], err = newPostFramebuffer(p.bloomTextures[i]); err != nil {
+			return err
+		}
+	}
+	p.ldrTexture = newPostTexture(gl.RGBA8, p.width, p.height)
+	if p.ldrFBO, err = newPostFramebuffer(p.ldrTexture); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (p *Chain) bloomWidth() int32  { return (p.width + 1) / 2 }
+func (p *Chain) bloomHeight() int32 { return (p.height + 1) / 2 }
+
+// Begin makes the scene framebuffer the render target. The scene is drawn between Begin and End.
+func (p *Chain) Begin() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, p.msFBO)
+	gl.Viewport(0, 0, p.width, p.height)
+}
+
+// End runs the enabled passes over the scene and leaves the result in the window's
+// framebuffer, which stays bound for the UI. Depth testing is off afterwards, like the UI
+// wants it; whoever draws the next scene turns it back on.
+func (p *Chain) End() {
+	// Resolve the samples into a texture the passes can read
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, p.msFBO)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, p.sceneFBO)
+	gl.BlitFramebuffer(0, 0, p.width, p.height, 0, 0, p.width, p.height, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.BLEND)
+	gl.BindVertexArray(p.vao)
+	gl.ActiveTexture(gl.TEXTURE0)
+
+	if p.Enabled[Bloom] {
+		gl.Viewport(0, 0, p.bloomWidth(), p.bloomHeight())
+		gl.UseProgram(p.brightProgram)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.bloomFBOs[0])
+		gl.BindTexture(gl.TEXTURE_2D, p.sceneTexture)
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+
+		gl.UseProgram(p.blurProgram)
+		for i := 0; i < bloomBlurPasses*2; i++ {
+			if i%2 == 0 {
+				gl.Uniform2f(p.blurDirectionUniform, 1/float32(p.bloomWidth()), 0)
+			} else {
+				gl.Uniform2f(p.blurDirectionUniform, 0, 1/float32(p.bloomHeight()))
+			}
+			gl.BindFramebuffer(gl.FRAMEBUFFER, p.bloomFBOs[(i+1)%2])
+			gl.BindTexture(gl.TEXTURE_2D, p.bloomTextures[i%2])
+			gl.DrawArrays(gl.TRIANGLES, 0, 3)
+		}
+		// An even number of passes ends back in bloomTextures[0]
+	}
+
+	gl.Viewport(0, 0, p.width, p.height)
+	if p.Enabled[FXAA] {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, p.ldrFBO)
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	}
+	gl.UseProgram(p.compositeProgram)
+	setPostBool(p.compositeTonemapUniform, p.Enabled[Tonemap])
+	setPostBool(p.compositeBloomUniform, p.Enabled[Bloom])
+	setPostBool(p.compositeVignetteUniform, p.Enabled[Vignette])
+	setPostBool(p.compositeGammaUniform, p.Enabled[Gamma])
+	gl.Uniform1f(p.compositeExposureUniform, p.Exposure)
+	gl.BindTexture(gl.TEXTURE_2D, p.sceneTexture)
+	gl.ActiveTexture(gl.TEXTURE1)
+	gl.BindTexture(gl.TEXTURE_2D, p.bloomTextures[0])
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.DrawArrays(gl.TRIANGLES, 0, 3)
+
+	if p.Enabled[FXAA] {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		gl.UseProgram(p.fxaaProgram)
+		gl.Uniform2f(p.fxaaTexelSizeUniform, 1/float32(p.width), 1/float32(p.height))
+		gl.BindTexture(gl.TEXTURE_2D, p.ldrTexture)
+		gl.DrawArrays(gl.TRIANGLES, 0, 3)
+	}
+
+	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.BindVertexArray(0)
+}
+
+func setPostBool(uniform int32, value bool) {
+	if value {
+		gl.Uniform1i(uniform, 1)
+	} else {
+		gl.Uniform1i(uniform, 0)
+	}
+}
+
+// Toggle turns an effect on or off.
+func (p *Chain) Toggle(effect Effect) {
+	p.Enabled[effect] = !p.Enabled[effect]
+	if p.Enabled[effect] {
+		log.Printf("%s: ON", EffectNames[effect])
+	} else {
+		log.Printf("%s: OFF", EffectNames[effect])
+	}
+}
+
+// HandleKeys toggles an effect when its key in EffectKeys goes down.
+func (p *Chain) HandleKeys(window *glfw.Window) {
+	for effect := Effect(0); effect < EffectCount; effect++ {
+		pressed := window.GetKey(EffectKeys[effect]) == glfw.Press
+		if pressed && !p.keyWasPressed[effect] {
+			p.Toggle(effect)
+		}
+		p.keyWasPressed[effect] = pressed
+	}
+}
+
+// releaseTargets deletes the framebuffers and textures made by Resize.
+func (p *Chain) releaseTargets() {
+	framebuffers := []*uint32{&p.msFBO, &p.sceneFBO, &p.bloomFBOs[0], &p.bloomFBOs[1], &p.ldrFBO}
+	for _, fbo := range framebuffers {
+		if *fbo != 0 {
+			gl.DeleteFramebuffers(1, fbo)
+			*fbo = 0
+		}
+	}
+	renderbuffers := []*uint32{&p.msColor, &p.msDepth}
+	for _, rbo := range renderbuffers {
+		if *rbo != 0 {
+			gl.DeleteRenderbuffers(1, rbo)
+			*rbo = 0
+		}
+	}
+	textures := []*uint32{&p.sceneTexture, &p.bloomTextures[0], &p.bloomTextures[1], &p.ldrTexture}
+	for _, texture := range textures {
+		if *texture != 0 {
+			gl.DeleteTextures(1, texture)
+			*texture = 0
+		}
+	}
+}
+
+// Release deletes everything the chain owns.
+func (p *Chain) Release() {
+	p.releaseTargets()
+	for _, program := range []uint32{p.brightProgram, p.blurProgram, p.compositeProgram, p.fxaaProgram} {
+		if program != 0 {
+			gl.DeleteProgram(program)
+		}
+	}
+	if p.vao != 0 {
+		gl.DeleteVertexArrays(1, &p.vao)
+	}
+}
+
+// DisplayColor is what the composite pass makes of a linear scene color with tonemapping
+// and gamma on and no bloom or vignette: exposure, the ACES curve and gamma. Software
+// renderers, which don't run the chain, use it to get the same colors.
+func DisplayColor(linear mgl32.Vec3, exposure float32) mgl32.Vec3 {
+	var display mgl32.Vec3
+	for i, v := range linear {
+		display[i] = float32(math.Pow(float64(ACESFilmic(v*exposure)), 1/2.2))
+	}
+	return display
+}
+
+// LinearColor undoes the gamma pass on an sRGB color, such as a background color picked by
+// eye, so it can be drawn into the chain's linear target.
+func LinearColor(srgb mgl32.Vec3) mgl32.Vec3 {
+	var linear mgl32.Vec3
+	for i, v := range srgb {
+		linear[i] = float32(math.Pow(float64(v), 2.2))
+	}
+	return linear
+}
+
+// ACESFilmic is Narkowicz's fit of the ACES filmic curve, as in the composite pass.
+func ACESFilmic(x float32) float32 {
+	return mgl32.Clamp(x*(2.51*x+0.03)/(x*(2.43*x+0.59)+0.14), 0, 1)
+}
@@ -0,0 +1,264 @@
+// Package raster is the core of the software renderers: near-plane clipping, perspective-
+// correct triangle rasterization with a depth test, and wireframe lines. The vertex and
+// fragment stages belong to each renderer, so each runs the math of its own shaders.
+package raster
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// MaxVaryings is the most floats a vertex can pass to the fragment stage.
+const MaxVaryings = 12
+
+// Vertex is a vertex after the vertex stage.
+type Vertex struct {
+	Clip     mgl32.Vec4           // Clip-space position, gl_Position
+	Varyings [MaxVaryings]float32 // Interpolated across the triangle for the fragment stage
+}
+
+// Fragment is a pixel covered by a triangle, as the fragment stage gets it.
+type Fragment struct {
+	X, Y        int                  // The pixel, row 0 at the top
+	FrontFacing bool                 // Counter-clockwise on screen is the front, as in OpenGL
+	Varyings    [MaxVaryings]float32 // Interpolated perspective-correctly, as OpenGL does
+}
+
+// FragmentShader returns the color and alpha of a fragment, or false to discard it.
+type FragmentShader func(f *Fragment) (color mgl32.Vec3, alpha float32, ok bool)
+
+// Frame is a color and a depth buffer, with row 0 at the top like images.
+type Frame struct {
+	Width, Height int
+	Color         []mgl32.Vec3 // What the fragment stage output, before it is clamped to 8 bits
+	Depth         []float32    // Window-space depth, 0 at the near plane and 1 at the far plane
+}
+
+// Resize reallocates the buffers. Their contents are lost until the next Clear.
+func (f *Frame) Resize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid frame size %dx%d", width, height)
+	}
+	f.Width, f.Height = width, height
+	f.Color = make([]mgl32.Vec3, width*height)
+	f.Depth = make([]float32, width*height)
+	return nil
+}
+
+// Release drops the buffers.
+func (f *Frame) Release() {
+	f.Color, f.Depth = nil, nil
+}
+
+// Clear fills the color buffer with a color and the depth buffer with the far plane.
+func (f *Frame) Clear(color mgl32.Vec3) {
+	for i := range f.Color {
+		f.Color[i] = color
+		f.Depth[i] = 1
+	}
+}
+
+// ReadPixels returns the frame as an image, top row first. encode, if set, turns each color
+// into display colors first, which are then clamped to 8 bits per channel.
+func (f *Frame) ReadPixels(encode func(mgl32.Vec3) mgl32.Vec3) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.Width, f.Height))
+	for i, c := range f.Color {
+		if encode != nil {
+			c = encode(c)
+		}
+		for channel := 0; channel < 3; channel++ {
+			img.Pix[i*4+channel] = uint8(mgl32.Clamp(c[channel], 0, 1)*255 + 0.5)
+		}
+		img.Pix[i*4+3] = 255
+	}
+	return img
+}
+
+// DrawTriangle clips a triangle against the near plane and shades every pixel whose center
+// is inside what is left and passes the depth test. Blended triangles are mixed into the
+// color buffer by their alpha and leave the depth buffer alone, the others overwrite both
+// and their alpha is ignored.
+func (f *Frame) DrawTriangle(v0, v1, v2 Vertex, blend bool, shade FragmentShader) {
+	polygon, n := clipNear(v0, v1, v2)
+	for i := 1; i+1 < n; i++ {
+		f.rasterize(&polygon[0], &polygon[i], &polygon[i+1], blend, shade)
+	}
+}
+
+// clipNear clips a triangle against the near plane. What is left is a polygon of n corners,
+// 3, 4 when a corner was cut off, or none. Only the near plane needs clipping, for the
+// divide by w; the rest of the view volume is enforced per pixel.
+func clipNear(v0, v1, v2 Vertex) ([4]Vertex, int) {
+	corners := [3]*Vertex{&v0, &v1, &v2}
+	var polygon [4]Vertex
+	n := 0
+	for i, a := range corners {
+		b := corners[(i+1)%3]
+		// In front of the near plane when z >= -w
+		da, db := a.Clip[2]+a.Clip[3], b.Clip[2]+b.Clip[3]
+		if da >= 0 {
+			polygon[n] = *a
+			n++
+		}
+		if (da >= 0) != (db >= 0) {
+			polygon[n] = lerpVertex(a, b, da/(da-db))
+			n++
+		}
+	}
+	return polygon, n
+}
+
+// lerpVertex returns the vertex a fraction t of the way from a to b, in clip space.
+func lerpVertex(a, b *Vertex, t float32) Vertex {
+	v := Vertex{Clip: a.Clip.Add(b.Clip.Sub(a.Clip).Mul(t))}
+	for k := range v.Varyings {
+		v.Varyings[k] = a.Varyings[k] + (b.Varyings[k]-a.Varyings[k])*t
+	}
+	return v
+}
+
+// toWindow returns a vertex's position in the frame, in pixels from the top left, with its
+// window-space depth and 1/w.
+func (f *Frame) toWindow(v *Vertex) (x, y, z, invW float32) {
+	invW = 1 / v.Clip[3]
+	x = (v.Clip[0]*invW + 1) * 0.5 * float32(f.Width)
+	y = (1 - v.Clip[1]*invW) * 0.5 * float32(f.Height)
+	z = (v.Clip[2]*invW + 1) * 0.5
+	return x, y, z, invW
+}
+
+// rasterize draws one triangle that is entirely in front of the near plane.
+func (f *Frame) rasterize(v0, v1, v2 *Vertex, blend bool, shade FragmentShader) {
+	vertices := [3]*Vertex{v0, v1, v2}
+	var x, y, z, invW [3]float32
+	for i, v := range vertices {
+		x[i], y[i], z[i], invW[i] = f.toWindow(v)
+	}
+	area := (x[1]-x[0])*(y[2]-y[0]) - (x[2]-x[0])*(y[1]-y[0])
+	if area == 0 {
+		return
+	}
+	// Rows go down here, which flips the sign of counter-clockwise
+	fragment := Fragment{FrontFacing: area < 0}
+
+	minX := clampInt(int(floor32(min3(x[0], x[1], x[2]))), 0, f.Width-1)
+	maxX := clampInt(int(floor32(max3(x[0], x[1], x[2]))), 0, f.Width-1)
+	minY := clampInt(int(floor32(min3(y[0], y[1], y[2]))), 0, f.Height-1)
+	maxY := clampInt(int(floor32(max3(y[0], y[1], y[2]))), 0, f.Height-1)
+
+	for py := minY; py <= maxY; py++ {
+		sy := float32(py) + 0.5
+		for px := minX; px <= maxX; px++ {
+			sx := float32(px) + 0.5
+			// Barycentric weights, all positive inside whichever way the triangle winds
+			w0 := ((x[2]-x[1])*(sy-y[1]) - (y[2]-y[1])*(sx-x[1])) / area
+			w1 := ((x[0]-x[2])*(sy-y[2]) - (y[0]-y[2])*(sx-x[2])) / area
+			w2 := 1 - w0 - w1
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+			depth := w0*z[0] + w1*z[1] + w2*z[2]
+			i := py*f.Width + px
+			if depth > 1 || depth >= f.Depth[i] {
+				continue // Beyond the far plane, or behind what is already drawn
+			}
+
+			// Weights for values interpolated in 3D rather than across the screen
+			p0, p1, p2 := w0*invW[0], w1*invW[1], w2*invW[2]
+			scale := 1 / (p0 + p1 + p2)
+			p0, p1, p2 = p0*scale, p1*scale, p2*scale
+			for k := range fragment.Varyings {
+				fragment.Varyings[k] = p0*v0.Varyings[k] + p1*v1.Varyings[k] + p2*v2.Varyings[k]
+			}
+			fragment.X, fragment.Y = px, py
+			color, alpha, ok := shade(&fragment)
+			if !ok {
+				continue // Discarded
+			}
+			if blend {
+				f.Color[i] = color.Mul(alpha).Add(f.Color[i].Mul(1 - alpha))
+				continue
+			}
+			f.Color[i] = color
+			f.Depth[i] = depth
+		}
+	}
+}
+
+// DrawTriangleEdges draws the edges of a triangle in a color, over what is drawn. Like
+// OpenGL lines in polygon mode they are depth tested, and pulled forward by depthBias times
+// the triangle's depth slope (glPolygonOffset's factor) so the faces they outline don't
+// hide them.
+func (f *Frame) DrawTriangleEdges(v0, v1, v2 Vertex, depthBias float32, color mgl32.Vec3) {
+	polygon, n := clipNear(v0, v1, v2)
+	if n < 3 {
+		return
+	}
+	var x, y, z [4]float32
+	for k := 0; k < n; k++ {
+		x[k], y[k], z[k], _ = f.toWindow(&polygon[k])
+	}
+	area := (x[1]-x[0])*(y[2]-y[0]) - (x[2]-x[0])*(y[1]-y[0])
+	if area == 0 {
+		return
+	}
+	// The steepest depth change per pixel
+	dzdx := ((z[1]-z[0])*(y[2]-y[0]) - (z[2]-z[0])*(y[1]-y[0])) / area
+	dzdy := ((x[1]-x[0])*(z[2]-z[0]) - (x[2]-x[0])*(z[1]-z[0])) / area
+	bias := float32(math.Max(math.Abs(float64(dzdx)), math.Abs(float64(dzdy)))) * depthBias
+	for k := 0; k < n; k++ {
+		next := (k + 1) % n
+		f.drawLine(x[k], y[k], z[k]+bias, x[next], y[next], z[next]+bias, color)
+	}
+}
+
+// drawLine steps from one end of a line to the other a pixel at a time, setting the pixels
+// it passes whose depth is at most the depth buffer's. Window-space depth changes linearly
+// along the line, so it is interpolated as it is.
+func (f *Frame) drawLine(x0, y0, z0, x1, y1, z1 float32, color mgl32.Vec3) {
+	steps := int(math.Ceil(math.Max(math.Abs(float64(x1-x0)), math.Abs(float64(y1-y0)))))
+	if steps == 0 {
+		steps = 1
+	}
+	for s := 0; s <= steps; s++ {
+		t := float32(s) / float32(steps)
+		px := int(floor32(x0 + (x1-x0)*t))
+		py := int(floor32(y0 + (y1-y0)*t))
+		if px < 0 || px >= f.Width || py < 0 || py >= f.Height {
+			continue
+		}
+		depth := z0 + (z1-z0)*t
+		i := py*f.Width + px
+		if depth > 1 || depth > f.Depth[i] {
+			continue
+		}
+		f.Color[i] = color
+	}
+}
+
+// --- Small helpers for the rasterizer ---
+
+func clampInt(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func floor32(v float32) float32 {
+	return float32(math.Floor(float64(v)))
+}
+
+func min3(a, b, c float32) float32 {
+	return float32(math.Min(float64(a), math.Min(float64(b), float64(c))))
+}
+
+func max3(a, b, c float32) float32 {
+	return float32(math.Max(float64(a), math.Max(float64(b), float64(c))))
+}
@@ -0,0 +1,91 @@
+package raster
+
+import (
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// vertex returns a vertex at a clip-space position with its first varying set.
+func vertex(x, y, z, w, varying float32) Vertex {
+	v := Vertex{Clip: mgl32.Vec4{x, y, z, w}}
+	v.Varyings[0] = varying
+	return v
+}
+
+func TestClipNear(t *testing.T) {
+	tests := []struct {
+		name       string
+		v0, v1, v2 Vertex
+		wantN      int
+	}{
+		{"in front", vertex(0, 0, 0, 1, 0), vertex(1, 0, 0, 1, 0), vertex(0, 1, 0, 1, 0), 3},
+		{"one corner behind", vertex(0, 0, -2, 1, 0), vertex(1, 0, 0, 1, 0), vertex(0, 1, 0, 1, 0), 4},
+		{"two corners behind", vertex(0, 0, -2, 1, 0), vertex(1, 0, -2, 1, 0), vertex(0, 1, 0, 1, 0), 3},
+		{"all behind", vertex(0, 0, -2, 1, 0), vertex(1, 0, -2, 1, 0), vertex(0, 1, -2, 1, 0), 0},
+	}
+	for _, tt := range tests {
+		polygon, n := clipNear(tt.v0, tt.v1, tt.v2)
+		if n != tt.wantN {
+			t.Errorf("%s: %d corners, want %d", tt.name, n, tt.wantN)
+		}
+		for k := 0; k < n; k++ {
+			if d := polygon[k].Clip[2] + polygon[k].Clip[3]; d < -1e-6 {
+				t.Errorf("%s: corner %d is behind the near plane (z + w = %v)", tt.name, k, d)
+			}
+		}
+	}
+
+	// A corner cut off halfway along an edge gets the varyings from halfway along it
+	polygon, _ := clipNear(vertex(0, 0, -3, 1, 10), vertex(1, 0, 1, 1, 20), vertex(0, 1, 1, 1, 20))
+	if polygon[0].Varyings[0] != 15 {
+		t.Errorf("clipped corner varying = %v, want 15", polygon[0].Varyings[0])
+	}
+}
+
+func TestDrawTriangle(t *testing.T) {
+	var f Frame
+	if err := f.Resize(4, 4); err != nil {
+		t.Fatal(err)
+	}
+	solid := func(c mgl32.Vec3, alpha float32) FragmentShader {
+		return func(*Fragment) (mgl32.Vec3, float32, bool) { return c, alpha, true }
+	}
+	red, green, blue := mgl32.Vec3{1, 0, 0}, mgl32.Vec3{0, 1, 0}, mgl32.Vec3{0, 0, 1}
+	// Covers the whole frame at depth z (0 near, 1 far)
+	fullScreen := func(z float32) (Vertex, Vertex, Vertex) {
+		ndc := z*2 - 1
+		return vertex(-1, -1, ndc, 1, 0), vertex(3, -1, ndc, 1, 0), vertex(-1, 3, ndc, 1, 0)
+	}
+
+	tests := []struct {
+		name      string
+		z         float32
+		blend     bool
+		shade     FragmentShader
+		wantColor mgl32.Vec3
+		wantDepth float32
+	}{
+		{"first triangle", 0.5, false, solid(red, 1), red, 0.5},
+		{"behind it", 0.75, false, solid(green, 1), red, 0.5},
+		{"in front of it", 0.25, false, solid(green, 0), green, 0.25}, // Alpha is ignored
+		{"discarded", 0.1, false, func(*Fragment) (mgl32.Vec3, float32, bool) { return red, 1, false }, green, 0.25},
+		{"blended", 0.1, true, solid(blue, 0.25), mgl32.Vec3{0, 0.75, 0.25}, 0.25},
+	}
+	f.Clear(mgl32.Vec3{})
+	for _, tt := range tests {
+		v0, v1, v2 := fullScreen(tt.z)
+		f.DrawTriangle(v0, v1, v2, tt.blend, tt.shade)
+		for i := range f.Color {
+			if !f.Color[i].ApproxEqualThreshold(tt.wantColor, 1e-6) || !mgl32.FloatEqualThreshold(f.Depth[i], tt.wantDepth, 1e-6) {
+				t.Errorf("%s: pixel %d is %v at depth %v, want %v at %v", tt.name, i, f.Color[i], f.Depth[i], tt.wantColor, tt.wantDepth)
+				break
+			}
+		}
+	}
+
+	img := f.ReadPixels(func(c mgl32.Vec3) mgl32.Vec3 { return c.Mul(2) })
+	if c := img.RGBAAt(0, 0); c.R != 0 || c.G != 255 || c.B != 128 || c.A != 255 {
+		t.Errorf("ReadPixels = %v, want the encoded color clamped to 8 bits", c)
+	}
+}
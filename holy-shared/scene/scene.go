@@ -0,0 +1,99 @@
+// Package scene reads and writes the JSON scene files of holy-engine-base and holy-mm. Both
+// programs save the same format, so scenes can be passed between the editor and the engine.
+// Fields one of them doesn't use are still carried through, so that opening a scene in it
+// and saving it again loses nothing.
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// FormatVersion is written to every scene file. Bump it when the layout changes in a way
+// older builds can't read.
+const FormatVersion = 1
+
+// File is the JSON layout of a saved scene.
+type File struct {
+	Version  int       `json:"version"`
+	Lighting *Lighting `json:"lighting,omitempty"` // Missing in older files, which get the default lighting
+	Objects  []Object  `json:"objects"`
+}
+
+// Lighting is the scene's sun and ambient light.
+type Lighting struct {
+	SunYaw       float32    `json:"sunYaw"`
+	SunPitch     float32    `json:"sunPitch"`
+	SunColor     mgl32.Vec3 `json:"sunColor"`
+	SunIntensity float32    `json:"sunIntensity"`
+	Ambient      float32    `json:"ambient"`
+}
+
+// Object is one GameObject. Meshes aren't stored: they are rebuilt from Primitive or
+// reloaded from ModelPath, whichever is set.
+type Object struct {
+	ID              string      `json:"id"`
+	Primitive       string      `json:"primitive,omitempty"`
+	ModelPath       string      `json:"modelPath,omitempty"`
+	TexturePath     string      `json:"texturePath,omitempty"`
+	NormalMap       string      `json:"normalMap,omitempty"`
+	Color           *mgl32.Vec4 `json:"color,omitempty"`     // Missing for white
+	AlphaMode       string      `json:"alphaMode,omitempty"` // Missing in older files, which take it from the texture
+	AlphaCutoff     float32     `json:"alphaCutoff,omitempty"`
+	AlphaToCoverage bool        `json:"alphaToCoverage,omitempty"`
+	Position        mgl32.Vec3  `json:"position"`
+	Rotation        mgl32.Vec3  `json:"rotation"`
+	Scale           mgl32.Vec3  `json:"scale"`
+	Mass            float32     `json:"mass"`
+	IsKinematic     bool        `json:"isKinematic"`
+	BoundingBox     Bounds      `json:"boundingBox"`     // Local space, around the mesh
+	Light           *Light      `json:"light,omitempty"` // Only for point lights
+}
+
+// Light is a point light object's light.
+type Light struct {
+	Color     mgl32.Vec3 `json:"color"`
+	Intensity float32    `json:"intensity"`
+	Range     float32    `json:"range"`
+}
+
+// Bounds is an axis-aligned bounding box.
+type Bounds struct {
+	Min mgl32.Vec3 `json:"min"`
+	Max mgl32.Vec3 `json:"max"`
+}
+
+// Save writes a scene file, setting its version to FormatVersion.
+func Save(filePath string, scene *File) error {
+	scene.Version = FormatVersion
+	if scene.Objects == nil {
+		scene.Objects = []Object{} // An empty list rather than null
+	}
+	data, err := json.MarshalIndent(scene, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode scene: %w", err)
+	}
+	if err := os.WriteFile(filePath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write scene file %s: %w", filePath, err)
+	}
+	return nil
+}
+
+// Load reads a scene file, failing on versions this build doesn't know.
+func Load(filePath string) (*File, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scene file %s: %w", filePath, err)
+	}
+	var scene File
+	if err := json.Unmarshal(data, &scene); err != nil {
+		return nil, fmt.Errorf("failed to parse scene file %s: %w", filePath, err)
+	}
+	if scene.Version < 1 || scene.Version > FormatVersion {
+		return nil, fmt.Errorf("scene file %s has unsupported version %d (expected at most %d)", filePath, scene.Version, FormatVersion)
+	}
+	return &scene, nil
+}
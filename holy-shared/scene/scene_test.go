@@ -0,0 +1,99 @@
+package scene
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		scene File
+	}{
+		{"empty", File{Objects: []Object{}}},
+		{
+			name: "primitives, model, tint and light",
+			scene: File{
+				Lighting: &Lighting{SunYaw: 30, SunPitch: -45, SunColor: mgl32.Vec3{1, 0.9, 0.8}, SunIntensity: 2, Ambient: 0.1},
+				Objects: []Object{
+					{
+						ID: "Cube_1", Primitive: "cube", TexturePath: "textures/crate.png", NormalMap: "textures/crate_n.png",
+						AlphaMode: "mask", AlphaCutoff: 0.3, AlphaToCoverage: true,
+						Position: mgl32.Vec3{1, 2, 3}, Rotation: mgl32.Vec3{0, 90, 0}, Scale: mgl32.Vec3{1, 1, 1},
+						Mass: 2.5, BoundingBox: Bounds{Min: mgl32.Vec3{-0.5, -0.5, -0.5}, Max: mgl32.Vec3{0.5, 0.5, 0.5}},
+					},
+					{
+						ID: "Model_2", ModelPath: "models/chair.holymb", Position: mgl32.Vec3{-1, 0, 0}, Scale: mgl32.Vec3{2, 2, 2},
+						IsKinematic: true, BoundingBox: Bounds{Min: mgl32.Vec3{0, 0, 0}, Max: mgl32.Vec3{1, 2, 1}},
+					},
+					{
+						// Tinted in holy-engine-base
+						ID: "Sphere_3", Primitive: "sphere", Color: &mgl32.Vec4{0.2, 0.4, 1, 0.5},
+						Position: mgl32.Vec3{0, 3, 0}, Scale: mgl32.Vec3{1, 1, 1}, Mass: 1,
+					},
+					{
+						ID: "Light_4", Primitive: "sphere", Scale: mgl32.Vec3{0.2, 0.2, 0.2},
+						Light: &Light{Color: mgl32.Vec3{1, 0.5, 0}, Intensity: 4, Range: 10},
+					},
+				},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".json")
+		saved := tt.scene
+		if err := Save(path, &saved); err != nil {
+			t.Errorf("%s: save failed: %v", tt.name, err)
+			continue
+		}
+		if saved.Version != FormatVersion {
+			t.Errorf("%s: saved version %d, want %d", tt.name, saved.Version, FormatVersion)
+		}
+		loaded, err := Load(path)
+		if err != nil {
+			t.Errorf("%s: load failed: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(*loaded, saved) {
+			t.Errorf("%s: loaded %+v, want %+v", tt.name, *loaded, saved)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string // Written to the file, no file when empty
+		wantErr string // Part of the expected error
+	}{
+		{"missing file", "", "failed to read scene file"},
+		{"not json", "objects: []", "failed to parse scene file"},
+		{"no version", `{"objects": []}`, "unsupported version 0"},
+		{"newer version", `{"version": 2, "objects": []}`, "unsupported version 2"},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".json")
+		if tt.data != "" {
+			if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+		_, err := Load(path)
+		if err == nil {
+			t.Errorf("%s: expected an error containing %q, got none", tt.name, tt.wantErr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: error = %q, want it to contain %q", tt.name, err.Error(), tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package viewmode holds the debug view modes of holy-engine-base, holy-mm and
+// holy-spinning-models: instead of lighting, the scene shader can show what a mesh is made
+// of, so broken UVs, flipped normals and inverted faces stand out. A wireframe can be drawn
+// over any of them. M cycles the modes and F toggles the wireframe.
+//
+// The shader side is ShaderUniforms and ShaderFunctions, pasted into each program's
+// fragment shader. Only the loop drawing the meshes again for the wireframe is left to the
+// programs, between BeginWireframe and EndWireframe.
+package viewmode
+
+import (
+	"log"
+	"math"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/post"
+)
+
+// Mode selects what the scene shader shows. Its value is the shader's viewMode uniform.
+type Mode int
+
+const (
+	Lit        Mode = iota // Textures or materials and lighting, the normal view
+	Unlit                  // Albedo without lighting
+	Normals                // World-space normal as a color, normal map included, back faces striped red
+	UVChecker              // Checkerboard over the texture coordinates, tinted by them
+	Depth                  // Distance from the camera, white at the near end of the depth range and black at the far end
+	FalseColor             // A distinct flat color per object or submesh, see Color
+	Count
+)
+
+// Names are the names shown on the Tools panels and logged on a change.
+var Names = [Count]string{"lit", "unlit", "normals", "uv checker", "depth", "false color"}
+
+// View mode constants
+const (
+	DefaultDepthRange  = 20.0 // Distance that is black in Depth, for scenes that don't set their own range
+	WireframeDepthBias = -1.0 // Pulls wireframe lines in front of the faces they outline
+)
+
+func (mode Mode) String() string {
+	if mode < 0 || mode >= Count {
+		return "lit"
+	}
+	return Names[mode]
+}
+
+// Color returns a flat color for the i-th object or submesh in FalseColor. Hues are spread
+// by the golden ratio, so consecutive ones land far apart on the color wheel.
+func Color(i int) mgl32.Vec3 {
+	hue := math.Mod(float64(i)*0.618033988749895, 1) * 6
+	sector := int(hue)
+	f := float32(hue - float64(sector))
+	const saturation, value float32 = 0.65, 0.9
+	p := value * (1 - saturation)
+	q := value * (1 - saturation*f)
+	t := value * (1 - saturation*(1-f))
+	switch sector {
+	case 0:
+		return mgl32.Vec3{value, t, p}
+	case 1:
+		return mgl32.Vec3{q, value, p}
+	case 2:
+		return mgl32.Vec3{p, value, t}
+	case 3:
+		return mgl32.Vec3{p, q, value}
+	case 4:
+		return mgl32.Vec3{t, p, value}
+	default:
+		return mgl32.Vec3{value, p, q}
+	}
+}
+
+// ShaderUniforms declares what the view modes need in a fragment shader. depthRange is the
+// distances that are white and black in Depth.
+const ShaderUniforms = `
+		uniform int viewMode;    // 0 lit, 1 unlit, 2 normals, 3 uv checker, 4 depth, 5 false color, see holy-shared/viewmode
+		uniform bool wireframe;  // Drawing the wireframe overlay
+		uniform vec2 depthRange; // Distances that are white and black
+
+		const vec3 wireframeColor = vec3(0.0);
+`
+
+// ShaderFunctions defines viewModeColor, which returns what the modes other than Lit show for
+// a fragment. It goes after ShaderUniforms and needs TexCoord, FragPos, viewPos and a
+// surfaceNormal() function declared before it. flatColor is the FalseColor of what is drawn.
+const ShaderFunctions = `
+		// viewModeColor returns what the view modes other than lit show for the fragment.
+		vec3 viewModeColor(vec3 albedo, vec3 flatColor) {
+			if (viewMode == 1) {
+				return albedo;
+			} else if (viewMode == 2) {
+				// Faces seen from behind are striped, inverted ones stand out on a closed mesh
+				if (!gl_FrontFacing) {
+					return mod(floor((gl_FragCoord.x + gl_FragCoord.y) / 8.0), 2.0) * vec3(1.0, 0.0, 0.0);
+				}
+				return surfaceNormal() * 0.5 + 0.5;
+			} else if (viewMode == 3) {
+				vec2 cell = floor(TexCoord * 8.0);
+				float checker = mod(cell.x + cell.y, 2.0);
+				return vec3(fract(TexCoord), 0.5) * (0.4 + 0.6 * checker);
+			} else if (viewMode == 4) {
+				return vec3(1.0 - clamp((length(viewPos - FragPos) - depthRange.x) / (depthRange.y - depthRange.x), 0.0, 1.0));
+			}
+			return flatColor;
+		}
+`
+
+// Keys cycles a view mode on M and toggles a wireframe on F, once per key press.
+type Keys struct {
+	mWasPressed bool // Debounce for 'M' key
+	fWasPressed bool // Debounce for 'F' key
+}
+
+// Handle reads M and F from the window and changes mode and wireframe, logging what changed.
+func (k *Keys) Handle(window *glfw.Window, mode *Mode, wireframe *bool) {
+	mState := window.GetKey(glfw.KeyM)
+	if mState == glfw.Press && !k.mWasPressed {
+		*mode = (*mode + 1) % Count
+		log.Printf("View mode: %s", *mode)
+	}
+	k.mWasPressed = (mState == glfw.Press)
+
+	fState := window.GetKey(glfw.KeyF)
+	if fState == glfw.Press && !k.fWasPressed {
+		*wireframe = !*wireframe
+		if *wireframe {
+			log.Println("Wireframe: ON")
+		} else {
+			log.Println("Wireframe: OFF")
+		}
+	}
+	k.fWasPressed = (fState == glfw.Press)
+}
+
+// BeginWireframe sets up drawing edges over the frame: faces are drawn as lines, pulled in
+// front of the faces they outline, by a program whose wireframe uniform is at
+// wireframeUniform. Draw the meshes again, then call EndWireframe.
+func BeginWireframe(wireframeUniform int32) {
+	gl.Uniform1i(wireframeUniform, 1)
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.LINE)
+	gl.Enable(gl.POLYGON_OFFSET_LINE)
+	gl.PolygonOffset(WireframeDepthBias, WireframeDepthBias)
+	gl.DepthFunc(gl.LEQUAL)
+}
+
+// EndWireframe puts back the state BeginWireframe changed, and turns off alpha to coverage
+// in case a draw turned it on.
+func EndWireframe(wireframeUniform int32) {
+	gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+	gl.DepthFunc(gl.LESS)
+	gl.Disable(gl.POLYGON_OFFSET_LINE)
+	gl.PolygonMode(gl.FRONT_AND_BACK, gl.FILL)
+	gl.Uniform1i(wireframeUniform, 0)
+}
+
+// EndPost runs a post-processing chain on the frame. The modes other than Lit show colors
+// that mean something, so the effects that would change them are skipped while one is on.
+func EndPost(chain *post.Chain, mode Mode) {
+	if mode == Lit {
+		chain.End()
+		return
+	}
+	enabled := chain.Enabled
+	for _, effect := range []post.Effect{post.Tonemap, post.Bloom, post.Vignette, post.Gamma} {
+		chain.Enabled[effect] = false
+	}
+	chain.End()
+	chain.Enabled = enabled
+}
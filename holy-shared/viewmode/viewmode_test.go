@@ -0,0 +1,36 @@
+package viewmode
+
+import "testing"
+
+func TestModeString(t *testing.T) {
+	tests := []struct {
+		mode Mode
+		want string
+	}{
+		{Lit, "lit"},
+		{UVChecker, "uv checker"},
+		{FalseColor, "false color"},
+		{Count, "lit"}, // Out of range falls back to lit
+		{-1, "lit"},
+	}
+	for _, tt := range tests {
+		if got := tt.mode.String(); got != tt.want {
+			t.Errorf("Mode(%d).String() = %q, want %q", int(tt.mode), got, tt.want)
+		}
+	}
+}
+
+func TestColor(t *testing.T) {
+	// Neighbouring objects get colors far enough apart to tell them apart
+	for i := 0; i < 16; i++ {
+		a, b := Color(i), Color(i+1)
+		if d := a.Sub(b).Len(); d < 0.2 {
+			t.Errorf("colors %d and %d are %v apart: %v and %v", i, i+1, d, a, b)
+		}
+		for k := 0; k < 3; k++ {
+			if a[k] < 0 || a[k] > 1 {
+				t.Errorf("color %d = %v, want every channel within 0-1", i, a)
+			}
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+)
+
+// isGLTFPath reports whether path names a glTF model file rather than a model folder.
+func isGLTFPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(path, string(os.PathSeparator))))
+	return ext == ".gltf" || ext == ".glb"
+}
+
+// loadGLTFModel loads a glTF 2.0 model (.gltf with external or embedded data, or .glb).
+// Every triangle primitive of every mesh in the scene becomes a submesh, with its node's
+// transform baked into the vertices and its base color texture or factor as the material.
+func (a *AppCore) loadGLTFModel(filePath string) error {
+	doc, err := gltf.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open glTF file %s: %w", filePath, err)
+	}
+
+	var vertices []float32
+	var indices []uint32
+	var submeshes []Submesh
+	var materials []*int // glTF material of each submesh
+
+	// visit walks the node hierarchy, depth-limited so a malformed file with a cycle can't hang us
+	var visit func(nodeIndex int, parent mgl32.Mat4, depth int)
+	visit = func(nodeIndex int, parent mgl32.Mat4, depth int) {
+		if nodeIndex < 0 || nodeIndex >= len(doc.Nodes) || depth > 64 {
+			log.Printf("Warning: Skipping invalid glTF node %d", nodeIndex)
+			return
+		}
+		node := doc.Nodes[nodeIndex]
+		world := parent.Mul4(gltfNodeMatrix(node))
+
+		if node.Mesh != nil && *node.Mesh >= 0 && *node.Mesh < len(doc.Meshes) {
+			mesh := doc.Meshes[*node.Mesh]
+			for i, primitive := range mesh.Primitives {
+				submesh := Submesh{Material: fmt.Sprintf("%s/%d", mesh.Name, i), FirstIndex: int32(len(indices))}
+				var err error
+				vertices, indices, err = appendGLTFPrimitive(doc, primitive, world, vertices, indices)
+				if err != nil {
+					log.Printf("Warning: Skipping primitive %d of mesh %q: %v", i, mesh.Name, err)
+					continue
+				}
+				submesh.IndexCount = int32(len(indices)) - submesh.FirstIndex
+				submeshes = append(submeshes, submesh)
+				materials = append(materials, primitive.Material)
+			}
+		}
+		for _, child := range node.Children {
+			visit(child, world, depth+1)
+		}
+	}
+	for _, root := range gltfRootNodes(doc) {
+		visit(root, mgl32.Ident4(), 0)
+	}
+
+	if len(indices) == 0 {
+		return fmt.Errorf("glTF file %s has no triangles to draw", filePath)
+	}
+
+	meshgen.FillMissingNormals(vertices, indices, vertexLayout) // For primitives without a NORMAL attribute
+
+	// The old model is only dropped once the new one is known to be usable
+	a.releaseModel()
+	textureCache := make(map[gltfTextureKey]Texture)
+	for i := range submeshes {
+		a.applyGLTFMaterial(doc, filePath, materials[i], &submeshes[i], textureCache)
+	}
+	a.submeshes = submeshes
+	a.vertices = vertices
+	a.indices = indices
+	a.findSubmeshCenters()
+	a.prepareViewModes()
+	if a.mesh, err = a.renderer.CreateMesh(a.vertices, a.indices); err != nil {
+		return fmt.Errorf("failed to upload glTF model %s: %w", filePath, err)
+	}
+
+	log.Printf("Loaded %d vertices, %d indices and %d primitives from %s", len(vertices)/vertexFloats, len(indices), len(a.submeshes), filePath)
+	return nil
+}
+
+// gltfRootNodes returns the nodes to draw: those of the default scene, or of the first scene
+// when there is no default, or every node that isn't a child when there are no scenes at all.
+func gltfRootNodes(doc *gltf.Document) []int {
+	if len(doc.Scenes) > 0 {
+		scene := 0
+		if doc.Scene != nil && *doc.Scene >= 0 && *doc.Scene < len(doc.Scenes) {
+			scene = *doc.Scene
+		}
+		return doc.Scenes[scene].Nodes
+	}
+	isChild := make([]bool, len(doc.Nodes))
+	for _, node := range doc.Nodes {
+		for _, child := range node.Children {
+			if child >= 0 && child < len(isChild) {
+				isChild[child] = true
+			}
+		}
+	}
+	var roots []int
+	for i := range doc.Nodes {
+		if !isChild[i] {
+			roots = append(roots, i)
+		}
+	}
+	return roots
+}
+
+// gltfNodeMatrix returns a node's local transform, from its matrix or its translation,
+// rotation and scale.
+func gltfNodeMatrix(node *gltf.Node) mgl32.Mat4 {
+	if matrix := node.MatrixOrDefault(); matrix != gltf.DefaultMatrix {
+		var m mgl32.Mat4
+		for i, v := range matrix {
+			m[i] = float32(v) // Both are column-major
+		}
+		return m
+	}
+	t := node.TranslationOrDefault()
+	r := node.RotationOrDefault() // x, y, z, w
+	s := node.ScaleOrDefault()
+	rotation := mgl32.Quat{W: float32(r[3]), V: mgl32.Vec3{float32(r[0]), float32(r[1]), float32(r[2])}}
+	return mgl32.Translate3D(float32(t[0]), float32(t[1]), float32(t[2])).
+		Mul4(rotation.Normalize().Mat4()).
+		Mul4(mgl32.Scale3D(float32(s[0]), float32(s[1]), float32(s[2])))
+}
+
+// appendGLTFPrimitive appends a primitive's vertices (position and normal transformed by world,
+// texcoord) and triangles to the model data. Strips and fans are turned into triangle lists.
+func appendGLTFPrimitive(doc *gltf.Document, primitive *gltf.Primitive, world mgl32.Mat4, vertices []float32, indices []uint32) ([]float32, []uint32, error) {
+	switch primitive.Mode {
+	case gltf.PrimitiveTriangles, gltf.PrimitiveTriangleStrip, gltf.PrimitiveTriangleFan:
+	default:
+		return vertices, indices, fmt.Errorf("mode %v is not drawn, only triangles are", primitive.Mode)
+	}
+
+	positionIndex, ok := primitive.Attributes[gltf.POSITION]
+	if !ok || positionIndex < 0 || positionIndex >= len(doc.Accessors) {
+		return vertices, indices, fmt.Errorf("no POSITION attribute")
+	}
+	positions, err := modeler.ReadPosition(doc, doc.Accessors[positionIndex], nil)
+	if err != nil {
+		return vertices, indices, fmt.Errorf("failed to read positions: %w", err)
+	}
+	var texCoords [][2]float32
+	if texCoordIndex, ok := primitive.Attributes[gltf.TEXCOORD_0]; ok && texCoordIndex >= 0 && texCoordIndex < len(doc.Accessors) {
+		if texCoords, err = modeler.ReadTextureCoord(doc, doc.Accessors[texCoordIndex], nil); err != nil {
+			return vertices, indices, fmt.Errorf("failed to read texture coordinates: %w", err)
+		}
+	}
+	var normals [][3]float32 // Left as (0, 0, 0) when missing, for meshgen.FillMissingNormals
+	if normalIndex, ok := primitive.Attributes[gltf.NORMAL]; ok && normalIndex >= 0 && normalIndex < len(doc.Accessors) {
+		if normals, err = modeler.ReadNormal(doc, doc.Accessors[normalIndex], nil); err != nil {
+			return vertices, indices, fmt.Errorf("failed to read normals: %w", err)
+		}
+	}
+	var tangents [][4]float32 // Left as (0, 0, 0, 0) when missing, for meshgen.GenerateTangents
+	if tangentIndex, ok := primitive.Attributes[gltf.TANGENT]; ok && tangentIndex >= 0 && tangentIndex < len(doc.Accessors) {
+		if tangents, err = modeler.ReadTangent(doc, doc.Accessors[tangentIndex], nil); err != nil {
+			return vertices, indices, fmt.Errorf("failed to read tangents: %w", err)
+		}
+	}
+
+	// Without an index accessor the vertices are drawn in order
+	var primitiveIndices []uint32
+	if primitive.Indices != nil {
+		if *primitive.Indices < 0 || *primitive.Indices >= len(doc.Accessors) {
+			return vertices, indices, fmt.Errorf("invalid index accessor %d", *primitive.Indices)
+		}
+		if primitiveIndices, err = modeler.ReadIndices(doc, doc.Accessors[*primitive.Indices], nil); err != nil {
+			return vertices, indices, fmt.Errorf("failed to read indices: %w", err)
+		}
+	} else {
+		primitiveIndices = make([]uint32, len(positions))
+		for i := range primitiveIndices {
+			primitiveIndices[i] = uint32(i)
+		}
+	}
+	for _, index := range primitiveIndices {
+		if int(index) >= len(positions) {
+			return vertices, indices, fmt.Errorf("index %d out of range (%d vertices)", index, len(positions))
+		}
+	}
+
+	base := uint32(len(vertices) / vertexFloats)
+	normalMatrix := world.Mat3().Inv().Transpose() // Keeps normals right under non-uniform scale
+	// A mirroring transform turns the triangles inside out, so their winding is swapped back
+	// below, and it swaps the UV mapping's handedness too
+	flip := world.Mat3().Det() < 0
+	for i, p := range positions {
+		pos := world.Mul4x1(mgl32.Vec4{p[0], p[1], p[2], 1}).Vec3()
+		uv := mgl32.Vec2{0, 0} // glTF UVs start at the top-left, like newTexture uploads images
+		if i < len(texCoords) {
+			uv = mgl32.Vec2{texCoords[i][0], texCoords[i][1]}
+		}
+		normal := mgl32.Vec3{0, 0, 0}
+		if i < len(normals) {
+			normal = normalMatrix.Mul3x1(mgl32.Vec3{normals[i][0], normals[i][1], normals[i][2]})
+			if normal.Len() > 0 {
+				normal = normal.Normalize()
+			}
+		}
+		tangent := mgl32.Vec4{0, 0, 0, 0}
+		if i < len(tangents) {
+			t := world.Mat3().Mul3x1(mgl32.Vec3{tangents[i][0], tangents[i][1], tangents[i][2]})
+			w := tangents[i][3]
+			if flip {
+				w = -w
+			}
+			if t.Len() > 0 {
+				tangent = t.Normalize().Vec4(w)
+			}
+		}
+		vertices = append(vertices, pos.X(), pos.Y(), pos.Z(), uv.X(), uv.Y(), normal.X(), normal.Y(), normal.Z())
+		vertices = append(vertices, tangent.X(), tangent.Y(), tangent.Z(), tangent.W())
+	}
+
+	addTriangle := func(i0, i1, i2 uint32) {
+		if flip {
+			i1, i2 = i2, i1
+		}
+		indices = append(indices, base+i0, base+i1, base+i2)
+	}
+	switch primitive.Mode {
+	case gltf.PrimitiveTriangleStrip:
+		for i := 0; i+2 < len(primitiveIndices); i++ {
+			if i%2 == 0 {
+				addTriangle(primitiveIndices[i], primitiveIndices[i+1], primitiveIndices[i+2])
+			} else {
+				addTriangle(primitiveIndices[i+1], primitiveIndices[i], primitiveIndices[i+2])
+			}
+		}
+	case gltf.PrimitiveTriangleFan:
+		for i := 1; i+1 < len(primitiveIndices); i++ {
+			addTriangle(primitiveIndices[0], primitiveIndices[i], primitiveIndices[i+1])
+		}
+	default:
+		for i := 0; i+2 < len(primitiveIndices); i += 3 {
+			addTriangle(primitiveIndices[i], primitiveIndices[i+1], primitiveIndices[i+2])
+		}
+	}
+	return vertices, indices, nil
+}
+
+// gltfTextureKey identifies an uploaded glTF image. The same image can be needed both as
+// color (sRGB) and as data.
+type gltfTextureKey struct {
+	image int
+	srgb  bool
+}
+
+// applyGLTFMaterial sets a submesh's maps and factors from its glTF material. The
+// metallicRoughness texture is bound as both the roughness and the metallic map, the shader
+// reads its green and blue channels. Primitives without a material are white, as the glTF
+// spec asks. Masked materials get alpha to coverage.
+func (a *AppCore) applyGLTFMaterial(doc *gltf.Document, filePath string, materialIndex *int, submesh *Submesh, textureCache map[gltfTextureKey]Texture) {
+	submesh.DiffuseColor = mgl32.Vec4{1, 1, 1, 1}
+	submesh.Roughness = 1 // glTF's defaults
+	submesh.Metallic = 1
+	submesh.AlphaCutoff = alphamode.DefaultCutoff
+	if materialIndex == nil || *materialIndex < 0 || *materialIndex >= len(doc.Materials) {
+		return
+	}
+	material := doc.Materials[*materialIndex]
+	if material.Name != "" {
+		submesh.Material = material.Name
+	}
+	switch material.AlphaMode {
+	case gltf.AlphaMask:
+		submesh.AlphaMode = alphamode.Mask
+		submesh.AlphaCutoff = float32(material.AlphaCutoffOrDefault())
+		submesh.AlphaToCoverage = true
+	case gltf.AlphaBlend:
+		submesh.AlphaMode = alphamode.Blend
+	}
+	if material.OcclusionTexture != nil && material.OcclusionTexture.Index != nil {
+		submesh.Maps[mapAO] = a.loadGLTFTexture(doc, filePath, *material.OcclusionTexture.Index, false, submesh.Material, textureCache)
+	}
+	if material.NormalTexture != nil && material.NormalTexture.Index != nil {
+		submesh.Maps[mapNormal] = a.loadGLTFTexture(doc, filePath, *material.NormalTexture.Index, false, submesh.Material, textureCache)
+	}
+	pbr := material.PBRMetallicRoughness
+	if pbr == nil {
+		return
+	}
+	factor := pbr.BaseColorFactorOrDefault()
+	submesh.DiffuseColor = mgl32.Vec4{float32(factor[0]), float32(factor[1]), float32(factor[2]), float32(factor[3])}
+	submesh.Roughness = float32(pbr.RoughnessFactorOrDefault())
+	submesh.Metallic = float32(pbr.MetallicFactorOrDefault())
+
+	if pbr.BaseColorTexture != nil {
+		submesh.Maps[mapAlbedo] = a.loadGLTFTexture(doc, filePath, pbr.BaseColorTexture.Index, true, submesh.Material, textureCache)
+	}
+	if pbr.MetallicRoughnessTexture != nil {
+		texture := a.loadGLTFTexture(doc, filePath, pbr.MetallicRoughnessTexture.Index, false, submesh.Material, textureCache)
+		submesh.Maps[mapRoughness] = texture
+		submesh.Maps[mapMetallic] = texture
+	}
+}
+
+// loadGLTFTexture uploads the image of a glTF texture, or returns the texture already
+// uploaded for it. It returns 0 if that fails, so the map's default is used.
+func (a *AppCore) loadGLTFTexture(doc *gltf.Document, filePath string, textureIndex int, srgb bool, materialName string, textureCache map[gltfTextureKey]Texture) Texture {
+	if textureIndex < 0 || textureIndex >= len(doc.Textures) || doc.Textures[textureIndex].Source == nil {
+		log.Printf("Warning: Material %s has an invalid texture", materialName)
+		return 0
+	}
+	key := gltfTextureKey{image: *doc.Textures[textureIndex].Source, srgb: srgb}
+	if texture, ok := textureCache[key]; ok {
+		return texture
+	}
+
+	img, err := readGLTFImage(doc, filePath, key.image)
+	if err != nil {
+		log.Printf("Warning: Failed to load texture for material %s: %v", materialName, err)
+		return 0
+	}
+	texture, _, err := a.newTexture(img, srgb) // glTF materials say their alpha mode themselves
+	if err != nil {
+		log.Printf("Warning: Failed to create texture for material %s: %v", materialName, err)
+		return 0
+	}
+	textureCache[key] = texture
+	return texture
+}
+
+// readGLTFImage decodes a glTF image, which can be a data URI, a buffer view (always the case
+// in .glb files) or a file relative to the glTF file, named by a percent-encoded URI.
+func readGLTFImage(doc *gltf.Document, filePath string, imageIndex int) (image.Image, error) {
+	if imageIndex < 0 || imageIndex >= len(doc.Images) {
+		return nil, fmt.Errorf("image %d does not exist", imageIndex)
+	}
+	gltfImage := doc.Images[imageIndex]
+
+	var data []byte
+	var err error
+	switch {
+	case gltfImage.BufferView != nil:
+		if *gltfImage.BufferView < 0 || *gltfImage.BufferView >= len(doc.BufferViews) {
+			return nil, fmt.Errorf("image %d has an invalid buffer view", imageIndex)
+		}
+		data, err = modeler.ReadBufferView(doc, doc.BufferViews[*gltfImage.BufferView])
+	case gltfImage.IsEmbeddedResource():
+		data, err = gltfImage.MarshalData()
+	case gltfImage.URI != "":
+		// gltf.Open has already percent-decoded the URI ("my%20texture.png" is the file
+		// "my texture.png"), so it must not be decoded again: "100%25.png" is "100%.png"
+		data, err = os.ReadFile(filepath.Join(filepath.Dir(filePath), filepath.FromSlash(gltfImage.URI)))
+	default:
+		return nil, fmt.Errorf("image %d has no data", imageIndex)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image %d: %w", imageIndex, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image %d: %w", imageIndex, err)
+	}
+	return img, nil
+}
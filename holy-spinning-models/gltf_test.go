@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/qmuntal/gltf"
+	"github.com/qmuntal/gltf/modeler"
+)
+
+// testQuad is four corners of a unit square facing +Z, with their normals and tangents.
+var testQuad = [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}}
+
+// testGLTFPrimitive writes positions, normals, tangents and indices into doc and returns the
+// primitive drawing them.
+func testGLTFPrimitive(doc *gltf.Document, mode gltf.PrimitiveMode, positions [][3]float32, indices []uint16) *gltf.Primitive {
+	normals := make([][3]float32, len(positions))
+	tangents := make([][4]float32, len(positions))
+	for i := range positions {
+		normals[i] = [3]float32{0, 0, 1}
+		tangents[i] = [4]float32{1, 0, 0, 1}
+	}
+	return &gltf.Primitive{
+		Mode: mode,
+		Attributes: gltf.PrimitiveAttributes{
+			gltf.POSITION: modeler.WritePosition(doc, positions),
+			gltf.NORMAL:   modeler.WriteNormal(doc, normals),
+			gltf.TANGENT:  modeler.WriteTangent(doc, tangents),
+		},
+		Indices: gltf.Index(modeler.WriteIndices(doc, indices)),
+	}
+}
+
+// vertexAt returns the position, normal and tangent of the i-th vertex in the model data.
+func vertexAt(vertices []float32, i int) (position, normal mgl32.Vec3, tangent mgl32.Vec4) {
+	v := vertices[i*vertexFloats : (i+1)*vertexFloats]
+	return mgl32.Vec3{v[0], v[1], v[2]}, mgl32.Vec3{v[5], v[6], v[7]}, mgl32.Vec4{v[8], v[9], v[10], v[11]}
+}
+
+func TestAppendGLTFPrimitive(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         gltf.PrimitiveMode
+		indices      []uint16
+		world        mgl32.Mat4
+		wantIndices  []uint32 // Before the one vertex already in the model is added
+		wantPosition mgl32.Vec3
+		wantNormal   mgl32.Vec3
+		wantTangent  mgl32.Vec4
+	}{
+		{"triangles", gltf.PrimitiveTriangles, []uint16{0, 1, 2, 2, 1, 3}, mgl32.Ident4(),
+			[]uint32{0, 1, 2, 2, 1, 3}, mgl32.Vec3{1, 1, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec4{1, 0, 0, 1}},
+		{"strip", gltf.PrimitiveTriangleStrip, []uint16{0, 1, 2, 3}, mgl32.Ident4(),
+			[]uint32{0, 1, 2, 2, 1, 3}, mgl32.Vec3{1, 1, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec4{1, 0, 0, 1}}, // Every other triangle turned back
+		{"fan", gltf.PrimitiveTriangleFan, []uint16{0, 1, 3, 2}, mgl32.Ident4(),
+			[]uint32{0, 1, 3, 0, 3, 2}, mgl32.Vec3{1, 1, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec4{1, 0, 0, 1}},
+		{"node transform", gltf.PrimitiveTriangles, []uint16{0, 1, 2}, mgl32.Translate3D(1, 2, 3).Mul4(mgl32.HomogRotate3DZ(mgl32.DegToRad(90))),
+			[]uint32{0, 1, 2}, mgl32.Vec3{0, 3, 3}, mgl32.Vec3{0, 0, 1}, mgl32.Vec4{0, 1, 0, 1}},
+		{"mirrored", gltf.PrimitiveTriangles, []uint16{0, 1, 2, 2, 1, 3}, mgl32.Scale3D(-1, 1, 1),
+			[]uint32{0, 2, 1, 2, 3, 1}, mgl32.Vec3{-1, 1, 0}, mgl32.Vec3{0, 0, 1}, mgl32.Vec4{-1, 0, 0, -1}}, // Winding and tangent handedness swapped
+	}
+	for _, tt := range tests {
+		doc := gltf.NewDocument()
+		primitive := testGLTFPrimitive(doc, tt.mode, testQuad, tt.indices)
+		// One vertex is already in the model, so the primitive's indices start after it
+		vertices, indices, err := appendGLTFPrimitive(doc, primitive, tt.world, make([]float32, vertexFloats), nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if len(vertices) != (1+len(testQuad))*vertexFloats {
+			t.Errorf("%s: %d floats, want %d vertices", tt.name, len(vertices), 1+len(testQuad))
+			continue
+		}
+		wantIndices := make([]uint32, len(tt.wantIndices))
+		for i, index := range tt.wantIndices {
+			wantIndices[i] = index + 1
+		}
+		if !reflect.DeepEqual(indices, wantIndices) {
+			t.Errorf("%s: indices = %v, want %v", tt.name, indices, wantIndices)
+		}
+		// The quad's far corner, (1, 1, 0) before the transform
+		position, normal, tangent := vertexAt(vertices, 1+3)
+		if position.Sub(tt.wantPosition).Len() > 1e-5 || normal.Sub(tt.wantNormal).Len() > 1e-5 || tangent.Sub(tt.wantTangent).Len() > 1e-5 {
+			t.Errorf("%s: corner at %v with normal %v and tangent %v, want %v, %v and %v", tt.name, position, normal, tangent, tt.wantPosition, tt.wantNormal, tt.wantTangent)
+		}
+	}
+}
+
+func TestAppendGLTFPrimitiveErrors(t *testing.T) {
+	doc := gltf.NewDocument()
+	outOfRange := testGLTFPrimitive(doc, gltf.PrimitiveTriangles, testQuad, []uint16{0, 1, 4})
+	lines := testGLTFPrimitive(doc, gltf.PrimitiveLines, testQuad, []uint16{0, 1})
+	noPositions := &gltf.Primitive{Mode: gltf.PrimitiveTriangles, Attributes: gltf.PrimitiveAttributes{}}
+
+	tests := []struct {
+		name      string
+		primitive *gltf.Primitive
+		wantErr   string
+	}{
+		{"index out of range", outOfRange, "index 4 out of range (4 vertices)"},
+		{"lines", lines, "mode LINES is not drawn, only triangles are"},
+		{"no positions", noPositions, "no POSITION attribute"},
+	}
+	for _, tt := range tests {
+		vertices, indices, err := appendGLTFPrimitive(doc, tt.primitive, mgl32.Ident4(), nil, nil)
+		if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+		if len(vertices) != 0 || len(indices) != 0 {
+			t.Errorf("%s: %d floats and %d indices added, want the model left alone", tt.name, len(vertices), len(indices))
+		}
+	}
+}
+
+// writeTestGLTF saves a model of one textured triangle under two nodes, the parent moved
+// up by 2 and the child scaled by 2, as a .gltf with its buffer in a file next to it and
+// its texture in textureFile, named by textureURI, or as a .glb holding both.
+func writeTestGLTF(t *testing.T, dir string, binary bool, textureFile, textureURI string) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	var pngData bytes.Buffer
+	if err := png.Encode(&pngData, img); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := gltf.NewDocument()
+	primitive := testGLTFPrimitive(doc, gltf.PrimitiveTriangles, testQuad[:3], []uint16{0, 1, 2})
+	primitive.Attributes[gltf.TEXCOORD_0] = modeler.WriteTextureCoord(doc, [][2]float32{{0, 0}, {1, 0}, {0, 1}})
+	primitive.Material = gltf.Index(0)
+	if binary {
+		imageIndex, err := modeler.WriteImage(doc, "crate", "image/png", &pngData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Textures = []*gltf.Texture{{Source: gltf.Index(imageIndex)}}
+	} else {
+		if err := os.WriteFile(filepath.Join(dir, textureFile), pngData.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		doc.Images = []*gltf.Image{{URI: textureURI}}
+		doc.Textures = []*gltf.Texture{{Source: gltf.Index(0)}}
+		doc.Buffers[0].URI = "model.bin"
+	}
+	doc.Materials = []*gltf.Material{{
+		Name:                 "Crate",
+		PBRMetallicRoughness: &gltf.PBRMetallicRoughness{BaseColorTexture: &gltf.TextureInfo{Index: 0}},
+	}}
+	doc.Meshes = []*gltf.Mesh{{Name: "Triangle", Primitives: []*gltf.Primitive{primitive}}}
+	doc.Nodes = []*gltf.Node{
+		{Translation: [3]float64{0, 2, 0}, Children: []int{1}},
+		{Scale: [3]float64{2, 2, 2}, Mesh: gltf.Index(0)},
+	}
+	doc.Scenes[0].Nodes = []int{0}
+
+	path := filepath.Join(dir, "model.gltf")
+	save := gltf.Save
+	if binary {
+		path = filepath.Join(dir, "model.glb")
+		save = gltf.SaveBinary
+	}
+	if err := save(doc, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadGLTFModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		binary      bool
+		textureFile string
+		textureURI  string // Percent-encoded, as glTF URIs are
+	}{
+		{"gltf", false, "my crate.png", "my%20crate.png"},
+		{"gltf with a percent sign", false, "100% crate.png", "100%25%20crate.png"}, // Decoded once only
+		{"glb", true, "", ""},
+	}
+	for _, tt := range tests {
+		path := writeTestGLTF(t, t.TempDir(), tt.binary, tt.textureFile, tt.textureURI)
+		renderer, err := newSoftwareRenderer(1, 1) // Only loads, nothing is drawn
+		if err != nil {
+			t.Fatalf("renderer setup failed: %v", err)
+		}
+		a := &AppCore{renderer: renderer}
+		if err := a.loadGLTFModel(path); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		// The child's scale and then the parent's move are baked into the vertices
+		want := []mgl32.Vec3{{0, 2, 0}, {2, 2, 0}, {0, 4, 0}}
+		for i, w := range want {
+			if position, _, _ := vertexAt(a.vertices, i); position.Sub(w).Len() > 1e-5 {
+				t.Errorf("%s: vertex %d at %v, want %v", tt.name, i, position, w)
+			}
+		}
+		if len(a.submeshes) != 1 || a.submeshes[0].Material != "Crate" || a.submeshes[0].IndexCount != 3 {
+			t.Errorf("%s: submeshes = %+v, want one drawing the triangle with Crate", tt.name, a.submeshes)
+			continue
+		}
+		// The texture is found from its URI, or in the .glb's buffer
+		if a.submeshes[0].Maps[mapAlbedo] == 0 {
+			t.Errorf("%s: the base color texture was not loaded", tt.name)
+		}
+	}
+}
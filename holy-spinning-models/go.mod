@@ -6,6 +6,7 @@ require (
 	github.com/go-gl/gl v0.0.0-20231021071112-07e5d0ea2e71
 	github.com/go-gl/glfw/v3.3/glfw v0.0.0-20250301202403-da16c1255728
 	github.com/go-gl/mathgl v1.2.0
+	github.com/toxichemicals/GO/holy-shared v0.0.0-00010101000000-000000000000
 	github.com/qmuntal/gltf v0.28.0
 )
 
@@ -14,3 +15,5 @@ require (
 	github.com/sheenobu/go-obj v0.2.0 // indirect
 	github.com/zeluisping/go-obj v0.0.0-20190708111432-6d63f7f7fc8b // indirect
 )
+
+replace github.com/toxichemicals/GO/holy-shared => ../holy-shared
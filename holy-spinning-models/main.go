@@ -1,28 +1,30 @@
 package main
 
 import (
+	"archive/zip"
 	"bufio"
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"image"
 	"image/draw"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"log"
 	"math"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
-	"unsafe"
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
-	objparser "github.com/go-gl/mathgl/obj" // Changed: Using github.com/go-gl/mathgl/obj for OBJ parsing
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/meshgen"
+	"github.com/toxichemicals/GO/holy-shared/objfile"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
 )
 
 // Constants for window dimensions
@@ -39,25 +41,47 @@ const (
 	cameraSpeed      = 250.0 // Units per second
 	mouseSensitivity = 0.1   // Degrees per pixel
 	farClippingPlane = 5000.0 // Increased for distant objects
+	fieldOfView      = 45.0   // Vertical, in degrees
+)
+
+// clearColor is the background behind the model, in sRGB like the textures.
+var clearColor = mgl32.Vec3{0.2, 0.3, 0.3}
+
+// Vertex layout and lighting constants
+const (
+	vertexFloats = 12 // Floats per vertex in a.vertices: position (3) + texcoord (2) + normal (3) + tangent (4)
+	sunIntensity = 0.8
+	ambient      = 0.6 // Scales the sky's image-based light, so the side facing away isn't black
 )
 
+// vertexLayout is where the attributes are in a.vertices, for holy-shared/meshgen.
+var vertexLayout = meshgen.Layout{Stride: vertexFloats, TexCoord: 3, Normal: 5, Tangent: 8}
+
+// sunDirection is the direction the sunlight travels in: down, from the front right.
+var sunDirection = mgl32.Vec3{-0.5, -1.0, -0.6}.Normalize()
+
+// pointLights are fixed around the origin, where models are drawn, to give them some
+// colored highlights on top of the sun. At most 8, the shader's MAX_POINT_LIGHTS.
+var pointLights = []struct {
+	Position mgl32.Vec3
+	Color    mgl32.Vec3 // Already multiplied by the intensity
+	Range    float32    // Distance at which the light has faded out completely
+}{
+	{mgl32.Vec3{3, 2, 3}, mgl32.Vec3{1.0, 0.8, 0.6}, 12},   // Warm, front right
+	{mgl32.Vec3{-3, 1, -2}, mgl32.Vec3{0.4, 0.5, 1.0}, 12}, // Cool, back left
+}
+
 // AppCore struct encapsulates the low-level graphics and windowing components.
 type AppCore struct {
 	window *glfw.Window
 
-	// OpenGL program and buffers for the model
-	program      uint32
-	vao          uint32
-	vbo          uint32
-	ebo          uint32
-	indicesCount int32
-	textureID    uint32
-
-	// Uniform locations
-	modelUniform      int32
-	viewUniform       int32
-	projectionUniform int32
-	textureUniform    int32
+	// What the model is drawn with, see renderer.go
+	renderer          Renderer
+	mesh              Mesh                       // The model's vertices and indices, shared by the submeshes
+	submeshes         []Submesh                  // One per material, drawn in order
+	textures          []Texture                  // Every texture the submeshes use, each loaded once
+	textureAlphaModes map[Texture]alphamode.Mode // What each texture's alpha channel calls for
+	blendedOrder      []int                      // Blended submeshes, drawn back to front each frame
 
 	// Window dimensions
 	width, height int
@@ -103,6 +127,12 @@ type AppCore struct {
 
 	// Custom Model Loading State
 	gKeyWasPressed bool
+
+	// View mode and wireframe overlay, see holy-shared/viewmode
+	viewMode    viewmode.Mode
+	wireframe   bool
+	viewKeys    viewmode.Keys
+	modelRadius float32 // Farthest vertex from the origin, for the depth view
 }
 
 // Global instance of AppCore
@@ -127,11 +157,6 @@ func initApp() error {
 		rotationEnabled: true,
 	}
 
-	// Load the 3D model from OBJ files, starting with the default directory
-	if err := app.loadAndSetupModel(defaultModelBaseDir); err != nil {
-		return fmt.Errorf("failed to load default OBJ model from %s: %w", defaultModelBaseDir, err)
-	}
-
 	if err := app.initializeWindow(); err != nil {
 		return fmt.Errorf("window initialization failed: %w", err)
 	}
@@ -140,11 +165,19 @@ func initApp() error {
 		return fmt.Errorf("OpenGL initialization failed: %w", err)
 	}
 
-	if err := app.setupShadersAndUniforms(); err != nil {
-		return fmt.Errorf("shader setup failed: %w", err)
+	renderer, err := newGLRenderer(app.width, app.height)
+	if err != nil {
+		return fmt.Errorf("renderer setup failed: %w", err)
 	}
+	app.renderer = renderer
 
-	app.setupCameraAndProjection()
+	app.updateCameraPosition()
+
+	// Load the 3D model from OBJ files, starting with the default directory.
+	// This needs the renderer for its mesh and textures.
+	if err := app.loadAndSetupModel(defaultModelBaseDir); err != nil {
+		return fmt.Errorf("failed to load default OBJ model from %s: %w", defaultModelBaseDir, err)
+	}
 
 	app.lastFrameTime = time.Now()
 	app.fpsLastUpdateTime = time.Now()
@@ -157,185 +190,277 @@ func initApp() error {
 type ObjVertex struct {
 	Pos      mgl32.Vec3
 	TexCoord mgl32.Vec2
+	Normal   mgl32.Vec3 // (0, 0, 0) when the corner has none, computed after loading
+}
+
+// Submesh is the part of the model drawn with one material: a range of the index buffer,
+// the material's maps and the factors they are multiplied by.
+type Submesh struct {
+	Material        string
+	FirstIndex      int32 // Offset into the shared index buffer, in indices
+	IndexCount      int32
+	Maps            [materialMapCount]Texture // 0 for maps the material doesn't have
+	DiffuseColor    mgl32.Vec4                // Albedo factor, the Kd color when there is no albedo map
+	Roughness       float32
+	Metallic        float32
+	AlphaMode       alphamode.Mode // How the albedo's alpha is used, see holy-shared/alphamode
+	AlphaCutoff     float32        // Alpha below this is cut out in the mask mode
+	AlphaToCoverage bool           // Antialias masked edges with MSAA instead of cutting them hard
+	Center          mgl32.Vec3     // Middle of the submesh's vertices, blended submeshes are sorted by it
+	FalseColor      mgl32.Vec3     // Its flat color in the false color view, see viewmodes.go
 }
 
-// loadAndSetupModel loads an OBJ model and sets up its OpenGL buffers and texture.
+// loadAndSetupModel loads an OBJ model and sets up its OpenGL buffers and textures.
 // It takes the base directory of the model (e.g., "my_model_folder/").
 // Assumes OBJ is in baseDir/source/ and textures are in baseDir/textures/
+// Faces are grouped by material into submeshes that share one vertex and index buffer.
+// A path to a .gltf or .glb file is loaded with loadGLTFModel instead.
 func (a *AppCore) loadAndSetupModel(baseDir string) error {
-	// Clean up previous buffers if any
-	if a.vao != 0 {
-		gl.DeleteVertexArrays(1, &a.vao)
-		gl.DeleteBuffers(1, &a.vbo)
-		gl.DeleteBuffers(1, &a.ebo)
-		if a.textureID != 0 {
-			gl.DeleteTextures(1, &a.textureID)
-			a.textureID = 0 // Reset texture ID
-		}
-	}
-
-	// Determine the main OBJ file name (e.g., "default.obj" from "default/" folder)
-	modelName := strings.TrimSuffix(filepath.Base(baseDir), string(os.PathSeparator))
-	if modelName == "" { // Handle cases like "." or "/"
-		modelName = "default" // Fallback name
+	if isGLTFPath(baseDir) {
+		return a.loadGLTFModel(strings.TrimSuffix(baseDir, string(os.PathSeparator)))
 	}
 
-	objFilePath := filepath.Join(baseDir, "source", modelName+".obj")
-	// The objparser.Parse function from go-gl/mathgl/obj expects the MTL path
-	// to be relative to the OBJ file, or absolute.
-	// We'll pass the directory containing the OBJ file as the base for MTL lookup.
-	mtlDir := filepath.Join(baseDir, "source")
-
-	objFile, err := os.Open(objFilePath)
+	objFile, objFilePath, err := openModelSource(baseDir)
 	if err != nil {
-		return fmt.Errorf("could not open OBJ file %s: %w", objFilePath, err)
+		return err
 	}
 	defer objFile.Close()
 
-	// Use go-gl/mathgl/obj.Parse to parse the OBJ file and its associated MTL
-	objModel, err := objparser.Parse(objFile, mtlDir)
+	// Material libraries are looked up from the source folder
+	mtlDir := filepath.Join(baseDir, "source")
+	objModel, err := objfile.Parse(objFile, objFilePath, mtlDir, false)
 	if err != nil {
 		return fmt.Errorf("failed to parse OBJ file %s: %w", objFilePath, err)
 	}
 
+	a.releaseModel()
+
+	// Faces are grouped by material, in the order the materials are first used
+	materialOrder, facesByMaterial := objModel.FacesByMaterial()
+
 	var vertices []float32
 	var indices []uint32
 	vertexMap := make(map[ObjVertex]uint32) // Map to store unique vertex combinations
 	currentIdx := uint32(0)
-
-	// Iterate through faces to build interleaved data
-	for _, face := range objModel.Faces {
-		if len(face.Vertices) != 3 { // We are only handling triangles for simplicity
-			log.Printf("Warning: Face with %d vertices encountered, skipping (only triangles supported).", len(face.Vertices))
-			continue
+	textureCache := make(map[string]Texture) // Texture path -> texture, for maps shared by materials
+
+	vertexIndex := func(face objfile.Face, corner int) uint32 {
+		c := face.Corners[corner]
+		pos := objModel.Positions[c.Position]
+		uv := mgl32.Vec2{0, 0} // Default UV if the corner has none
+		if texCoordIdx := c.TexCoord; texCoordIdx >= 0 {
+			// OBJ puts V=0 at the bottom of the image, newTexture uploads the top row first
+			rawUV := objModel.TexCoords[texCoordIdx]
+			uv = mgl32.Vec2{rawUV.X(), 1 - rawUV.Y()}
 		}
-		for i := 0; i < 3; i++ { // Iterate through the 3 vertices of the triangle
-			vertexIdx := face.Vertices[i]
-			texCoordIdx := face.TexCoords[i] // go-gl/mathgl/obj separates these indices
 
-			// go-gl/mathgl/obj uses 0-based indices directly
-			pos := objModel.Positions[vertexIdx]
-			uv := mgl32.Vec2{0, 0} // Default UV if not found
+		normal := mgl32.Vec3{0, 0, 0}
+		if normalIdx := c.Normal; normalIdx >= 0 {
+			normal = objModel.Normals[normalIdx]
+		}
 
-			if texCoordIdx >= 0 && texCoordIdx < len(objModel.TexCoords) {
-				rawUV := objModel.TexCoords[texCoordIdx]
-				uv = mgl32.Vec2{rawUV.X(), rawUV.Y()} // TexCoords are mgl32.Vec2 in this library
-			} else {
-				log.Printf("Warning: Missing or invalid texture coordinate index for vertex in face. Defaulting to (0,0).")
+		v := ObjVertex{Pos: pos, TexCoord: uv, Normal: normal}
+		if idx, ok := vertexMap[v]; ok {
+			return idx
+		}
+		vertexMap[v] = currentIdx
+		vertices = append(vertices, v.Pos.X(), v.Pos.Y(), v.Pos.Z())
+		vertices = append(vertices, v.TexCoord.X(), v.TexCoord.Y())
+		vertices = append(vertices, v.Normal.X(), v.Normal.Y(), v.Normal.Z())
+		vertices = append(vertices, 0, 0, 0, 0) // Tangent, from meshgen.GenerateTangents
+		currentIdx++
+		return currentIdx - 1
+	}
+
+	for _, materialName := range materialOrder {
+		submesh := Submesh{
+			Material:     materialName,
+			FirstIndex:   int32(len(indices)),
+			DiffuseColor: mgl32.Vec4{1, 1, 1, 1}, // White if the material is missing
+			Roughness:    DefaultRoughness,
+			AlphaCutoff:  alphamode.DefaultCutoff,
+		}
+		// Polygons are triangulated as a fan around their first corner
+		for _, face := range facesByMaterial[materialName] {
+			first := vertexIndex(face, 0)
+			for i := 1; i+1 < len(face.Corners); i++ {
+				indices = append(indices, first, vertexIndex(face, i), vertexIndex(face, i+1))
 			}
+		}
+		submesh.IndexCount = int32(len(indices)) - submesh.FirstIndex
 
-			v := ObjVertex{
-				Pos:      pos, // Positions are already mgl32.Vec3
-				TexCoord: uv,
+		if mtl, ok := objModel.Materials[materialName]; ok {
+			a.loadMaterialMaps(baseDir, mtl, &submesh, textureCache)
+			submesh.DiffuseColor = mgl32.Vec4{mtl.Kd.X(), mtl.Kd.Y(), mtl.Kd.Z(), mtl.D}
+			if submesh.Maps[mapAlbedo] != 0 {
+				submesh.DiffuseColor = mgl32.Vec4{1, 1, 1, mtl.D} // Kd is usually the map's average, don't darken it twice
 			}
-
-			if idx, ok := vertexMap[v]; ok {
-				indices = append(indices, idx)
-			} else {
-				vertexMap[v] = currentIdx
-				indices = append(indices, currentIdx)
-				vertices = append(vertices, v.Pos.X(), v.Pos.Y(), v.Pos.Z())
-				vertices = append(vertices, v.TexCoord.X(), v.TexCoord.Y())
-				currentIdx++
+			// MTL has no alpha mode. Albedo maps with alpha are mostly cut-outs like hair and
+			// eye cards, which alpha to coverage draws without sorting; dissolved materials blend.
+			if a.textureAlphaModes[submesh.Maps[mapAlbedo]] != alphamode.Opaque {
+				submesh.AlphaMode = alphamode.Mask
+				submesh.AlphaToCoverage = true
+			}
+			if mtl.D < 1 {
+				submesh.AlphaMode = alphamode.Blend
+			}
+			// Explicit PBR values win, then values converted from Phong, then what the maps say alone
+			switch {
+			case mtl.Pr >= 0:
+				submesh.Roughness = mtl.Pr
+			case submesh.Maps[mapRoughness] != 0:
+				submesh.Roughness = 1
+			case mtl.Ns >= 0:
+				submesh.Roughness = roughnessFromShininess(mtl.Ns)
 			}
+			if mtl.Pm >= 0 {
+				submesh.Metallic = mtl.Pm
+			} else if submesh.Maps[mapMetallic] != 0 {
+				submesh.Metallic = 1
+			}
+			log.Printf("Material %s: roughness %.2f, metallic %.2f, %s", materialName, submesh.Roughness, submesh.Metallic, describeMaps(&submesh))
+		} else if materialName != "" {
+			log.Printf("Warning: Material %s is not defined in any MTL file, using white.", materialName)
 		}
+		a.submeshes = append(a.submeshes, submesh)
 	}
 
+	meshgen.FillMissingNormals(vertices, indices, vertexLayout)
+	vertices, indices = meshgen.GenerateTangents(vertices, indices, vertexLayout)
 	a.vertices = vertices
 	a.indices = indices
-	a.indicesCount = int32(len(a.indices))
-
-	// --- Load Texture ---
-	a.textureID = 0 // Default to 0 if no texture is found/loaded
-	if len(objModel.Materials) > 0 {
-		// Iterate through materials to find a diffuse texture map
-		for _, mtl := range objModel.Materials {
-			if mtl.MapKd != "" { // MapKd is the diffuse texture map
-				// Construct the full path to the texture file
-				texturePath := filepath.Join(baseDir, "textures", mtl.MapKd)
-				imgFile, err := os.Open(texturePath)
-				if err != nil {
-					log.Printf("Warning: Could not open texture file %s for material %s: %v", texturePath, mtl.Name, err)
-					continue // Try next material
-				}
-				defer imgFile.Close()
+	a.findSubmeshCenters()
+	a.prepareViewModes()
+	if len(textureCache) == 0 {
+		log.Println("Warning: No texture loaded for the model, drawing material colors.")
+	}
+	if a.mesh, err = a.renderer.CreateMesh(a.vertices, a.indices); err != nil {
+		return fmt.Errorf("failed to upload OBJ model %s: %w", objFilePath, err)
+	}
 
-				img, _, err := image.Decode(imgFile)
-				if err != nil {
-					log.Printf("Warning: Failed to decode texture image %s for material %s: %v", texturePath, mtl.Name, err)
-					continue
-				}
+	log.Printf("Loaded %d unique vertices, %d indices and %d materials from %s", currentIdx, len(a.indices), len(a.submeshes), objFilePath)
+	return nil
+}
 
-				a.textureID, err = newTexture(img)
-				if err != nil {
-					log.Printf("Warning: Failed to create OpenGL texture from %s: %v", texturePath, err)
-				} else {
-					log.Printf("Texture '%s' loaded successfully.", texturePath)
-					break // Texture loaded, stop looking
+// releaseModel deletes the mesh and textures of the current model, if any.
+func (a *AppCore) releaseModel() {
+	if a.mesh != 0 {
+		a.renderer.DeleteMesh(a.mesh)
+		a.mesh = 0
+	}
+	for _, texture := range a.textures {
+		a.renderer.DeleteTexture(texture)
+	}
+	a.textures = nil
+	a.textureAlphaModes = make(map[Texture]alphamode.Mode)
+	a.submeshes = nil
+}
+
+// findSubmeshCenters sets the Center of every submesh, the middle of the box around the
+// vertices it draws.
+func (a *AppCore) findSubmeshCenters() {
+	for i := range a.submeshes {
+		submesh := &a.submeshes[i]
+		if submesh.IndexCount == 0 {
+			continue
+		}
+		var minV, maxV mgl32.Vec3
+		for n, index := range a.indices[submesh.FirstIndex : submesh.FirstIndex+submesh.IndexCount] {
+			pos := mgl32.Vec3{a.vertices[index*vertexFloats], a.vertices[index*vertexFloats+1], a.vertices[index*vertexFloats+2]}
+			for axis := 0; axis < 3; axis++ {
+				if n == 0 || pos[axis] < minV[axis] {
+					minV[axis] = pos[axis]
+				}
+				if n == 0 || pos[axis] > maxV[axis] {
+					maxV[axis] = pos[axis]
 				}
 			}
 		}
-		if a.textureID == 0 {
-			log.Println("Warning: No diffuse texture loaded for the model.")
-		}
-	} else {
-		log.Println("Warning: No materials found in OBJ model.")
+		submesh.Center = minV.Add(maxV).Mul(0.5)
 	}
+}
 
-
-	// Setup OpenGL buffers
-	if a.vao == 0 {
-		gl.GenVertexArrays(1, &a.vao)
-		gl.GenBuffers(1, &a.vbo)
-		gl.GenBuffers(1, &a.ebo)
+// openModelSource opens the OBJ file of a model folder. That is baseDir/source/<folder name>.obj
+// if it exists, otherwise the first .obj in baseDir/source/, otherwise the first .obj inside a
+// .zip there (the default model ships zipped).
+func openModelSource(baseDir string) (io.ReadCloser, string, error) {
+	// Determine the main OBJ file name (e.g., "default.obj" from "default/" folder)
+	modelName := strings.TrimSuffix(filepath.Base(baseDir), string(os.PathSeparator))
+	if modelName == "" { // Handle cases like "." or "/"
+		modelName = "default" // Fallback name
 	}
+	sourceDir := filepath.Join(baseDir, "source")
 
-	gl.BindVertexArray(a.vao)
-
-	gl.BindBuffer(gl.ARRAY_BUFFER, a.vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(a.vertices)*4, gl.Ptr(a.vertices), gl.STATIC_DRAW)
-
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, a.ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(a.indices)*4, gl.Ptr(a.indices), gl.STATIC_DRAW)
-
-	// Position attribute (layout location 0, 3 floats)
-	// Vertex stride is 5*4 bytes (3 pos + 2 texcoord)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 5*4, gl.Ptr(nil))
-	gl.EnableVertexAttribArray(0)
-
-	// Texture coordinate attribute (layout location 1, 2 floats, offset after 3 positions)
-	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
-
-	gl.BindVertexArray(0) // Unbind VAO
+	objFilePath := filepath.Join(sourceDir, modelName+".obj")
+	if objFile, err := os.Open(objFilePath); err == nil {
+		return objFile, objFilePath, nil
+	}
 
-	log.Printf("Loaded %d unique vertices and %d indices from %s", currentIdx, len(a.indices), objFilePath)
-	return nil
+	entries, err := os.ReadDir(sourceDir)
+	if err != nil {
+		return nil, "", fmt.Errorf("could not read model source folder %s: %w", sourceDir, err)
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(filepath.Ext(entry.Name()), ".obj") {
+			objFilePath = filepath.Join(sourceDir, entry.Name())
+			objFile, err := os.Open(objFilePath)
+			if err != nil {
+				return nil, "", fmt.Errorf("could not open OBJ file %s: %w", objFilePath, err)
+			}
+			return objFile, objFilePath, nil
+		}
+	}
+	for _, entry := range entries {
+		if !strings.EqualFold(filepath.Ext(entry.Name()), ".zip") {
+			continue
+		}
+		zipPath := filepath.Join(sourceDir, entry.Name())
+		archive, err := zip.OpenReader(zipPath)
+		if err != nil {
+			log.Printf("Warning: Could not open %s: %v", zipPath, err)
+			continue
+		}
+		for _, file := range archive.File {
+			if strings.EqualFold(filepath.Ext(file.Name), ".obj") {
+				objFile, err := file.Open()
+				if err != nil {
+					archive.Close()
+					return nil, "", fmt.Errorf("could not open %s in %s: %w", file.Name, zipPath, err)
+				}
+				return zipEntryReader{objFile, archive}, zipPath + ":" + file.Name, nil
+			}
+		}
+		archive.Close()
+	}
+	return nil, "", fmt.Errorf("no OBJ file found in %s", sourceDir)
 }
 
-// newTexture creates an OpenGL texture from an image.
-func newTexture(img image.Image) (uint32, error) {
-	var texture uint32
-	gl.GenTextures(1, &texture)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-
-	// Set texture wrapping and filtering options
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR) // Use mipmaps
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-
-	rgba := image.NewRGBA(img.Bounds())
-	// Ensure that the image is copied into an RGBA format that OpenGL expects
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+// zipEntryReader reads one file of a zip archive and closes the archive with it.
+type zipEntryReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
 
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(rgba.Rect.Size().X), int32(rgba.Rect.Size().Y), 0,
-		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+func (z zipEntryReader) Close() error {
+	z.ReadCloser.Close()
+	return z.archive.Close()
+}
 
-	gl.BindTexture(gl.TEXTURE_2D, 0) // Unbind texture
+// newTexture creates a texture from an image and adds it to the model's textures. Color
+// maps are stored as sRGB, so the shader reads them as linear values; data maps (roughness,
+// AO...) are stored as they are. It also returns the alpha mode the image's alpha channel
+// calls for.
+func (a *AppCore) newTexture(img image.Image, srgb bool) (Texture, alphamode.Mode, error) {
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	// Ensure that the image is copied into the RGBA format the renderer expects
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
 
-	return texture, nil
+	texture, err := a.renderer.CreateTexture(rgba, srgb)
+	if err != nil {
+		return 0, alphamode.Opaque, err
+	}
+	a.textures = append(a.textures, texture)
+	return texture, alphamode.ImageMode(rgba.Pix), nil
 }
 
 // initializeWindow handles GLFW initialization and window creation.
@@ -365,9 +490,13 @@ func (a *AppCore) initializeWindow() error {
 	a.window.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
 		a.width = width
 		a.height = height
-		gl.Viewport(0, 0, int32(width), int32(height))
-		projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
-		gl.UniformMatrix4fv(a.projectionUniform, 1, false, &projection[0])
+		if a.renderer != nil {
+			if err := a.renderer.Resize(width, height); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			// Re-calculate projection matrix on resize
+			a.updateCameraPosition()
+		}
 	})
 
 	a.window.SetScrollCallback(func(_ *glfw.Window, xoff, yoff float64) {
@@ -441,61 +570,8 @@ func (a *AppCore) initializeOpenGL() error {
 	return nil
 }
 
-// setupShadersAndUniforms compiles shaders, links the program, and gets uniform locations.
-func (a *AppCore) setupShadersAndUniforms() error {
-	vertexShaderSource := `
-		#version 410 core
-		layout (location = 0) in vec3 aPos;
-		layout (location = 1) in vec2 aTexCoord;
-
-		out vec2 TexCoord;
-
-		uniform mat4 model;
-		uniform mat4 view;
-		uniform mat4 projection;
-
-		void main() {
-			gl_Position = projection * view * model * vec4(aPos, 1.0);
-			TexCoord = aTexCoord;
-		}
-	` + "\x00"
-
-	fragmentShaderSource := `
-		#version 410 core
-		in vec2 TexCoord;
-		out vec4 FragColor;
-
-		uniform sampler2D ourTexture;
-
-		void main() {
-			FragColor = texture(ourTexture, TexCoord);
-		}
-	` + "\x00"
-
-	program, err := compileShader(vertexShaderSource, fragmentShaderSource)
-	if err != nil {
-		return fmt.Errorf("failed to compile shaders: %w", err)
-	}
-	gl.UseProgram(program)
-	a.program = program
-
-	a.modelUniform = gl.GetUniformLocation(a.program, gl.Str("model\x00"))
-	a.viewUniform = gl.GetUniformLocation(a.program, gl.Str("view\x00"))
-	a.projectionUniform = gl.GetUniformLocation(a.program, gl.Str("projection\x00"))
-	a.textureUniform = gl.GetUniformLocation(a.program, gl.Str("ourTexture\x00"))
-
-	return nil
-}
-
-// setupCameraAndProjection sets up initial view and projection matrices.
-func (a *AppCore) setupCameraAndProjection() {
-	a.updateCameraPosition()
-
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
-	gl.UniformMatrix4fv(a.projectionUniform, 1, false, &projection[0])
-}
-
-// updateCameraPosition recalculates and updates the view matrix based on the current camera state.
+// updateCameraPosition recalculates the view and projection matrices from the current camera
+// state and window size, and hands them to the renderer.
 func (a *AppCore) updateCameraPosition() {
 	yawRad := mgl32.DegToRad(a.yaw)
 	pitchRad := mgl32.DegToRad(a.pitch)
@@ -506,7 +582,8 @@ func (a *AppCore) updateCameraPosition() {
 	a.cameraFront = mgl32.Vec3{frontX, frontY, frontZ}.Normalize()
 
 	view := mgl32.LookAtV(a.cameraPos, a.cameraPos.Add(a.cameraFront), a.cameraUp)
-	gl.UniformMatrix4fv(a.viewUniform, 1, false, &view[0])
+	projection := mgl32.Perspective(mgl32.DegToRad(fieldOfView), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
+	a.renderer.SetCamera(view, projection, a.cameraPos)
 }
 
 // processInput handles keyboard/mouse input.
@@ -547,17 +624,17 @@ func (a *AppCore) processInput() {
 	// G key to load custom model from folder
 	currentGState := a.window.GetKey(glfw.KeyG)
 	if currentGState == glfw.Press && !a.gKeyWasPressed {
-		log.Print("Enter path to model's base directory (e.g., my_model_folder/): ")
+		log.Print("Enter path to model's base directory or a .gltf/.glb file (e.g., my_model_folder/): ")
 		reader := bufio.NewReader(os.Stdin)
 		inputPath, _ := reader.ReadString('\n')
 		inputPath = strings.TrimSpace(inputPath)
 
 		if inputPath != "" {
-			// Ensure inputPath ends with a slash for consistent directory handling
-			if !strings.HasSuffix(inputPath, string(os.PathSeparator)) {
+			// Ensure folder paths end with a slash for consistent directory handling
+			if !isGLTFPath(inputPath) && !strings.HasSuffix(inputPath, string(os.PathSeparator)) {
 				inputPath += string(os.PathSeparator)
 			}
-			log.Printf("Attempting to load custom model from directory: %s", inputPath)
+			log.Printf("Attempting to load custom model from: %s", inputPath)
 			if err := a.loadAndSetupModel(inputPath); err != nil {
 				log.Printf("Error loading custom model from %s: %v", inputPath, err)
 			} else {
@@ -572,6 +649,14 @@ func (a *AppCore) processInput() {
 	}
 	a.gKeyWasPressed = (currentGState == glfw.Press)
 
+	// Keys 1-5 toggle the post-processing effects
+	if r, ok := a.renderer.(*GLRenderer); ok {
+		r.post.HandleKeys(a.window)
+	}
+
+	// M cycles the view modes, F toggles the wireframe
+	a.viewKeys.Handle(a.window, &a.viewMode, &a.wireframe)
+
 	// WASD camera movement
 	cameraMoveSpeed := cameraSpeed * float32(time.Since(app.lastFrameTime).Seconds())
 	if a.window.GetKey(glfw.KeyW) == glfw.Press {
@@ -600,30 +685,53 @@ func (a *AppCore) updateScene(deltaTime float32) {
 	}
 }
 
-// renderScene clears buffers, draws the model, and swaps buffers.
+// renderScene draws the frame and swaps buffers.
 func (a *AppCore) renderScene() {
-	gl.ClearColor(0.2, 0.3, 0.3, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	a.drawFrame()
+	a.window.SwapBuffers()
+}
 
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, a.textureID)
-	gl.Uniform1i(a.textureUniform, 0)
+// drawFrame draws the model with its current rotation, in the current view mode.
+func (a *AppCore) drawFrame() {
+	// The view mode goes first, the clear color depends on it
+	near, far := a.depthRange()
+	a.renderer.SetViewMode(a.viewMode, near, far)
+	a.renderer.BeginFrame(clearColor)
 
 	model := mgl32.Ident4()
 	model = model.Mul4(mgl32.HomogRotate3DY(a.totalRotationY))
 	model = model.Mul4(mgl32.HomogRotate3DX(a.totalRotationX))
 
 	a.drawModel(model)
-	a.window.SwapBuffers()
+	if a.wireframe {
+		a.renderer.DrawWireframe(a.mesh, model)
+	}
+	a.renderer.EndFrame()
 }
 
-// drawModel draws the loaded 3D model with the given model matrix.
+// drawModel draws the loaded 3D model with the given model matrix, one draw per submesh
+// with that submesh's material. Blended submeshes come last, from the farthest to the
+// nearest, each blended over what is already drawn.
 func (a *AppCore) drawModel(modelMatrix mgl32.Mat4) {
-	gl.UniformMatrix4fv(a.modelUniform, 1, false, &modelMatrix[0])
+	a.blendedOrder = a.blendedOrder[:0]
+	for i := range a.submeshes {
+		submesh := &a.submeshes[i]
+		if submesh.AlphaMode == alphamode.Blend {
+			a.blendedOrder = append(a.blendedOrder, i)
+			continue
+		}
+		a.renderer.Draw(a.mesh, modelMatrix, submesh)
+	}
 
-	gl.BindVertexArray(a.vao)
-	gl.DrawElements(gl.TRIANGLES, a.indicesCount, gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
-	gl.BindVertexArray(0)
+	if len(a.blendedOrder) > 0 {
+		centers := make([]mgl32.Vec3, len(a.blendedOrder))
+		for k, i := range a.blendedOrder {
+			centers[k] = mgl32.TransformCoordinate(a.submeshes[i].Center, modelMatrix)
+		}
+		for _, k := range alphamode.BackToFront(centers, a.cameraPos) {
+			a.renderer.Draw(a.mesh, modelMatrix, &a.submeshes[a.blendedOrder[k]])
+		}
+	}
 }
 
 // updateAndDisplayFPS calculates and displays FPS in the window title.
@@ -637,17 +745,14 @@ func (a *AppCore) updateAndDisplayFPS() {
 	}
 }
 
-// shutdownApp cleans up core OpenGL and GLFW resources.
+// shutdownApp cleans up the renderer and GLFW resources.
 func shutdownApp() {
 	if app == nil {
 		return
 	}
-	gl.DeleteVertexArrays(1, &app.vao)
-	gl.DeleteBuffers(1, &app.vbo)
-	gl.DeleteBuffers(1, &app.ebo)
-	gl.DeleteProgram(app.program)
-	if app.textureID != 0 {
-		gl.DeleteTextures(1, &app.textureID)
+	if app.renderer != nil {
+		app.releaseModel()
+		app.renderer.Release()
 	}
 
 	if app.window != nil {
@@ -656,69 +761,6 @@ func shutdownApp() {
 	glfw.Terminate()
 }
 
-// --- Helper functions for shader compilation (unchanged) ---
-
-func compileShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
-	glShaderSource(vertexShader, vertexShaderSource)
-	gl.CompileShader(vertexShader)
-	if err := checkShaderCompileStatus(vertexShader, "vertex"); err != nil {
-		return 0, err
-	}
-
-	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
-	glShaderSource(fragmentShader, fragmentShaderSource)
-	gl.CompileShader(fragmentShader)
-	if err := checkShaderCompileStatus(fragmentShader, "fragment"); err != nil {
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-	if err := checkProgramLinkStatus(program); err != nil {
-		return 0, err
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
-func glShaderSource(shader uint32, source string) {
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-}
-
-func checkShaderCompileStatus(shader uint32, shaderType string) error {
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		return fmt.Errorf("failed to compile %s shader:\n%v", shaderType, log)
-	}
-	return nil
-}
-
-func checkProgramLinkStatus(program uint32) error {
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-		return fmt.Errorf("failed to link program:\n%v", log)
-	}
-	return nil
-}
-
 func (a *AppCore) shouldClose() bool {
 	return a.window.ShouldClose() || !a.running
 }
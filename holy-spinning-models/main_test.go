@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/golden"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// Golden frames are a fifth of the window's size, which keeps its aspect ratio
+const (
+	testWidth  = screenWidth / 5
+	testHeight = screenHeight / 5
+)
+
+// newTestApp sets up the app as initApp does, but drawn by a SoftwareRenderer instead of
+// into a window, and loads a model from baseDir. The camera is pulled back to fit the whole
+// model in the frame, whatever its size.
+func newTestApp(t *testing.T, baseDir string) *AppCore {
+	t.Helper()
+	renderer, err := newSoftwareRenderer(testWidth, testHeight)
+	if err != nil {
+		t.Fatalf("renderer setup failed: %v", err)
+	}
+	a := &AppCore{
+		width:           testWidth,
+		height:          testHeight,
+		renderer:        renderer,
+		cameraUp:        mgl32.Vec3{0, 1, 0},
+		yaw:             -90.0,
+		rotationEnabled: true,
+	}
+	a.updateCameraPosition()
+	if err := a.loadAndSetupModel(baseDir); err != nil {
+		t.Fatalf("failed to load model from %s: %v", baseDir, err)
+	}
+	a.cameraPos = mgl32.Vec3{0, 0, a.modelRadius * 2.2}
+	a.updateCameraPosition()
+	return a
+}
+
+// TestGoldenFrames renders the bundled models at fixed times after the start, lit and in
+// the view modes that show the normals and texture coordinates.
+func TestGoldenFrames(t *testing.T) {
+	frames := []struct {
+		name     string
+		model    string
+		seconds  float32
+		viewMode viewmode.Mode
+	}{
+		{"default_lit", defaultModelBaseDir, 0, viewmode.Lit},
+		{"default_lit", defaultModelBaseDir, 1, viewmode.Lit},
+		{"default_normals", defaultModelBaseDir, 1, viewmode.Normals},
+		{"pizza_lit", "plain-pizza-slice/", 0.5, viewmode.Lit},
+		{"pizza_uv", "plain-pizza-slice/", 0.5, viewmode.UVChecker},
+	}
+	for _, frame := range frames {
+		name := fmt.Sprintf("%s_%.1fs", frame.name, frame.seconds)
+		t.Run(name, func(t *testing.T) {
+			a := newTestApp(t, frame.model)
+			a.viewMode = frame.viewMode
+			a.updateScene(frame.seconds)
+			a.drawFrame()
+			golden.Check(t, name, a.renderer.ReadPixels())
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/objfile"
+)
+
+// Physically based materials: every submesh binds one texture per materialMap, and the
+// ambient light comes from a sky environment map (image-based lighting).
+
+// materialMap is one of the textures a material can have. Its value is also the texture
+// unit the map is bound to.
+type materialMap int
+
+const (
+	mapAlbedo     materialMap = iota // Base color, sRGB
+	mapRoughness                     // Roughness in the green channel (glTF packs metallic in blue)
+	mapMetallic                      // Metallic in the blue channel, grayscale maps work too
+	mapSpecular                      // Dielectric reflectance level in red, 0.5 is the usual 4%
+	mapAO                            // Ambient occlusion in red
+	mapScattering                    // Subsurface scattering color, sRGB, black for none
+	mapNormal                        // Tangent-space normals, flat for none
+	materialMapCount
+)
+
+// Texture units after the material maps
+const (
+	environmentUnit = int(materialMapCount) + iota // Prefiltered sky for reflections
+	irradianceUnit                                 // Sky convolved for diffuse ambient light
+)
+
+// Material and environment constants
+const (
+	DefaultRoughness = 0.6 // For materials that give neither a roughness nor a shininess
+	environmentSize  = 128 // Texels per side of each environment cube face
+	irradianceSize   = 16  // Texels per side of each irradiance cube face
+	irradianceSource = 32  // Environment resolution the irradiance is convolved from
+)
+
+// materialMapNames are the uniform names of the maps, in materialMap order.
+var materialMapNames = [materialMapCount]string{"albedoMap", "roughnessMap", "metallicMap", "specularMap", "aoMap", "scatteringMap", "normalMap"}
+
+// materialMapSuffixes are the file name endings looked for when an MTL file doesn't name a
+// map, as in fd_pizza4Cheese_rough.jpeg for material fd_pizza4Cheese.
+var materialMapSuffixes = [materialMapCount][]string{
+	{"albedo", "basecolor", "base_color", "diffuse", "color"},
+	{"rough", "roughness"},
+	{"metal", "metallic", "metalness"},
+	{"specular", "spec"},
+	{"ao", "occlusion", "ambientocclusion"},
+	{"scattering", "sss", "subsurface"},
+	{"normal", "norm", "nrm", "normalgl"},
+}
+
+// materialMapMtlKinds are the MTL maps the material maps are read from. MTL has no
+// scattering map, that one is only found by file name.
+var materialMapMtlKinds = map[materialMap]objfile.MapKind{
+	mapAlbedo:    objfile.MapDiffuse,
+	mapRoughness: objfile.MapRoughness,
+	mapMetallic:  objfile.MapMetallic,
+	mapSpecular:  objfile.MapSpecular,
+	mapAO:        objfile.MapAO,
+	mapNormal:    objfile.MapNormal,
+}
+
+// materialMapDefaults are the 1x1 textures bound for missing maps. They leave the factors
+// alone, so a material without maps is just its colors and numbers.
+var materialMapDefaults = [materialMapCount][4]uint8{
+	{255, 255, 255, 255}, // Albedo: white, times the material color
+	{255, 255, 255, 255}, // Roughness: 1, times the material roughness
+	{255, 255, 255, 255}, // Metallic: 1, times the material metallic
+	{128, 128, 128, 255}, // Specular: 0.5, the 4% reflectance of most dielectrics
+	{255, 255, 255, 255}, // AO: unoccluded
+	{0, 0, 0, 255},       // Scattering: none
+	{128, 128, 255, 255}, // Normal: straight out of the surface
+}
+
+// materialMapDefaultImage returns the 1x1 image of a map's default.
+func materialMapDefaultImage(kind materialMap) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	copy(img.Pix, materialMapDefaults[kind][:])
+	return img
+}
+
+// materialMapIsColor tells which maps hold colors (stored as sRGB) rather than data.
+func materialMapIsColor(kind materialMap) bool {
+	return kind == mapAlbedo || kind == mapScattering
+}
+
+// loadMaterialMaps loads every map of an MTL material into submesh, from its map_* statements
+// or, for maps it doesn't name, from files following the <material>_<suffix> convention.
+// Maps are looked for in baseDir/textures/ first, then next to the MTL file.
+func (a *AppCore) loadMaterialMaps(baseDir string, mtl *objfile.Material, submesh *Submesh, cache map[string]Texture) {
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		texturePath := ""
+		name := ""
+		if mtlKind, ok := materialMapMtlKinds[kind]; ok {
+			name = mtl.Maps[mtlKind]
+		}
+		if name != "" {
+			var err error
+			if texturePath, err = findMaterialFile(baseDir, mtl, name); err != nil {
+				log.Printf("Warning: Could not find texture %s for material %s", name, mtl.Name)
+			}
+		}
+		if texturePath == "" {
+			texturePath = findConventionalMap(baseDir, mtl, kind)
+		}
+		if texturePath != "" {
+			submesh.Maps[kind] = a.loadMaterialTexture(texturePath, mtl.Name, materialMapIsColor(kind), cache)
+		}
+	}
+}
+
+// findMaterialFile finds a texture named in an MTL file.
+func findMaterialFile(baseDir string, mtl *objfile.Material, name string) (string, error) {
+	texturePath, err := objfile.FindFile(filepath.Join(baseDir, "textures"), name)
+	if err != nil {
+		texturePath, err = objfile.FindFile(mtl.Dir, name)
+	}
+	return texturePath, err
+}
+
+// findConventionalMap returns the texture named after the material and one of the map's
+// suffixes, or "" if there is none.
+func findConventionalMap(baseDir string, mtl *objfile.Material, kind materialMap) string {
+	for _, dir := range []string{filepath.Join(baseDir, "textures"), mtl.Dir} {
+		for _, suffix := range materialMapSuffixes[kind] {
+			for _, ext := range []string{".png", ".jpg", ".jpeg"} {
+				if texturePath, err := objfile.FindFile(dir, mtl.Name+"_"+suffix+ext); err == nil {
+					return texturePath
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// loadMaterialTexture loads a texture file, or returns the texture already loaded for the
+// same file. It returns 0 if the file can't be loaded, so the map's default is used.
+func (a *AppCore) loadMaterialTexture(texturePath, materialName string, srgb bool, cache map[string]Texture) Texture {
+	if texture, ok := cache[texturePath]; ok {
+		return texture
+	}
+
+	imgFile, err := os.Open(texturePath)
+	if err != nil {
+		log.Printf("Warning: Could not open texture file %s for material %s: %v", texturePath, materialName, err)
+		return 0
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		log.Printf("Warning: Failed to decode texture image %s for material %s: %v", texturePath, materialName, err)
+		return 0
+	}
+
+	texture, alphaMode, err := a.newTexture(img, srgb)
+	if err != nil {
+		log.Printf("Warning: Failed to create texture from %s: %v", texturePath, err)
+		return 0
+	}
+	log.Printf("Texture '%s' loaded successfully.", texturePath)
+	cache[texturePath] = texture
+	a.textureAlphaModes[texture] = alphaMode
+	return texture
+}
+
+// roughnessFromShininess converts a Phong exponent (MTL Ns) to a GGX roughness.
+func roughnessFromShininess(ns float32) float32 {
+	return float32(math.Sqrt(2.0 / (float64(ns) + 2.0)))
+}
+
+// --- Environment lighting ---
+
+// skyRadiance is the light arriving from direction d (pointing away from the viewer) in
+// the procedural sky: a blue gradient above the horizon, a dim ground below and a glow
+// around the sun.
+func skyRadiance(d mgl32.Vec3) mgl32.Vec3 {
+	zenith := mgl32.Vec3{0.25, 0.45, 0.85}
+	horizon := mgl32.Vec3{0.75, 0.8, 0.85}
+	ground := mgl32.Vec3{0.25, 0.22, 0.2}
+
+	var color mgl32.Vec3
+	if d.Y() >= 0 {
+		t := float32(math.Sqrt(float64(d.Y())))
+		color = horizon.Mul(1 - t).Add(zenith.Mul(t))
+	} else {
+		t := float32(math.Min(1, float64(-d.Y())*4)) // Quick fade from the horizon into the ground
+		color = horizon.Mul(1 - t).Add(ground.Mul(t))
+	}
+	sunAmount := math.Max(0, float64(d.Dot(sunDirection.Mul(-1))))
+	return color.Add(mgl32.Vec3{1, 0.9, 0.7}.Mul(float32(math.Pow(sunAmount, 64)) * 4))
+}
+
+// cubeFaceDirection returns the direction through texel (x, y) of a cube map face, using
+// OpenGL's face orientations (faces in TEXTURE_CUBE_MAP_POSITIVE_X order).
+func cubeFaceDirection(face, x, y, size int) mgl32.Vec3 {
+	s := 2*(float32(x)+0.5)/float32(size) - 1
+	t := 2*(float32(y)+0.5)/float32(size) - 1
+	var d mgl32.Vec3
+	switch face {
+	case 0:
+		d = mgl32.Vec3{1, -t, -s}
+	case 1:
+		d = mgl32.Vec3{-1, -t, s}
+	case 2:
+		d = mgl32.Vec3{s, 1, t}
+	case 3:
+		d = mgl32.Vec3{s, -1, -t}
+	case 4:
+		d = mgl32.Vec3{s, -t, 1}
+	default:
+		d = mgl32.Vec3{-s, -t, -1}
+	}
+	return d.Normalize()
+}
+
+// skyFaces renders the procedural sky into six cube faces.
+func skyFaces(size int) [6][]float32 {
+	var faces [6][]float32
+	for face := range faces {
+		data := make([]float32, 0, size*size*3)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				c := skyRadiance(cubeFaceDirection(face, x, y, size))
+				data = append(data, c.X(), c.Y(), c.Z())
+			}
+		}
+		faces[face] = data
+	}
+	return faces
+}
+
+// irradianceFaces convolves the sky with a cosine lobe: each texel is the diffuse light a
+// surface facing its direction receives from the whole sky.
+func irradianceFaces(size, sourceSize int) [6][]float32 {
+	type sample struct {
+		direction mgl32.Vec3
+		radiance  mgl32.Vec3 // Already weighted by the texel's solid angle
+	}
+	var samples []sample
+	for face := 0; face < 6; face++ {
+		for y := 0; y < sourceSize; y++ {
+			for x := 0; x < sourceSize; x++ {
+				s := 2*(float32(x)+0.5)/float32(sourceSize) - 1
+				t := 2*(float32(y)+0.5)/float32(sourceSize) - 1
+				// Texels near a face's corners cover less of the sphere
+				solidAngle := float32(math.Pow(float64(1+s*s+t*t), -1.5)) * 4 / float32(sourceSize*sourceSize)
+				d := cubeFaceDirection(face, x, y, sourceSize)
+				samples = append(samples, sample{d, skyRadiance(d).Mul(solidAngle)})
+			}
+		}
+	}
+
+	var faces [6][]float32
+	for face := range faces {
+		data := make([]float32, 0, size*size*3)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				normal := cubeFaceDirection(face, x, y, size)
+				var sum mgl32.Vec3
+				for _, smp := range samples {
+					if cosine := normal.Dot(smp.direction); cosine > 0 {
+						sum = sum.Add(smp.radiance.Mul(cosine))
+					}
+				}
+				sum = sum.Mul(1 / math.Pi) // Lambert, so a white sky gives an irradiance of 1
+				data = append(data, sum.X(), sum.Y(), sum.Z())
+			}
+		}
+		faces[face] = data
+	}
+	return faces
+}
+
+// describeMaps lists the maps a submesh has, for the load log.
+func describeMaps(submesh *Submesh) string {
+	names := ""
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		if submesh.Maps[kind] != 0 {
+			if names != "" {
+				names += ", "
+			}
+			names += materialMapNames[kind]
+		}
+	}
+	if names == "" {
+		return "no maps"
+	}
+	return fmt.Sprintf("maps: %s", names)
+}
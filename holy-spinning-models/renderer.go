@@ -0,0 +1,60 @@
+package main
+
+import (
+	"image"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// Rendering backends: AppCore draws the model through a Renderer, never through OpenGL
+// directly. GLRenderer draws with OpenGL into the window (renderer_gl.go), SoftwareRenderer
+// rasterizes on the CPU into an image (renderer_soft.go), so frames can be rendered in tests
+// and on machines without a GPU.
+
+// Mesh identifies a mesh created by a Renderer. 0 is no mesh.
+type Mesh uint32
+
+// Texture identifies a texture created by a Renderer. 0 is no texture, a Submesh map that
+// is 0 is drawn with the map's default from materialMapDefaults.
+type Texture uint32
+
+// Renderer is what the model is drawn with. A frame is BeginFrame, any number of Draws and
+// DrawWireframes and EndFrame, after which ReadPixels returns it.
+type Renderer interface {
+	// CreateMesh uploads interleaved vertices (vertexFloats each) and triangle indices.
+	CreateMesh(vertices []float32, indices []uint32) (Mesh, error)
+	DeleteMesh(mesh Mesh)
+
+	// CreateTexture uploads an image, top row first. Color maps are sRGB and read back as
+	// linear values; data maps (roughness, AO...) are used as they are.
+	CreateTexture(img *image.RGBA, srgb bool) (Texture, error)
+	DeleteTexture(texture Texture)
+
+	// SetCamera sets the view and projection matrices and the camera's world position,
+	// which the reflections and highlights depend on.
+	SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3)
+
+	// SetViewMode sets what the next draws show, see holy-shared/viewmode. depthNear and depthFar
+	// are the distances that are white and black in the depth view.
+	SetViewMode(mode viewmode.Mode, depthNear, depthFar float32)
+
+	BeginFrame(clearColor mgl32.Vec3)
+
+	// Draw draws a submesh's range of a mesh's indices with its material. Blended submeshes
+	// are blended over what is drawn and don't write depth, so the caller draws them last,
+	// back to front.
+	Draw(mesh Mesh, model mgl32.Mat4, submesh *Submesh)
+
+	// DrawWireframe draws the edges of all of a mesh's triangles over what is drawn.
+	DrawWireframe(mesh Mesh, model mgl32.Mat4)
+
+	EndFrame()
+
+	// ReadPixels returns the last frame, top row first.
+	ReadPixels() *image.RGBA
+
+	Resize(width, height int) error
+	Release()
+}
@@ -0,0 +1,669 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/go-gl/gl/v4.6-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/post"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// GLRenderer draws with OpenGL on the current context, through the post-processing chain.
+type GLRenderer struct {
+	program     uint32
+	meshes      map[Mesh]glMesh
+	textures    map[Texture]uint32 // OpenGL texture of each Texture
+	lastMesh    Mesh               // Last Mesh handed out
+	lastTexture Texture            // Last Texture handed out
+	viewMode    viewmode.Mode      // For EndFrame, set by SetViewMode
+
+	// Physically based materials (see pbr.go)
+	defaultMaps    [materialMapCount]uint32 // 1x1 textures bound for maps a material lacks
+	environmentMap uint32                   // Sky cube map, mipmapped for rough reflections
+	irradianceMap  uint32                   // Sky convolved for diffuse ambient light
+
+	// Uniform locations
+	modelUniform        int32
+	viewUniform         int32
+	projectionUniform   int32
+	diffuseColorUniform int32
+	roughnessUniform    int32
+	metallicUniform     int32
+	alphaUniforms       alphamode.Uniforms
+	normalMatrixUniform int32
+	viewPosUniform      int32
+	viewModeUniform     int32 // See holy-shared/viewmode
+	wireframeUniform    int32
+	depthRangeUniform   int32
+	falseColorUniform   int32
+
+	// Offscreen HDR target and post-processing passes, see holy-shared/post
+	post *post.Chain
+
+	width, height int
+}
+
+// glMesh is the vertex array and buffers of a Mesh.
+type glMesh struct {
+	vao, vbo, ebo uint32
+	indicesCount  int32
+}
+
+// newGLRenderer compiles the shaders, builds the environment maps and sets up the
+// post-processing chain. The OpenGL context must be current and initialized.
+func newGLRenderer(width, height int) (*GLRenderer, error) {
+	r := &GLRenderer{meshes: make(map[Mesh]glMesh), textures: make(map[Texture]uint32), width: width, height: height}
+	if err := r.setupShadersAndUniforms(); err != nil {
+		return nil, fmt.Errorf("shader setup failed: %w", err)
+	}
+
+	// The scene renders into the post-processing chain's HDR target. Lighting is done in
+	// linear space, so the chain tonemaps it and encodes it for the screen.
+	chain, err := post.NewChain(width, height, post.DefaultEffects...)
+	if err != nil {
+		r.releaseEnvironment()
+		gl.DeleteProgram(r.program)
+		return nil, fmt.Errorf("post-processing setup failed: %w", err)
+	}
+	r.post = chain
+	return r, nil
+}
+
+// setupShadersAndUniforms compiles shaders, links the program, and gets uniform locations.
+func (r *GLRenderer) setupShadersAndUniforms() error {
+	vertexShaderSource := `
+		#version 410 core
+		layout (location = 0) in vec3 aPos;
+		layout (location = 1) in vec2 aTexCoord;
+		layout (location = 2) in vec3 aNormal;
+		layout (location = 3) in vec4 aTangent; // Bitangent sign in w
+
+		out vec2 TexCoord;
+		out vec3 FragPos; // World space
+		out vec3 Normal;  // World space
+		out vec4 Tangent; // World space, w passed on
+
+		uniform mat4 model;
+		uniform mat4 view;
+		uniform mat4 projection;
+		uniform mat3 normalMatrix; // Inverse transpose of the model matrix
+
+		void main() {
+			vec4 worldPos = model * vec4(aPos, 1.0);
+			gl_Position = projection * view * worldPos;
+			TexCoord = aTexCoord;
+			FragPos = worldPos.xyz;
+			Normal = normalMatrix * aNormal;
+			Tangent = vec4(mat3(model) * aTangent.xyz, aTangent.w); // Tangents follow the surface, so the model matrix itself
+		}
+	` + "\x00"
+
+	// Physically based fragment shader: Cook-Torrance (GGX) with the metallic/roughness
+	// workflow, lit by the sun, the point lights and the sky environment maps.
+	// SoftwareRenderer.shade does the same on the CPU.
+	fragmentShaderSource := `
+		#version 410 core
+		#define MAX_POINT_LIGHTS 8
+
+		in vec2 TexCoord;
+		in vec3 FragPos;
+		in vec3 Normal;
+		in vec4 Tangent;
+		out vec4 FragColor;
+
+		// Material maps, the defaults bound for missing maps leave the factors unchanged
+		uniform sampler2D albedoMap;     // sRGB color, alpha is opacity
+		uniform sampler2D roughnessMap;  // Green channel, like glTF's metallicRoughness texture
+		uniform sampler2D metallicMap;   // Blue channel
+		uniform sampler2D specularMap;   // Red channel, 0.5 is 4% reflectance
+		uniform sampler2D aoMap;         // Red channel
+		uniform sampler2D scatteringMap; // sRGB color of light passing through thin parts
+		uniform sampler2D normalMap;     // Tangent space, OpenGL convention (green is the top of the image)
+		uniform vec4 diffuseColor;       // Albedo factor
+		uniform float roughness;
+		uniform float metallic;
+	` + alphamode.ShaderUniforms + `
+
+		uniform vec3 viewPos;
+		uniform vec3 sunDirection; // Direction the sunlight travels in
+		uniform vec3 sunColor;     // Already multiplied by the intensity
+		uniform vec3 ambientColor; // Scales the environment light
+		uniform int pointLightCount;
+		uniform vec3 pointLightPositions[MAX_POINT_LIGHTS];
+		uniform vec3 pointLightColors[MAX_POINT_LIGHTS]; // Already multiplied by the intensity
+		uniform float pointLightRanges[MAX_POINT_LIGHTS];
+
+		uniform samplerCube environmentMap; // Sky, mip levels get blurrier
+		uniform samplerCube irradianceMap;  // Diffuse light from the sky per normal direction
+		uniform float environmentMaxLod;
+
+	` + viewmode.ShaderUniforms + `
+		uniform vec3 falseColor;
+
+		const float PI = 3.14159265;
+		const float scatterWrap = 0.5; // How far scattered light wraps past the shadow line
+
+		float distributionGGX(float NdotH, float alpha) {
+			float a2 = alpha * alpha;
+			float d = NdotH * NdotH * (a2 - 1.0) + 1.0;
+			return a2 / (PI * d * d);
+		}
+
+		// Smith's shadowing-masking with Schlick-GGX, k as for direct lights
+		float geometrySmith(float NdotV, float NdotL, float rough) {
+			float k = (rough + 1.0) * (rough + 1.0) / 8.0;
+			return NdotV / (NdotV * (1.0 - k) + k) * NdotL / (NdotL * (1.0 - k) + k);
+		}
+
+		vec3 fresnelSchlick(float cosTheta, vec3 F0) {
+			return F0 + (1.0 - F0) * pow(1.0 - cosTheta, 5.0);
+		}
+
+		// envBRDFApprox is Karis's fit of the split-sum BRDF, instead of a lookup texture.
+		vec3 envBRDFApprox(vec3 F0, float rough, float NdotV) {
+			vec4 r = rough * vec4(-1.0, -0.0275, -0.572, 0.022) + vec4(1.0, 0.0425, 1.04, -0.04);
+			float a004 = min(r.x * r.x, exp2(-9.28 * NdotV)) * r.x + r.y;
+			vec2 AB = vec2(-1.04, 1.04) * a004 + r.zw;
+			return F0 * AB.x + AB.y;
+		}
+
+		// shade returns the light reflected towards the viewer from one light.
+		// lightDir points from the surface towards the light. Light colors are what a white
+		// surface facing the light shows, so the diffuse term has no 1/PI.
+		vec3 shade(vec3 normal, vec3 lightDir, vec3 viewDir, vec3 lightColor,
+		           vec3 albedo, vec3 F0, float rough, float metal, vec3 scattering) {
+			float NdotL = max(dot(normal, lightDir), 0.0);
+			// Scattered light reaches a bit past where direct light stops
+			vec3 scattered = scattering * max((dot(normal, lightDir) + scatterWrap) / (1.0 + scatterWrap), 0.0);
+			if (NdotL <= 0.0) {
+				return lightColor * scattered;
+			}
+			vec3 halfway = normalize(lightDir + viewDir);
+			float NdotV = max(dot(normal, viewDir), 0.0001);
+			float NdotH = max(dot(normal, halfway), 0.0);
+			vec3 F = fresnelSchlick(max(dot(halfway, viewDir), 0.0), F0);
+			float D = distributionGGX(NdotH, rough * rough);
+			float G = geometrySmith(NdotV, NdotL, rough);
+			vec3 specular = D * G * F / (4.0 * NdotV * NdotL);
+			vec3 kD = (1.0 - F) * (1.0 - metal); // Metals have no diffuse light
+			return lightColor * (NdotL * (kD * albedo + PI * specular) + scattered);
+		}
+
+		// surfaceNormal bends the interpolated normal by the normal map. The default map is
+		// flat, so models without one keep their vertex normals.
+		vec3 surfaceNormal() {
+			vec3 normal = normalize(Normal);
+			vec3 tangent = normalize(Tangent.xyz - normal * dot(normal, Tangent.xyz)); // Interpolation skews it
+			vec3 bitangent = Tangent.w * cross(normal, tangent);
+			vec3 mapped = texture(normalMap, TexCoord).xyz * 2.0 - 1.0;
+			return normalize(mat3(tangent, bitangent, normal) * mapped);
+		}
+
+	` + alphamode.ShaderFunctions + `
+	` + viewmode.ShaderFunctions + `
+
+		void main() {
+			if (wireframe) {
+				FragColor = vec4(wireframeColor, 1.0);
+				return;
+			}
+			vec4 albedo = texture(albedoMap, TexCoord) * diffuseColor;
+			albedo.a = applyAlphaMode(albedo.a);
+			if (viewMode != 0) {
+				FragColor = vec4(viewModeColor(albedo.rgb, falseColor), albedo.a);
+				return;
+			}
+			float rough = clamp(roughness * texture(roughnessMap, TexCoord).g, 0.04, 1.0); // Perfect mirrors alias
+			float metal = clamp(metallic * texture(metallicMap, TexCoord).b, 0.0, 1.0);
+			float specularLevel = texture(specularMap, TexCoord).r;
+			float ao = texture(aoMap, TexCoord).r;
+			vec3 scattering = texture(scatteringMap, TexCoord).rgb;
+
+			// Dielectrics reflect 0-8% depending on the specular level, metals their albedo
+			vec3 F0 = mix(vec3(0.08 * specularLevel), albedo.rgb, metal);
+
+			vec3 normal = surfaceNormal();
+			vec3 viewDir = normalize(viewPos - FragPos);
+			float NdotV = max(dot(normal, viewDir), 0.0001);
+
+			// Image-based ambient light: irradiance for the diffuse part, the environment
+			// blurred by roughness for the specular part
+			vec3 irradiance = texture(irradianceMap, normal).rgb;
+			vec3 reflected = textureLod(environmentMap, reflect(-viewDir, normal), rough * environmentMaxLod).rgb;
+			vec3 specularAmbient = reflected * envBRDFApprox(F0, rough, NdotV);
+			vec3 diffuseAmbient = irradiance * (albedo.rgb * (1.0 - metal) + scattering);
+			vec3 color = ambientColor * ao * (diffuseAmbient + specularAmbient);
+
+			color += shade(normal, -sunDirection, viewDir, sunColor, albedo.rgb, F0, rough, metal, scattering);
+			for (int i = 0; i < pointLightCount; i++) {
+				vec3 toLight = pointLightPositions[i] - FragPos;
+				float distance = length(toLight);
+				// Fades smoothly to nothing at the light's range
+				float attenuation = clamp(1.0 - distance / pointLightRanges[i], 0.0, 1.0);
+				attenuation *= attenuation;
+				color += attenuation * shade(normal, toLight / max(distance, 0.0001), viewDir, pointLightColors[i],
+				                             albedo.rgb, F0, rough, metal, scattering);
+			}
+			// Linear and unclamped, the post-processing chain tonemaps it and applies gamma
+			FragColor = vec4(color, albedo.a);
+		}
+	` + "\x00"
+
+	program, err := compileShader(vertexShaderSource, fragmentShaderSource)
+	if err != nil {
+		return fmt.Errorf("failed to compile shaders: %w", err)
+	}
+	gl.UseProgram(program)
+	r.program = program
+
+	r.modelUniform = gl.GetUniformLocation(r.program, gl.Str("model\x00"))
+	r.viewUniform = gl.GetUniformLocation(r.program, gl.Str("view\x00"))
+	r.projectionUniform = gl.GetUniformLocation(r.program, gl.Str("projection\x00"))
+	r.diffuseColorUniform = gl.GetUniformLocation(r.program, gl.Str("diffuseColor\x00"))
+	r.roughnessUniform = gl.GetUniformLocation(r.program, gl.Str("roughness\x00"))
+	r.metallicUniform = gl.GetUniformLocation(r.program, gl.Str("metallic\x00"))
+	r.alphaUniforms = alphamode.Locate(r.program)
+	r.normalMatrixUniform = gl.GetUniformLocation(r.program, gl.Str("normalMatrix\x00"))
+	r.viewPosUniform = gl.GetUniformLocation(r.program, gl.Str("viewPos\x00"))
+	r.viewModeUniform = gl.GetUniformLocation(r.program, gl.Str("viewMode\x00"))
+	r.wireframeUniform = gl.GetUniformLocation(r.program, gl.Str("wireframe\x00"))
+	r.depthRangeUniform = gl.GetUniformLocation(r.program, gl.Str("depthRange\x00"))
+	r.falseColorUniform = gl.GetUniformLocation(r.program, gl.Str("falseColor\x00"))
+	r.applyLighting()
+	r.setupMaterialDefaults()
+	r.setupEnvironment()
+
+	return nil
+}
+
+// applyLighting uploads the sun, the ambient light and the point lights. They never
+// change, so this runs once after the program is linked.
+func (r *GLRenderer) applyLighting() {
+	sunColor := mgl32.Vec3{sunIntensity, sunIntensity, sunIntensity}
+	ambientColor := mgl32.Vec3{ambient, ambient, ambient}
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("sunDirection\x00")), 1, &sunDirection[0])
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("sunColor\x00")), 1, &sunColor[0])
+	gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str("ambientColor\x00")), 1, &ambientColor[0])
+
+	gl.Uniform1i(gl.GetUniformLocation(r.program, gl.Str("pointLightCount\x00")), int32(len(pointLights)))
+	for i, light := range pointLights {
+		// Array elements each have their own location
+		gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightPositions[%d]\x00", i))), 1, &light.Position[0])
+		gl.Uniform3fv(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightColors[%d]\x00", i))), 1, &light.Color[0])
+		gl.Uniform1f(gl.GetUniformLocation(r.program, gl.Str(fmt.Sprintf("pointLightRanges[%d]\x00", i))), light.Range)
+	}
+}
+
+// setupMaterialDefaults creates the 1x1 fallback texture of every material map.
+func (r *GLRenderer) setupMaterialDefaults() {
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		r.defaultMaps[kind] = newGLTexture(materialMapDefaultImage(kind), materialMapIsColor(kind))
+	}
+}
+
+// setupEnvironment builds the sky's environment and irradiance cube maps and tells the
+// shader where they are.
+func (r *GLRenderer) setupEnvironment() {
+	gl.Enable(gl.TEXTURE_CUBE_MAP_SEAMLESS) // Filter across face edges
+	r.environmentMap = newCubeMap(skyFaces(environmentSize), environmentSize, true)
+	r.irradianceMap = newCubeMap(irradianceFaces(irradianceSize, irradianceSource), irradianceSize, false)
+
+	maxLod := float32(math.Log2(environmentSize))
+	gl.Uniform1f(gl.GetUniformLocation(r.program, gl.Str("environmentMaxLod\x00")), maxLod)
+	gl.Uniform1i(gl.GetUniformLocation(r.program, gl.Str("environmentMap\x00")), int32(environmentUnit))
+	gl.Uniform1i(gl.GetUniformLocation(r.program, gl.Str("irradianceMap\x00")), int32(irradianceUnit))
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		gl.Uniform1i(gl.GetUniformLocation(r.program, gl.Str(materialMapNames[kind]+"\x00")), int32(kind))
+	}
+
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(environmentUnit))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.environmentMap)
+	gl.ActiveTexture(gl.TEXTURE0 + uint32(irradianceUnit))
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, r.irradianceMap)
+	gl.ActiveTexture(gl.TEXTURE0)
+}
+
+// releaseEnvironment deletes the environment maps and the default material maps.
+func (r *GLRenderer) releaseEnvironment() {
+	gl.DeleteTextures(1, &r.environmentMap)
+	gl.DeleteTextures(1, &r.irradianceMap)
+	gl.DeleteTextures(int32(materialMapCount), &r.defaultMaps[0])
+}
+
+// newCubeMap uploads six faces of RGB float data, each size x size texels.
+func newCubeMap(faces [6][]float32, size int, mipmaps bool) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, texture)
+	for face, data := range faces {
+		gl.TexImage2D(gl.TEXTURE_CUBE_MAP_POSITIVE_X+uint32(face), 0, gl.RGB16F, int32(size), int32(size), 0,
+			gl.RGB, gl.FLOAT, gl.Ptr(data))
+	}
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_WRAP_R, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	if mipmaps {
+		// Rougher reflections read blurrier mip levels
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR)
+		gl.GenerateMipmap(gl.TEXTURE_CUBE_MAP)
+	} else {
+		gl.TexParameteri(gl.TEXTURE_CUBE_MAP, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	}
+	gl.BindTexture(gl.TEXTURE_CUBE_MAP, 0)
+	return texture
+}
+
+// CreateMesh sets up the vertex array and buffers for the vertices and indices.
+func (r *GLRenderer) CreateMesh(vertices []float32, indices []uint32) (Mesh, error) {
+	if len(vertices) == 0 || len(indices) == 0 {
+		return 0, fmt.Errorf("mesh has no vertices or no indices")
+	}
+	var m glMesh
+	m.indicesCount = int32(len(indices))
+
+	gl.GenVertexArrays(1, &m.vao)
+	gl.GenBuffers(1, &m.vbo)
+	gl.GenBuffers(1, &m.ebo)
+
+	gl.BindVertexArray(m.vao)
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	// Position attribute (layout location 0, 3 floats)
+	// Vertex stride is 12*4 bytes (3 pos + 2 texcoord + 3 normal + 4 tangent)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, vertexFloats*4, gl.Ptr(nil))
+	gl.EnableVertexAttribArray(0)
+
+	// Texture coordinate attribute (layout location 1, 2 floats, offset after 3 positions)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+
+	// Normal attribute (layout location 2, 3 floats, offset after the texcoord)
+	gl.VertexAttribPointer(2, 3, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(5*4))
+	gl.EnableVertexAttribArray(2)
+
+	// Tangent attribute (layout location 3, 4 floats, bitangent sign in the last)
+	gl.VertexAttribPointer(3, 4, gl.FLOAT, false, vertexFloats*4, gl.PtrOffset(8*4))
+	gl.EnableVertexAttribArray(3)
+
+	gl.BindVertexArray(0) // Unbind VAO
+
+	r.lastMesh++
+	r.meshes[r.lastMesh] = m
+	return r.lastMesh, nil
+}
+
+// DeleteMesh deletes a mesh's vertex array and buffers.
+func (r *GLRenderer) DeleteMesh(mesh Mesh) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	gl.DeleteVertexArrays(1, &m.vao)
+	gl.DeleteBuffers(1, &m.vbo)
+	gl.DeleteBuffers(1, &m.ebo)
+	delete(r.meshes, mesh)
+}
+
+// CreateTexture uploads an image as a mipmapped, repeating texture. Color maps are stored
+// as sRGB, so the shader reads them as linear values.
+func (r *GLRenderer) CreateTexture(img *image.RGBA, srgb bool) (Texture, error) {
+	if img.Rect.Empty() {
+		return 0, fmt.Errorf("texture image is empty")
+	}
+	r.lastTexture++
+	r.textures[r.lastTexture] = newGLTexture(img, srgb)
+	return r.lastTexture, nil
+}
+
+// newGLTexture creates an OpenGL texture from an image, see CreateTexture.
+func newGLTexture(img *image.RGBA, srgb bool) uint32 {
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+
+	// Set texture wrapping and filtering options
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR_MIPMAP_LINEAR) // Use mipmaps
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+
+	internalFormat := int32(gl.RGBA8)
+	if srgb {
+		internalFormat = gl.SRGB8_ALPHA8
+	}
+	// Rows are read with the image's stride, so sub-images work too
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(img.Stride/4))
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internalFormat, int32(img.Rect.Dx()), int32(img.Rect.Dy()), 0,
+		gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+	gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+	gl.GenerateMipmap(gl.TEXTURE_2D)
+
+	gl.BindTexture(gl.TEXTURE_2D, 0) // Unbind texture
+	return texture
+}
+
+// DeleteTexture deletes a texture.
+func (r *GLRenderer) DeleteTexture(texture Texture) {
+	glTexture, ok := r.textures[texture]
+	if !ok {
+		return
+	}
+	gl.DeleteTextures(1, &glTexture)
+	delete(r.textures, texture)
+}
+
+// SetCamera uploads the view and projection matrices and the camera position.
+func (r *GLRenderer) SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3) {
+	gl.UseProgram(r.program) // The post-processing passes use their own programs
+	gl.UniformMatrix4fv(r.viewUniform, 1, false, &view[0])
+	gl.UniformMatrix4fv(r.projectionUniform, 1, false, &projection[0])
+	gl.Uniform3fv(r.viewPosUniform, 1, &position[0]) // For specular highlights
+}
+
+// SetViewMode uploads the view mode and the depth range.
+func (r *GLRenderer) SetViewMode(mode viewmode.Mode, depthNear, depthFar float32) {
+	r.viewMode = mode
+	gl.UseProgram(r.program)
+	gl.Uniform1i(r.viewModeUniform, int32(mode))
+	gl.Uniform2f(r.depthRangeUniform, depthNear, depthFar)
+}
+
+// BeginFrame starts drawing into the post-processing chain's target and clears it. The
+// lit view is tonemapped and gamma encoded on the way out, so the clear color is made
+// linear for it first.
+func (r *GLRenderer) BeginFrame(clearColor mgl32.Vec3) {
+	r.post.Begin()
+	gl.Enable(gl.DEPTH_TEST)
+	if r.viewMode == viewmode.Lit {
+		clearColor = post.LinearColor(clearColor)
+	}
+	gl.ClearColor(clearColor.X(), clearColor.Y(), clearColor.Z(), 1.0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+}
+
+// setModelMatrix uploads the model matrix and the normal matrix made from it.
+func (r *GLRenderer) setModelMatrix(model mgl32.Mat4) {
+	gl.UniformMatrix4fv(r.modelUniform, 1, false, &model[0])
+	normalMatrix := model.Mat3().Inv().Transpose()
+	gl.UniformMatrix3fv(r.normalMatrixUniform, 1, false, &normalMatrix[0])
+}
+
+// Draw binds a submesh's material and draws its range of the mesh's index buffer.
+func (r *GLRenderer) Draw(mesh Mesh, model mgl32.Mat4, submesh *Submesh) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	gl.UseProgram(r.program)
+	r.setModelMatrix(model)
+	r.bindMaterial(submesh)
+
+	// Blended submeshes test against the depth buffer but don't write to it, so the
+	// ones behind still show through
+	blended := submesh.AlphaMode == alphamode.Blend
+	if blended {
+		gl.Enable(gl.BLEND)
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+		gl.DepthMask(false)
+	}
+	gl.BindVertexArray(m.vao)
+	gl.DrawElements(gl.TRIANGLES, submesh.IndexCount, gl.UNSIGNED_INT, gl.PtrOffset(int(submesh.FirstIndex)*4))
+	gl.BindVertexArray(0)
+	if blended {
+		gl.DepthMask(true)
+		gl.Disable(gl.BLEND)
+	}
+	gl.Disable(gl.SAMPLE_ALPHA_TO_COVERAGE)
+}
+
+// bindMaterial binds a submesh's maps, or the defaults for the ones it lacks, and sets its factors.
+func (r *GLRenderer) bindMaterial(submesh *Submesh) {
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		texture, ok := r.textures[submesh.Maps[kind]]
+		if !ok {
+			texture = r.defaultMaps[kind]
+		}
+		gl.ActiveTexture(gl.TEXTURE0 + uint32(kind))
+		gl.BindTexture(gl.TEXTURE_2D, texture)
+	}
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.Uniform4fv(r.diffuseColorUniform, 1, &submesh.DiffuseColor[0])
+	gl.Uniform1f(r.roughnessUniform, submesh.Roughness)
+	gl.Uniform1f(r.metallicUniform, submesh.Metallic)
+	gl.Uniform3fv(r.falseColorUniform, 1, &submesh.FalseColor[0])
+
+	r.alphaUniforms.Set(submesh.AlphaMode, submesh.AlphaCutoff, submesh.AlphaToCoverage)
+}
+
+// DrawWireframe draws the edges of every triangle of the mesh as lines.
+func (r *GLRenderer) DrawWireframe(mesh Mesh, model mgl32.Mat4) {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return
+	}
+	gl.UseProgram(r.program)
+	r.setModelMatrix(model)
+	viewmode.BeginWireframe(r.wireframeUniform)
+	gl.BindVertexArray(m.vao)
+	gl.DrawElements(gl.TRIANGLES, m.indicesCount, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+	viewmode.EndWireframe(r.wireframeUniform)
+}
+
+// EndFrame runs the post-processing chain, which leaves the frame in the window's back
+// buffer.
+func (r *GLRenderer) EndFrame() {
+	viewmode.EndPost(r.post, r.viewMode)
+}
+
+// ReadPixels reads the window's back buffer. OpenGL's rows start at the bottom, so they
+// are flipped.
+func (r *GLRenderer) ReadPixels() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, r.width, r.height))
+	pixels := make([]uint8, len(img.Pix))
+	gl.ReadPixels(0, 0, int32(r.width), int32(r.height), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	for y := 0; y < r.height; y++ {
+		copy(img.Pix[y*img.Stride:(y+1)*img.Stride], pixels[(r.height-1-y)*img.Stride:(r.height-y)*img.Stride])
+	}
+	return img
+}
+
+// Resize changes the viewport and the size of the post-processing targets.
+func (r *GLRenderer) Resize(width, height int) error {
+	r.width, r.height = width, height
+	gl.Viewport(0, 0, int32(width), int32(height))
+	if err := r.post.Resize(width, height); err != nil {
+		return fmt.Errorf("failed to resize the post-processing targets: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the meshes, the textures, the environment, the post-processing chain
+// and the program.
+func (r *GLRenderer) Release() {
+	for mesh := range r.meshes {
+		r.DeleteMesh(mesh)
+	}
+	for texture := range r.textures {
+		r.DeleteTexture(texture)
+	}
+	r.releaseEnvironment()
+	r.post.Release()
+	gl.DeleteProgram(r.program)
+}
+
+// --- Helper functions for shader compilation (unchanged) ---
+
+// compileShader compiles vertex and fragment shaders into an OpenGL program.
+func compileShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
+	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
+	glShaderSource(vertexShader, vertexShaderSource)
+	gl.CompileShader(vertexShader)
+	if err := checkShaderCompileStatus(vertexShader, "vertex"); err != nil {
+		return 0, err
+	}
+
+	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
+	glShaderSource(fragmentShader, fragmentShaderSource)
+	gl.CompileShader(fragmentShader)
+	if err := checkShaderCompileStatus(fragmentShader, "fragment"); err != nil {
+		return 0, err
+	}
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertexShader)
+	gl.AttachShader(program, fragmentShader)
+	gl.LinkProgram(program)
+	if err := checkProgramLinkStatus(program); err != nil {
+		return 0, err
+	}
+
+	gl.DeleteShader(vertexShader)
+	gl.DeleteShader(fragmentShader)
+
+	return program, nil
+}
+
+func glShaderSource(shader uint32, source string) {
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+}
+
+func checkShaderCompileStatus(shader uint32, shaderType string) error {
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to compile %s shader:\n%v", shaderType, log)
+	}
+	return nil
+}
+
+func checkProgramLinkStatus(program uint32) error {
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+		log := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
+		return fmt.Errorf("failed to link program:\n%v", log)
+	}
+	return nil
+}
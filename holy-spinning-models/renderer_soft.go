@@ -0,0 +1,598 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/alphamode"
+	"github.com/toxichemicals/GO/holy-shared/post"
+	"github.com/toxichemicals/GO/holy-shared/raster"
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// Software rendering: SoftwareRenderer draws what GLRenderer draws without a GPU, running
+// the same vertex and shading math per vertex and per pixel on the CPU. It is meant for
+// tests and headless tools, so it keeps to the basics: textures are sampled bilinearly from
+// their full-size image (no mipmaps), there is no antialiasing, so masked alpha is always
+// cut hard, and of the post-processing chain only tonemapping and gamma are applied, by
+// ReadPixels.
+
+// softMesh is a mesh's data, kept as it was given to CreateMesh.
+type softMesh struct {
+	vertices []float32
+	indices  []uint32
+}
+
+// softTexture is a texture's image. sRGB ones are decoded to linear as they are sampled.
+type softTexture struct {
+	width, height int
+	pix           []uint8 // RGBA, top row first
+	srgb          bool
+}
+
+// softCubeMap is the six faces of a cube map, RGB floats in face order, for every mip
+// level from the full size down to 1x1.
+type softCubeMap struct {
+	levels [][6][]float32
+	sizes  []int
+}
+
+// SoftwareRenderer rasterizes into memory. Its buffers have row 0 at the top, like images.
+type SoftwareRenderer struct {
+	frame raster.Frame // What the shader output: linear and unclamped in the lit view
+
+	meshes      map[Mesh]*softMesh
+	textures    map[Texture]*softTexture
+	lastMesh    Mesh    // Last Mesh handed out
+	lastTexture Texture // Last Texture handed out
+
+	// Physically based materials (see pbr.go), as GLRenderer has them
+	defaultMaps    [materialMapCount]*softTexture
+	environmentMap *softCubeMap
+	irradianceMap  *softCubeMap
+
+	view, projection mgl32.Mat4
+	viewPos          mgl32.Vec3
+	viewMode         viewmode.Mode
+	depthNear        float32 // White in the depth view
+	depthFar         float32 // Black in the depth view
+
+	// Vertex stage output, reused by the draws of the same mesh and model matrix
+	transformed      []raster.Vertex
+	transformedMesh  Mesh // 0 when transformed is out of date
+	transformedModel mgl32.Mat4
+}
+
+// newSoftwareRenderer creates a renderer with a width x height frame and builds the
+// environment maps.
+func newSoftwareRenderer(width, height int) (*SoftwareRenderer, error) {
+	r := &SoftwareRenderer{
+		meshes:     make(map[Mesh]*softMesh),
+		textures:   make(map[Texture]*softTexture),
+		view:       mgl32.Ident4(),
+		projection: mgl32.Ident4(),
+	}
+	if err := r.Resize(width, height); err != nil {
+		return nil, err
+	}
+	for kind := materialMap(0); kind < materialMapCount; kind++ {
+		r.defaultMaps[kind] = newSoftTexture(materialMapDefaultImage(kind), materialMapIsColor(kind))
+	}
+	r.environmentMap = newSoftCubeMap(skyFaces(environmentSize), environmentSize, true)
+	r.irradianceMap = newSoftCubeMap(irradianceFaces(irradianceSize, irradianceSource), irradianceSize, false)
+	return r, nil
+}
+
+// CreateMesh keeps copies of the vertices and indices.
+func (r *SoftwareRenderer) CreateMesh(vertices []float32, indices []uint32) (Mesh, error) {
+	if len(vertices) == 0 || len(indices) == 0 {
+		return 0, fmt.Errorf("mesh has no vertices or no indices")
+	}
+	vertexCount := uint32(len(vertices) / vertexFloats)
+	for _, index := range indices {
+		if index >= vertexCount {
+			return 0, fmt.Errorf("index %d is out of range for %d vertices", index, vertexCount)
+		}
+	}
+	m := &softMesh{
+		vertices: append([]float32(nil), vertices...),
+		indices:  append([]uint32(nil), indices...),
+	}
+	r.lastMesh++
+	r.meshes[r.lastMesh] = m
+	return r.lastMesh, nil
+}
+
+// DeleteMesh forgets a mesh.
+func (r *SoftwareRenderer) DeleteMesh(mesh Mesh) {
+	delete(r.meshes, mesh)
+	if r.transformedMesh == mesh {
+		r.transformedMesh = 0
+	}
+}
+
+// CreateTexture keeps a copy of the image.
+func (r *SoftwareRenderer) CreateTexture(img *image.RGBA, srgb bool) (Texture, error) {
+	if img.Rect.Empty() {
+		return 0, fmt.Errorf("texture image is empty")
+	}
+	r.lastTexture++
+	r.textures[r.lastTexture] = newSoftTexture(img, srgb)
+	return r.lastTexture, nil
+}
+
+// newSoftTexture copies an image's pixels, row by row in case it is a sub-image.
+func newSoftTexture(img *image.RGBA, srgb bool) *softTexture {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	t := &softTexture{width: width, height: height, pix: make([]uint8, width*height*4), srgb: srgb}
+	for y := 0; y < height; y++ {
+		copy(t.pix[y*width*4:(y+1)*width*4], img.Pix[y*img.Stride:y*img.Stride+width*4])
+	}
+	return t
+}
+
+// DeleteTexture forgets a texture.
+func (r *SoftwareRenderer) DeleteTexture(texture Texture) {
+	delete(r.textures, texture)
+}
+
+// SetCamera sets the matrices and position the next draws use.
+func (r *SoftwareRenderer) SetCamera(view, projection mgl32.Mat4, position mgl32.Vec3) {
+	r.view, r.projection, r.viewPos = view, projection, position
+	r.transformedMesh = 0
+}
+
+// SetViewMode sets what the next draws show.
+func (r *SoftwareRenderer) SetViewMode(mode viewmode.Mode, depthNear, depthFar float32) {
+	r.viewMode, r.depthNear, r.depthFar = mode, depthNear, depthFar
+}
+
+// BeginFrame clears the color and depth buffers. ReadPixels encodes the lit view, so the
+// clear color is made linear for it first.
+func (r *SoftwareRenderer) BeginFrame(clearColor mgl32.Vec3) {
+	if r.viewMode == viewmode.Lit {
+		clearColor = post.LinearColor(clearColor)
+	}
+	r.frame.Clear(clearColor)
+}
+
+// Draw rasterizes the triangles of a submesh's range of indices with its material.
+func (r *SoftwareRenderer) Draw(mesh Mesh, model mgl32.Mat4, submesh *Submesh) {
+	m := r.transform(mesh, model)
+	if m == nil {
+		return
+	}
+	first := int(submesh.FirstIndex)
+	last := first + int(submesh.IndexCount)
+	if first < 0 || last > len(m.indices) {
+		return
+	}
+	blend := submesh.AlphaMode == alphamode.Blend
+	shade := func(f *raster.Fragment) (mgl32.Vec3, float32, bool) {
+		return r.shade(f, submesh)
+	}
+	for i := first; i+2 < last; i += 3 {
+		r.frame.DrawTriangle(r.transformed[m.indices[i]], r.transformed[m.indices[i+1]], r.transformed[m.indices[i+2]], blend, shade)
+	}
+}
+
+// transform runs the vertex stage over a whole mesh into r.transformed, unless it already
+// holds that mesh with that model matrix. It returns nil for an unknown mesh. The varyings
+// are world position (3) + texcoord (2) + normal (3) + tangent (4), as the shader's FragPos,
+// TexCoord, Normal and Tangent.
+func (r *SoftwareRenderer) transform(mesh Mesh, model mgl32.Mat4) *softMesh {
+	m, ok := r.meshes[mesh]
+	if !ok {
+		return nil
+	}
+	if r.transformedMesh == mesh && r.transformedModel == model {
+		return m
+	}
+	viewProjection := r.projection.Mul4(r.view)
+	normalMatrix := model.Mat3().Inv().Transpose()
+	tangentMatrix := model.Mat3() // Tangents follow the surface, so the model matrix itself
+
+	vertexCount := len(m.vertices) / vertexFloats
+	if cap(r.transformed) < vertexCount {
+		r.transformed = make([]raster.Vertex, vertexCount)
+	}
+	r.transformed = r.transformed[:vertexCount]
+	for i := range r.transformed {
+		v := m.vertices[i*vertexFloats : (i+1)*vertexFloats]
+		world := model.Mul4x1(mgl32.Vec4{v[0], v[1], v[2], 1})
+		normal := normalMatrix.Mul3x1(mgl32.Vec3{v[5], v[6], v[7]})
+		tangent := tangentMatrix.Mul3x1(mgl32.Vec3{v[8], v[9], v[10]})
+		r.transformed[i] = raster.Vertex{
+			Clip: viewProjection.Mul4x1(world),
+			Varyings: [raster.MaxVaryings]float32{
+				world[0], world[1], world[2],
+				v[3], v[4],
+				normal[0], normal[1], normal[2],
+				tangent[0], tangent[1], tangent[2], v[11],
+			},
+		}
+	}
+	r.transformedMesh, r.transformedModel = mesh, model
+	return m
+}
+
+// DrawWireframe draws the edges of every triangle of the mesh, black, over what is drawn.
+// Like GLRenderer's lines they are depth tested, pulled forward by the triangle's depth
+// slope so they aren't hidden by the faces they outline.
+func (r *SoftwareRenderer) DrawWireframe(mesh Mesh, model mgl32.Mat4) {
+	m := r.transform(mesh, model)
+	if m == nil {
+		return
+	}
+	for i := 0; i+2 < len(m.indices); i += 3 {
+		// Black is the shader's wireframeColor
+		r.frame.DrawTriangleEdges(r.transformed[m.indices[i]], r.transformed[m.indices[i+1]], r.transformed[m.indices[i+2]], viewmode.WireframeDepthBias, mgl32.Vec3{})
+	}
+}
+
+// shade is the fragment shader of GLRenderer on the CPU. It returns the color and alpha of
+// the pixel, or false where the shader discards it.
+func (r *SoftwareRenderer) shade(f *raster.Fragment, submesh *Submesh) (mgl32.Vec3, float32, bool) {
+	varyings := &f.Varyings
+	fragPos := mgl32.Vec3{varyings[0], varyings[1], varyings[2]}
+	texCoord := mgl32.Vec2{varyings[3], varyings[4]}
+
+	albedo4 := r.mapTexture(submesh, mapAlbedo).sample(texCoord)
+	for c := range albedo4 {
+		albedo4[c] *= submesh.DiffuseColor[c]
+	}
+	albedo, alpha := albedo4.Vec3(), albedo4[3]
+	switch submesh.AlphaMode {
+	case alphamode.Opaque:
+		alpha = 1
+	case alphamode.Mask:
+		// No multisampling to turn alpha into coverage, so always the hard cut
+		if alpha < submesh.AlphaCutoff {
+			return mgl32.Vec3{}, 0, false
+		}
+		alpha = 1
+	}
+	if r.viewMode != viewmode.Lit {
+		return r.viewModeColor(f, submesh, albedo), alpha, true
+	}
+
+	rough := mgl32.Clamp(submesh.Roughness*r.mapTexture(submesh, mapRoughness).sample(texCoord)[1], 0.04, 1) // Perfect mirrors alias
+	metal := mgl32.Clamp(submesh.Metallic*r.mapTexture(submesh, mapMetallic).sample(texCoord)[2], 0, 1)
+	specularLevel := r.mapTexture(submesh, mapSpecular).sample(texCoord)[0]
+	ao := r.mapTexture(submesh, mapAO).sample(texCoord)[0]
+	scattering := r.mapTexture(submesh, mapScattering).sample(texCoord).Vec3()
+
+	// Dielectrics reflect 0-8% depending on the specular level, metals their albedo
+	dielectric := 0.08 * specularLevel
+	F0 := mgl32.Vec3{dielectric, dielectric, dielectric}.Mul(1 - metal).Add(albedo.Mul(metal))
+
+	normal := r.surfaceNormal(varyings, submesh)
+	viewDir := safeNormalize(r.viewPos.Sub(fragPos))
+	NdotV := float32(math.Max(float64(normal.Dot(viewDir)), 0.0001))
+
+	// Image-based ambient light: irradiance for the diffuse part, the environment
+	// blurred by roughness for the specular part
+	irradiance := r.irradianceMap.sample(normal, 0)
+	reflectDir := viewDir.Mul(-1).Sub(normal.Mul(2 * normal.Dot(viewDir.Mul(-1))))
+	reflected := r.environmentMap.sample(reflectDir, rough*float32(math.Log2(environmentSize)))
+	specularAmbient := mulVec3(reflected, envBRDFApprox(F0, rough, NdotV))
+	diffuseAmbient := mulVec3(irradiance, albedo.Mul(1-metal).Add(scattering))
+	color := diffuseAmbient.Add(specularAmbient).Mul(ambient * ao)
+
+	sunColor := mgl32.Vec3{sunIntensity, sunIntensity, sunIntensity}
+	color = color.Add(shadeLight(normal, sunDirection.Mul(-1), viewDir, sunColor, albedo, F0, rough, metal, scattering))
+	for _, light := range pointLights {
+		toLight := light.Position.Sub(fragPos)
+		distance := toLight.Len()
+		// Fades smoothly to nothing at the light's range
+		attenuation := mgl32.Clamp(1-distance/light.Range, 0, 1)
+		attenuation *= attenuation
+		lightDir := toLight.Mul(1 / float32(math.Max(float64(distance), 0.0001)))
+		color = color.Add(shadeLight(normal, lightDir, viewDir, light.Color, albedo, F0, rough, metal, scattering).Mul(attenuation))
+	}
+	// Linear and unclamped, ReadPixels tonemaps it and applies gamma
+	return color, alpha, true
+}
+
+// viewModeColor returns what the view modes other than lit show for a pixel.
+func (r *SoftwareRenderer) viewModeColor(f *raster.Fragment, submesh *Submesh, albedo mgl32.Vec3) mgl32.Vec3 {
+	varyings := &f.Varyings
+	switch r.viewMode {
+	case viewmode.Unlit:
+		return albedo
+	case viewmode.Normals:
+		// Faces seen from behind are striped, inverted ones stand out on a closed mesh
+		if !f.FrontFacing {
+			// gl_FragCoord counts rows from the bottom
+			fragX, fragY := float64(f.X)+0.5, float64(r.frame.Height-1-f.Y)+0.5
+			return mgl32.Vec3{float32(math.Mod(math.Floor((fragX+fragY)/8), 2)), 0, 0}
+		}
+		return r.surfaceNormal(varyings, submesh).Mul(0.5).Add(mgl32.Vec3{0.5, 0.5, 0.5})
+	case viewmode.UVChecker:
+		u, v := float64(varyings[3]), float64(varyings[4])
+		cells := math.Floor(u*8) + math.Floor(v*8)
+		checker := float32(cells - 2*math.Floor(cells/2)) // GLSL's mod, never negative
+		return mgl32.Vec3{float32(u - math.Floor(u)), float32(v - math.Floor(v)), 0.5}.Mul(0.4 + 0.6*checker)
+	case viewmode.Depth:
+		fragPos := mgl32.Vec3{varyings[0], varyings[1], varyings[2]}
+		gray := 1 - mgl32.Clamp((r.viewPos.Sub(fragPos).Len()-r.depthNear)/(r.depthFar-r.depthNear), 0, 1)
+		return mgl32.Vec3{gray, gray, gray}
+	}
+	return submesh.FalseColor
+}
+
+// surfaceNormal bends the interpolated normal by the normal map, as the shader's does.
+func (r *SoftwareRenderer) surfaceNormal(varyings *[raster.MaxVaryings]float32, submesh *Submesh) mgl32.Vec3 {
+	normal := safeNormalize(mgl32.Vec3{varyings[5], varyings[6], varyings[7]})
+	rawTangent := mgl32.Vec3{varyings[8], varyings[9], varyings[10]}
+	tangent := safeNormalize(rawTangent.Sub(normal.Mul(normal.Dot(rawTangent)))) // Interpolation skews it
+	bitangent := normal.Cross(tangent).Mul(varyings[11])
+	mapped := r.mapTexture(submesh, mapNormal).sample(mgl32.Vec2{varyings[3], varyings[4]}).Vec3().Mul(2).Sub(mgl32.Vec3{1, 1, 1})
+	return safeNormalize(tangent.Mul(mapped[0]).Add(bitangent.Mul(mapped[1])).Add(normal.Mul(mapped[2])))
+}
+
+// mapTexture returns a submesh's map, or the map's default.
+func (r *SoftwareRenderer) mapTexture(submesh *Submesh, kind materialMap) *softTexture {
+	if t, ok := r.textures[submesh.Maps[kind]]; ok {
+		return t
+	}
+	return r.defaultMaps[kind]
+}
+
+// --- The shader's lighting functions ---
+
+func distributionGGX(NdotH, alpha float32) float32 {
+	a2 := alpha * alpha
+	d := NdotH*NdotH*(a2-1) + 1
+	return a2 / (math.Pi * d * d)
+}
+
+// geometrySmith is Smith's shadowing-masking with Schlick-GGX, k as for direct lights.
+func geometrySmith(NdotV, NdotL, rough float32) float32 {
+	k := (rough + 1) * (rough + 1) / 8
+	return NdotV / (NdotV*(1-k) + k) * NdotL / (NdotL*(1-k) + k)
+}
+
+func fresnelSchlick(cosTheta float32, F0 mgl32.Vec3) mgl32.Vec3 {
+	f := float32(math.Pow(float64(1-cosTheta), 5))
+	return F0.Add(mgl32.Vec3{1, 1, 1}.Sub(F0).Mul(f))
+}
+
+// envBRDFApprox is Karis's fit of the split-sum BRDF, instead of a lookup texture.
+func envBRDFApprox(F0 mgl32.Vec3, rough, NdotV float32) mgl32.Vec3 {
+	r := mgl32.Vec4{-1, -0.0275, -0.572, 0.022}.Mul(rough).Add(mgl32.Vec4{1, 0.0425, 1.04, -0.04})
+	a004 := float32(math.Min(float64(r[0]*r[0]), math.Exp2(-9.28*float64(NdotV))))*r[0] + r[1]
+	scale, bias := -1.04*a004+r[2], 1.04*a004+r[3]
+	return F0.Mul(scale).Add(mgl32.Vec3{bias, bias, bias})
+}
+
+// shadeLight returns the light reflected towards the viewer from one light, the shader's
+// shade. lightDir points from the surface towards the light. Light colors are what a white
+// surface facing the light shows, so the diffuse term has no 1/PI.
+func shadeLight(normal, lightDir, viewDir, lightColor, albedo, F0 mgl32.Vec3, rough, metal float32, scattering mgl32.Vec3) mgl32.Vec3 {
+	const scatterWrap = 0.5 // How far scattered light wraps past the shadow line
+	NdotL := normal.Dot(lightDir)
+	// Scattered light reaches a bit past where direct light stops
+	scattered := scattering.Mul(float32(math.Max(float64((NdotL+scatterWrap)/(1+scatterWrap)), 0)))
+	if NdotL <= 0 {
+		return mulVec3(lightColor, scattered)
+	}
+	halfway := safeNormalize(lightDir.Add(viewDir))
+	NdotV := float32(math.Max(float64(normal.Dot(viewDir)), 0.0001))
+	NdotH := float32(math.Max(float64(normal.Dot(halfway)), 0))
+	F := fresnelSchlick(float32(math.Max(float64(halfway.Dot(viewDir)), 0)), F0)
+	D := distributionGGX(NdotH, rough*rough)
+	G := geometrySmith(NdotV, NdotL, rough)
+	specular := F.Mul(D * G / (4 * NdotV * NdotL))
+	kD := mgl32.Vec3{1, 1, 1}.Sub(F).Mul(1 - metal) // Metals have no diffuse light
+	reflected := mulVec3(kD, albedo).Add(specular.Mul(math.Pi)).Mul(NdotL).Add(scattered)
+	return mulVec3(lightColor, reflected)
+}
+
+// --- Textures ---
+
+// srgbToLinear decodes 8-bit sRGB values, as OpenGL does for SRGB8_ALPHA8 textures.
+var srgbToLinear = func() (table [256]float32) {
+	for i := range table {
+		c := float64(i) / 255
+		if c <= 0.04045 {
+			table[i] = float32(c / 12.92)
+		} else {
+			table[i] = float32(math.Pow((c+0.055)/1.055, 2.4))
+		}
+	}
+	return table
+}()
+
+// sample reads the texture at a texture coordinate with bilinear filtering, repeating it
+// outside 0..1. sRGB colors are decoded before they are filtered; alpha never is.
+func (t *softTexture) sample(uv mgl32.Vec2) mgl32.Vec4 {
+	x := float64(uv[0])*float64(t.width) - 0.5
+	y := float64(uv[1])*float64(t.height) - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := float32(x-x0), float32(y-y0)
+	ix, iy := int(x0), int(y0)
+
+	var result mgl32.Vec4
+	for _, corner := range [4]struct {
+		dx, dy int
+		weight float32
+	}{
+		{0, 0, (1 - fx) * (1 - fy)},
+		{1, 0, fx * (1 - fy)},
+		{0, 1, (1 - fx) * fy},
+		{1, 1, fx * fy},
+	} {
+		if corner.weight == 0 {
+			continue
+		}
+		result = result.Add(t.texel(ix+corner.dx, iy+corner.dy).Mul(corner.weight))
+	}
+	return result
+}
+
+// texel returns one texel, wrapping its coordinates around the image.
+func (t *softTexture) texel(x, y int) mgl32.Vec4 {
+	x = ((x % t.width) + t.width) % t.width
+	y = ((y % t.height) + t.height) % t.height
+	p := t.pix[(y*t.width+x)*4 : (y*t.width+x)*4+4]
+	if t.srgb {
+		return mgl32.Vec4{srgbToLinear[p[0]], srgbToLinear[p[1]], srgbToLinear[p[2]], float32(p[3]) / 255}
+	}
+	return mgl32.Vec4{float32(p[0]) / 255, float32(p[1]) / 255, float32(p[2]) / 255, float32(p[3]) / 255}
+}
+
+// newSoftCubeMap keeps six faces of RGB float data, each size x size texels, and with
+// mipmaps builds the smaller levels by averaging 2x2 texels, as glGenerateMipmap does.
+func newSoftCubeMap(faces [6][]float32, size int, mipmaps bool) *softCubeMap {
+	c := &softCubeMap{levels: [][6][]float32{faces}, sizes: []int{size}}
+	for mipmaps && size > 1 {
+		previous := c.levels[len(c.levels)-1]
+		half := size / 2
+		var level [6][]float32
+		for face := range level {
+			data := make([]float32, half*half*3)
+			for y := 0; y < half; y++ {
+				for x := 0; x < half; x++ {
+					for channel := 0; channel < 3; channel++ {
+						sum := previous[face][((2*y)*size+2*x)*3+channel] +
+							previous[face][((2*y)*size+2*x+1)*3+channel] +
+							previous[face][((2*y+1)*size+2*x)*3+channel] +
+							previous[face][((2*y+1)*size+2*x+1)*3+channel]
+						data[(y*half+x)*3+channel] = sum / 4
+					}
+				}
+			}
+			level[face] = data
+		}
+		c.levels = append(c.levels, level)
+		c.sizes = append(c.sizes, half)
+		size = half
+	}
+	return c
+}
+
+// sample reads the cube map in a direction, blending the two mip levels around lod.
+func (c *softCubeMap) sample(d mgl32.Vec3, lod float32) mgl32.Vec3 {
+	face, s, t := cubeFaceCoords(d)
+	lod = mgl32.Clamp(lod, 0, float32(len(c.levels)-1))
+	level := int(lod)
+	color := c.sampleLevel(level, face, s, t)
+	if f := lod - float32(level); f > 0 && level+1 < len(c.levels) {
+		color = color.Mul(1 - f).Add(c.sampleLevel(level+1, face, s, t).Mul(f))
+	}
+	return color
+}
+
+// sampleLevel reads one mip level of a face with bilinear filtering, clamped to the face's
+// edges. OpenGL filters across the edges into the next face, which this doesn't.
+func (c *softCubeMap) sampleLevel(level, face int, s, t float32) mgl32.Vec3 {
+	size := c.sizes[level]
+	data := c.levels[level][face]
+	x := s*float32(size) - 0.5
+	y := t*float32(size) - 0.5
+	x0, y0 := float32(math.Floor(float64(x))), float32(math.Floor(float64(y)))
+	fx, fy := x-x0, y-y0
+	texel := func(tx, ty int) mgl32.Vec3 {
+		if tx < 0 {
+			tx = 0
+		} else if tx >= size {
+			tx = size - 1
+		}
+		if ty < 0 {
+			ty = 0
+		} else if ty >= size {
+			ty = size - 1
+		}
+		i := (ty*size + tx) * 3
+		return mgl32.Vec3{data[i], data[i+1], data[i+2]}
+	}
+	ix, iy := int(x0), int(y0)
+	top := texel(ix, iy).Mul(1 - fx).Add(texel(ix+1, iy).Mul(fx))
+	bottom := texel(ix, iy+1).Mul(1 - fx).Add(texel(ix+1, iy+1).Mul(fx))
+	return top.Mul(1 - fy).Add(bottom.Mul(fy))
+}
+
+// cubeFaceCoords returns the cube map face a direction points into and the 0..1 texture
+// coordinates on it, the inverse of cubeFaceDirection.
+func cubeFaceCoords(d mgl32.Vec3) (face int, s, t float32) {
+	ax, ay, az := abs32(d.X()), abs32(d.Y()), abs32(d.Z())
+	var sc, tc, major float32
+	switch {
+	case ax >= ay && ax >= az:
+		major = ax
+		if d.X() > 0 {
+			face, sc, tc = 0, -d.Z(), -d.Y()
+		} else {
+			face, sc, tc = 1, d.Z(), -d.Y()
+		}
+	case ay >= az:
+		major = ay
+		if d.Y() > 0 {
+			face, sc, tc = 2, d.X(), d.Z()
+		} else {
+			face, sc, tc = 3, d.X(), -d.Z()
+		}
+	default:
+		major = az
+		if d.Z() > 0 {
+			face, sc, tc = 4, d.X(), -d.Y()
+		} else {
+			face, sc, tc = 5, -d.X(), -d.Y()
+		}
+	}
+	if major == 0 {
+		return 0, 0.5, 0.5 // No direction at all
+	}
+	return face, (sc/major + 1) / 2, (tc/major + 1) / 2
+}
+
+// EndFrame has nothing to do, every draw went straight into the buffers.
+func (r *SoftwareRenderer) EndFrame() {}
+
+// ReadPixels brings the frame to 8 bits per channel. In the lit view the colors are linear and
+// unclamped, so they get the post-processing chain's default exposure, ACES curve and
+// gamma; the other view modes are used as they are, as GLRenderer skips those effects for them.
+func (r *SoftwareRenderer) ReadPixels() *image.RGBA {
+	if r.viewMode != viewmode.Lit {
+		return r.frame.ReadPixels(nil)
+	}
+	return r.frame.ReadPixels(func(c mgl32.Vec3) mgl32.Vec3 {
+		return post.DisplayColor(c, post.DefaultExposure)
+	})
+}
+
+// Resize reallocates the buffers. Their contents are lost until the next frame.
+func (r *SoftwareRenderer) Resize(width, height int) error {
+	return r.frame.Resize(width, height)
+}
+
+// Release drops the meshes, textures and buffers.
+func (r *SoftwareRenderer) Release() {
+	r.meshes = make(map[Mesh]*softMesh)
+	r.textures = make(map[Texture]*softTexture)
+	r.transformed, r.transformedMesh = nil, 0
+	r.frame.Release()
+}
+
+// --- Small helpers for the rasterizer ---
+
+// safeNormalize normalizes a vector, leaving a zero vector as it is instead of making NaNs.
+func safeNormalize(v mgl32.Vec3) mgl32.Vec3 {
+	if length := v.Len(); length > 0 {
+		return v.Mul(1 / length)
+	}
+	return v
+}
+
+// mulVec3 multiplies two vectors component by component.
+func mulVec3(a, b mgl32.Vec3) mgl32.Vec3 {
+	return mgl32.Vec3{a[0] * b[0], a[1] * b[1], a[2] * b[2]}
+}
+
+func abs32(v float32) float32 {
+	return float32(math.Abs(float64(v)))
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/viewmode"
+)
+
+// The view modes themselves are in holy-shared/viewmode. What they need from the model
+// loaded here is below.
+
+// prepareViewModes sets what the view modes need from a newly loaded model: the false color
+// of each submesh and the model's radius around the origin it spins about.
+func (a *AppCore) prepareViewModes() {
+	for i := range a.submeshes {
+		a.submeshes[i].FalseColor = viewmode.Color(i)
+	}
+	a.modelRadius = 0
+	for i := 0; i+2 < len(a.vertices); i += vertexFloats {
+		pos := mgl32.Vec3{a.vertices[i], a.vertices[i+1], a.vertices[i+2]}
+		if length := pos.Len(); length > a.modelRadius {
+			a.modelRadius = length
+		}
+	}
+}
+
+// depthRange returns the distances that are white and black in the depth view: the nearest and
+// farthest the model can reach from the camera, however it is turned.
+func (a *AppCore) depthRange() (near, far float32) {
+	distance := a.cameraPos.Len()
+	near = distance - a.modelRadius
+	if near < 0 {
+		near = 0 // The camera is inside the model's sphere
+	}
+	return near, distance + a.modelRadius
+}
@@ -5,13 +5,13 @@ import (
 	"log"
 	"math"
 	"runtime"
-	"strings"
 	"time"
-	"unsafe" // For gl.PtrOffset
 
 	"github.com/go-gl/gl/v4.6-core/gl"
 	"github.com/go-gl/glfw/v3.3/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+
+	"github.com/toxichemicals/GO/holy-shared/phong"
 )
 
 // Constants for window dimensions
@@ -29,21 +29,37 @@ const (
 	numMinorSegments = 30 // Number of segments around the tube's cross-section
 )
 
+// Camera constants
+const (
+	fieldOfView      = 45.0 // Vertical, in degrees
+	farClippingPlane = 100.0
+)
+
+// cameraPos is where the camera looks at the torus from.
+var cameraPos = mgl32.Vec3{0, 0, 3}
+
+// clearColor is the background behind the torus.
+var clearColor = mgl32.Vec3{0.2, 0.3, 0.3}
+
+// lighting is what the torus is lit with: the sun, coming down from the front right, and point
+// lights fixed around the torus to give it some colored highlights on top of it.
+var lighting = phong.Lighting{
+	SunDirection: mgl32.Vec3{-0.5, -1.0, -0.6}.Normalize(),
+	SunIntensity: 0.8,
+	Ambient:      0.25,
+	PointLights: []phong.PointLight{
+		{Position: mgl32.Vec3{1.5, 1, 1.5}, Color: mgl32.Vec3{1.0, 0.8, 0.6}, Range: 5},   // Warm, front right
+		{Position: mgl32.Vec3{-1.5, 0.5, -1}, Color: mgl32.Vec3{0.4, 0.5, 1.0}, Range: 5}, // Cool, back left
+	},
+}
+
 // AppCore struct encapsulates the low-level graphics and windowing components.
 type AppCore struct {
 	window *glfw.Window
 
-	// OpenGL program and buffers for the torus
-	program      uint32
-	vao          uint32
-	vbo          uint32
-	ebo          uint32
-	indicesCount int32
-
-	// Uniform locations
-	modelUniform      int32
-	viewUniform       int32
-	projectionUniform int32
+	// What the torus is drawn with, see holy-shared/phong
+	renderer phong.Renderer
+	torus    phong.Mesh
 
 	// Window dimensions
 	width, height int
@@ -88,7 +104,6 @@ func initApp() error {
 	app.vertices, app.indices = generateTorusVerticesAndIndices(
 		majorRadius, minorRadius, numMajorSegments, numMinorSegments,
 	)
-	app.indicesCount = int32(len(app.indices))
 
 	if err := app.initializeWindow(); err != nil {
 		return fmt.Errorf("window initialization failed: %w", err)
@@ -98,11 +113,13 @@ func initApp() error {
 		return fmt.Errorf("OpenGL initialization failed: %w", err)
 	}
 
-	if err := app.setupShadersAndUniforms(); err != nil {
-		return fmt.Errorf("shader setup failed: %w", err)
+	renderer, err := phong.NewGLRenderer(app.width, app.height, lighting)
+	if err != nil {
+		return fmt.Errorf("renderer setup failed: %w", err)
 	}
+	app.renderer = renderer
 
-	if err := app.setupTorusBuffers(); err != nil {
+	if app.torus, err = app.renderer.CreateMesh(app.vertices, app.indices); err != nil {
 		return fmt.Errorf("torus buffer setup failed: %w", err)
 	}
 
@@ -116,7 +133,7 @@ func initApp() error {
 }
 
 // generateTorusVerticesAndIndices calculates the vertices and indices for a torus.
-// Each vertex includes X, Y, Z position and R, G, B color.
+// Each vertex includes X, Y, Z position, R, G, B color and the X, Y, Z normal.
 func generateTorusVerticesAndIndices(majorR, minorR float32, majorSegs, minorSegs int) ([]float32, []uint32) {
 	vertices := []float32{}
 	indices := []uint32{}
@@ -142,7 +159,12 @@ func generateTorusVerticesAndIndices(majorR, minorR float32, majorSegs, minorSeg
 			g := float32(j) / float32(minorSegs)
 			b := float32(1.0 - (r+g)/2.0) // Simple interpolated color
 
-			vertices = append(vertices, x, y, z, r, g, b)
+			// The normal points from the center of the tube out through the vertex
+			nx := cosPhi * cosTheta
+			ny := cosPhi * sinTheta
+			nz := sinPhi
+
+			vertices = append(vertices, x, y, z, r, g, b, nx, ny, nz)
 		}
 	}
 
@@ -192,10 +214,13 @@ func (a *AppCore) initializeWindow() error {
 	a.window.SetFramebufferSizeCallback(func(_ *glfw.Window, width, height int) {
 		a.width = width
 		a.height = height
-		gl.Viewport(0, 0, int32(width), int32(height))
-		// Re-calculate projection matrix on resize
-		projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, 100.0)
-		gl.UniformMatrix4fv(a.projectionUniform, 1, false, &projection[0])
+		if a.renderer != nil {
+			if err := a.renderer.Resize(width, height); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			// Re-calculate projection matrix on resize
+			a.setupCameraAndProjection()
+		}
 	})
 
 	return nil
@@ -213,86 +238,13 @@ func (a *AppCore) initializeOpenGL() error {
 	return nil
 }
 
-// setupShadersAndUniforms compiles shaders, links the program, and gets uniform locations.
-func (a *AppCore) setupShadersAndUniforms() error {
-	vertexShaderSource := `
-		#version 410 core
-		layout (location = 0) in vec3 aPos;
-		layout (location = 1) in vec3 aColor;
-
-		out vec3 ourColor;
-
-		uniform mat4 model;
-		uniform mat4 view;
-		uniform mat4 projection;
-
-		void main() {
-			gl_Position = projection * view * model * vec4(aPos, 1.0);
-			ourColor = aColor;
-		}
-	` + "\x00"
-
-	fragmentShaderSource := `
-		#version 410 core
-		in vec3 ourColor;
-		out vec4 FragColor;
-
-		void main() {
-			FragColor = vec4(ourColor, 1.0);
-		}
-	` + "\x00"
-
-	program, err := compileShader(vertexShaderSource, fragmentShaderSource)
-	if err != nil {
-		return fmt.Errorf("failed to compile shaders: %w", err)
-	}
-	gl.UseProgram(program)
-	a.program = program
-
-	a.modelUniform = gl.GetUniformLocation(a.program, gl.Str("model\x00"))
-	a.viewUniform = gl.GetUniformLocation(a.program, gl.Str("view\x00"))
-	a.projectionUniform = gl.GetUniformLocation(a.program, gl.Str("projection\x00"))
-
-	return nil
-}
-
-// setupTorusBuffers configures VAO, VBO, and EBO for the torus data.
-// This function is generic enough that it could still be called setupMeshBuffers.
-func (a *AppCore) setupTorusBuffers() error {
-	gl.GenVertexArrays(1, &a.vao)
-	gl.BindVertexArray(a.vao)
-
-	gl.GenBuffers(1, &a.vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, a.vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(a.vertices)*4, gl.Ptr(a.vertices), gl.STATIC_DRAW)
-
-	gl.GenBuffers(1, &a.ebo)
-	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, a.ebo)
-	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(a.indices)*4, gl.Ptr(a.indices), gl.STATIC_DRAW)
-
-	// Position attribute (layout location 0)
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 6*4, gl.Ptr(nil))
-	gl.EnableVertexAttribArray(0)
-
-	// Color attribute (layout location 1)
-	gl.VertexAttribPointer(1, 3, gl.FLOAT, false, 6*4, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
-
-	gl.BindVertexArray(0) // Unbind VAO
-
-	return nil
-}
-
-// setupCameraAndProjection sets up initial view and projection matrices.
+// setupCameraAndProjection sets up the view and projection matrices for the window's size.
 func (a *AppCore) setupCameraAndProjection() {
-	cameraPos := mgl32.Vec3{0, 0, 3}
 	cameraFront := mgl32.Vec3{0, 0, -1}
 	cameraUp := mgl32.Vec3{0, 1, 0}
 	view := mgl32.LookAtV(cameraPos, cameraPos.Add(cameraFront), cameraUp)
-	gl.UniformMatrix4fv(a.viewUniform, 1, false, &view[0])
-
-	projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(a.width)/float32(a.height), 0.1, 100.0)
-	gl.UniformMatrix4fv(a.projectionUniform, 1, false, &projection[0])
+	projection := mgl32.Perspective(mgl32.DegToRad(fieldOfView), float32(a.width)/float32(a.height), 0.1, farClippingPlane)
+	a.renderer.SetCamera(view, projection, cameraPos)
 }
 
 // processInput handles keyboard/mouse input.
@@ -316,6 +268,11 @@ func (a *AppCore) processInput() {
 		}
 	}
 	a.vKeyWasPressed = (currentVState == glfw.Press)
+
+	// Keys 1-5 toggle the post-processing effects
+	if r, ok := a.renderer.(*phong.GLRenderer); ok {
+		r.Post.HandleKeys(a.window)
+	}
 }
 
 // updateScene updates the game state (e.g., torus rotation).
@@ -324,27 +281,24 @@ func (a *AppCore) updateScene(deltaTime float32) {
 	a.totalRotationX += deltaTime * mgl32.DegToRad(25.0)
 }
 
-// renderScene clears buffers, draws the torus, and swaps buffers.
+// renderScene draws a frame and swaps buffers.
 func (a *AppCore) renderScene() {
-	gl.ClearColor(0.2, 0.3, 0.3, 1.0)
-	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	a.drawFrame()
+	a.window.SwapBuffers()
+}
+
+// drawFrame clears the frame and draws the torus at its current rotation. It only uses the
+// renderer, so it works with any of them.
+func (a *AppCore) drawFrame() {
+	a.renderer.BeginFrame(clearColor)
 
 	// Calculate the model matrix for the torus's current rotation
 	model := mgl32.Ident4()
 	model = model.Mul4(mgl32.HomogRotate3DY(a.totalRotationY))
 	model = model.Mul4(mgl32.HomogRotate3DX(a.totalRotationX))
 
-	a.drawTorus(model)
-	a.window.SwapBuffers()
-}
-
-// drawTorus draws the predefined torus with the given model matrix.
-func (a *AppCore) drawTorus(modelMatrix mgl32.Mat4) {
-	gl.UniformMatrix4fv(a.modelUniform, 1, false, &modelMatrix[0])
-
-	gl.BindVertexArray(a.vao)
-	gl.DrawElements(gl.TRIANGLES, a.indicesCount, gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
-	gl.BindVertexArray(0)
+	a.renderer.Draw(a.torus, model)
+	a.renderer.EndFrame()
 }
 
 // updateAndDisplayFPS calculates and displays FPS in the window title.
@@ -363,10 +317,9 @@ func shutdownApp() {
 	if app == nil { // Ensure app is initialized before attempting to clean up
 		return
 	}
-	gl.DeleteVertexArrays(1, &app.vao)
-	gl.DeleteBuffers(1, &app.vbo)
-	gl.DeleteBuffers(1, &app.ebo)
-	gl.DeleteProgram(app.program)
+	if app.renderer != nil {
+		app.renderer.Release() // Deletes the torus's mesh too
+	}
 
 	if app.window != nil {
 		app.window.Destroy()
@@ -374,73 +327,6 @@ func shutdownApp() {
 	glfw.Terminate() // Terminate GLFW
 }
 
-// --- Helper functions for shader compilation (unchanged) ---
-
-// compileShader compiles vertex and fragment shaders into an OpenGL program.
-func compileShader(vertexShaderSource, fragmentShaderSource string) (uint32, error) {
-	vertexShader := gl.CreateShader(gl.VERTEX_SHADER)
-	glShaderSource(vertexShader, vertexShaderSource)
-	gl.CompileShader(vertexShader)
-	if err := checkShaderCompileStatus(vertexShader, "vertex"); err != nil {
-		return 0, err
-	}
-
-	fragmentShader := gl.CreateShader(gl.FRAGMENT_SHADER)
-	glShaderSource(fragmentShader, fragmentShaderSource)
-	gl.CompileShader(fragmentShader)
-	if err := checkShaderCompileStatus(fragmentShader, "fragment"); err != nil {
-		return 0, err
-	}
-
-	program := gl.CreateProgram()
-	gl.AttachShader(program, vertexShader)
-	gl.AttachShader(program, fragmentShader)
-	gl.LinkProgram(program)
-	if err := checkProgramLinkStatus(program); err != nil {
-		return 0, err
-	}
-
-	gl.DeleteShader(vertexShader)
-	gl.DeleteShader(fragmentShader)
-
-	return program, nil
-}
-
-// glShaderSource is a helper to correctly pass GLSL source to OpenGL
-func glShaderSource(shader uint32, source string) {
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-}
-
-// checkShaderCompileStatus checks if a shader compiled successfully.
-func checkShaderCompileStatus(shader uint32, shaderType string) error {
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
-		return fmt.Errorf("failed to compile %s shader:\n%v", shaderType, log)
-	}
-	return nil
-}
-
-// checkProgramLinkStatus checks if a shader program linked successfully.
-func checkProgramLinkStatus(program uint32) error {
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-		return fmt.Errorf("failed to link program:\n%v", log)
-	}
-	return nil
-}
-
 // shouldClose returns true if the window should close.
 func (a *AppCore) shouldClose() bool {
 	return a.window.ShouldClose() || !a.running
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/toxichemicals/GO/holy-shared/golden"
+	"github.com/toxichemicals/GO/holy-shared/phong"
+)
+
+// Golden frames are a fifth of the window's size, which keeps its aspect ratio
+const (
+	testWidth  = screenWidth / 5
+	testHeight = screenHeight / 5
+)
+
+// newTestApp sets up the torus as initApp does, but drawn by a SoftwareRenderer instead of
+// into a window.
+func newTestApp(t *testing.T) *AppCore {
+	t.Helper()
+	renderer, err := phong.NewSoftwareRenderer(testWidth, testHeight, lighting)
+	if err != nil {
+		t.Fatalf("renderer setup failed: %v", err)
+	}
+	a := &AppCore{width: testWidth, height: testHeight, renderer: renderer}
+	a.vertices, a.indices = generateTorusVerticesAndIndices(
+		majorRadius, minorRadius, numMajorSegments, numMinorSegments,
+	)
+	if a.torus, err = renderer.CreateMesh(a.vertices, a.indices); err != nil {
+		t.Fatalf("torus buffer setup failed: %v", err)
+	}
+	a.setupCameraAndProjection()
+	return a
+}
+
+// TestGoldenFrames renders the torus as it is a few fixed times after the start.
+func TestGoldenFrames(t *testing.T) {
+	for _, seconds := range []float32{0, 0.5, 2} {
+		name := fmt.Sprintf("torus_%.1fs", seconds)
+		t.Run(name, func(t *testing.T) {
+			a := newTestApp(t)
+			a.updateScene(seconds)
+			a.drawFrame()
+			golden.Check(t, name, a.renderer.ReadPixels())
+		})
+	}
+}